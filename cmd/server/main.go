@@ -13,6 +13,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/italosilva18/destack-transport-api/configs"
 	"github.com/italosilva18/destack-transport-api/internal/api/routes"
+	"github.com/italosilva18/destack-transport-api/internal/assinatura"
+	"github.com/italosilva18/destack-transport-api/internal/ingestao"
+	"github.com/italosilva18/destack-transport-api/internal/jobs"
+	"github.com/italosilva18/destack-transport-api/internal/validacao"
+	"github.com/italosilva18/destack-transport-api/internal/watcher"
 	"github.com/italosilva18/destack-transport-api/pkg/database"
 	"github.com/italosilva18/destack-transport-api/pkg/database/seeds"
 	"github.com/italosilva18/destack-transport-api/pkg/logger"
@@ -112,6 +117,51 @@ func main() {
 		}
 	}
 
+	// Configurar a validação XSD dos XMLs recebidos
+	if err := validacao.Configurar(validacao.Config{
+		Modo:      config.ValidacaoConfig.Modo,
+		Diretorio: config.ValidacaoConfig.DiretorioSchemas,
+	}); err != nil {
+		log.Fatal().Err(err).Msg("Configuração de validação XSD inválida")
+	}
+	log.Info().Str("modo", config.ValidacaoConfig.Modo).Msg("Validação XSD configurada")
+
+	// Configurar a verificação das assinaturas digitais (cadeia ICP-Brasil)
+	if err := assinatura.Configurar(assinatura.Config{
+		Modo:            config.AssinaturaConfig.Modo,
+		DiretorioRaizes: config.AssinaturaConfig.DiretorioRaizes,
+	}); err != nil {
+		log.Fatal().Err(err).Msg("Configuração de verificação de assinaturas inválida")
+	}
+	log.Info().Str("modo", config.AssinaturaConfig.Modo).Msg("Verificação de assinaturas configurada")
+
+	// Iniciar o pool de processamento de XML
+	pool := jobs.NewPool(db, jobs.Config{
+		Workers:       config.JobsConfig.Workers,
+		MaxTentativas: config.JobsConfig.MaxTentativas,
+		BackoffBase:   time.Duration(config.JobsConfig.BackoffSegundos) * time.Second,
+	})
+	if err := pool.Start(); err != nil {
+		log.Fatal().Err(err).Msg("Erro ao iniciar o pool de jobs")
+	}
+
+	// Iniciar a importação dos diretórios monitorados, se configurada
+	var dirWatcher *watcher.Watcher
+	if len(config.WatchConfig.Diretorios) > 0 {
+		dirWatcher = watcher.NewWatcher(db, watcher.Config{
+			Diretorios: config.WatchConfig.Diretorios,
+			Limites: ingestao.Limites{
+				MaxEntradas:       config.UploadConfig.MaxEntradas,
+				MaxTamanhoEntrada: int64(config.UploadConfig.MaxTamanhoEntradaMB) << 20,
+				MaxTamanhoTotal:   int64(config.UploadConfig.MaxTamanhoTotalMB) << 20,
+			},
+			Estabilizacao: time.Duration(config.WatchConfig.EstabilizacaoSegundos) * time.Second,
+		})
+		if err := dirWatcher.Start(); err != nil {
+			log.Fatal().Err(err).Msg("Erro ao iniciar o monitoramento de diretórios")
+		}
+	}
+
 	// Criar o router Gin
 	router := gin.New()
 
@@ -119,7 +169,7 @@ func main() {
 	router.Use(gin.Recovery())
 
 	// Configurar rotas
-	routes.SetupRoutes(router, db)
+	routes.SetupRoutes(router, db, config)
 
 	// Configurar Swagger
 	if config.Environment != "production" {
@@ -164,6 +214,20 @@ func main() {
 		log.Fatal().Err(err).Msg("Erro ao desligar o servidor")
 	}
 
+	// Aguardar os jobs em execução antes de fechar o banco
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer drainCancel()
+
+	if dirWatcher != nil {
+		if err := dirWatcher.Shutdown(drainCtx); err != nil {
+			log.Error().Err(err).Msg("Erro ao finalizar o monitoramento de diretórios")
+		}
+	}
+
+	if err := pool.Shutdown(drainCtx); err != nil {
+		log.Error().Err(err).Msg("Erro ao finalizar o pool de jobs")
+	}
+
 	// Fechar conexão com o banco
 	sqlDB, err := db.DB()
 	if err == nil {
@@ -3,15 +3,21 @@ package configs
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config armazena todas as configurações da aplicação
 type Config struct {
-	Environment  string
-	ServerPort   string
-	DBConfig     DBConfig
-	JWTSecret    string
-	JWTExpiresIn int
+	Environment      string
+	ServerPort       string
+	DBConfig         DBConfig
+	JWTSecret        string
+	JWTExpiresIn     int
+	JobsConfig       JobsConfig
+	UploadConfig     UploadConfig
+	WatchConfig      WatchConfig
+	ValidacaoConfig  ValidacaoConfig
+	AssinaturaConfig AssinaturaConfig
 }
 
 // DBConfig armazena configurações do banco de dados
@@ -24,6 +30,41 @@ type DBConfig struct {
 	SSLMode  string
 }
 
+// JobsConfig armazena configurações do pool de processamento
+type JobsConfig struct {
+	Workers         int
+	MaxTentativas   int
+	BackoffSegundos int
+}
+
+// UploadConfig armazena os limites de arquivos compactados enviados
+type UploadConfig struct {
+	MaxEntradas         int
+	MaxTamanhoEntradaMB int
+	MaxTamanhoTotalMB   int
+}
+
+// WatchConfig armazena os diretórios monitorados para importação de XMLs
+type WatchConfig struct {
+	Diretorios            []string
+	EstabilizacaoSegundos int
+}
+
+// ValidacaoConfig armazena o modo de validação XSD e a origem dos schemas
+type ValidacaoConfig struct {
+	Modo string
+	// DiretorioSchemas substitui os schemas embutidos (ex.: pacotes oficiais completos)
+	DiretorioSchemas string
+}
+
+// AssinaturaConfig armazena o modo de verificação das assinaturas e as ACs confiáveis
+type AssinaturaConfig struct {
+	Modo string
+	// DiretorioRaizes contém os certificados das ACs raiz e intermediárias da ICP-Brasil,
+	// obrigatório no modo estrito (o padrão é tolerante, pois as ACs não acompanham o projeto)
+	DiretorioRaizes string
+}
+
 // LoadConfig carrega as configurações usando apenas variáveis de ambiente
 func LoadConfig(path string) (Config, error) {
 	config := Config{
@@ -39,6 +80,28 @@ func LoadConfig(path string) (Config, error) {
 		},
 		JWTSecret:    getEnv("JWT_SECRET", "default_jwt_secret_change_in_production"),
 		JWTExpiresIn: getEnvAsInt("JWT_EXPIRES_IN", 24),
+		JobsConfig: JobsConfig{
+			Workers:         getEnvAsInt("JOB_WORKERS", 4),
+			MaxTentativas:   getEnvAsInt("JOB_MAX_TENTATIVAS", 3),
+			BackoffSegundos: getEnvAsInt("JOB_BACKOFF_SEGUNDOS", 5),
+		},
+		UploadConfig: UploadConfig{
+			MaxEntradas:         getEnvAsInt("UPLOAD_MAX_ENTRADAS", 10000),
+			MaxTamanhoEntradaMB: getEnvAsInt("UPLOAD_MAX_TAMANHO_ENTRADA_MB", 10),
+			MaxTamanhoTotalMB:   getEnvAsInt("UPLOAD_MAX_TAMANHO_TOTAL_MB", 1024),
+		},
+		WatchConfig: WatchConfig{
+			Diretorios:            getEnvAsList("WATCH_DIRS"),
+			EstabilizacaoSegundos: getEnvAsInt("WATCH_ESTABILIZACAO_SEGUNDOS", 2),
+		},
+		ValidacaoConfig: ValidacaoConfig{
+			Modo:             getEnv("VALIDACAO_XSD_MODO", "tolerante"),
+			DiretorioSchemas: getEnv("VALIDACAO_XSD_DIR", ""),
+		},
+		AssinaturaConfig: AssinaturaConfig{
+			Modo:            getEnv("ASSINATURA_MODO", "tolerante"),
+			DiretorioRaizes: getEnv("ASSINATURA_RAIZES_DIR", ""),
+		},
 	}
 
 	return config, nil
@@ -60,3 +123,14 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsList obtém variável de ambiente como lista separada por vírgulas
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
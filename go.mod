@@ -2,7 +2,13 @@ module github.com/italosilva18/destack-transport-api
 
 go 1.23.0
 
-require github.com/stretchr/testify v1.10.0
+require (
+	github.com/boombuler/barcode v1.1.0
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/go-pdf/fpdf v0.9.0
+	github.com/stretchr/testify v1.10.0
+	golang.org/x/text v0.25.0
+)
 
 require (
 	github.com/KyleBanks/depth v1.2.1 // indirect
@@ -27,7 +33,6 @@ require (
 	github.com/bytedance/sonic/loader v0.2.4 // indirect
 	github.com/cloudwego/base64x v0.1.5 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/fsnotify/fsnotify v1.9.0 // indirect
 	github.com/gabriel-vasile/mimetype v1.4.9 // indirect
 	github.com/gin-contrib/cors v1.7.5
 	github.com/gin-contrib/sse v1.1.0 // indirect
@@ -70,7 +75,6 @@ require (
 	golang.org/x/net v0.40.0 // indirect
 	golang.org/x/sync v0.14.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
-	golang.org/x/text v0.25.0 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	gorm.io/driver/postgres v1.5.11
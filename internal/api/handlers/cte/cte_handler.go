@@ -1,11 +1,15 @@
 package cte
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/italosilva18/destack-transport-api/internal/pdf"
+	"github.com/italosilva18/destack-transport-api/internal/services"
 	"github.com/italosilva18/destack-transport-api/pkg/logger"
 	"github.com/rs/zerolog"
 	"gorm.io/gorm"
@@ -31,11 +35,12 @@ type ListCTEsRequest struct {
 	Limit          int    `form:"limit" binding:"omitempty,min=1,max=100"`
 	DataInicio     string `form:"data_inicio" binding:"omitempty"`
 	DataFim        string `form:"data_fim" binding:"omitempty"`
-	Modalidade     string `form:"modalidade" binding:"omitempty,oneof=CIF FOB"`
+	Modalidade     string `form:"modalidade" binding:"omitempty,oneof=CIF FOB TER"`
 	Status         string `form:"status" binding:"omitempty"`
 	NumeroDoc      string `form:"numero_doc" binding:"omitempty"`
 	EmitenteID     string `form:"emitente_id" binding:"omitempty"`
 	DestinatarioID string `form:"destinatario_id" binding:"omitempty"`
+	TomadorID      string `form:"tomador_id" binding:"omitempty"`
 }
 
 // ListCTEs lista os CTEs com filtros e paginação
@@ -60,7 +65,7 @@ func (h *CTEHandler) ListCTEs(c *gin.Context) {
 	offset := (page - 1) * limit
 
 	// Construir query
-	query := h.db.Model(&models.CTE{}).Preload("Emitente").Preload("Destinatario").Preload("Remetente")
+	query := h.db.Model(&models.CTE{}).Preload("Emitente").Preload("Destinatario").Preload("Remetente").Preload("Tomador")
 
 	// Aplicar filtros
 	if req.DataInicio != "" && req.DataFim != "" {
@@ -95,6 +100,10 @@ func (h *CTEHandler) ListCTEs(c *gin.Context) {
 		query = query.Where("destinatario_id = ?", req.DestinatarioID)
 	}
 
+	if req.TomadorID != "" {
+		query = query.Where("tomador_id = ?", req.TomadorID)
+	}
+
 	// Contar total para paginação
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -122,80 +131,290 @@ func (h *CTEHandler) ListCTEs(c *gin.Context) {
 	})
 }
 
+// CTEDetalhe é o CTE acompanhado da decomposição da chave de acesso e do resumo dos tributos
+type CTEDetalhe struct {
+	models.CTE
+	ChaveAcesso    *parsers.ChaveAcesso  `json:"chave_acesso,omitempty"`
+	ResumoImpostos models.ResumoImpostos `json:"resumo_impostos"`
+	// ReferenciadoPor são os CT-es complementares, de anulação ou substitutos deste CT-e
+	ReferenciadoPor []CTEReferenciador `json:"referenciado_por,omitempty"`
+}
+
+// CTEReferenciador é um CT-e que referencia outro como original
+type CTEReferenciador struct {
+	Chave      string  `json:"chave"`
+	Relacao    string  `json:"relacao"`
+	ValorTotal float64 `json:"valor_total"`
+	Cancelado  bool    `json:"cancelado"`
+}
+
 // GetCTE obtém um CTE pelo ID ou chave
 func (h *CTEHandler) GetCTE(c *gin.Context) {
 	chave := c.Param("chave")
 
 	var cte models.CTE
-	result := h.db.Preload("Emitente").Preload("Destinatario").Preload("Remetente").Preload("Tomador").Where("chave = ?", chave).First(&cte)
+	result := h.db.Preload("Emitente").Preload("Destinatario").Preload("Remetente").Preload("Expedidor").Preload("Recebedor").Preload("Tomador").Preload("Impostos").Preload("Componentes").Preload("Quantidades").Preload("Referencias").Scopes(models.PreloadModal).Where("chave = ?", chave).First(&cte)
 	if result.Error != nil {
 		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("CTE não encontrado")
 		c.JSON(http.StatusNotFound, gin.H{"error": "CTE não encontrado"})
 		return
 	}
 
-	c.JSON(http.StatusOK, cte)
+	detalhe := CTEDetalhe{CTE: cte}
+	for _, imposto := range cte.Impostos {
+		detalhe.ResumoImpostos.Somar(imposto.Tributo, imposto.Valor)
+	}
+	detalhe.ResumoImpostos.ValorTotalTributos = cte.ValorTotalTributos
+	if chaveAcesso, err := parsers.DecomporChaveAcesso(cte.Chave); err == nil {
+		detalhe.ChaveAcesso = chaveAcesso
+	}
+	if err := h.db.Table("cte_referencias AS r").
+		Joins("JOIN ctes s ON s.id = r.cte_id").
+		Where("r.chave_original = ?", cte.Chave).
+		Where("r.deleted_at IS NULL AND s.deleted_at IS NULL").
+		Select("s.chave, r.relacao, s.valor_total, s.cancelado").
+		Order("s.data_emissao").
+		Scan(&detalhe.ReferenciadoPor).Error; err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao buscar CT-es que referenciam o CTE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar CTE"})
+		return
+	}
+
+	c.JSON(http.StatusOK, detalhe)
 }
 
 // DownloadXML baixa o XML de um CTE
+//
+// O parâmetro opcional format define a representação retornada:
+//   - (vazio): XML original armazenado, byte a byte
+//   - procxml: XML de processamento (cteProc) com o protocolo de autorização
+//   - xml: apenas o elemento CTe, sem o protocolo
+//   - json: visão normalizada do documento em JSON
 func (h *CTEHandler) DownloadXML(c *gin.Context) {
 	chave := c.Param("chave")
+	formato := c.Query("format")
+
+	if formato != "" && formato != "procxml" && formato != "xml" && formato != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato inválido. Use procxml, xml ou json"})
+		return
+	}
+
+	var cte models.CTE
+	result := h.db.Select("id", "chave", "xml_original").Where("chave = ?", chave).First(&cte)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "CTE não encontrado"})
+			return
+		}
+		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("Erro ao buscar XML do CTE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar XML do CTE"})
+		return
+	}
+
+	if cte.XMLOriginal == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "XML do CTE não disponível"})
+		return
+	}
+
+	xmlOriginal := []byte(cte.XMLOriginal)
+	contentType := "application/xml; charset=" + parsers.CharsetXML(xmlOriginal)
+
+	switch formato {
+	case "procxml":
+		raiz, err := parsers.ElementoRaiz(xmlOriginal)
+		if err != nil || raiz != "cteProc" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "XML de processamento (cteProc) não disponível para este CTE"})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=cte_"+chave+"_proc.xml")
+		c.Data(http.StatusOK, contentType, xmlOriginal)
 
-	// Em um sistema real, recuperaríamos o XML armazenado
-	// Aqui, simulamos uma resposta com um XML simples
-	xml := `<?xml version="1.0" encoding="UTF-8"?>
-<CTe xmlns="http://www.portalfiscal.inf.br/cte">
-  <infCte Id="CTe` + chave + `">
-    <!-- conteúdo do CT-e aqui -->
-  </infCte>
-</CTe>`
-
-	c.Header("Content-Disposition", "attachment; filename=cte_"+chave+".xml")
-	c.Data(http.StatusOK, "application/xml", []byte(xml))
+	case "xml":
+		elemento, err := parsers.ExtrairElemento(xmlOriginal, "CTe")
+		if err != nil {
+			h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao extrair elemento CTe")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao extrair XML do CTE"})
+			return
+		}
+		conteudo := elemento
+		if declaracao := parsers.DeclaracaoXML(xmlOriginal); len(declaracao) > 0 {
+			conteudo = append(append(append([]byte{}, declaracao...), '\n'), elemento...)
+		}
+		c.Header("Content-Disposition", "attachment; filename=cte_"+chave+"_sem_protocolo.xml")
+		c.Data(http.StatusOK, contentType, conteudo)
+
+	case "json":
+		cteParsed, err := parsers.ParseCTe(xmlOriginal)
+		if err != nil {
+			h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao interpretar XML do CTE")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao interpretar XML do CTE"})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=cte_"+chave+".json")
+		c.JSON(http.StatusOK, cteParsed)
+
+	default:
+		c.Header("Content-Disposition", "attachment; filename=cte_"+chave+".xml")
+		c.Data(http.StatusOK, contentType, xmlOriginal)
+	}
 }
 
 // GerarDACTE gera um DACTE em PDF
+//
+// O parâmetro opcional orientacao (retrato ou paisagem) sobrepõe o tpImp do CT-e.
 func (h *CTEHandler) GerarDACTE(c *gin.Context) {
 	chave := c.Param("chave")
 
-	// Em um sistema real, geraria o PDF
-	// Aqui, simulamos uma resposta com um PDF simples
-	c.Header("Content-Disposition", "attachment; filename=dacte_"+chave+".pdf")
-	c.Data(http.StatusOK, "application/pdf", []byte("Simulação de PDF do DACTE"))
-}
-
-// Reprocessar reprocessa um CTE
-func (h *CTEHandler) Reprocessar(c *gin.Context) {
-	chave := c.Param("chave")
+	opcoes := pdf.Opcoes{}
+	switch c.Query("orientacao") {
+	case "":
+	case "retrato":
+		opcoes.Orientacao = pdf.OrientacaoRetrato
+	case "paisagem":
+		opcoes.Orientacao = pdf.OrientacaoPaisagem
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Orientação inválida. Use retrato ou paisagem"})
+		return
+	}
 
 	var cte models.CTE
-	result := h.db.Where("chave = ?", chave).First(&cte)
+	result := h.db.Select("id", "chave", "status", "cancelado", "xml_original").Where("chave = ?", chave).First(&cte)
 	if result.Error != nil {
-		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("CTE não encontrado")
-		c.JSON(http.StatusNotFound, gin.H{"error": "CTE não encontrado"})
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "CTE não encontrado"})
+			return
+		}
+		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("Erro ao buscar XML do CTE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar XML do CTE"})
 		return
 	}
 
-	// Em um sistema real, faria o reprocessamento
-	// Aqui, apenas atualizamos um campo para simular
-	if err := h.db.Model(&cte).Update("data_processamento", time.Now()).Error; err != nil {
+	if cte.XMLOriginal == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "XML do CTE não disponível"})
+		return
+	}
+
+	opcoes.Cancelado = cte.Cancelado || cte.Status == "101"
+
+	// O DACTE reflete a última carta de correção registrada
+	corrigido, err := services.CorrigirCTe(h.db, cte.Chave, []byte(cte.XMLOriginal))
+	if err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao aplicar carta de correção")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar DACTE"})
+		return
+	}
+	if corrigido.Carta != nil {
+		opcoes.CartaCorrecao = corrigido.Carta.Sequencia
+	}
+
+	conteudo, err := pdf.GerarDACTE(corrigido.XML, opcoes)
+	if err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao gerar DACTE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar DACTE"})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=dacte_"+chave+".pdf")
+	c.Data(http.StatusOK, "application/pdf", conteudo)
+}
+
+// Reprocessar reprocessa um CTE a partir do XML original armazenado
+// e retorna os campos que mudaram
+func (h *CTEHandler) Reprocessar(c *gin.Context) {
+	chave := c.Param("chave")
+
+	resultado, err := services.ReprocessarCTe(h.db, chave)
+	if err != nil {
 		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao reprocessar CTE")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao reprocessar CTE"})
+		switch {
+		case errors.Is(err, services.ErrDocumentoNaoEncontrado):
+			c.JSON(http.StatusNotFound, gin.H{"error": "CTE não encontrado"})
+		case errors.Is(err, services.ErrXMLIndisponivel):
+			c.JSON(http.StatusNotFound, gin.H{"error": "XML do CTE não disponível"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao reprocessar CTE: " + err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "CTE reprocessado com sucesso",
-		"chave":   chave,
+		"message":    "CTE reprocessado com sucesso",
+		"chave":      chave,
+		"alteracoes": resultado.Alteracoes,
 	})
 }
 
+// ReprocessarLoteRequest representa os filtros do reprocessamento em lote
+type ReprocessarLoteRequest struct {
+	Chaves     []string `json:"chaves"`
+	DataInicio string   `json:"data_inicio"`
+	DataFim    string   `json:"data_fim"`
+	Status     string   `json:"status"`
+	EmitenteID string   `json:"emitente_id"`
+	Limite     int      `json:"limite" binding:"omitempty,min=1,max=200"` // services.MaxReprocessamentoLote
+	AposChave  string   `json:"apos_chave"`
+}
+
+// ReprocessarLote reprocessa os CTEs que atendem aos filtros informados, no
+// máximo services.MaxReprocessamentoLote por chamada
+func (h *CTEHandler) ReprocessarLote(c *gin.Context) {
+	var req ReprocessarLoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filtro := services.FiltroReprocessamento{
+		Chaves:     req.Chaves,
+		Status:     req.Status,
+		EmitenteID: req.EmitenteID,
+		Limite:     req.Limite,
+		AposChave:  req.AposChave,
+	}
+
+	if req.DataInicio != "" {
+		dataInicio, err := time.Parse("2006-01-02", req.DataInicio)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data inválido"})
+			return
+		}
+		filtro.DataInicio = &dataInicio
+	}
+	if req.DataFim != "" {
+		dataFim, err := time.Parse("2006-01-02", req.DataFim)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data inválido"})
+			return
+		}
+		// Ajustar hora final para o final do dia
+		dataFim = time.Date(dataFim.Year(), dataFim.Month(), dataFim.Day(), 23, 59, 59, 0, dataFim.Location())
+		filtro.DataFim = &dataFim
+	}
+
+	// Sem nenhum filtro o lote abrangeria toda a base; exigir ao menos um critério
+	if len(filtro.Chaves) == 0 && filtro.DataInicio == nil && filtro.DataFim == nil &&
+		filtro.Status == "" && filtro.EmitenteID == "" && filtro.Limite == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Informe ao menos um filtro para o reprocessamento"})
+		return
+	}
+
+	resumo, err := services.ReprocessarCTesEmLote(h.db, filtro)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao reprocessar CTEs em lote")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao reprocessar CTEs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resumo)
+}
+
 // PainelCTEResponse representa a resposta para o painel de CT-e
 type PainelCTEResponse struct {
 	TotalCTEs          int64              `json:"total_ctes"`
 	ValorTotal         float64            `json:"valor_total"`
 	ValorCIF           float64            `json:"valor_cif"`
 	ValorFOB           float64            `json:"valor_fob"`
+	ValorTER           float64            `json:"valor_ter"`
 	TotalAutorizados   int64              `json:"total_autorizados"`
 	TotalCancelados    int64              `json:"total_cancelados"`
 	TotalRejeitados    int64              `json:"total_rejeitados"`
@@ -214,12 +433,101 @@ type TopCliente struct {
 	TicketMedio    float64 `json:"ticket_medio"`
 }
 
-// DistribuicaoCIFFOB representa a distribuição entre CIF e FOB
+// DistribuicaoCIFFOB representa a distribuição entre CIF, FOB e frete pago por terceiros
 type DistribuicaoCIFFOB struct {
 	ValorCIF      float64 `json:"valor_cif"`
 	ValorFOB      float64 `json:"valor_fob"`
+	ValorTER      float64 `json:"valor_ter"`
 	PercentualCIF float64 `json:"percentual_cif"`
 	PercentualFOB float64 `json:"percentual_fob"`
+	PercentualTER float64 `json:"percentual_ter"`
+}
+
+// CTECorrigido representa o CT-e original lado a lado com os dados corrigidos pela última CC-e
+type CTECorrigido struct {
+	Chave         string                     `json:"chave"`
+	CartaCorrecao *models.DocumentoEvento    `json:"carta_correcao"`
+	Correcoes     []parsers.CorrecaoAplicada `json:"correcoes"`
+	Original      *parsers.CTeParsed         `json:"original"`
+	Corrigido     *parsers.CTeParsed         `json:"corrigido"`
+}
+
+// GetCTECorrigido retorna o CT-e como emitido e como corrigido pela última carta de correção registrada
+func (h *CTEHandler) GetCTECorrigido(c *gin.Context) {
+	chave := c.Param("chave")
+
+	var cte models.CTE
+	result := h.db.Select("id", "chave", "xml_original").Where("chave = ?", chave).First(&cte)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "CTE não encontrado"})
+			return
+		}
+		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("Erro ao buscar XML do CTE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar XML do CTE"})
+		return
+	}
+
+	if cte.XMLOriginal == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "XML do CTE não disponível"})
+		return
+	}
+
+	corrigido, err := services.CorrigirCTe(h.db, cte.Chave, []byte(cte.XMLOriginal))
+	if err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao aplicar carta de correção")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao aplicar carta de correção"})
+		return
+	}
+
+	resposta := CTECorrigido{
+		Chave:         cte.Chave,
+		CartaCorrecao: corrigido.Carta,
+		Correcoes:     corrigido.Correcoes,
+	}
+	if resposta.Original, err = parsers.ParseCTe([]byte(cte.XMLOriginal)); err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao ler XML do CTE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao ler XML do CTE"})
+		return
+	}
+	if resposta.Corrigido, err = parsers.ParseCTe(corrigido.XML); err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao ler XML corrigido do CTE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao ler XML corrigido do CTE"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resposta)
+}
+
+// GetEventos retorna a linha do tempo de eventos do CTE, incluindo os
+// recebidos antes da importação do documento
+func (h *CTEHandler) GetEventos(c *gin.Context) {
+	chave := c.Param("chave")
+
+	var total int64
+	if err := h.db.Model(&models.CTE{}).Where("chave = ?", chave).Count(&total).Error; err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao buscar CTE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar eventos"})
+		return
+	}
+
+	eventos, err := models.EventosDocumento(h.db, chave)
+	if err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao buscar eventos do CTE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar eventos"})
+		return
+	}
+
+	if total == 0 && len(eventos) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "CTE não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"chave":               chave,
+		"documento_importado": total > 0,
+		"eventos":             eventos,
+	})
 }
 
 // GetPainelCTE retorna os dados para o painel de CT-e
@@ -284,6 +592,13 @@ func (h *CTEHandler) GetPainelCTE(c *gin.Context) {
 		Select("COALESCE(SUM(valor_total), 0)").
 		Scan(&response.ValorFOB)
 
+	// Valor pago por terceiros (toma4)
+	h.db.Model(&models.CTE{}).
+		Where("data_emissao BETWEEN ? AND ?", dataInicioTime, dataFimTime).
+		Where("modalidade_frete = ?", "TER").
+		Select("COALESCE(SUM(valor_total), 0)").
+		Scan(&response.ValorTER)
+
 	// Status
 	h.db.Model(&models.CTE{}).
 		Where("data_emissao BETWEEN ? AND ?", dataInicioTime, dataFimTime).
@@ -301,7 +616,7 @@ func (h *CTEHandler) GetPainelCTE(c *gin.Context) {
 		Where("cancelado = ?", false).
 		Count(&response.TotalRejeitados)
 
-	// Top Clientes (Destinatários)
+	// Top Clientes (tomadores do serviço)
 	type TopClienteQuery struct {
 		ID             string
 		Nome           string
@@ -321,7 +636,7 @@ func (h *CTEHandler) GetPainelCTE(c *gin.Context) {
 			COUNT(c.id) AS quantidade_ctes,
 			COALESCE(SUM(c.valor_total), 0) AS valor_total
 		FROM ctes c
-		JOIN empresas e ON c.destinatario_id = e.id
+		JOIN empresas e ON c.tomador_id = e.id
 		WHERE c.data_emissao BETWEEN ? AND ?
 		GROUP BY e.id, e.razao_social, e.cnpj, e.cpf
 		ORDER BY valor_total DESC
@@ -347,18 +662,21 @@ func (h *CTEHandler) GetPainelCTE(c *gin.Context) {
 		}
 	}
 
-	// Distribuição CIF/FOB
-	totalValor := response.ValorCIF + response.ValorFOB
+	// Distribuição CIF/FOB/terceiros
+	totalValor := response.ValorCIF + response.ValorFOB + response.ValorTER
 	response.DistribuicaoCIFFOB = DistribuicaoCIFFOB{
 		ValorCIF:      response.ValorCIF,
 		ValorFOB:      response.ValorFOB,
+		ValorTER:      response.ValorTER,
 		PercentualCIF: 0,
 		PercentualFOB: 0,
+		PercentualTER: 0,
 	}
 
 	if totalValor > 0 {
 		response.DistribuicaoCIFFOB.PercentualCIF = (response.ValorCIF / totalValor) * 100
 		response.DistribuicaoCIFFOB.PercentualFOB = (response.ValorFOB / totalValor) * 100
+		response.DistribuicaoCIFFOB.PercentualTER = (response.ValorTER / totalValor) * 100
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -0,0 +1,150 @@
+package cteos
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+// CTEOSHandler contém os handlers para CT-es OS
+type CTEOSHandler struct {
+	db     *gorm.DB
+	logger zerolog.Logger
+}
+
+// NewCTEOSHandler cria uma nova instância de CTEOSHandler
+func NewCTEOSHandler(db *gorm.DB) *CTEOSHandler {
+	return &CTEOSHandler{
+		db:     db,
+		logger: logger.GetLogger(),
+	}
+}
+
+// ListCTEOSRequest representa os parâmetros de request para listar CT-es OS
+type ListCTEOSRequest struct {
+	Page        int    `form:"page" binding:"omitempty,min=1"`
+	Limit       int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	DataInicio  string `form:"data_inicio" binding:"omitempty"`
+	DataFim     string `form:"data_fim" binding:"omitempty"`
+	TipoServico string `form:"tipo_servico" binding:"omitempty,oneof=6 7 8"`
+	Status      string `form:"status" binding:"omitempty"`
+	NumeroDoc   string `form:"numero_doc" binding:"omitempty"`
+	EmitenteID  string `form:"emitente_id" binding:"omitempty"`
+	TomadorID   string `form:"tomador_id" binding:"omitempty"`
+}
+
+// ListCTEOS lista os CT-es OS com filtros e paginação
+func (h *CTEOSHandler) ListCTEOS(c *gin.Context) {
+	var req ListCTEOSRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Valores padrão para paginação
+	page := 1
+	if req.Page > 0 {
+		page = req.Page
+	}
+
+	limit := 20
+	if req.Limit > 0 {
+		limit = req.Limit
+	}
+
+	offset := (page - 1) * limit
+
+	// Construir query
+	query := h.db.Model(&models.CTEOS{}).Preload("Emitente").Preload("Tomador")
+
+	// Aplicar filtros
+	if req.DataInicio != "" && req.DataFim != "" {
+		dataInicio, err := time.Parse("2006-01-02", req.DataInicio)
+		if err == nil {
+			dataFim, err := time.Parse("2006-01-02", req.DataFim)
+			if err == nil {
+				// Ajustar hora final para o final do dia
+				dataFim = time.Date(dataFim.Year(), dataFim.Month(), dataFim.Day(), 23, 59, 59, 0, dataFim.Location())
+				query = query.Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim)
+			}
+		}
+	}
+
+	if req.TipoServico != "" {
+		query = query.Where("tipo_servico = ?", req.TipoServico)
+	}
+
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	if req.NumeroDoc != "" {
+		query = query.Where("numero = ?", req.NumeroDoc)
+	}
+
+	if req.EmitenteID != "" {
+		query = query.Where("emitente_id = ?", req.EmitenteID)
+	}
+
+	if req.TomadorID != "" {
+		query = query.Where("tomador_id = ?", req.TomadorID)
+	}
+
+	// Contar total para paginação
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao contar CT-es OS")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao contar CT-es OS"})
+		return
+	}
+
+	// Buscar CT-es OS com paginação
+	var documentos []models.CTEOS
+	if err := query.Offset(offset).Limit(limit).Order("data_emissao DESC").Find(&documentos).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao listar CT-es OS")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao listar CT-es OS"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": documentos,
+		"meta": gin.H{
+			"current_page": page,
+			"per_page":     limit,
+			"total":        total,
+			"last_page":    (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// CTEOSDetalhe é o CT-e OS acompanhado da decomposição da chave de acesso
+type CTEOSDetalhe struct {
+	models.CTEOS
+	ChaveAcesso *parsers.ChaveAcesso `json:"chave_acesso,omitempty"`
+}
+
+// GetCTEOS obtém um CT-e OS pela chave
+func (h *CTEOSHandler) GetCTEOS(c *gin.Context) {
+	chave := c.Param("chave")
+
+	var cteOS models.CTEOS
+	result := h.db.Preload("Emitente").Preload("Tomador").Where("chave = ?", chave).First(&cteOS)
+	if result.Error != nil {
+		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("CT-e OS não encontrado")
+		c.JSON(http.StatusNotFound, gin.H{"error": "CT-e OS não encontrado"})
+		return
+	}
+
+	detalhe := CTEOSDetalhe{CTEOS: cteOS}
+	if chaveAcesso, err := parsers.DecomporChaveAcesso(cteOS.Chave); err == nil {
+		detalhe.ChaveAcesso = chaveAcesso
+	}
+
+	c.JSON(http.StatusOK, detalhe)
+}
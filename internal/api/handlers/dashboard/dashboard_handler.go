@@ -32,6 +32,18 @@ type DashboardRequest struct {
 	Periodo    string `form:"periodo" binding:"omitempty,oneof=mes trimestre ano 7dias 30dias personalizado"`
 	DataInicio string `form:"data_inicio" binding:"omitempty"`
 	DataFim    string `form:"data_fim" binding:"omitempty"`
+	// TipoDocumento restringe os totais a CT-e ou CT-e OS; vazio inclui os dois
+	TipoDocumento string `form:"tipo_documento" binding:"omitempty,oneof=CTE CTEOS"`
+	// Modal restringe os totais a um modal de transporte (01 a 06)
+	Modal string `form:"modal" binding:"omitempty,oneof=01 02 03 04 05 06"`
+}
+
+// ResumoModal representa a receita de um modal de transporte
+type ResumoModal struct {
+	Modal      string  `json:"modal"`
+	Descricao  string  `json:"descricao"`
+	Quantidade int64   `json:"quantidade"`
+	ValorTotal float64 `json:"valor_total"`
 }
 
 // GetDashboardCards retorna os dados dos cards do dashboard
@@ -49,36 +61,53 @@ func (h *DashboardHandler) GetDashboardCards(c *gin.Context) {
 		return
 	}
 
-	// Estatísticas de CT-e
-	var totalCTe int64
-	var valorTotalCTe float64
+	// Estatísticas de CT-e e CT-e OS
+	var totalCTe, totalCTeOS, naoVerificados int64
+	var valorTotalCTe, valorTotalCTeOS float64
 	var valorCIF float64
 	var valorFOB float64
+	var valorTerceiros float64
+
+	// Documentos de receita não cancelados do período
+	documentos := func() *gorm.DB {
+		query := h.db.Table(models.DocumentosReceita(req.TipoDocumento)+" AS d").
+			Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
+			Where("cancelado = ?", false)
+		if req.Modal != "" {
+			query = query.Where("modal = ?", req.Modal)
+		}
+		return query
+	}
 
-	// Contar total de CT-es no período
-	query := h.db.Model(&models.CTE{}).
-		Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-		Where("cancelado = ?", false)
-
-	err = query.Count(&totalCTe).Error
+	// Contar e somar os documentos por tipo (o CT-e complementar soma o valor, mas não é contado)
+	var porTipo []struct {
+		Tipo           string
+		Quantidade     int64
+		Valor          float64
+		NaoVerificados int64
+	}
+	err = documentos().
+		Select("tipo, " + models.SQLQuantidadePrestacoes + " AS quantidade, COALESCE(SUM(valor_total), 0) AS valor, " +
+			models.SQLNaoVerificados + " AS nao_verificados").
+		Group("tipo").
+		Scan(&porTipo).Error
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Erro ao contar CT-es")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados"})
 		return
 	}
-
-	// Somar valor total
-	err = query.Select("COALESCE(SUM(valor_total), 0)").Scan(&valorTotalCTe).Error
-	if err != nil {
-		h.logger.Error().Err(err).Msg("Erro ao calcular valor total de CT-es")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados"})
-		return
+	for _, linha := range porTipo {
+		naoVerificados += linha.NaoVerificados
+		switch linha.Tipo {
+		case models.DocumentoCTe:
+			totalCTe, valorTotalCTe = linha.Quantidade, linha.Valor
+		case models.DocumentoCTeOS:
+			totalCTeOS, valorTotalCTeOS = linha.Quantidade, linha.Valor
+		}
 	}
 
 	// Valor CIF
-	err = h.db.Model(&models.CTE{}).
-		Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-		Where("cancelado = ?", false).
+	err = documentos().
 		Where("modalidade_frete = ?", "CIF").
 		Select("COALESCE(SUM(valor_total), 0)").
 		Scan(&valorCIF).Error
@@ -89,9 +118,7 @@ func (h *DashboardHandler) GetDashboardCards(c *gin.Context) {
 	}
 
 	// Valor FOB
-	err = h.db.Model(&models.CTE{}).
-		Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-		Where("cancelado = ?", false).
+	err = documentos().
 		Where("modalidade_frete = ?", "FOB").
 		Select("COALESCE(SUM(valor_total), 0)").
 		Scan(&valorFOB).Error
@@ -101,12 +128,42 @@ func (h *DashboardHandler) GetDashboardCards(c *gin.Context) {
 		return
 	}
 
+	// Valor pago por terceiros (toma4)
+	err = documentos().
+		Where("modalidade_frete = ?", "TER").
+		Select("COALESCE(SUM(valor_total), 0)").
+		Scan(&valorTerceiros).Error
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao calcular valor pago por terceiros")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados"})
+		return
+	}
+
+	// Receita por modal de transporte
+	var porModal []ResumoModal
+	err = documentos().
+		Select("modal, " + models.SQLQuantidadePrestacoes + " AS quantidade, COALESCE(SUM(valor_total), 0) AS valor_total").
+		Group("modal").
+		Order("valor_total DESC").
+		Scan(&porModal).Error
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao agrupar por modal")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados"})
+		return
+	}
+	for i := range porModal {
+		porModal[i].Descricao = models.DescricaoModal[porModal[i].Modal]
+	}
+
 	// Estatísticas de MDF-e
 	var totalMDFe int64
-	err = h.db.Model(&models.MDFE{}).
+	queryMDFe := h.db.Model(&models.MDFE{}).
 		Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-		Where("cancelado = ?", false).
-		Count(&totalMDFe).Error
+		Where("cancelado = ?", false)
+	if req.Modal != "" {
+		queryMDFe = queryMDFe.Where("modal = ?", req.Modal)
+	}
+	err = queryMDFe.Count(&totalMDFe).Error
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Erro ao contar MDF-es")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados"})
@@ -114,11 +171,19 @@ func (h *DashboardHandler) GetDashboardCards(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"total_ctes":      totalCTe,
-		"valor_total_cte": valorTotalCTe,
-		"valor_cif":       valorCIF,
-		"valor_fob":       valorFOB,
-		"total_mdfe":      totalMDFe,
+		"total_ctes":        totalCTe,
+		"valor_total_cte":   valorTotalCTe,
+		"total_cteos":       totalCTeOS,
+		"valor_total_cteos": valorTotalCTeOS,
+		"valor_total":       valorTotalCTe + valorTotalCTeOS,
+		"valor_cif":         valorCIF,
+		"valor_fob":         valorFOB,
+		"valor_ter":         valorTerceiros,
+		"total_mdfe":        totalMDFe,
+		"por_modal":         porModal,
+		"modal":             req.Modal,
+		// Documentos incluídos nos totais sem assinatura digital verificada
+		"documentos_nao_verificados": naoVerificados,
 		"periodo": gin.H{
 			"data_inicio": dataInicio.Format("2006-01-02"),
 			"data_fim":    dataFim.Format("2006-01-02"),
@@ -218,6 +283,27 @@ func (h *DashboardHandler) GetUltimosLancamentos(c *gin.Context) {
 		})
 	}
 
+	// Buscar últimos CT-es OS
+	var cteos []models.CTEOS
+	if err := h.db.Order("data_emissao DESC").Limit(limit).Find(&cteos).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao buscar últimos CT-es OS")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar lançamentos"})
+		return
+	}
+	for _, cteOS := range cteos {
+		lancamentos = append(lancamentos, Lancamento{
+			ID:          cteOS.ID.String(),
+			Chave:       cteOS.Chave,
+			Tipo:        "CTEOS",
+			Numero:      cteOS.Numero,
+			DataEmissao: cteOS.DataEmissao,
+			ValorTotal:  cteOS.ValorTotal,
+			Origem:      cteOS.MunicipioInicio,
+			Destino:     cteOS.MunicipioFim,
+			Status:      cteOS.Status,
+		})
+	}
+
 	// Buscar últimos MDF-es (similar ao CT-e)
 	// Por simplicidade, omitimos o código de busca de MDF-es
 
@@ -256,6 +342,8 @@ func (h *DashboardHandler) GetCifFobData(c *gin.Context) {
 		Ano        int     `json:"ano"`
 		ValorCIF   float64 `json:"valor_cif"`
 		ValorFOB   float64 `json:"valor_fob"`
+		ValorTER   float64 `json:"valor_ter"`
+		ValorCTEOS float64 `json:"valor_cteos"`
 		ValorTotal float64 `json:"valor_total"`
 	}
 
@@ -268,15 +356,24 @@ func (h *DashboardHandler) GetCifFobData(c *gin.Context) {
             EXTRACT(MONTH FROM data_emissao) AS mes,
             SUM(CASE WHEN modalidade_frete = 'CIF' THEN valor_total ELSE 0 END) AS valor_cif,
             SUM(CASE WHEN modalidade_frete = 'FOB' THEN valor_total ELSE 0 END) AS valor_fob,
+            SUM(CASE WHEN modalidade_frete = 'TER' THEN valor_total ELSE 0 END) AS valor_ter,
+            SUM(CASE WHEN tipo = 'CTEOS' THEN valor_total ELSE 0 END) AS valor_cteos,
             SUM(valor_total) AS valor_total
-        FROM ctes
+        FROM ` + models.DocumentosReceita(req.TipoDocumento) + ` AS d
         WHERE data_emissao BETWEEN ? AND ?
         AND cancelado = false
+    `
+	params := []interface{}{dataInicio, dataFim}
+	if req.Modal != "" {
+		query += " AND modal = ?"
+		params = append(params, req.Modal)
+	}
+	query += `
         GROUP BY ano, mes
         ORDER BY ano, mes
     `
 
-	if err := h.db.Raw(query, dataInicio, dataFim).Scan(&dados).Error; err != nil {
+	if err := h.db.Raw(query, params...).Scan(&dados).Error; err != nil {
 		h.logger.Error().Err(err).Msg("Erro ao buscar dados CIF/FOB")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar dados para o gráfico"})
 		return
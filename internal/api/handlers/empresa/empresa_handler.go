@@ -161,7 +161,9 @@ func (h *EmpresaHandler) DeleteEmpresa(c *gin.Context) {
 
 	// Verificar se existem CT-es ou MDF-es vinculados
 	var countCTEs int64
-	h.db.Model(&models.CTE{}).Where("emitente_id = ? OR destinatario_id = ? OR remetente_id = ?", id, id, id).Count(&countCTEs)
+	h.db.Model(&models.CTE{}).
+		Where("emitente_id = ? OR destinatario_id = ? OR remetente_id = ? OR expedidor_id = ? OR recebedor_id = ? OR tomador_id = ?", id, id, id, id, id, id).
+		Count(&countCTEs)
 
 	if countCTEs > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -195,8 +197,10 @@ func (h *EmpresaHandler) GetEmpresa(c *gin.Context) {
 	var stats struct {
 		TotalCTEsEmitidos  int64      `json:"total_ctes_emitidos"`
 		TotalCTEsRecebidos int64      `json:"total_ctes_recebidos"`
+		TotalCTEsTomador   int64      `json:"total_ctes_tomador"`
 		ValorTotalEmitido  float64    `json:"valor_total_emitido"`
 		ValorTotalRecebido float64    `json:"valor_total_recebido"`
+		ValorTotalTomador  float64    `json:"valor_total_tomador"`
 		UltimaMovimentacao *time.Time `json:"ultima_movimentacao"`
 	}
 
@@ -218,6 +222,13 @@ func (h *EmpresaHandler) GetEmpresa(c *gin.Context) {
 		Select("COALESCE(SUM(valor_total), 0)").
 		Scan(&stats.ValorTotalRecebido)
 
+	// CT-es em que a empresa é a tomadora (paga o frete)
+	h.db.Model(&models.CTE{}).Where("tomador_id = ?", id).Count(&stats.TotalCTEsTomador)
+	h.db.Model(&models.CTE{}).
+		Where("tomador_id = ?", id).
+		Select("COALESCE(SUM(valor_total), 0)").
+		Scan(&stats.ValorTotalTomador)
+
 	// Última movimentação
 	var ultimoCTE models.CTE
 	if err := h.db.Where("emitente_id = ? OR destinatario_id = ?", id, id).
@@ -32,6 +32,8 @@ type FinanceiroRequest struct {
 	DataInicio  string `form:"data_inicio" binding:"omitempty"`
 	DataFim     string `form:"data_fim" binding:"omitempty"`
 	Agrupamento string `form:"agrupamento" binding:"omitempty,oneof=cliente veiculo distribuidora"`
+	// TipoDocumento restringe os totais a CT-e ou CT-e OS; vazio inclui os dois
+	TipoDocumento string `form:"tipo_documento" binding:"omitempty,oneof=CTE CTEOS"`
 }
 
 // GetDadosFinanceiros retorna os dados do painel financeiro
@@ -55,31 +57,51 @@ func (h *FinanceiroHandler) GetDadosFinanceiros(c *gin.Context) {
 	}
 
 	// Estatísticas principais
-	var totalFaturamento float64
-	var totalCTEs int64
-	var valorCIF, valorFOB float64
+	var totalFaturamento, valorCTEOS, valorComplementos float64
+	var totalCTEs, totalCTEOS, naoVerificados int64
+	var valorCIF, valorFOB, valorTerceiros float64
 
-	// Total de faturamento e CT-es
-	query := h.db.Model(&models.CTE{}).
-		Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-		Where("cancelado = ?", false)
+	// Documentos de receita (CT-e e CT-e OS) não cancelados do período
+	documentos := func() *gorm.DB {
+		return h.db.Table(models.DocumentosReceita(req.TipoDocumento)+" AS d").
+			Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
+			Where("cancelado = ?", false)
+	}
 
-	if err := query.Select("COALESCE(SUM(valor_total), 0)").Scan(&totalFaturamento).Error; err != nil {
+	// Total de faturamento e documentos por tipo; o CT-e complementar soma o
+	// valor, mas não conta como nova prestação
+	var porTipo []struct {
+		Tipo           string
+		Quantidade     int64
+		Valor          float64
+		Complemento    float64
+		NaoVerificados int64
+	}
+	if err := documentos().
+		Select("tipo, " + models.SQLQuantidadePrestacoes + " AS quantidade, COALESCE(SUM(valor_total), 0) AS valor, " +
+			"COALESCE(SUM(CASE WHEN tipo_cte = '1' THEN valor_total ELSE 0 END), 0) AS complemento, " +
+			models.SQLNaoVerificados + " AS nao_verificados").
+		Group("tipo").
+		Scan(&porTipo).Error; err != nil {
 		h.logger.Error().Err(err).Msg("Erro ao calcular faturamento total")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular dados financeiros"})
 		return
 	}
-
-	if err := query.Count(&totalCTEs).Error; err != nil {
-		h.logger.Error().Err(err).Msg("Erro ao contar total de CT-es")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular dados financeiros"})
-		return
+	for _, linha := range porTipo {
+		totalFaturamento += linha.Valor
+		valorComplementos += linha.Complemento
+		naoVerificados += linha.NaoVerificados
+		switch linha.Tipo {
+		case models.DocumentoCTe:
+			totalCTEs = linha.Quantidade
+		case models.DocumentoCTeOS:
+			totalCTEOS = linha.Quantidade
+			valorCTEOS = linha.Valor
+		}
 	}
 
-	// Valores CIF e FOB
-	if err := h.db.Model(&models.CTE{}).
-		Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-		Where("cancelado = ?", false).
+	// Valores CIF e FOB (apenas CT-e)
+	if err := documentos().
 		Where("modalidade_frete = ?", "CIF").
 		Select("COALESCE(SUM(valor_total), 0)").
 		Scan(&valorCIF).Error; err != nil {
@@ -88,9 +110,7 @@ func (h *FinanceiroHandler) GetDadosFinanceiros(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Model(&models.CTE{}).
-		Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-		Where("cancelado = ?", false).
+	if err := documentos().
 		Where("modalidade_frete = ?", "FOB").
 		Select("COALESCE(SUM(valor_total), 0)").
 		Scan(&valorFOB).Error; err != nil {
@@ -99,27 +119,56 @@ func (h *FinanceiroHandler) GetDadosFinanceiros(c *gin.Context) {
 		return
 	}
 
+	if err := documentos().
+		Where("modalidade_frete = ?", "TER").
+		Select("COALESCE(SUM(valor_total), 0)").
+		Scan(&valorTerceiros).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao calcular valor pago por terceiros")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular dados financeiros"})
+		return
+	}
+
+	// Tributos destacados nos documentos do período
+	impostos, err := h.resumoImpostos(dataInicio, dataFim, req.TipoDocumento)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao calcular impostos")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular dados financeiros"})
+		return
+	}
+
 	// Ticket médio
 	ticketMedio := float64(0)
-	if totalCTEs > 0 {
-		ticketMedio = totalFaturamento / float64(totalCTEs)
+	if totalCTEs+totalCTEOS > 0 {
+		ticketMedio = totalFaturamento / float64(totalCTEs+totalCTEOS)
 	}
 
-	// Percentuais CIF/FOB
-	percentCIF, percentFOB := 0.0, 0.0
+	// Percentuais CIF/FOB/terceiros e CT-e OS
+	percentCIF, percentFOB, percentTerceiros, percentCTEOS := 0.0, 0.0, 0.0, 0.0
 	if totalFaturamento > 0 {
 		percentCIF = (valorCIF / totalFaturamento) * 100
 		percentFOB = (valorFOB / totalFaturamento) * 100
+		percentTerceiros = (valorTerceiros / totalFaturamento) * 100
+		percentCTEOS = (valorCTEOS / totalFaturamento) * 100
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"faturamento_total": totalFaturamento,
-		"total_ctes":        totalCTEs,
-		"ticket_medio":      ticketMedio,
-		"valor_cif":         valorCIF,
-		"valor_fob":         valorFOB,
-		"percent_cif":       percentCIF,
-		"percent_fob":       percentFOB,
+		"faturamento_total":  totalFaturamento,
+		"total_ctes":         totalCTEs,
+		"total_cteos":        totalCTEOS,
+		"ticket_medio":       ticketMedio,
+		"valor_cif":          valorCIF,
+		"valor_fob":          valorFOB,
+		"valor_ter":          valorTerceiros,
+		"valor_cteos":        valorCTEOS,
+		"valor_complementos": valorComplementos,
+		"percent_cif":        percentCIF,
+		"percent_fob":        percentFOB,
+		"percent_ter":        percentTerceiros,
+		"percent_cteos":      percentCTEOS,
+		"impostos":           impostos,
+		"tipo_documento":     req.TipoDocumento,
+		// Documentos incluídos nos totais sem assinatura digital verificada
+		"documentos_nao_verificados": naoVerificados,
 		"periodo": gin.H{
 			"data_inicio": dataInicio.Format("2006-01-02"),
 			"data_fim":    dataFim.Format("2006-01-02"),
@@ -127,6 +176,51 @@ func (h *FinanceiroHandler) GetDadosFinanceiros(c *gin.Context) {
 	})
 }
 
+// resumoImpostos totaliza os tributos dos documentos não cancelados emitidos no
+// período. Do CT-e OS, que não tem os tributos detalhados, entra o ICMS.
+func (h *FinanceiroHandler) resumoImpostos(dataInicio, dataFim time.Time, tipoDocumento string) (models.ResumoImpostos, error) {
+	var resumo models.ResumoImpostos
+
+	if tipoDocumento != models.DocumentoCTeOS {
+		var porTributo []struct {
+			Tributo string
+			Valor   float64
+		}
+		if err := h.db.Table("cte_impostos AS i").
+			Joins("JOIN "+models.DocumentosReceita(models.DocumentoCTe)+" c ON c.id = i.cte_id").
+			Where("c.data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
+			Where("c.cancelado = ?", false).
+			Where("i.deleted_at IS NULL").
+			Select("i.tributo, COALESCE(SUM(i.valor), 0) AS valor").
+			Group("i.tributo").
+			Scan(&porTributo).Error; err != nil {
+			return resumo, err
+		}
+		for _, linha := range porTributo {
+			resumo.Somar(linha.Tributo, linha.Valor)
+		}
+	}
+
+	if tipoDocumento != models.DocumentoCTe {
+		var icmsCTEOS float64
+		if err := h.db.Model(&models.CTEOS{}).
+			Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
+			Where("cancelado = ?", false).
+			Select("COALESCE(SUM(valor_icms), 0)").
+			Scan(&icmsCTEOS).Error; err != nil {
+			return resumo, err
+		}
+		resumo.Somar("ICMS", icmsCTEOS)
+	}
+
+	err := h.db.Table(models.DocumentosReceita(tipoDocumento)+" AS d").
+		Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
+		Where("cancelado = ?", false).
+		Select("COALESCE(SUM(valor_total_tributos), 0)").
+		Scan(&resumo.ValorTotalTributos).Error
+	return resumo, err
+}
+
 // GetFaturamentoMensal retorna o faturamento mensal para o gráfico
 func (h *FinanceiroHandler) GetFaturamentoMensal(c *gin.Context) {
 	var req FinanceiroRequest
@@ -148,7 +242,10 @@ func (h *FinanceiroHandler) GetFaturamentoMensal(c *gin.Context) {
 		Ano         int     `json:"ano"`
 		ValorCIF    float64 `json:"valor_cif"`
 		ValorFOB    float64 `json:"valor_fob"`
+		ValorTER    float64 `json:"valor_ter"`
+		ValorCTEOS  float64 `json:"valor_cteos"`
 		ValorTotal  float64 `json:"valor_total"`
+		ValorICMS   float64 `json:"valor_icms"`
 		QtdEntregas int64   `json:"qtd_entregas"`
 	}
 
@@ -161,9 +258,12 @@ func (h *FinanceiroHandler) GetFaturamentoMensal(c *gin.Context) {
             EXTRACT(MONTH FROM data_emissao) AS mes,
             SUM(CASE WHEN modalidade_frete = 'CIF' THEN valor_total ELSE 0 END) AS valor_cif,
             SUM(CASE WHEN modalidade_frete = 'FOB' THEN valor_total ELSE 0 END) AS valor_fob,
+            SUM(CASE WHEN modalidade_frete = 'TER' THEN valor_total ELSE 0 END) AS valor_ter,
+            SUM(CASE WHEN tipo = 'CTEOS' THEN valor_total ELSE 0 END) AS valor_cteos,
             SUM(valor_total) AS valor_total,
-            COUNT(*) AS qtd_entregas
-        FROM ctes
+            SUM(valor_icms) AS valor_icms,
+            ` + models.SQLQuantidadePrestacoes + ` AS qtd_entregas
+        FROM ` + models.DocumentosReceita(req.TipoDocumento) + ` AS d
         WHERE data_emissao BETWEEN ? AND ?
         AND cancelado = false
         GROUP BY ano, mes
@@ -230,18 +330,21 @@ func (h *FinanceiroHandler) GetDadosAgrupados(c *gin.Context) {
 	var query string
 	var countQuery string
 
+	// CT-es e CT-es OS, conforme o filtro de tipo de documento
+	receitas := models.DocumentosReceita(req.TipoDocumento)
+
 	// Construir query baseada no tipo de agrupamento
 	switch req.Agrupamento {
 	case "cliente":
-		// Agrupar por cliente (destinatário)
+		// Agrupar por cliente (tomador do serviço, quem paga o frete)
 		query = `
             SELECT 
                 e.id,
                 e.razao_social AS nome,
                 SUM(c.valor_total) AS total,
-                COUNT(c.id) AS qtd_ctes
-            FROM ctes c
-            JOIN empresas e ON c.destinatario_id = e.id
+                ` + models.SQLQuantidadePrestacoes + ` AS qtd_ctes
+            FROM ` + receitas + ` c
+            JOIN empresas e ON c.tomador_id = e.id
             WHERE c.data_emissao BETWEEN ? AND ?
             AND c.cancelado = false
             GROUP BY e.id, e.razao_social
@@ -249,8 +352,8 @@ func (h *FinanceiroHandler) GetDadosAgrupados(c *gin.Context) {
             LIMIT ? OFFSET ?
         `
 		countQuery = `
-            SELECT COUNT(DISTINCT c.destinatario_id)
-            FROM ctes c
+            SELECT COUNT(DISTINCT c.tomador_id)
+            FROM ` + receitas + ` c
             WHERE c.data_emissao BETWEEN ? AND ?
             AND c.cancelado = false
         `
@@ -283,8 +386,8 @@ func (h *FinanceiroHandler) GetDadosAgrupados(c *gin.Context) {
                 e.id,
                 e.razao_social AS nome,
                 SUM(c.valor_total) AS total,
-                COUNT(c.id) AS qtd_ctes
-            FROM ctes c
+                ` + models.SQLQuantidadePrestacoes + ` AS qtd_ctes
+            FROM ` + receitas + ` c
             JOIN empresas e ON c.emitente_id = e.id
             WHERE c.data_emissao BETWEEN ? AND ?
             AND c.cancelado = false
@@ -294,7 +397,7 @@ func (h *FinanceiroHandler) GetDadosAgrupados(c *gin.Context) {
         `
 		countQuery = `
             SELECT COUNT(DISTINCT c.emitente_id)
-            FROM ctes c
+            FROM ` + receitas + ` c
             WHERE c.data_emissao BETWEEN ? AND ?
             AND c.cancelado = false
         `
@@ -346,6 +449,98 @@ func (h *FinanceiroHandler) GetDadosAgrupados(c *gin.Context) {
 	})
 }
 
+// GetComposicaoFrete retorna a composição do frete (componentes do valor da
+// prestação) e o preço médio por kg no período, opcionalmente de um cliente
+func (h *FinanceiroHandler) GetComposicaoFrete(c *gin.Context) {
+	var req FinanceiroRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Determinar período
+	dataInicio, dataFim, err := getPeriodDates(req.Periodo, req.DataInicio, req.DataFim)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	clienteID := c.Query("cliente_id")
+
+	type ComposicaoComponente struct {
+		Tipo       string  `json:"tipo"`
+		Valor      float64 `json:"valor"`
+		QtdCTEs    int64   `json:"qtd_ctes"`
+		Percentual float64 `json:"percentual"`
+	}
+
+	var componentes []ComposicaoComponente
+	query := h.db.Table("cte_componentes AS comp").
+		Joins("JOIN "+models.DocumentosReceita(models.DocumentoCTe)+" c ON c.id = comp.cte_id").
+		Where("c.data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
+		Where("c.cancelado = ?", false).
+		Where("comp.deleted_at IS NULL")
+	if clienteID != "" {
+		query = query.Where("c.tomador_id = ?", clienteID)
+	}
+	if err := query.Select("comp.tipo, COALESCE(SUM(comp.valor), 0) AS valor, COUNT(DISTINCT comp.cte_id) AS qtd_ctes").
+		Group("comp.tipo").
+		Order("valor DESC").
+		Scan(&componentes).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao buscar composição do frete")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar composição do frete"})
+		return
+	}
+
+	totalComponentes := 0.0
+	for _, componente := range componentes {
+		totalComponentes += componente.Valor
+	}
+	for i := range componentes {
+		if totalComponentes > 0 {
+			componentes[i].Percentual = (componentes[i].Valor / totalComponentes) * 100
+		}
+	}
+
+	// Preço por kg: apenas CT-es com peso informado em KG ou TON, sem os anulados e substituídos
+	var totais struct {
+		ValorFrete float64
+		PesoKg     float64
+		QtdCTEs    int64
+	}
+	queryPeso := h.db.Model(&models.CTE{}).
+		Where("id IN (SELECT id FROM "+models.DocumentosReceita(models.DocumentoCTe)+" AS d)").
+		Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
+		Where("cancelado = ?", false).
+		Where("peso_kg > 0")
+	if clienteID != "" {
+		queryPeso = queryPeso.Where("tomador_id = ?", clienteID)
+	}
+	if err := queryPeso.Select("COALESCE(SUM(valor_total), 0) AS valor_frete, COALESCE(SUM(peso_kg), 0) AS peso_kg, COUNT(*) AS qtd_ctes").
+		Scan(&totais).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao calcular preço por kg")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar composição do frete"})
+		return
+	}
+
+	valorPorKg := 0.0
+	if totais.PesoKg > 0 {
+		valorPorKg = totais.ValorFrete / totais.PesoKg
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"componentes":       componentes,
+		"total_componentes": totalComponentes,
+		"peso_total_kg":     totais.PesoKg,
+		"valor_por_kg":      valorPorKg,
+		"qtd_ctes_com_peso": totais.QtdCTEs,
+		"cliente_id":        clienteID,
+		"periodo": gin.H{
+			"data_inicio": dataInicio.Format("2006-01-02"),
+			"data_fim":    dataFim.Format("2006-01-02"),
+		},
+	})
+}
+
 // GetDetalheItem retorna detalhes de um item específico do agrupamento
 func (h *FinanceiroHandler) GetDetalheItem(c *gin.Context) {
 	id := c.Param("id")
@@ -375,51 +570,50 @@ func (h *FinanceiroHandler) GetDetalheItem(c *gin.Context) {
 			return
 		}
 
-		// Buscar CT-es do cliente
-		var ctes []models.CTE
-		if err := h.db.Where("destinatario_id = ?", id).
+		// Documentos pagos pelo cliente (tomador), com os mesmos valores compensados do agrupamento
+		receitas := h.db.Table(models.DocumentosReceita(req.TipoDocumento)+" AS d").
+			Where("tomador_id = ?", id).
 			Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-			Where("cancelado = ?", false).
+			Where("cancelado = ?", false)
+
+		var ctes []struct {
+			ID              string    `json:"id"`
+			Tipo            string    `json:"tipo"`
+			Numero          int       `json:"numero"`
+			Chave           string    `json:"chave"`
+			DataEmissao     time.Time `json:"data_emissao"`
+			Status          string    `json:"status"`
+			ValorTotal      float64   `json:"valor_total"`
+			ModalidadeFrete *string   `json:"modalidade_frete"`
+			TipoCTe         string    `json:"tipo_cte"`
+		}
+		if err := receitas.Session(&gorm.Session{}).
+			Select("id, tipo, numero, chave, data_emissao, status, valor_total, modalidade_frete, tipo_cte").
 			Order("data_emissao DESC").
 			Limit(20).
-			Find(&ctes).Error; err != nil {
+			Scan(&ctes).Error; err != nil {
 			h.logger.Error().Err(err).Str("id", id).Msg("Erro ao buscar CT-es do cliente")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar detalhes"})
 			return
 		}
 
 		// Calcular valores
-		var totalCTEs int64
-		var valorTotal, valorCIF, valorFOB float64
-
-		h.db.Model(&models.CTE{}).
-			Where("destinatario_id = ?", id).
-			Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-			Where("cancelado = ?", false).
-			Count(&totalCTEs)
-
-		h.db.Model(&models.CTE{}).
-			Where("destinatario_id = ?", id).
-			Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-			Where("cancelado = ?", false).
-			Select("COALESCE(SUM(valor_total), 0)").
-			Scan(&valorTotal)
-
-		h.db.Model(&models.CTE{}).
-			Where("destinatario_id = ?", id).
-			Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-			Where("cancelado = ?", false).
-			Where("modalidade_frete = ?", "CIF").
-			Select("COALESCE(SUM(valor_total), 0)").
-			Scan(&valorCIF)
-
-		h.db.Model(&models.CTE{}).
-			Where("destinatario_id = ?", id).
-			Where("data_emissao BETWEEN ? AND ?", dataInicio, dataFim).
-			Where("cancelado = ?", false).
-			Where("modalidade_frete = ?", "FOB").
-			Select("COALESCE(SUM(valor_total), 0)").
-			Scan(&valorFOB)
+		var totais struct {
+			Quantidade int64
+			ValorTotal float64
+			ValorCIF   float64
+			ValorFOB   float64
+		}
+		if err := receitas.Session(&gorm.Session{}).
+			Select("COALESCE(" + models.SQLQuantidadePrestacoes + ", 0) AS quantidade, " +
+				"COALESCE(SUM(valor_total), 0) AS valor_total, " +
+				"COALESCE(SUM(CASE WHEN modalidade_frete = 'CIF' THEN valor_total ELSE 0 END), 0) AS valor_cif, " +
+				"COALESCE(SUM(CASE WHEN modalidade_frete = 'FOB' THEN valor_total ELSE 0 END), 0) AS valor_fob").
+			Scan(&totais).Error; err != nil {
+			h.logger.Error().Err(err).Str("id", id).Msg("Erro ao totalizar CT-es do cliente")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar detalhes"})
+			return
+		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"cliente": gin.H{
@@ -430,10 +624,10 @@ func (h *FinanceiroHandler) GetDetalheItem(c *gin.Context) {
 				"uf":           cliente.UF,
 			},
 			"ctes":        ctes,
-			"total_ctes":  totalCTEs,
-			"valor_total": valorTotal,
-			"valor_cif":   valorCIF,
-			"valor_fob":   valorFOB,
+			"total_ctes":  totais.Quantidade,
+			"valor_total": totais.ValorTotal,
+			"valor_cif":   totais.ValorCIF,
+			"valor_fob":   totais.ValorFOB,
 			"periodo": gin.H{
 				"data_inicio": dataInicio.Format("2006-01-02"),
 				"data_fim":    dataFim.Format("2006-01-02"),
@@ -0,0 +1,89 @@
+package financeiro
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDetalheClienteCompensado(t *testing.T) {
+	logger.InitLogger()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "financeiro.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.CTE{}, &models.CTEOS{}, &models.CTEReferencia{}, &models.CTEImposto{}))
+
+	cnpj := "55555555000191"
+	cliente := models.Empresa{CNPJ: &cnpj, RazaoSocial: "CLIENTE"}
+	require.NoError(t, db.Create(&cliente).Error)
+	emissao := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	documento := func(chave, tipo string, valor float64) models.DocumentoFiscal {
+		return models.DocumentoFiscal{Chave: fmt.Sprintf("%044s", chave), Tipo: tipo, Numero: 1, Serie: "1", DataEmissao: emissao, Status: "100",
+			ValorTotal: valor, EmitenteID: cliente.ID, UFInicio: "SP", UFDestino: "RJ"}
+	}
+	cte := func(chave, tipoCTe string, valor float64) models.CTE {
+		return models.CTE{DocumentoFiscal: documento(chave, "CTE", valor), TomadorID: &cliente.ID, ModalidadeFrete: "CIF", TipoCTe: tipoCTe,
+			RemetenteID: cliente.ID, DestinatarioID: cliente.ID, CFOP: "6353"}
+	}
+
+	// Normal, complementar, anulado (com o CT-e de anulação) e CT-e OS
+	normal, complementar := cte("1", "0", 1000), cte("2", "1", 200)
+	anulado, anulacao := cte("3", "0", 800), cte("4", "2", 800)
+	for _, c := range []*models.CTE{&normal, &complementar, &anulado, &anulacao} {
+		require.NoError(t, db.Create(c).Error)
+	}
+	require.NoError(t, db.Create(&models.CTEReferencia{CTEID: anulacao.ID, ChaveOriginal: anulado.Chave, Relacao: models.RelacaoAnulacao}).Error)
+	require.NoError(t, db.Create(&models.CTEOS{DocumentoFiscal: documento("5", "CTEOS", 500), TomadorID: &cliente.ID, CFOP: "6357", TipoServico: "6"}).Error)
+
+	router := gin.New()
+	router.GET("/detalhes/:tipo/:id", NewFinanceiroHandler(db).GetDetalheItem)
+	resposta := httptest.NewRecorder()
+	router.ServeHTTP(resposta, httptest.NewRequest(http.MethodGet,
+		"/detalhes/cliente/"+cliente.ID.String()+"?periodo=personalizado&data_inicio=2024-01-01&data_fim=2024-01-31", nil))
+	require.Equal(t, http.StatusOK, resposta.Code, resposta.Body.String())
+
+	var detalhe struct {
+		CTEs       []map[string]interface{} `json:"ctes"`
+		TotalCTEs  int64                    `json:"total_ctes"`
+		ValorTotal float64                  `json:"valor_total"`
+		ValorCIF   float64                  `json:"valor_cif"`
+	}
+	require.NoError(t, json.Unmarshal(resposta.Body.Bytes(), &detalhe))
+	assert.Len(t, detalhe.CTEs, 3)
+	assert.Equal(t, int64(2), detalhe.TotalCTEs)
+	assert.Equal(t, 1700.0, detalhe.ValorTotal)
+	assert.Equal(t, 1200.0, detalhe.ValorCIF)
+
+	// Somente CT-e
+	resposta = httptest.NewRecorder()
+	router.ServeHTTP(resposta, httptest.NewRequest(http.MethodGet,
+		"/detalhes/cliente/"+cliente.ID.String()+"?periodo=personalizado&data_inicio=2024-01-01&data_fim=2024-01-31&tipo_documento=CTE", nil))
+	require.NoError(t, json.Unmarshal(resposta.Body.Bytes(), &detalhe))
+	assert.Equal(t, 1200.0, detalhe.ValorTotal)
+
+	// O painel sinaliza os documentos somados sem assinatura verificada
+	require.NoError(t, db.Model(&normal).Update("assinatura_status", "VALIDA").Error)
+	router.GET("/dados", NewFinanceiroHandler(db).GetDadosFinanceiros)
+	resposta = httptest.NewRecorder()
+	router.ServeHTTP(resposta, httptest.NewRequest(http.MethodGet, "/dados?periodo=personalizado&data_inicio=2024-01-01&data_fim=2024-01-31", nil))
+	require.Equal(t, http.StatusOK, resposta.Code, resposta.Body.String())
+	var dados struct {
+		FaturamentoTotal         float64 `json:"faturamento_total"`
+		DocumentosNaoVerificados int64   `json:"documentos_nao_verificados"`
+	}
+	require.NoError(t, json.Unmarshal(resposta.Body.Bytes(), &dados))
+	assert.Equal(t, 1700.0, dados.FaturamentoTotal)
+	assert.Equal(t, int64(2), dados.DocumentosNaoVerificados)
+}
@@ -31,6 +31,8 @@ type GeograficoRequest struct {
 	DataInicio string `form:"data_inicio" binding:"omitempty"`
 	DataFim    string `form:"data_fim" binding:"omitempty"`
 	UF         string `form:"uf" binding:"omitempty,len=2"`
+	// Modal restringe a um modal de transporte (01 a 06)
+	Modal string `form:"modal" binding:"omitempty,oneof=01 02 03 04 05 06"`
 }
 
 // GetDadosGeograficos retorna os dados do painel geográfico
@@ -68,6 +70,10 @@ func (h *GeograficoHandler) GetDadosGeograficos(c *gin.Context) {
 		queryOrigens = queryOrigens.Where("uf_inicio = ?", req.UF)
 	}
 
+	if req.Modal != "" {
+		queryOrigens = queryOrigens.Where("modal = ?", req.Modal)
+	}
+
 	queryOrigens.Count(&totalOrigens)
 
 	// Destino: combinação única de UF_destino e municipio_fim
@@ -79,6 +85,10 @@ func (h *GeograficoHandler) GetDadosGeograficos(c *gin.Context) {
 		queryDestinos = queryDestinos.Where("uf_destino = ?", req.UF)
 	}
 
+	if req.Modal != "" {
+		queryDestinos = queryDestinos.Where("modal = ?", req.Modal)
+	}
+
 	queryDestinos.Count(&totalDestinos)
 
 	// Rotas: combinação única de origem e destino
@@ -90,6 +100,10 @@ func (h *GeograficoHandler) GetDadosGeograficos(c *gin.Context) {
 		queryRotas = queryRotas.Where("uf_inicio = ? OR uf_destino = ?", req.UF, req.UF)
 	}
 
+	if req.Modal != "" {
+		queryRotas = queryRotas.Where("modal = ?", req.Modal)
+	}
+
 	queryRotas.Count(&totalRotas)
 
 	c.JSON(http.StatusOK, gin.H{
@@ -100,7 +114,8 @@ func (h *GeograficoHandler) GetDadosGeograficos(c *gin.Context) {
 			"data_inicio": dataInicio.Format("2006-01-02"),
 			"data_fim":    dataFim.Format("2006-01-02"),
 		},
-		"filtro_uf": req.UF,
+		"filtro_uf":    req.UF,
+		"filtro_modal": req.Modal,
 	})
 }
 
@@ -166,6 +181,11 @@ func (h *GeograficoHandler) GetTopOrigens(c *gin.Context) {
 		countQuery += " AND uf_inicio = ?"
 		params = append(params, req.UF)
 	}
+	if req.Modal != "" {
+		query += " AND modal = ?"
+		countQuery += " AND modal = ?"
+		params = append(params, req.Modal)
+	}
 
 	query += `
         GROUP BY uf_inicio, municipio_inicio
@@ -204,7 +224,8 @@ func (h *GeograficoHandler) GetTopOrigens(c *gin.Context) {
 			"data_inicio": dataInicio.Format("2006-01-02"),
 			"data_fim":    dataFim.Format("2006-01-02"),
 		},
-		"filtro_uf": req.UF,
+		"filtro_uf":    req.UF,
+		"filtro_modal": req.Modal,
 	})
 }
 
@@ -270,6 +291,11 @@ func (h *GeograficoHandler) GetTopDestinos(c *gin.Context) {
 		countQuery += " AND uf_destino = ?"
 		params = append(params, req.UF)
 	}
+	if req.Modal != "" {
+		query += " AND modal = ?"
+		countQuery += " AND modal = ?"
+		params = append(params, req.Modal)
+	}
 
 	query += `
         GROUP BY uf_destino, municipio_fim
@@ -308,7 +334,8 @@ func (h *GeograficoHandler) GetTopDestinos(c *gin.Context) {
 			"data_inicio": dataInicio.Format("2006-01-02"),
 			"data_fim":    dataFim.Format("2006-01-02"),
 		},
-		"filtro_uf": req.UF,
+		"filtro_uf":    req.UF,
+		"filtro_modal": req.Modal,
 	})
 }
 
@@ -380,6 +407,11 @@ func (h *GeograficoHandler) GetRotasFrequentes(c *gin.Context) {
 		countQuery += " AND (uf_inicio = ? OR uf_destino = ?)"
 		params = append(params, req.UF, req.UF)
 	}
+	if req.Modal != "" {
+		query += " AND modal = ?"
+		countQuery += " AND modal = ?"
+		params = append(params, req.Modal)
+	}
 
 	query += `
         GROUP BY uf_inicio, municipio_inicio, uf_destino, municipio_fim
@@ -418,7 +450,8 @@ func (h *GeograficoHandler) GetRotasFrequentes(c *gin.Context) {
 			"data_inicio": dataInicio.Format("2006-01-02"),
 			"data_fim":    dataFim.Format("2006-01-02"),
 		},
-		"filtro_uf": req.UF,
+		"filtro_uf":    req.UF,
+		"filtro_modal": req.Modal,
 	})
 }
 
@@ -464,6 +497,10 @@ func (h *GeograficoHandler) GetFluxoUFs(c *gin.Context) {
 		query += " AND (uf_inicio = ? OR uf_destino = ?)"
 		params = append(params, req.UF, req.UF)
 	}
+	if req.Modal != "" {
+		query += " AND modal = ?"
+		params = append(params, req.Modal)
+	}
 
 	query += `
         GROUP BY uf_inicio, uf_destino
@@ -483,10 +520,234 @@ func (h *GeograficoHandler) GetFluxoUFs(c *gin.Context) {
 			"data_inicio": dataInicio.Format("2006-01-02"),
 			"data_fim":    dataFim.Format("2006-01-02"),
 		},
+		"filtro_uf":    req.UF,
+		"filtro_modal": req.Modal,
+	})
+}
+
+// FluxoModal representa a movimentação de um modal de transporte
+type FluxoModal struct {
+	Modal      string  `json:"modal"`
+	Descricao  string  `json:"descricao"`
+	Quantidade int64   `json:"quantidade"`
+	ValorTotal float64 `json:"valor_total"`
+	TotalRotas int64   `json:"total_rotas"`
+}
+
+// GetFluxoModais retorna a quantidade, o valor e as rotas distintas de cada modal
+func (h *GeograficoHandler) GetFluxoModais(c *gin.Context) {
+	var req GeograficoRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Determinar período
+	dataInicio, dataFim, err := getPeriodDates("", req.DataInicio, req.DataFim)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filtro := `
+        WHERE data_emissao BETWEEN ? AND ?
+        AND cancelado = false
+    `
+	params := []interface{}{dataInicio, dataFim}
+
+	// Adicionar filtro de UF se fornecido
+	if req.UF != "" {
+		filtro += " AND (uf_inicio = ? OR uf_destino = ?)"
+		params = append(params, req.UF, req.UF)
+	}
+
+	query := `
+        SELECT 
+            modal,
+            COUNT(*) AS quantidade,
+            COALESCE(SUM(valor_total), 0) AS valor_total
+        FROM ctes
+    ` + filtro + `
+        GROUP BY modal
+        ORDER BY quantidade DESC
+    `
+
+	var modais []FluxoModal
+	if err := h.db.Raw(query, params...).Scan(&modais).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao buscar fluxo por modal")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar fluxo por modal"})
+		return
+	}
+
+	// Rotas distintas (origem e destino) de cada modal
+	rotasQuery := `
+        SELECT modal, COUNT(*) AS total_rotas FROM (
+            SELECT DISTINCT modal, uf_inicio, municipio_inicio, uf_destino, municipio_fim
+            FROM ctes
+    ` + filtro + `
+        ) AS t
+        GROUP BY modal
+    `
+
+	var rotas []struct {
+		Modal      string
+		TotalRotas int64
+	}
+	if err := h.db.Raw(rotasQuery, params...).Scan(&rotas).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao contar rotas por modal")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar fluxo por modal"})
+		return
+	}
+	totalRotas := make(map[string]int64, len(rotas))
+	for _, rota := range rotas {
+		totalRotas[rota.Modal] = rota.TotalRotas
+	}
+
+	for i := range modais {
+		modais[i].Descricao = models.DescricaoModal[modais[i].Modal]
+		modais[i].TotalRotas = totalRotas[modais[i].Modal]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": modais,
+		"periodo": gin.H{
+			"data_inicio": dataInicio.Format("2006-01-02"),
+			"data_fim":    dataFim.Format("2006-01-02"),
+		},
 		"filtro_uf": req.UF,
 	})
 }
 
+// DescargaMDFe representa um município de descarga de MDF-es no ranking
+type DescargaMDFe struct {
+	UF                   string `json:"uf"`
+	CodigoMunicipio      string `json:"codigo_municipio"`
+	Municipio            string `json:"municipio"`
+	QuantidadeMDFes      int64  `json:"quantidade_mdfes" gorm:"column:quantidade_mdfes"`
+	QuantidadeDocumentos int64  `json:"quantidade_documentos"`
+}
+
+// GetDescargasMDFe retorna os municípios de descarga dos MDF-es, considerando
+// todas as paradas de cada manifesto, e o resumo de paradas por viagem
+func (h *GeograficoHandler) GetDescargasMDFe(c *gin.Context) {
+	var req GeograficoRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Determinar período
+	dataInicio, dataFim, err := getPeriodDates("", req.DataInicio, req.DataFim)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Paginação
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	juncao := `
+        FROM mdfe_descargas d
+        JOIN mdfes m ON m.id = d.mdfe_id
+    `
+	filtro := `
+        WHERE m.data_emissao BETWEEN ? AND ?
+        AND m.cancelado = false
+        AND m.deleted_at IS NULL
+        AND d.deleted_at IS NULL
+    `
+	params := []interface{}{dataInicio, dataFim}
+
+	// Adicionar filtro de UF (da descarga) se fornecido
+	if req.UF != "" {
+		filtro += " AND d.uf = ?"
+		params = append(params, req.UF)
+	}
+	if req.Modal != "" {
+		filtro += " AND m.modal = ?"
+		params = append(params, req.Modal)
+	}
+
+	query := `
+        SELECT 
+            d.uf,
+            d.codigo_municipio,
+            d.municipio,
+            COUNT(DISTINCT d.mdfe_id) AS quantidade_mdfes,
+            COUNT(dd.id) AS quantidade_documentos
+    ` + juncao + `
+        LEFT JOIN mdfe_descarga_documentos dd ON dd.descarga_id = d.id AND dd.deleted_at IS NULL
+    ` + filtro + `
+        GROUP BY d.uf, d.codigo_municipio, d.municipio
+        ORDER BY quantidade_mdfes DESC, quantidade_documentos DESC
+        LIMIT ? OFFSET ?
+    `
+
+	var descargas []DescargaMDFe
+	if err := h.db.Raw(query, append(params, limit, offset)...).Scan(&descargas).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao buscar municípios de descarga")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar municípios de descarga"})
+		return
+	}
+
+	// Contar total para paginação
+	var total int64
+	countQuery := "SELECT COUNT(*) FROM (SELECT DISTINCT d.uf, d.codigo_municipio, d.municipio " + juncao + filtro + ") AS t"
+	if err := h.db.Raw(countQuery, params...).Scan(&total).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao contar municípios de descarga")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao contar municípios de descarga"})
+		return
+	}
+
+	// Paradas por manifesto
+	var resumo struct {
+		TotalMDFes            int64   `json:"total_mdfes" gorm:"column:total_mdfes"`
+		TotalParadas          int64   `json:"total_paradas"`
+		MDFesMultiplasParadas int64   `json:"mdfes_multiplas_paradas" gorm:"column:mdfes_multiplas_paradas"`
+		MediaParadas          float64 `json:"media_paradas"`
+	}
+	resumoQuery := `
+        SELECT 
+            COUNT(*) AS total_mdfes,
+            COALESCE(SUM(paradas), 0) AS total_paradas,
+            COALESCE(SUM(CASE WHEN paradas > 1 THEN 1 ELSE 0 END), 0) AS mdfes_multiplas_paradas
+        FROM (SELECT d.mdfe_id, COUNT(*) AS paradas ` + juncao + filtro + ` GROUP BY d.mdfe_id) AS t
+    `
+	if err := h.db.Raw(resumoQuery, params...).Scan(&resumo).Error; err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao resumir paradas dos MDF-es")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar municípios de descarga"})
+		return
+	}
+	if resumo.TotalMDFes > 0 {
+		resumo.MediaParadas = float64(resumo.TotalParadas) / float64(resumo.TotalMDFes)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   descargas,
+		"resumo": resumo,
+		"meta": gin.H{
+			"current_page": page,
+			"per_page":     limit,
+			"total":        total,
+			"last_page":    (total + int64(limit) - 1) / int64(limit),
+		},
+		"periodo": gin.H{
+			"data_inicio": dataInicio.Format("2006-01-02"),
+			"data_fim":    dataFim.Format("2006-01-02"),
+		},
+		"filtro_uf":    req.UF,
+		"filtro_modal": req.Modal,
+	})
+}
+
 // Helper para obter datas de início e fim baseado no período
 func getPeriodDates(periodo, dataInicioStr, dataFimStr string) (time.Time, time.Time, error) {
 	now := time.Now()
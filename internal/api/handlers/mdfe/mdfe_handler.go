@@ -1,11 +1,15 @@
 package mdfe
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/italosilva18/destack-transport-api/internal/pdf"
+	"github.com/italosilva18/destack-transport-api/internal/services"
 	"github.com/italosilva18/destack-transport-api/pkg/logger"
 	"github.com/rs/zerolog"
 	"gorm.io/gorm"
@@ -27,14 +31,15 @@ func NewMDFEHandler(db *gorm.DB) *MDFEHandler {
 
 // ListMDFEsRequest representa os parâmetros de request para listar MDFEs
 type ListMDFEsRequest struct {
-	Page       int    `form:"page" binding:"omitempty,min=1"`
-	Limit      int    `form:"limit" binding:"omitempty,min=1,max=100"`
-	DataInicio string `form:"data_inicio" binding:"omitempty"`
-	DataFim    string `form:"data_fim" binding:"omitempty"`
-	Placa      string `form:"placa" binding:"omitempty"`
-	Status     string `form:"status" binding:"omitempty"`
-	UFInicio   string `form:"uf_inicio" binding:"omitempty"`
-	UFFim      string `form:"uf_fim" binding:"omitempty"`
+	Page        int    `form:"page" binding:"omitempty,min=1"`
+	Limit       int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	DataInicio  string `form:"data_inicio" binding:"omitempty"`
+	DataFim     string `form:"data_fim" binding:"omitempty"`
+	Placa       string `form:"placa" binding:"omitempty"` // tração ou qualquer reboque
+	CPFCondutor string `form:"cpf_condutor" binding:"omitempty"`
+	Status      string `form:"status" binding:"omitempty"`
+	UFInicio    string `form:"uf_inicio" binding:"omitempty"`
+	UFFim       string `form:"uf_fim" binding:"omitempty"`
 }
 
 // ListMDFEs lista os MDFEs com filtros e paginação
@@ -75,9 +80,14 @@ func (h *MDFEHandler) ListMDFEs(c *gin.Context) {
 	}
 
 	if req.Placa != "" {
-		// Assumindo que temos um relacionamento para Veiculo aqui
-		query = query.Joins("JOIN veiculos v ON mdfe.veiculo_tracao_id = v.id").
-			Where("v.placa LIKE ?", "%"+req.Placa+"%")
+		placa := "%" + req.Placa + "%"
+		query = query.Where(`veiculo_tracao_id IN (SELECT id FROM veiculos WHERE placa LIKE ?)
+			OR id IN (SELECT r.mdfe_id FROM mdfe_reboques r JOIN veiculos v ON v.id = r.veiculo_id
+				WHERE v.placa LIKE ? AND r.deleted_at IS NULL)`, placa, placa)
+	}
+
+	if req.CPFCondutor != "" {
+		query = query.Where("id IN (SELECT mdfe_id FROM mdfe_condutores WHERE cpf = ? AND deleted_at IS NULL)", req.CPFCondutor)
 	}
 
 	if req.Status != "" {
@@ -130,7 +140,12 @@ func (h *MDFEHandler) GetMDFE(c *gin.Context) {
 	chave := c.Param("chave")
 
 	var mdfe models.MDFE
-	result := h.db.Preload("Emitente").Where("chave = ?", chave).First(&mdfe)
+	result := h.db.Preload("Emitente").Preload("VeiculoTracao").
+		Preload("Reboques", func(db *gorm.DB) *gorm.DB { return db.Order("posicao") }).Preload("Reboques.Veiculo").
+		Preload("Condutores", func(db *gorm.DB) *gorm.DB { return db.Order("ordem") }).Preload("Contratantes").
+		Preload("Descargas", func(db *gorm.DB) *gorm.DB { return db.Order("ordem") }).Preload("Descargas.Documentos").
+		Preload("Seguros", func(db *gorm.DB) *gorm.DB { return db.Order("ordem") }).Preload("Seguros.Averbacoes").
+		Scopes(models.PreloadModal).Where("chave = ?", chave).First(&mdfe)
 	if result.Error != nil {
 		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("MDFE não encontrado")
 		c.JSON(http.StatusNotFound, gin.H{"error": "MDFE não encontrado"})
@@ -141,55 +156,151 @@ func (h *MDFEHandler) GetMDFE(c *gin.Context) {
 }
 
 // DownloadXML baixa o XML de um MDFE
+//
+// O parâmetro opcional format define a representação retornada:
+//   - (vazio): XML original armazenado, byte a byte
+//   - procxml: XML de processamento (mdfeProc) com o protocolo de autorização
+//   - xml: apenas o elemento MDFe, sem o protocolo
+//   - json: visão normalizada do documento em JSON
 func (h *MDFEHandler) DownloadXML(c *gin.Context) {
 	chave := c.Param("chave")
+	formato := c.Query("format")
+
+	if formato != "" && formato != "procxml" && formato != "xml" && formato != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato inválido. Use procxml, xml ou json"})
+		return
+	}
+
+	var mdfe models.MDFE
+	result := h.db.Select("id", "chave", "xml_original").Where("chave = ?", chave).First(&mdfe)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "MDFE não encontrado"})
+			return
+		}
+		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("Erro ao buscar XML do MDFE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar XML do MDFE"})
+		return
+	}
 
-	// Em um sistema real, recuperaríamos o XML armazenado
-	// Aqui, simulamos uma resposta com um XML simples
-	xml := `<?xml version="1.0" encoding="UTF-8"?>
-<MDFe xmlns="http://www.portalfiscal.inf.br/mdfe">
-  <infMDFe Id="MDFe` + chave + `">
-    <!-- conteúdo do MDF-e aqui -->
-  </infMDFe>
-</MDFe>`
-
-	c.Header("Content-Disposition", "attachment; filename=mdfe_"+chave+".xml")
-	c.Data(http.StatusOK, "application/xml", []byte(xml))
+	if mdfe.XMLOriginal == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "XML do MDFE não disponível"})
+		return
+	}
+
+	xmlOriginal := []byte(mdfe.XMLOriginal)
+	contentType := "application/xml; charset=" + parsers.CharsetXML(xmlOriginal)
+
+	switch formato {
+	case "procxml":
+		raiz, err := parsers.ElementoRaiz(xmlOriginal)
+		if err != nil || raiz != "mdfeProc" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "XML de processamento (mdfeProc) não disponível para este MDFE"})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=mdfe_"+chave+"_proc.xml")
+		c.Data(http.StatusOK, contentType, xmlOriginal)
+
+	case "xml":
+		elemento, err := parsers.ExtrairElemento(xmlOriginal, "MDFe")
+		if err != nil {
+			h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao extrair elemento MDFe")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao extrair XML do MDFE"})
+			return
+		}
+		conteudo := elemento
+		if declaracao := parsers.DeclaracaoXML(xmlOriginal); len(declaracao) > 0 {
+			conteudo = append(append(append([]byte{}, declaracao...), '\n'), elemento...)
+		}
+		c.Header("Content-Disposition", "attachment; filename=mdfe_"+chave+"_sem_protocolo.xml")
+		c.Data(http.StatusOK, contentType, conteudo)
+
+	case "json":
+		mdfeParsed, err := parsers.ParseMDFe(xmlOriginal)
+		if err != nil {
+			h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao interpretar XML do MDFE")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao interpretar XML do MDFE"})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=mdfe_"+chave+".json")
+		c.JSON(http.StatusOK, mdfeParsed)
+
+	default:
+		c.Header("Content-Disposition", "attachment; filename=mdfe_"+chave+".xml")
+		c.Data(http.StatusOK, contentType, xmlOriginal)
+	}
 }
 
 // GerarDAMDFE gera um DAMDFE em PDF
+//
+// O parâmetro opcional orientacao (retrato ou paisagem) define o layout; o padrão é retrato.
 func (h *MDFEHandler) GerarDAMDFE(c *gin.Context) {
 	chave := c.Param("chave")
 
-	// Em um sistema real, geraria o PDF
-	// Aqui, simulamos uma resposta com um PDF simples
-	c.Header("Content-Disposition", "attachment; filename=damdfe_"+chave+".pdf")
-	c.Data(http.StatusOK, "application/pdf", []byte("Simulação de PDF do DAMDFE"))
-}
-
-// Reprocessar reprocessa um MDFE
-func (h *MDFEHandler) Reprocessar(c *gin.Context) {
-	chave := c.Param("chave")
+	opcoes := pdf.Opcoes{}
+	switch c.Query("orientacao") {
+	case "", "retrato":
+		opcoes.Orientacao = pdf.OrientacaoRetrato
+	case "paisagem":
+		opcoes.Orientacao = pdf.OrientacaoPaisagem
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Orientação inválida. Use retrato ou paisagem"})
+		return
+	}
 
 	var mdfe models.MDFE
-	result := h.db.Where("chave = ?", chave).First(&mdfe)
+	result := h.db.Select("id", "chave", "status", "cancelado", "xml_original").Where("chave = ?", chave).First(&mdfe)
 	if result.Error != nil {
-		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("MDFE não encontrado")
-		c.JSON(http.StatusNotFound, gin.H{"error": "MDFE não encontrado"})
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "MDFE não encontrado"})
+			return
+		}
+		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("Erro ao buscar XML do MDFE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar XML do MDFE"})
+		return
+	}
+
+	if mdfe.XMLOriginal == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "XML do MDFE não disponível"})
 		return
 	}
 
-	// Em um sistema real, faria o reprocessamento
-	// Aqui, apenas atualizamos um campo para simular
-	if err := h.db.Model(&mdfe).Update("data_processamento", time.Now()).Error; err != nil {
+	opcoes.Cancelado = mdfe.Cancelado || mdfe.Status == "101"
+
+	conteudo, err := pdf.GerarDAMDFE([]byte(mdfe.XMLOriginal), opcoes)
+	if err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao gerar DAMDFE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar DAMDFE"})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=damdfe_"+chave+".pdf")
+	c.Data(http.StatusOK, "application/pdf", conteudo)
+}
+
+// Reprocessar reprocessa um MDFE a partir do XML original armazenado
+// e retorna os campos que mudaram
+func (h *MDFEHandler) Reprocessar(c *gin.Context) {
+	chave := c.Param("chave")
+
+	resultado, err := services.ReprocessarMDFe(h.db, chave)
+	if err != nil {
 		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao reprocessar MDFE")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao reprocessar MDFE"})
+		switch {
+		case errors.Is(err, services.ErrDocumentoNaoEncontrado):
+			c.JSON(http.StatusNotFound, gin.H{"error": "MDFE não encontrado"})
+		case errors.Is(err, services.ErrXMLIndisponivel):
+			c.JSON(http.StatusNotFound, gin.H{"error": "XML do MDFE não disponível"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao reprocessar MDFE: " + err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "MDFE reprocessado com sucesso",
-		"chave":   chave,
+		"message":    "MDFE reprocessado com sucesso",
+		"chave":      chave,
+		"alteracoes": resultado.Alteracoes,
 	})
 }
 
@@ -232,28 +343,117 @@ func (h *MDFEHandler) Encerrar(c *gin.Context) {
 	})
 }
 
-// GetDocumentosVinculados retorna os documentos vinculados a um MDFE
+// DocumentoDescarga é um CT-e ou NF-e entregue em um município de descarga
+type DocumentoDescarga struct {
+	Tipo  string `json:"tipo"`
+	Chave string `json:"chave"`
+	// Importado indica se o documento está na base (a NF-e é indexada pela chave ao importar o MDF-e)
+	Importado bool     `json:"importado"`
+	Valor     *float64 `json:"valor"`
+	Cancelado bool     `json:"cancelado"`
+}
+
+// MunicipioDescarga é uma parada do MDF-e com os documentos entregues nela
+type MunicipioDescarga struct {
+	Ordem           int                 `json:"ordem"`
+	CodigoMunicipio string              `json:"codigo_municipio"`
+	Municipio       string              `json:"municipio"`
+	UF              string              `json:"uf"`
+	ValorTotal      float64             `json:"valor_total"`
+	Documentos      []DocumentoDescarga `json:"documentos"`
+}
+
+// GetDocumentosVinculados retorna os documentos do MDFE agrupados por município de descarga
 func (h *MDFEHandler) GetDocumentosVinculados(c *gin.Context) {
 	chave := c.Param("chave")
 
-	// Em um sistema real, buscaríamos a relação entre MDF-e e CT-e
-	// Aqui, simulamos alguns dados
-	documentos := []gin.H{
-		{
-			"tipo":  "CTE",
-			"chave": "31234567890123456789012345678901234567890123",
-			"valor": 1500.50,
-		},
-		{
-			"tipo":  "CTE",
-			"chave": "31234567890123456789012345678901234567890124",
-			"valor": 2500.75,
-		},
+	var mdfe models.MDFE
+	result := h.db.Select("id", "chave", "municipio_inicio", "uf_inicio", "uf_destino").
+		Preload("Descargas", func(db *gorm.DB) *gorm.DB { return db.Order("ordem") }).
+		Preload("Descargas.Documentos").
+		Where("chave = ?", chave).First(&mdfe)
+	if result.Error != nil {
+		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("MDFE não encontrado")
+		c.JSON(http.StatusNotFound, gin.H{"error": "MDFE não encontrado"})
+		return
+	}
+
+	// Valores dos CT-es e NF-es já importados
+	var chavesCTe, chavesNFe []string
+	for _, descarga := range mdfe.Descargas {
+		for _, doc := range descarga.Documentos {
+			if doc.Tipo == "CTE" {
+				chavesCTe = append(chavesCTe, doc.Chave)
+			} else {
+				chavesNFe = append(chavesNFe, doc.Chave)
+			}
+		}
+	}
+
+	var ctes []models.CTE
+	if len(chavesCTe) > 0 {
+		if err := h.db.Select("chave", "valor_total", "cancelado").Where("chave IN ?", chavesCTe).Find(&ctes).Error; err != nil {
+			h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao buscar CT-es do MDFE")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar documentos vinculados"})
+			return
+		}
+	}
+	ctesPorChave := make(map[string]models.CTE, len(ctes))
+	for _, cte := range ctes {
+		ctesPorChave[cte.Chave] = cte
+	}
+
+	var nfes []models.NFe
+	if len(chavesNFe) > 0 {
+		if err := h.db.Select("chave", "valor").Where("chave IN ?", chavesNFe).Find(&nfes).Error; err != nil {
+			h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao buscar NF-es do MDFE")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar documentos vinculados"})
+			return
+		}
+	}
+	nfesPorChave := make(map[string]models.NFe, len(nfes))
+	for _, nfe := range nfes {
+		nfesPorChave[nfe.Chave] = nfe
+	}
+
+	descargas := make([]MunicipioDescarga, 0, len(mdfe.Descargas))
+	documentos := []DocumentoDescarga{}
+	for _, descarga := range mdfe.Descargas {
+		municipio := MunicipioDescarga{
+			Ordem:           descarga.Ordem,
+			CodigoMunicipio: descarga.CodigoMunicipio,
+			Municipio:       descarga.Municipio,
+			UF:              descarga.UF,
+			Documentos:      []DocumentoDescarga{},
+		}
+		for _, doc := range descarga.Documentos {
+			documento := DocumentoDescarga{Tipo: doc.Tipo, Chave: doc.Chave}
+			if cte, ok := ctesPorChave[doc.Chave]; ok && doc.Tipo == "CTE" {
+				valor := cte.ValorTotal
+				documento.Importado = true
+				documento.Valor = &valor
+				documento.Cancelado = cte.Cancelado
+			} else if nfe, ok := nfesPorChave[doc.Chave]; ok && doc.Tipo == "NFE" {
+				documento.Importado = true
+				documento.Valor = nfe.Valor
+			}
+			if documento.Valor != nil && !documento.Cancelado {
+				municipio.ValorTotal += *documento.Valor
+			}
+			municipio.Documentos = append(municipio.Documentos, documento)
+			documentos = append(documentos, documento)
+		}
+		descargas = append(descargas, municipio)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"mdfe_chave": chave,
-		"documentos": documentos,
+		"mdfe_chave":       mdfe.Chave,
+		"municipio_inicio": mdfe.MunicipioInicio,
+		"uf_inicio":        mdfe.UFInicio,
+		"uf_destino":       mdfe.UFDestino,
+		"total_paradas":    len(descargas),
+		"descargas":        descargas,
+		"documentos":       documentos,
 	})
 }
 
@@ -286,6 +486,37 @@ type DistribuicaoCTEs struct {
 	QuantidadeCTEs int64  `json:"quantidade_ctes"`
 }
 
+// GetEventos retorna a linha do tempo de eventos do MDFE, incluindo os
+// recebidos antes da importação do documento
+func (h *MDFEHandler) GetEventos(c *gin.Context) {
+	chave := c.Param("chave")
+
+	var total int64
+	if err := h.db.Model(&models.MDFE{}).Where("chave = ?", chave).Count(&total).Error; err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao buscar MDFE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar eventos"})
+		return
+	}
+
+	eventos, err := models.EventosDocumento(h.db, chave)
+	if err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao buscar eventos do MDFE")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar eventos"})
+		return
+	}
+
+	if total == 0 && len(eventos) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "MDFE não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"chave":               chave,
+		"documento_importado": total > 0,
+		"eventos":             eventos,
+	})
+}
+
 // GetPainelMDFE retorna os dados para o painel de MDF-e
 func (h *MDFEHandler) GetPainelMDFE(c *gin.Context) {
 	// Obter parâmetros de filtro
@@ -0,0 +1,107 @@
+package nfe
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+// NFeHandler contém os handlers para as NF-es referenciadas pelos documentos de transporte
+type NFeHandler struct {
+	db     *gorm.DB
+	logger zerolog.Logger
+}
+
+// NewNFeHandler cria uma nova instância de NFeHandler
+func NewNFeHandler(db *gorm.DB) *NFeHandler {
+	return &NFeHandler{
+		db:     db,
+		logger: logger.GetLogger(),
+	}
+}
+
+// MDFeRastreio é um MDF-e que levou a NF-e, diretamente ou por meio de um CT-e
+type MDFeRastreio struct {
+	models.MDFE
+	// Via indica como o MDF-e referencia a nota: NFE (infNFe) ou CTE (infCTe)
+	Via      string `json:"via"`
+	ChaveCTe string `json:"chave_cte,omitempty"`
+}
+
+// RastreioNFe é a resposta do rastreamento de uma NF-e
+type RastreioNFe struct {
+	NFe   models.NFe     `json:"nfe"`
+	CTes  []models.CTE   `json:"ctes"`
+	MDFes []MDFeRastreio `json:"mdfes"`
+	// UltimaMovimentacao é a emissão mais recente entre os documentos que levaram a nota
+	UltimaMovimentacao *time.Time `json:"ultima_movimentacao"`
+}
+
+// Rastrear retorna todos os CT-es e MDF-es que transportaram a NF-e
+func (h *NFeHandler) Rastrear(c *gin.Context) {
+	chave := c.Param("chave")
+
+	var nfe models.NFe
+	result := h.db.
+		Preload("CTes", func(db *gorm.DB) *gorm.DB { return db.Order("data_emissao") }).
+		Preload("CTes.Emitente").
+		Preload("CTes.Tomador").
+		Preload("CTes.MDFes").
+		Preload("MDFes").
+		Where("chave = ?", chave).
+		First(&nfe)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "NF-e não encontrada em nenhum CT-e ou MDF-e"})
+			return
+		}
+		h.logger.Error().Err(result.Error).Str("chave", chave).Msg("Erro ao buscar NF-e")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar NF-e"})
+		return
+	}
+
+	rastreio := RastreioNFe{NFe: nfe, CTes: nfe.CTes, MDFes: []MDFeRastreio{}}
+	rastreio.NFe.CTes, rastreio.NFe.MDFes = nil, nil
+
+	// MDF-es que citam a nota e os que citam os CT-es dela
+	vistos := make(map[string]bool)
+	for _, mdfe := range nfe.MDFes {
+		vistos[mdfe.Chave] = true
+		rastreio.MDFes = append(rastreio.MDFes, MDFeRastreio{MDFE: mdfe, Via: "NFE"})
+	}
+	for i := range rastreio.CTes {
+		for _, mdfe := range rastreio.CTes[i].MDFes {
+			if vistos[mdfe.Chave] {
+				continue
+			}
+			vistos[mdfe.Chave] = true
+			rastreio.MDFes = append(rastreio.MDFes, MDFeRastreio{MDFE: mdfe, Via: "CTE", ChaveCTe: rastreio.CTes[i].Chave})
+		}
+		rastreio.CTes[i].MDFes = nil
+	}
+	sort.Slice(rastreio.MDFes, func(i, j int) bool {
+		return rastreio.MDFes[i].DataEmissao.Before(rastreio.MDFes[j].DataEmissao)
+	})
+
+	for _, cte := range rastreio.CTes {
+		if rastreio.UltimaMovimentacao == nil || cte.DataEmissao.After(*rastreio.UltimaMovimentacao) {
+			data := cte.DataEmissao
+			rastreio.UltimaMovimentacao = &data
+		}
+	}
+	for _, mdfe := range rastreio.MDFes {
+		if rastreio.UltimaMovimentacao == nil || mdfe.DataEmissao.After(*rastreio.UltimaMovimentacao) {
+			data := mdfe.DataEmissao
+			rastreio.UltimaMovimentacao = &data
+		}
+	}
+
+	c.JSON(http.StatusOK, rastreio)
+}
@@ -0,0 +1,165 @@
+package seguro
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+// SeguroHandler contém os handlers de seguro da carga
+type SeguroHandler struct {
+	db     *gorm.DB
+	logger zerolog.Logger
+}
+
+// NewSeguroHandler cria uma nova instância de SeguroHandler
+func NewSeguroHandler(db *gorm.DB) *SeguroHandler {
+	return &SeguroHandler{
+		db:     db,
+		logger: logger.GetLogger(),
+	}
+}
+
+// ApoliceRequest representa os parâmetros de request da consulta de apólice
+type ApoliceRequest struct {
+	DataInicio     string `form:"data_inicio" binding:"omitempty"`
+	DataFim        string `form:"data_fim" binding:"omitempty"`
+	SeguradoraCNPJ string `form:"seguradora_cnpj" binding:"omitempty,len=14"`
+}
+
+// ViagemApolice é um MDF-e coberto pela apólice
+type ViagemApolice struct {
+	MDFEID          uuid.UUID `json:"mdfe_id"`
+	Chave           string    `json:"chave"`
+	Numero          int       `json:"numero"`
+	Serie           string    `json:"serie"`
+	DataEmissao     time.Time `json:"data_emissao"`
+	UFInicio        string    `json:"uf_inicio"`
+	UFDestino       string    `json:"uf_destino"`
+	MunicipioInicio string    `json:"municipio_inicio"`
+	MunicipioFim    string    `json:"municipio_fim"`
+	Placa           string    `json:"placa"`
+	ValorCarga      float64   `json:"valor_carga"`
+	PesoBrutoTotal  float64   `json:"peso_bruto_total"`
+	Encerrado       bool      `json:"encerrado"`
+	SeguradoraNome  string    `json:"seguradora_nome"`
+	SeguradoraCNPJ  string    `json:"seguradora_cnpj"`
+	Responsavel     string    `json:"responsavel"` // 1-emitente, 2-contratante
+	Averbacoes      []string  `json:"averbacoes" gorm:"-"`
+
+	SeguroID uuid.UUID `json:"-"`
+}
+
+// GetApolice lista as viagens (MDF-es não cancelados) cobertas por uma apólice,
+// com as averbações de cada uma
+func (h *SeguroHandler) GetApolice(c *gin.Context) {
+	numero := c.Param("numero")
+
+	var req ApoliceRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.db.Table("mdfe_seguros s").
+		Select(`s.id AS seguro_id, m.id AS mdfe_id, m.chave, m.numero, m.serie, m.data_emissao,
+			m.uf_inicio, m.uf_destino, m.municipio_inicio, m.municipio_fim, v.placa,
+			m.valor_total AS valor_carga, m.peso_bruto_total, m.encerrado,
+			s.seguradora_nome, s.seguradora_cnpj, s.responsavel`).
+		Joins("JOIN mdfes m ON m.id = s.mdfe_id").
+		Joins("LEFT JOIN veiculos v ON v.id = m.veiculo_tracao_id").
+		Where("s.numero_apolice = ? AND s.deleted_at IS NULL", numero).
+		Where("m.deleted_at IS NULL AND m.cancelado = ?", false)
+
+	if req.SeguradoraCNPJ != "" {
+		query = query.Where("s.seguradora_cnpj = ?", req.SeguradoraCNPJ)
+	}
+
+	// Período de emissão, normalmente o mês do relatório à seguradora
+	if req.DataInicio != "" {
+		dataInicio, err := time.Parse("2006-01-02", req.DataInicio)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Data inicial inválida. Use o formato AAAA-MM-DD"})
+			return
+		}
+		query = query.Where("m.data_emissao >= ?", dataInicio)
+	}
+	if req.DataFim != "" {
+		dataFim, err := time.Parse("2006-01-02", req.DataFim)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Data final inválida. Use o formato AAAA-MM-DD"})
+			return
+		}
+		// Ajustar hora final para o final do dia
+		dataFim = time.Date(dataFim.Year(), dataFim.Month(), dataFim.Day(), 23, 59, 59, 0, dataFim.Location())
+		query = query.Where("m.data_emissao <= ?", dataFim)
+	}
+
+	var linhas []ViagemApolice
+	if err := query.Order("m.data_emissao, m.numero, s.ordem").Scan(&linhas).Error; err != nil {
+		h.logger.Error().Err(err).Str("apolice", numero).Msg("Erro ao buscar viagens da apólice")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar viagens da apólice"})
+		return
+	}
+
+	// Averbações de cada grupo de seguro
+	seguroIDs := make([]uuid.UUID, 0, len(linhas))
+	for _, linha := range linhas {
+		seguroIDs = append(seguroIDs, linha.SeguroID)
+	}
+	var averbacoes []struct {
+		SeguroID uuid.UUID
+		Numero   string
+	}
+	if len(seguroIDs) > 0 {
+		if err := h.db.Table("mdfe_averbacoes").Select("seguro_id, numero").
+			Where("seguro_id IN ? AND deleted_at IS NULL", seguroIDs).
+			Order("numero").Scan(&averbacoes).Error; err != nil {
+			h.logger.Error().Err(err).Str("apolice", numero).Msg("Erro ao buscar averbações da apólice")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar viagens da apólice"})
+			return
+		}
+	}
+	averbacoesPorSeguro := make(map[uuid.UUID][]string, len(seguroIDs))
+	for _, averbacao := range averbacoes {
+		averbacoesPorSeguro[averbacao.SeguroID] = append(averbacoesPorSeguro[averbacao.SeguroID], averbacao.Numero)
+	}
+
+	// Um MDF-e pode citar a mesma apólice em mais de um grupo de seguro: uma viagem por MDF-e
+	viagens := []ViagemApolice{}
+	posicao := make(map[uuid.UUID]int, len(linhas))
+	var valorCargaTotal float64
+	var totalAverbacoes int
+	for _, linha := range linhas {
+		numeros := averbacoesPorSeguro[linha.SeguroID]
+		totalAverbacoes += len(numeros)
+		if i, ok := posicao[linha.MDFEID]; ok {
+			viagens[i].Averbacoes = append(viagens[i].Averbacoes, numeros...)
+			continue
+		}
+		linha.Averbacoes = append([]string{}, numeros...)
+		posicao[linha.MDFEID] = len(viagens)
+		viagens = append(viagens, linha)
+		valorCargaTotal += linha.ValorCarga
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"apolice": numero,
+		"viagens": viagens,
+		"totais": gin.H{
+			"quantidade_viagens":    len(viagens),
+			"quantidade_averbacoes": totalAverbacoes,
+			"valor_carga":           valorCargaTotal,
+		},
+		"filtros": gin.H{
+			"data_inicio":     req.DataInicio,
+			"data_fim":        req.DataFim,
+			"seguradora_cnpj": req.SeguradoraCNPJ,
+		},
+	})
+}
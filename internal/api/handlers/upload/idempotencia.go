@@ -0,0 +1,187 @@
+package upload
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// HeaderIdempotencia identifica requisições repetidas pelo cliente
+const HeaderIdempotencia = "Idempotency-Key"
+
+const (
+	// validadeIdempotencia é o período em que a resposta gravada é repetida
+	validadeIdempotencia = 24 * time.Hour
+	// tempoMaximoRequisicao libera chaves de requisições que não terminaram (ex.: queda do servidor)
+	tempoMaximoRequisicao = 10 * time.Minute
+	// tamanhoMaximoChave é o tamanho máximo aceito para a chave
+	tamanhoMaximoChave = 255
+)
+
+var (
+	errChaveEmAndamento  = errors.New("requisição com esta chave ainda em andamento")
+	errPayloadDivergente = errors.New("Idempotency-Key já utilizada com outro conteúdo")
+)
+
+// respostaFunc executa o handler e devolve o status HTTP e o corpo da resposta
+type respostaFunc func(c *gin.Context) (int, interface{})
+
+// responder executa fn e envia a resposta. Com o header Idempotency-Key, a
+// primeira resposta de sucesso é gravada e repetida nas requisições seguintes
+// do mesmo usuário ao mesmo endpoint com a mesma chave e o mesmo conteúdo, sem
+// registrar os arquivos novamente. A mesma chave com outro conteúdo recebe 422.
+func (h *UploadHandler) responder(c *gin.Context, fn respostaFunc) {
+	chave := strings.TrimSpace(c.GetHeader(HeaderIdempotencia))
+	if chave == "" {
+		status, body := fn(c)
+		c.JSON(status, body)
+		return
+	}
+	if len(chave) > tamanhoMaximoChave {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key deve ter no máximo 255 caracteres"})
+		return
+	}
+
+	// Formulário inválido: o handler responde com o erro, que não é gravado
+	hash, err := hashPayload(c)
+	if err != nil {
+		status, body := fn(c)
+		c.JSON(status, body)
+		return
+	}
+
+	registro, err := h.reservarChave(c.GetString("user_id"), c.FullPath(), chave, hash)
+	if err != nil {
+		switch {
+		case errors.Is(err, errChaveEmAndamento):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, errPayloadDivergente):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao reservar chave de idempotência")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao processar Idempotency-Key"})
+		}
+		return
+	}
+
+	// Requisição repetida: devolver a resposta original
+	if registro.StatusHTTP != 0 {
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(registro.StatusHTTP, "application/json; charset=utf-8", []byte(registro.Resposta))
+		return
+	}
+
+	status, body := fn(c)
+
+	// Apenas respostas de sucesso são gravadas; em caso de erro o cliente pode tentar de novo
+	resposta, err := json.Marshal(body)
+	if err == nil && status < http.StatusMultipleChoices {
+		err = h.db.Model(registro).Updates(map[string]interface{}{
+			"status_http": status,
+			"resposta":    string(resposta),
+		}).Error
+	} else {
+		err = h.db.Unscoped().Delete(registro).Error
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Str("chave", chave).Msg("Erro ao gravar resposta da chave de idempotência")
+	}
+
+	c.JSON(status, body)
+}
+
+// hashPayload calcula o SHA-256 do conteúdo do formulário multipart: campos e
+// arquivos em ordem, sem o boundary, que o cliente pode gerar a cada envio
+func hashPayload(c *gin.Context) (string, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return "", err
+	}
+
+	soma := sha256.New()
+	campos := make([]string, 0, len(form.Value))
+	for campo := range form.Value {
+		campos = append(campos, campo)
+	}
+	sort.Strings(campos)
+	for _, campo := range campos {
+		for _, valor := range form.Value[campo] {
+			fmt.Fprintf(soma, "valor\x00%s\x00%d\x00%s\x00", campo, len(valor), valor)
+		}
+	}
+
+	campos = campos[:0]
+	for campo := range form.File {
+		campos = append(campos, campo)
+	}
+	sort.Strings(campos)
+	for _, campo := range campos {
+		for _, fh := range form.File[campo] {
+			fmt.Fprintf(soma, "arquivo\x00%s\x00%s\x00%d\x00", campo, fh.Filename, fh.Size)
+			arquivo, err := fh.Open()
+			if err != nil {
+				return "", err
+			}
+			_, err = io.Copy(soma, arquivo)
+			arquivo.Close()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	return hex.EncodeToString(soma.Sum(nil)), nil
+}
+
+// reservarChave busca a resposta gravada para a chave do usuário no endpoint ou,
+// se não houver, reserva a chave para esta requisição. O índice único impede que
+// duas requisições simultâneas com a mesma chave sejam executadas. A chave só
+// vale para o conteúdo com que foi reservada.
+func (h *UploadHandler) reservarChave(usuario, endpoint, chave, hash string) (*models.ChaveIdempotencia, error) {
+	escopo := h.db.Where("user_id = ? AND endpoint = ? AND chave = ?", usuario, endpoint, chave).Session(&gorm.Session{})
+
+	var existente models.ChaveIdempotencia
+	err := escopo.Take(&existente).Error
+	switch {
+	case err == nil:
+		expirada := time.Since(existente.CreatedAt) > validadeIdempotencia
+		abandonada := existente.StatusHTTP == 0 && time.Since(existente.CreatedAt) > tempoMaximoRequisicao
+		if !expirada && !abandonada {
+			if existente.HashPayload != hash {
+				return nil, errPayloadDivergente
+			}
+			if existente.StatusHTTP == 0 {
+				return nil, errChaveEmAndamento
+			}
+			return &existente, nil
+		}
+		if err := h.db.Unscoped().Delete(&existente).Error; err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	registro := models.ChaveIdempotencia{UserID: usuario, Endpoint: endpoint, Chave: chave, HashPayload: hash}
+	if err := h.db.Create(&registro).Error; err != nil {
+		// Outra requisição reservou a mesma chave entre a busca e a criação
+		if escopo.Take(&existente).Error == nil {
+			if existente.HashPayload != hash {
+				return nil, errPayloadDivergente
+			}
+			return nil, errChaveEmAndamento
+		}
+		return nil, err
+	}
+	return &registro, nil
+}
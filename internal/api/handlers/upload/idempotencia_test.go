@@ -0,0 +1,102 @@
+package upload
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/internal/ingestao"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestReservarChavePorUsuarioEEndpoint(t *testing.T) {
+	logger.InitLogger()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "idempotencia.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.ChaveIdempotencia{}))
+
+	h := NewUploadHandler(db, ingestao.Limites{})
+
+	const endpoint = "/api/upload/single"
+	registro, err := h.reservarChave("usuario-a", endpoint, "envio-1", "hash-1")
+	require.NoError(t, err)
+
+	// Outro usuário ou outro endpoint com a mesma chave não colidem
+	_, err = h.reservarChave("usuario-b", endpoint, "envio-1", "hash-1")
+	require.NoError(t, err)
+	_, err = h.reservarChave("usuario-a", "/api/upload/batch", "envio-1", "hash-1")
+	require.NoError(t, err)
+
+	// O mesmo usuário no mesmo endpoint aguarda a requisição original
+	_, err = h.reservarChave("usuario-a", endpoint, "envio-1", "hash-1")
+	assert.ErrorIs(t, err, errChaveEmAndamento)
+
+	require.NoError(t, db.Model(registro).Updates(map[string]interface{}{"status_http": 202, "resposta": "{}"}).Error)
+	repetido, err := h.reservarChave("usuario-a", endpoint, "envio-1", "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, registro.ID, repetido.ID)
+	assert.Equal(t, 202, repetido.StatusHTTP)
+
+	// A mesma chave com outro conteúdo é recusada
+	_, err = h.reservarChave("usuario-a", endpoint, "envio-1", "hash-2")
+	assert.ErrorIs(t, err, errPayloadDivergente)
+}
+
+// formularioXML monta o corpo multipart do upload com o boundary informado
+func formularioXML(t *testing.T, boundary, conteudo string) (*bytes.Buffer, string) {
+	corpo := &bytes.Buffer{}
+	w := multipart.NewWriter(corpo)
+	require.NoError(t, w.SetBoundary(boundary))
+	parte, err := w.CreateFormFile("arquivo_xml", "cte.xml")
+	require.NoError(t, err)
+	_, err = parte.Write([]byte(conteudo))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return corpo, w.FormDataContentType()
+}
+
+func TestIdempotenciaConfereConteudo(t *testing.T) {
+	logger.InitLogger()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "payload.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.ChaveIdempotencia{}, &models.UploadBatch{}, &models.Upload{}, &models.Job{}))
+
+	router := gin.New()
+	router.POST("/upload", NewUploadHandler(db, ingestao.Limites{}).UploadSingle)
+	enviar := func(boundary, conteudo string) *httptest.ResponseRecorder {
+		corpo, tipo := formularioXML(t, boundary, conteudo)
+		req := httptest.NewRequest(http.MethodPost, "/upload", corpo)
+		req.Header.Set("Content-Type", tipo)
+		req.Header.Set(HeaderIdempotencia, "envio-1")
+		resposta := httptest.NewRecorder()
+		router.ServeHTTP(resposta, req)
+		return resposta
+	}
+
+	primeira := enviar("boundary-a", "<CTe/>")
+	require.Equal(t, http.StatusAccepted, primeira.Code, primeira.Body.String())
+
+	// Reenvio do mesmo arquivo, com outro boundary: a resposta original é repetida
+	repetida := enviar("boundary-b", "<CTe/>")
+	assert.Equal(t, http.StatusAccepted, repetida.Code)
+	assert.Equal(t, "true", repetida.Header().Get("Idempotent-Replayed"))
+	assert.JSONEq(t, primeira.Body.String(), repetida.Body.String())
+
+	// Outro arquivo com a mesma chave
+	divergente := enviar("boundary-a", "<MDFe/>")
+	assert.Equal(t, http.StatusUnprocessableEntity, divergente.Code)
+
+	var uploads int64
+	require.NoError(t, db.Model(&models.Upload{}).Count(&uploads).Error)
+	assert.Equal(t, int64(1), uploads)
+}
@@ -0,0 +1,145 @@
+package upload
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/services"
+)
+
+const (
+	// intervaloEventosLote é o intervalo de consulta do progresso no stream SSE
+	intervaloEventosLote = time.Second
+	// intervaloHeartbeatLote mantém a conexão aberta em proxies quando o progresso não muda
+	intervaloHeartbeatLote = 15 * time.Second
+)
+
+// LoteResponse representa o progresso de um lote de uploads
+type LoteResponse struct {
+	ID            string    `json:"id"`
+	NomeArquivo   string    `json:"nome_arquivo"`
+	Status        string    `json:"status"`
+	DataUpload    time.Time `json:"data_upload"`
+	TotalArquivos int       `json:"total_arquivos"`
+	TamanhoTotal  int64     `json:"tamanho_total"`
+	Pendentes     int       `json:"pendentes"`
+	Processando   int       `json:"processando"`
+	Concluidos    int       `json:"concluidos"`
+	Erros         int       `json:"erros"`
+	Duplicados    int       `json:"duplicados"`
+	Progresso     float64   `json:"progresso"`
+	Finalizado    bool      `json:"finalizado"`
+}
+
+// novoLoteResponse monta a resposta a partir do lote
+func novoLoteResponse(batch *models.UploadBatch) LoteResponse {
+	return LoteResponse{
+		ID:            batch.ID.String(),
+		NomeArquivo:   batch.NomeArquivo,
+		Status:        batch.Status,
+		DataUpload:    batch.DataUpload,
+		TotalArquivos: batch.TotalArquivos,
+		TamanhoTotal:  batch.TamanhoTotal,
+		Pendentes:     batch.Pendentes,
+		Processando:   batch.Processando,
+		Concluidos:    batch.Concluidos,
+		Erros:         batch.Erros,
+		Duplicados:    batch.Duplicados,
+		Progresso:     batch.Progresso(),
+		Finalizado: batch.Status == services.StatusLoteConcluido ||
+			batch.Status == services.StatusLoteConcluidoComErros,
+	}
+}
+
+// carregarLote busca o lote. A consulta não grava nada: os contadores são
+// recalculados pelos workers a cada mudança de status dos uploads.
+func (h *UploadHandler) carregarLote(id string) (*models.UploadBatch, error) {
+	var batch models.UploadBatch
+	if err := h.db.First(&batch, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetBatch retorna o progresso atual de um lote de uploads
+func (h *UploadHandler) GetBatch(c *gin.Context) {
+	id := c.Param("id")
+
+	batch, err := h.carregarLote(id)
+	if err != nil {
+		h.logger.Error().Err(err).Str("id", id).Msg("Lote não encontrado")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lote não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, novoLoteResponse(batch))
+}
+
+// StreamBatchEvents envia o progresso do lote como Server-Sent Events.
+// Um evento "progresso" é enviado a cada mudança nos contadores e o stream
+// termina com o evento "concluido" quando todos os uploads forem finalizados.
+func (h *UploadHandler) StreamBatchEvents(c *gin.Context) {
+	id := c.Param("id")
+
+	batch, err := h.carregarLote(id)
+	if err != nil {
+		h.logger.Error().Err(err).Str("id", id).Msg("Lote não encontrado")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lote não encontrado"})
+		return
+	}
+
+	// O stream dura mais que o WriteTimeout do servidor
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn().Err(err).Msg("Não foi possível remover o prazo de escrita do stream")
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(intervaloEventosLote)
+	defer ticker.Stop()
+
+	atual := novoLoteResponse(batch)
+	var enviado *LoteResponse
+	ultimoEnvio := time.Now()
+
+	c.Stream(func(w io.Writer) bool {
+		if enviado != nil {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-ticker.C:
+			}
+
+			batch, err := h.carregarLote(id)
+			if err != nil {
+				h.logger.Error().Err(err).Str("id", id).Msg("Erro ao consultar lote")
+				c.SSEvent("erro", gin.H{"error": "Erro ao consultar lote"})
+				return false
+			}
+			atual = novoLoteResponse(batch)
+		}
+
+		if atual.Finalizado {
+			c.SSEvent("concluido", atual)
+			return false
+		}
+
+		if enviado == nil || *enviado != atual {
+			c.SSEvent("progresso", atual)
+			copia := atual
+			enviado = &copia
+			ultimoEnvio = time.Now()
+		} else if time.Since(ultimoEnvio) >= intervaloHeartbeatLote {
+			// Comentário SSE, ignorado pelo EventSource
+			io.WriteString(w, ": heartbeat\n\n")
+			ultimoEnvio = time.Now()
+		}
+		return true
+	})
+}
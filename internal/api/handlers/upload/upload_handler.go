@@ -1,18 +1,15 @@
 package upload
 
 import (
-	"bytes"
-	"io"
+	"errors"
+	"fmt"
 	"mime/multipart"
 	"net/http"
-	"strings"
-	"sync"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/internal/ingestao"
 	"github.com/italosilva18/destack-transport-api/internal/models"
-	"github.com/italosilva18/destack-transport-api/internal/services"
 	"github.com/italosilva18/destack-transport-api/pkg/logger"
 	"github.com/rs/zerolog"
 	"gorm.io/gorm"
@@ -20,236 +17,264 @@ import (
 
 // UploadHandler contém os handlers para upload de arquivos
 type UploadHandler struct {
-	db     *gorm.DB
-	logger zerolog.Logger
+	db      *gorm.DB
+	logger  zerolog.Logger
+	limites ingestao.Limites
 }
 
 // NewUploadHandler cria uma nova instância de UploadHandler
-func NewUploadHandler(db *gorm.DB) *UploadHandler {
+func NewUploadHandler(db *gorm.DB, limites ingestao.Limites) *UploadHandler {
 	return &UploadHandler{
-		db:     db,
-		logger: logger.GetLogger(),
+		db:      db,
+		logger:  logger.GetLogger(),
+		limites: limites,
 	}
 }
 
 // UploadSingleResponse representa a resposta do upload
 type UploadSingleResponse struct {
-	ID      string `json:"id"`
-	Message string `json:"message"`
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	DuplicadoDeID string `json:"duplicado_de_id,omitempty"`
+	Message       string `json:"message"`
 }
 
-// UploadSingle recebe um único arquivo XML
+// novoUploadResponse monta a resposta de um upload registrado
+func novoUploadResponse(upload *models.Upload) UploadSingleResponse {
+	if upload.DuplicadoDeID != nil {
+		return UploadSingleResponse{
+			ID:            upload.ID.String(),
+			Status:        upload.Status,
+			DuplicadoDeID: upload.DuplicadoDeID.String(),
+			Message:       "Arquivo já enviado anteriormente. Nenhum processamento necessário.",
+		}
+	}
+	return UploadSingleResponse{
+		ID:      upload.ID.String(),
+		Status:  upload.Status,
+		Message: "Upload recebido. Processamento iniciado.",
+	}
+}
+
+// UploadLoteResponse representa a resposta do upload de um arquivo compactado
+type UploadLoteResponse struct {
+	BatchID       string `json:"batch_id"`
+	NomeArquivo   string `json:"nome_arquivo"`
+	TotalArquivos int    `json:"total_arquivos"`
+	Duplicados    int    `json:"duplicados"`
+	Message       string `json:"message"`
+}
+
+// UploadSingle recebe um único arquivo XML ou um arquivo compactado (.zip, .tar.gz).
+// Aceita o header Idempotency-Key para que reenvios da mesma requisição não gerem novo trabalho.
 func (h *UploadHandler) UploadSingle(c *gin.Context) {
+	h.responder(c, h.uploadSingle)
+}
+
+func (h *UploadHandler) uploadSingle(c *gin.Context) (int, interface{}) {
 	// Obter o arquivo do request
 	file, header, err := c.Request.FormFile("arquivo_xml")
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Erro ao receber arquivo")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Arquivo não encontrado ou inválido"})
-		return
+		return http.StatusBadRequest, gin.H{"error": "Arquivo não encontrado ou inválido"}
 	}
 	defer file.Close()
 
 	// Validar o tipo do arquivo
-	if !strings.HasSuffix(strings.ToLower(header.Filename), ".xml") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas arquivos XML são permitidos"})
-		return
+	tipo := ingestao.TipoArquivo(header.Filename)
+	if tipo == "" {
+		return http.StatusBadRequest, gin.H{"error": "Apenas arquivos XML, ZIP ou TAR.GZ são permitidos"}
 	}
 
-	// Ler o conteúdo do arquivo
-	buf := bytes.NewBuffer(nil)
-	if _, err := io.Copy(buf, file); err != nil {
-		h.logger.Error().Err(err).Msg("Erro ao ler arquivo")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao processar arquivo"})
-		return
+	if tipo != ingestao.TipoXML {
+		lote, status, err := h.registrarCompactado(header.Filename, tipo, file, header.Size)
+		if err != nil {
+			if lote != nil {
+				return status, gin.H{"error": err.Error(), "lote": lote}
+			}
+			return status, gin.H{"error": err.Error()}
+		}
+		return http.StatusAccepted, lote
 	}
 
-	// Criar registro de upload
-	uploadID := uuid.New()
-	upload := models.Upload{
-		BaseModel: models.BaseModel{
-			ID: uploadID,
-		},
-		NomeArquivo:           header.Filename,
-		Status:                "PENDENTE",
-		DataUpload:            time.Now(),
-		ChaveDocProcessado:    nil,
-		DetalhesProcessamento: "",
+	// Ler o conteúdo do arquivo, com o mesmo limite das entradas dos compactados
+	conteudo, err := ingestao.LerXML(header.Filename, file, h.limites)
+	if err != nil {
+		if errors.Is(err, ingestao.ErrLimiteExcedido) {
+			return http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()}
+		}
+		h.logger.Error().Err(err).Msg("Erro ao ler arquivo")
+		return http.StatusInternalServerError, gin.H{"error": "Erro ao processar arquivo"}
 	}
 
-	// Salvar registro no banco
-	if err := h.db.Create(&upload).Error; err != nil {
+	// Salvar registro e enfileirar o processamento
+	upload, err := ingestao.RegistrarXML(h.db, header.Filename, conteudo)
+	if err != nil {
 		h.logger.Error().Err(err).Msg("Erro ao salvar registro de upload")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar upload"})
-		return
+		return http.StatusInternalServerError, gin.H{"error": "Erro ao registrar upload"}
 	}
 
-	// Iniciar processamento assíncrono
-	go func() {
-		result, err := services.ProcessarXML(h.db, uploadID.String(), buf.Bytes())
-		if err != nil {
-			h.logger.Error().Err(err).Str("upload_id", uploadID.String()).Msg("Erro ao processar XML")
-			h.db.Model(&models.Upload{}).Where("id = ?", uploadID).Updates(map[string]interface{}{
-				"status":                 "ERRO",
-				"detalhes_processamento": err.Error(),
-			})
-			return
-		}
-
-		// Atualizar status após processamento
-		h.db.Model(&models.Upload{}).Where("id = ?", uploadID).Updates(map[string]interface{}{
-			"status":               "CONCLUIDO",
-			"chave_doc_processado": &result.Chave,
-		})
-	}()
-
-	// Retornar resposta de sucesso
-	c.JSON(http.StatusAccepted, UploadSingleResponse{
-		ID:      uploadID.String(),
-		Message: "Upload recebido. Processamento iniciado.",
-	})
+	// Reenvio de um XML já recebido: nada foi enfileirado
+	if upload.DuplicadoDeID != nil {
+		return http.StatusOK, novoUploadResponse(upload)
+	}
+	return http.StatusAccepted, novoUploadResponse(upload)
 }
 
 // UploadBatchResponse representa a resposta do upload em lote
 type UploadBatchResponse struct {
 	Message       string                 `json:"message"`
+	BatchID       string                 `json:"batch_id,omitempty"`
 	TotalRecebido int                    `json:"total_recebido"`
+	TotalArquivos int                    `json:"total_arquivos"`
 	Uploads       []UploadSingleResponse `json:"uploads"`
+	Erros         []UploadErroResponse   `json:"erros"`
 }
 
-// UploadBatch recebe múltiplos arquivos XML
+// UploadErroResponse representa um arquivo do lote que não pôde ser registrado
+type UploadErroResponse struct {
+	NomeArquivo string `json:"nome_arquivo"`
+	Erro        string `json:"erro"`
+}
+
+// UploadBatch recebe múltiplos arquivos XML e/ou compactados em um único lote.
+// Aceita o header Idempotency-Key, assim como UploadSingle.
 func (h *UploadHandler) UploadBatch(c *gin.Context) {
+	h.responder(c, h.uploadBatch)
+}
+
+func (h *UploadHandler) uploadBatch(c *gin.Context) (int, interface{}) {
 	// Obter o formulário multipart
 	form, err := c.MultipartForm()
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Erro ao receber formulário multipart")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao processar formulário"})
-		return
+		return http.StatusBadRequest, gin.H{"error": "Erro ao processar formulário"}
 	}
 
 	// Obter arquivos do campo "arquivos_xml"
 	files := form.File["arquivos_xml"]
 	if len(files) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Nenhum arquivo foi enviado"})
-		return
+		return http.StatusBadRequest, gin.H{"error": "Nenhum arquivo foi enviado"}
 	}
 
-	// Limite de arquivos por upload
+	// Limite de arquivos por upload; arquivos compactados contam como um
 	const maxFiles = 100
 	if len(files) > maxFiles {
-		c.JSON(http.StatusBadRequest, gin.H{
+		return http.StatusBadRequest, gin.H{
 			"error": "Limite máximo de arquivos excedido",
 			"max":   maxFiles,
-		})
-		return
+		}
+	}
+
+	// Todos os arquivos do request, inclusive o conteúdo dos compactados, ficam no mesmo lote
+	batch, err := ingestao.NovoLote(h.db, fmt.Sprintf("Upload em lote (%d arquivos)", len(files)))
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Erro ao registrar lote")
+		return http.StatusInternalServerError, gin.H{"error": "Erro ao registrar lote"}
 	}
 
 	response := UploadBatchResponse{
+		BatchID:       batch.ID.String(),
 		TotalRecebido: len(files),
 		Uploads:       make([]UploadSingleResponse, 0, len(files)),
+		Erros:         []UploadErroResponse{},
 	}
 
-	// Processar cada arquivo
-	var wg sync.WaitGroup
-	uploadsChan := make(chan UploadSingleResponse, len(files))
-	errorsChan := make(chan error, len(files))
-
-	for _, fileHeader := range files {
-		// Validar o tipo do arquivo
-		if !strings.HasSuffix(strings.ToLower(fileHeader.Filename), ".xml") {
-			h.logger.Warn().Str("filename", fileHeader.Filename).Msg("Arquivo ignorado - não é XML")
-			continue
+	for _, fh := range files {
+		if err := h.registrarParte(batch.ID, fh, &response); err != nil {
+			h.logger.Error().Err(err).Str("filename", fh.Filename).Msg("Erro durante upload em lote")
+			response.Erros = append(response.Erros, UploadErroResponse{NomeArquivo: fh.Filename, Erro: err.Error()})
 		}
+	}
 
-		wg.Add(1)
-		go func(fh *multipart.FileHeader) {
-			defer wg.Done()
+	// Nenhum arquivo registrado: o lote vazio não é mantido
+	if response.TotalArquivos == 0 {
+		h.db.Delete(batch)
+		return http.StatusBadRequest, gin.H{
+			"error": "Nenhum arquivo do lote pôde ser registrado",
+			"erros": response.Erros,
+		}
+	}
 
-			// Abrir arquivo
-			file, err := fh.Open()
-			if err != nil {
-				errorsChan <- err
-				return
-			}
-			defer file.Close()
+	// Todos os arquivos registrados: o lote pode ser concluído
+	if err := ingestao.FinalizarRegistro(h.db, batch.ID); err != nil {
+		h.logger.Error().Err(err).Str("batch_id", batch.ID.String()).Msg("Erro ao finalizar registro do lote")
+	}
 
-			// Ler conteúdo
-			buf := bytes.NewBuffer(nil)
-			if _, err := io.Copy(buf, file); err != nil {
-				errorsChan <- err
-				return
-			}
+	if len(response.Erros) > 0 {
+		response.Message = "Upload em lote concluído com alguns erros"
+	} else {
+		response.Message = "Upload em lote concluído com sucesso"
+	}
 
-			// Criar registro de upload
-			uploadID := uuid.New()
-			upload := models.Upload{
-				BaseModel: models.BaseModel{
-					ID: uploadID,
-				},
-				NomeArquivo:           fh.Filename,
-				Status:                "PENDENTE",
-				DataUpload:            time.Now(),
-				ChaveDocProcessado:    nil,
-				DetalhesProcessamento: "",
-			}
+	return http.StatusAccepted, response
+}
 
-			// Salvar registro no banco
-			if err := h.db.Create(&upload).Error; err != nil {
-				errorsChan <- err
-				return
-			}
+// registrarParte registra um arquivo do upload em lote conforme o tipo
+func (h *UploadHandler) registrarParte(batchID uuid.UUID, fh *multipart.FileHeader, response *UploadBatchResponse) error {
+	tipo := ingestao.TipoArquivo(fh.Filename)
+	if tipo == "" {
+		return errors.New("tipo de arquivo não permitido")
+	}
 
-			// Iniciar processamento assíncrono
-			go func(id uuid.UUID, content []byte) {
-				result, err := services.ProcessarXML(h.db, id.String(), content)
-				if err != nil {
-					h.logger.Error().Err(err).Str("upload_id", id.String()).Msg("Erro ao processar XML")
-					h.db.Model(&models.Upload{}).Where("id = ?", id).Updates(map[string]interface{}{
-						"status":                 "ERRO",
-						"detalhes_processamento": err.Error(),
-					})
-					return
-				}
-
-				// Atualizar status após processamento
-				h.db.Model(&models.Upload{}).Where("id = ?", id).Updates(map[string]interface{}{
-					"status":               "CONCLUIDO",
-					"chave_doc_processado": &result.Chave,
-				})
-			}(uploadID, buf.Bytes())
-
-			uploadsChan <- UploadSingleResponse{
-				ID:      uploadID.String(),
-				Message: "Upload recebido. Processamento iniciado.",
-			}
-		}(fileHeader)
+	file, err := fh.Open()
+	if err != nil {
+		return err
 	}
+	defer file.Close()
 
-	// Aguardar todos os uploads
-	wg.Wait()
-	close(uploadsChan)
-	close(errorsChan)
+	if tipo != ingestao.TipoXML {
+		// Os XMLs gravados antes de uma falha permanecem no lote
+		total, err := ingestao.AdicionarCompactado(h.db, batchID, tipo, file, fh.Size, h.limites)
+		response.TotalArquivos += total
+		return err
+	}
 
-	// Coletar resultados
-	for upload := range uploadsChan {
-		response.Uploads = append(response.Uploads, upload)
+	conteudo, err := ingestao.LerXML(fh.Filename, file, h.limites)
+	if err != nil {
+		return err
+	}
+	upload, err := ingestao.AdicionarXML(h.db, batchID, fh.Filename, conteudo)
+	if err != nil {
+		return err
 	}
 
-	// Verificar erros
-	var hasErrors bool
-	for err := range errorsChan {
-		if err != nil {
-			hasErrors = true
-			h.logger.Error().Err(err).Msg("Erro durante upload em lote")
+	response.TotalArquivos++
+	response.Uploads = append(response.Uploads, novoUploadResponse(upload))
+	return nil
+}
+
+// registrarCompactado registra o lote de um arquivo compactado e devolve o
+// status HTTP adequado em caso de erro. Se parte do arquivo foi registrada antes
+// do erro, o lote também é devolvido.
+func (h *UploadHandler) registrarCompactado(nome, tipo string, file multipart.File, tamanho int64) (*UploadLoteResponse, int, error) {
+	batch, err := ingestao.RegistrarCompactado(h.db, nome, tipo, file, tamanho, h.limites)
+
+	var lote *UploadLoteResponse
+	if batch != nil {
+		lote = &UploadLoteResponse{
+			BatchID:       batch.ID.String(),
+			NomeArquivo:   batch.NomeArquivo,
+			TotalArquivos: batch.TotalArquivos,
+			Duplicados:    batch.Duplicados,
+			Message:       "Arquivo compactado recebido. Processamento iniciado.",
 		}
 	}
-
-	if hasErrors {
-		response.Message = "Upload em lote concluído com alguns erros"
-	} else {
-		response.Message = "Upload em lote concluído com sucesso"
+	if err == nil {
+		return lote, 0, nil
 	}
 
-	c.JSON(http.StatusAccepted, response)
+	h.logger.Error().Err(err).Str("filename", nome).Msg("Erro ao registrar arquivo compactado")
+	if lote != nil {
+		lote.Message = fmt.Sprintf("Arquivo compactado registrado parcialmente (%d XMLs): %v", lote.TotalArquivos, err)
+	}
+	switch {
+	case errors.Is(err, ingestao.ErrLimiteExcedido):
+		return lote, http.StatusRequestEntityTooLarge, err
+	case errors.Is(err, ingestao.ErrArquivoInvalido), errors.Is(err, ingestao.ErrArquivoVazio):
+		return lote, http.StatusBadRequest, err
+	}
+	return lote, http.StatusInternalServerError, errors.New("erro ao registrar arquivo compactado")
 }
 
 // DeleteUpload exclui um upload
@@ -266,7 +291,7 @@ func (h *UploadHandler) DeleteUpload(c *gin.Context) {
 	}
 
 	// Verificar se o upload ainda está pendente
-	if upload.Status == "PENDENTE" {
+	if upload.Status == "PENDENTE" || upload.Status == "PROCESSANDO" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Não é possível excluir um upload em processamento",
 		})
@@ -15,9 +15,12 @@ func setupCTeRoutes(router *gin.RouterGroup, db *gorm.DB) {
 	cteRoutes := router.Group("/ctes")
 	{
 		cteRoutes.GET("", cteHandler.ListCTEs)
+		cteRoutes.POST("/reprocess", cteHandler.ReprocessarLote)
 		cteRoutes.GET("/:chave", cteHandler.GetCTE)
 		cteRoutes.GET("/:chave/download-xml", cteHandler.DownloadXML)
 		cteRoutes.GET("/:chave/dacte", cteHandler.GerarDACTE)
+		cteRoutes.GET("/:chave/eventos", cteHandler.GetEventos)
+		cteRoutes.GET("/:chave/corrigido", cteHandler.GetCTECorrigido)
 		cteRoutes.POST("/:chave/reprocess", cteHandler.Reprocessar)
 	}
 
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/internal/api/handlers/cteos"
+	"gorm.io/gorm"
+)
+
+// setupCTeOSRoutes configura as rotas de CT-e OS
+func setupCTeOSRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	// Criar handler de CT-e OS
+	cteOSHandler := cteos.NewCTEOSHandler(db)
+
+	// Grupo de rotas de CT-e OS
+	cteOSRoutes := router.Group("/cteos")
+	{
+		cteOSRoutes.GET("", cteOSHandler.ListCTEOS)
+		cteOSRoutes.GET("/:chave", cteOSHandler.GetCTEOS)
+	}
+}
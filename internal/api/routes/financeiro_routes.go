@@ -17,6 +17,7 @@ func setupFinanceiroRoutes(router *gin.RouterGroup, db *gorm.DB) {
 		financeiroRoutes.GET("", financeiroHandler.GetDadosFinanceiros)
 		financeiroRoutes.GET("/faturamento-mensal", financeiroHandler.GetFaturamentoMensal)
 		financeiroRoutes.GET("/agrupado", financeiroHandler.GetDadosAgrupados)
+		financeiroRoutes.GET("/composicao-frete", financeiroHandler.GetComposicaoFrete)
 		financeiroRoutes.GET("/detalhes/:tipo/:id", financeiroHandler.GetDetalheItem)
 	}
 }
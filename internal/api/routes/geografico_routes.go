@@ -19,5 +19,7 @@ func setupGeograficoRoutes(router *gin.RouterGroup, db *gorm.DB) {
 		geograficoRoutes.GET("/destinos", geograficoHandler.GetTopDestinos)
 		geograficoRoutes.GET("/rotas", geograficoHandler.GetRotasFrequentes)
 		geograficoRoutes.GET("/fluxo-ufs", geograficoHandler.GetFluxoUFs)
+		geograficoRoutes.GET("/modais", geograficoHandler.GetFluxoModais)
+		geograficoRoutes.GET("/descargas", geograficoHandler.GetDescargasMDFe)
 	}
 }
@@ -21,6 +21,7 @@ func setupMDFeRoutes(router *gin.RouterGroup, db *gorm.DB) {
 		mdfeRoutes.POST("/:chave/reprocess", mdfeHandler.Reprocessar)
 		mdfeRoutes.POST("/:chave/encerrar", mdfeHandler.Encerrar)
 		mdfeRoutes.GET("/:chave/documentos", mdfeHandler.GetDocumentosVinculados)
+		mdfeRoutes.GET("/:chave/eventos", mdfeHandler.GetEventos)
 	}
 
 	// Rota para o painel de MDF-e
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/internal/api/handlers/nfe"
+	"gorm.io/gorm"
+)
+
+// setupNFeRoutes configura as rotas de NF-e
+func setupNFeRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	// Criar handler de NF-e
+	nfeHandler := nfe.NewNFeHandler(db)
+
+	// Grupo de rotas de NF-e
+	nfeRoutes := router.Group("/nfes")
+	{
+		nfeRoutes.GET("/:chave/trace", nfeHandler.Rastrear)
+	}
+}
@@ -3,13 +3,14 @@ package routes
 import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/configs"
 	"github.com/italosilva18/destack-transport-api/internal/api/middlewares"
 	"github.com/italosilva18/destack-transport-api/pkg/logger"
 	"gorm.io/gorm"
 )
 
 // SetupRoutes configura todas as rotas da API
-func SetupRoutes(router *gin.Engine, db *gorm.DB) {
+func SetupRoutes(router *gin.Engine, db *gorm.DB, config configs.Config) {
 	log := logger.GetLogger()
 	log.Info().Msg("Configurando rotas da API")
 
@@ -46,8 +47,11 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 	// Rotas protegidas
 	setupEmpresaRoutes(protected, db)
 	setupCTeRoutes(protected, db)
+	setupCTeOSRoutes(protected, db)
 	setupMDFeRoutes(protected, db)
-	setupUploadRoutes(protected, db)
+	setupNFeRoutes(protected, db)
+	setupSeguroRoutes(protected, db)
+	setupUploadRoutes(protected, db, config)
 	setupDashboardRoutes(protected, db)
 	setupFinanceiroRoutes(protected, db)
 	setupGeograficoRoutes(protected, db)
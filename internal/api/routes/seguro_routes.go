@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/internal/api/handlers/seguro"
+	"gorm.io/gorm"
+)
+
+// setupSeguroRoutes configura as rotas de seguro da carga
+func setupSeguroRoutes(router *gin.RouterGroup, db *gorm.DB) {
+	// Criar handler de seguro
+	seguroHandler := seguro.NewSeguroHandler(db)
+
+	// Grupo de rotas de seguro
+	seguroRoutes := router.Group("/seguros")
+	{
+		seguroRoutes.GET("/apolices/:numero", seguroHandler.GetApolice)
+	}
+}
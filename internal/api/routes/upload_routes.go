@@ -2,26 +2,34 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/configs"
 	"github.com/italosilva18/destack-transport-api/internal/api/handlers/upload"
+	"github.com/italosilva18/destack-transport-api/internal/ingestao"
 	"gorm.io/gorm"
 )
 
-// setupUploadRoutes configura as rotas de upload
-func setupUploadRoutes(router *gin.RouterGroup, db *gorm.DB) {
+// setupUploadRoutes configura as rotas de upload com os limites da configuração carregada na inicialização
+func setupUploadRoutes(router *gin.RouterGroup, db *gorm.DB, config configs.Config) {
 	// Criar handler de upload
-	uploadHandler := upload.NewUploadHandler(db)
+	uploadHandler := upload.NewUploadHandler(db, ingestao.Limites{
+		MaxEntradas:       config.UploadConfig.MaxEntradas,
+		MaxTamanhoEntrada: int64(config.UploadConfig.MaxTamanhoEntradaMB) << 20,
+		MaxTamanhoTotal:   int64(config.UploadConfig.MaxTamanhoTotalMB) << 20,
+	})
 
 	// Grupo de rotas de upload
 	uploadRoutes := router.Group("/upload")
 	{
 		uploadRoutes.POST("/single", uploadHandler.UploadSingle)
-		// Implementar outros endpoints de upload conforme necessário
+		uploadRoutes.POST("/batch", uploadHandler.UploadBatch)
 	}
 
 	// Rotas para gestão de uploads
 	uploadsRoutes := router.Group("/uploads")
 	{
 		uploadsRoutes.GET("", uploadHandler.ListUploads)
+		uploadsRoutes.GET("/batches/:id", uploadHandler.GetBatch)
+		uploadsRoutes.GET("/batches/:id/events", uploadHandler.StreamBatchEvents)
 		uploadsRoutes.GET("/:id", uploadHandler.GetUpload)
 	}
 }
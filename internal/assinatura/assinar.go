@@ -0,0 +1,70 @@
+package assinatura
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Assinar assina o grupo infCte, infMDFe ou infEvento do documento com RSA-SHA1,
+// no formato XMLDSig aceito pela SEFAZ, e insere a Signature logo após o grupo.
+// Usado para gerar documentos de homologação e de teste; o documento não pode
+// estar assinado.
+func Assinar(xmlContent []byte, chave *rsa.PrivateKey, certificado *x509.Certificate) ([]byte, error) {
+	raiz, err := lerArvore(xmlContent)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler XML: %w", err)
+	}
+	info := raiz.buscar(func(e *elemento) bool { return elementosAssinados[e.local] })
+	if info == nil || info.pai == nil {
+		return nil, errors.New("grupo assinado não encontrado")
+	}
+	if info.pai.filho(nsDSig, "Signature") != nil {
+		return nil, errors.New("documento já assinado")
+	}
+
+	// O grupo não se repete dentro dele mesmo: o primeiro fechamento é o dele
+	nome := info.local
+	if info.prefixo != "" {
+		nome = info.prefixo + ":" + info.local
+	}
+	fim := bytes.Index(xmlContent, []byte("</"+nome+">"))
+	if fim < 0 {
+		return nil, fmt.Errorf("fechamento de %s não encontrado", nome)
+	}
+	fim += len("</" + nome + ">")
+
+	digest := resumo(crypto.SHA1, canonicalizar(info, nil))
+	signedInfo := `<SignedInfo><CanonicalizationMethod Algorithm="` + algC14N + `"></CanonicalizationMethod>` +
+		`<SignatureMethod Algorithm="` + algRSASHA1 + `"></SignatureMethod>` +
+		`<Reference URI="#` + info.atributo("Id") + `"><Transforms>` +
+		`<Transform Algorithm="` + algEnvelopedSignature + `"></Transform><Transform Algorithm="` + algC14N + `"></Transform>` +
+		`</Transforms><DigestMethod Algorithm="` + algSHA1 + `"></DigestMethod>` +
+		`<DigestValue>` + base64.StdEncoding.EncodeToString(digest) + `</DigestValue></Reference></SignedInfo>`
+	const marcador = "{{SIGNATURE_VALUE}}"
+	signature := `<Signature xmlns="` + nsDSig + `">` + signedInfo + `<SignatureValue>` + marcador + `</SignatureValue>` +
+		`<KeyInfo><X509Data><X509Certificate>` + base64.StdEncoding.EncodeToString(certificado.Raw) +
+		`</X509Certificate></X509Data></KeyInfo></Signature>`
+
+	documento := make([]byte, 0, len(xmlContent)+len(signature)+512)
+	documento = append(documento, xmlContent[:fim]...)
+	documento = append(documento, signature...)
+	documento = append(documento, xmlContent[fim:]...)
+
+	// O SignedInfo é canonicalizado no contexto do documento, com os namespaces herdados
+	raiz, err = lerArvore(documento)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler XML assinado: %w", err)
+	}
+	si := raiz.buscar(func(e *elemento) bool { return e.espaco == nsDSig && e.local == "SignedInfo" })
+	valor, err := rsa.SignPKCS1v15(rand.Reader, chave, crypto.SHA1, resumo(crypto.SHA1, canonicalizar(si, nil)))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao assinar: %w", err)
+	}
+	return bytes.Replace(documento, []byte(marcador), []byte(base64.StdEncoding.EncodeToString(valor)), 1), nil
+}
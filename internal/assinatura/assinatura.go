@@ -0,0 +1,282 @@
+package assinatura
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	// Algoritmos de hash usados pelas assinaturas
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+)
+
+// Status da verificação da assinatura
+const (
+	StatusValida   = "VALIDA"
+	StatusInvalida = "INVALIDA"
+	// StatusAusente indica documento sem assinatura (ex.: XML gerado fora do emissor)
+	StatusAusente = "AUSENTE"
+)
+
+// ErrAssinaturaInvalida indica documento adulterado ou assinatura que não confere
+var ErrAssinaturaInvalida = errors.New("assinatura digital inválida")
+
+// Namespace e algoritmos do XMLDSig aceitos pelos documentos fiscais
+const (
+	nsDSig = "http://www.w3.org/2000/09/xmldsig#"
+
+	algC14N               = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	algEnvelopedSignature = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algRSASHA1            = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	algRSASHA256          = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algSHA1               = "http://www.w3.org/2000/09/xmldsig#sha1"
+	algSHA256             = "http://www.w3.org/2001/04/xmlenc#sha256"
+)
+
+var algoritmosAssinatura = map[string]crypto.Hash{
+	algRSASHA1:   crypto.SHA1,
+	algRSASHA256: crypto.SHA256,
+}
+
+var algoritmosDigest = map[string]crypto.Hash{
+	algSHA1:   crypto.SHA1,
+	algSHA256: crypto.SHA256,
+}
+
+// elementosAssinados são os grupos referenciados pela assinatura de cada documento
+var elementosAssinados = map[string]bool{
+	"infCte":    true,
+	"infCTe":    true,
+	"infMDFe":   true,
+	"infNFe":    true,
+	"infEvento": true,
+}
+
+// Resultado é o resultado da verificação da assinatura
+type Resultado struct {
+	Status string
+	// CNPJ do titular do certificado que assinou o documento
+	CNPJ string
+	// Titular é o nome (CN) do certificado
+	Titular string
+	// Motivo descreve a falha quando a assinatura é inválida ou ausente
+	Motivo string
+	// estrito indica que a verificação foi feita no modo estrito
+	estrito bool
+}
+
+// Erro retorna ErrAssinaturaInvalida com o motivo quando a assinatura é inválida
+// ou, no modo estrito, ausente; nos demais casos, nil
+func (r *Resultado) Erro() error {
+	switch {
+	case r.Status == StatusInvalida:
+	case r.Status == StatusAusente && r.estrito:
+	default:
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrAssinaturaInvalida, r.Motivo)
+}
+
+// ConferirEmitente invalida a assinatura feita com certificado de outra empresa:
+// a raiz (8 primeiros dígitos) do CNPJ do certificado deve ser a do emitente.
+// Emitente com CNPJ e certificado sem CNPJ também invalida a assinatura.
+func (r *Resultado) ConferirEmitente(cnpjEmitente string) {
+	if r.Status != StatusValida || len(cnpjEmitente) != 14 {
+		return
+	}
+	if r.CNPJ == "" {
+		r.Status = StatusInvalida
+		r.Motivo = fmt.Sprintf("o certificado de %s não informa o CNPJ do emitente %s", r.Titular, cnpjEmitente)
+		return
+	}
+	if len(r.CNPJ) != 14 || r.CNPJ[:8] != cnpjEmitente[:8] {
+		r.Status = StatusInvalida
+		r.Motivo = fmt.Sprintf("o certificado do CNPJ %s não pertence ao emitente %s", r.CNPJ, cnpjEmitente)
+	}
+}
+
+func invalida(formato string, args ...interface{}) *Resultado {
+	return &Resultado{Status: StatusInvalida, Motivo: fmt.Sprintf(formato, args...)}
+}
+
+// Verificar verifica a assinatura do documento com o verificador configurado
+func Verificar(xmlContent []byte) *Resultado {
+	return Padrao().Verificar(xmlContent)
+}
+
+// Verificar canonicaliza o grupo assinado (infCte, infMDFe, ...), confere o
+// DigestValue e a SignatureValue com o certificado X.509 embutido e, nos
+// documentos processados, compara o digVal do protocolo com o digest do documento.
+// Com raízes configuradas, o certificado deve ter cadeia até uma delas; no modo
+// estrito, documento sem assinatura é inválido, com ou sem protocolo.
+func (v *Verificador) Verificar(xmlContent []byte) *Resultado {
+	resultado := v.verificar(xmlContent)
+	resultado.estrito = v.modo == ModoEstrito
+	return resultado
+}
+
+func (v *Verificador) verificar(xmlContent []byte) *Resultado {
+	raiz, err := lerArvore(xmlContent)
+	if err != nil {
+		return invalida("erro ao ler XML: %v", err)
+	}
+
+	info := raiz.buscar(func(e *elemento) bool { return elementosAssinados[e.local] })
+	var signature *elemento
+	if info != nil && info.pai != nil {
+		signature = info.pai.filho(nsDSig, "Signature")
+	}
+	if signature == nil {
+		if v.modo == ModoEstrito {
+			return invalida("documento sem assinatura digital")
+		}
+		if info == nil || info.pai == nil {
+			return &Resultado{Status: StatusAusente, Motivo: "grupo assinado não encontrado"}
+		}
+		return &Resultado{Status: StatusAusente, Motivo: "documento sem assinatura digital"}
+	}
+
+	signedInfo := signature.filho(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return invalida("SignedInfo ausente")
+	}
+	if alg := algoritmo(signedInfo.filho(nsDSig, "CanonicalizationMethod")); alg != algC14N {
+		return invalida("método de canonicalização não suportado: %s", alg)
+	}
+	hashAssinatura, ok := algoritmosAssinatura[algoritmo(signedInfo.filho(nsDSig, "SignatureMethod"))]
+	if !ok {
+		return invalida("método de assinatura não suportado: %s", algoritmo(signedInfo.filho(nsDSig, "SignatureMethod")))
+	}
+
+	// Referência ao grupo assinado
+	referencia := signedInfo.filho(nsDSig, "Reference")
+	if referencia == nil {
+		return invalida("Reference ausente")
+	}
+	if uri := referencia.atributo("URI"); uri != "#"+info.atributo("Id") {
+		return invalida("a referência %s não corresponde ao Id %s de %s", uri, info.atributo("Id"), info.local)
+	}
+	if transforms := referencia.filho(nsDSig, "Transforms"); transforms != nil {
+		for _, transform := range transforms.filhos(nsDSig, "Transform") {
+			if alg := algoritmo(transform); alg != algEnvelopedSignature && alg != algC14N {
+				return invalida("transformação não suportada: %s", alg)
+			}
+		}
+	}
+	hashDigest, ok := algoritmosDigest[algoritmo(referencia.filho(nsDSig, "DigestMethod"))]
+	if !ok {
+		return invalida("método de digest não suportado: %s", algoritmo(referencia.filho(nsDSig, "DigestMethod")))
+	}
+	digestInformado, err := decodificarBase64(referencia.filho(nsDSig, "DigestValue"))
+	if err != nil {
+		return invalida("DigestValue: %v", err)
+	}
+
+	// Conferir o digest do grupo assinado
+	digestCalculado := resumo(hashDigest, canonicalizar(info, signature))
+	if !bytes.Equal(digestCalculado, digestInformado) {
+		return invalida("o DigestValue não confere com o conteúdo de %s: documento alterado após a assinatura", info.local)
+	}
+
+	// Conferir a assinatura do SignedInfo com a chave do certificado
+	certificado, adicionais, err := certificadosAssinante(signature)
+	if err != nil {
+		return invalida("%v", err)
+	}
+	chave, ok := certificado.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return invalida("o certificado não contém chave RSA")
+	}
+	valorAssinatura, err := decodificarBase64(signature.filho(nsDSig, "SignatureValue"))
+	if err != nil {
+		return invalida("SignatureValue: %v", err)
+	}
+	hash := resumo(hashAssinatura, canonicalizar(signedInfo, nil))
+	if err := rsa.VerifyPKCS1v15(chave, hashAssinatura, hash, valorAssinatura); err != nil {
+		return invalida("a SignatureValue não confere com o certificado de %s", certificado.Subject.CommonName)
+	}
+	if err := v.conferirCadeia(certificado, adicionais, momentoAssinatura(raiz)); err != nil {
+		return invalida("%v", err)
+	}
+
+	// Nos documentos processados, o digVal do protocolo é o digest autorizado pela
+	// SEFAZ; protocolo sem infProt ou sem digVal não comprova a autorização
+	if protocolo := raiz.buscar(func(e *elemento) bool { return e.local == "protCTe" || e.local == "protMDFe" }); protocolo != nil {
+		infProt := protocolo.filho(protocolo.espaco, "infProt")
+		if infProt == nil {
+			return invalida("%s sem infProt", protocolo.local)
+		}
+		digVal := infProt.filho(infProt.espaco, "digVal")
+		if digVal == nil {
+			return invalida("o protocolo de autorização não informa o digVal")
+		}
+		valor, err := decodificarBase64(digVal)
+		if err != nil || !bytes.Equal(valor, digestInformado) {
+			return invalida("o digVal do protocolo de autorização não confere com o digest do documento")
+		}
+	}
+
+	return &Resultado{
+		Status:  StatusValida,
+		CNPJ:    cnpjDoCertificado(certificado),
+		Titular: certificado.Subject.CommonName,
+	}
+}
+
+// algoritmo retorna o atributo Algorithm do elemento
+func algoritmo(e *elemento) string {
+	if e == nil {
+		return ""
+	}
+	return e.atributo("Algorithm")
+}
+
+// decodificarBase64 decodifica o texto do elemento, ignorando quebras de linha
+func decodificarBase64(e *elemento) ([]byte, error) {
+	if e == nil {
+		return nil, errors.New("elemento ausente")
+	}
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(e.texto()), ""))
+}
+
+// resumo calcula o hash dos dados
+func resumo(hash crypto.Hash, dados []byte) []byte {
+	h := hash.New()
+	h.Write(dados)
+	return h.Sum(nil)
+}
+
+// certificadosAssinante lê o certificado do assinante (o primeiro de
+// KeyInfo/X509Data) e os demais certificados informados, usados como intermediários
+func certificadosAssinante(signature *elemento) (*x509.Certificate, []*x509.Certificate, error) {
+	var x509Data *elemento
+	if keyInfo := signature.filho(nsDSig, "KeyInfo"); keyInfo != nil {
+		x509Data = keyInfo.filho(nsDSig, "X509Data")
+	}
+	if x509Data == nil {
+		return nil, nil, errors.New("certificado X.509 ausente em KeyInfo")
+	}
+	elementos := x509Data.filhos(nsDSig, "X509Certificate")
+	if len(elementos) == 0 {
+		return nil, nil, errors.New("X509Certificate: elemento ausente")
+	}
+
+	certificados := make([]*x509.Certificate, 0, len(elementos))
+	for _, e := range elementos {
+		der, err := decodificarBase64(e)
+		if err != nil {
+			return nil, nil, fmt.Errorf("X509Certificate: %w", err)
+		}
+		certificado, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, nil, fmt.Errorf("certificado X.509 inválido: %w", err)
+		}
+		certificados = append(certificados, certificado)
+	}
+	return certificados[0], certificados[1:], nil
+}
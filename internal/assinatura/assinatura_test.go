@@ -0,0 +1,311 @@
+package assinatura
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Exemplo 3.3 da especificação Canonical XML 1.0 (sem o atributo padrão do DTD)
+func TestCanonicalizar(t *testing.T) {
+	entrada := `<doc>
+   <e1   />
+   <e2   ></e2>
+   <e3   name = "elem3"   id="elem3"   />
+   <e4   name="elem4"   id="elem4"   ></e4>
+   <e5 a:attr="out" b:attr="sorted" attr2="all" attr="I'm"
+      xmlns:b="http://www.ietf.org"
+      xmlns:a="http://www.w3.org"
+      xmlns="http://example.org"/>
+   <e6 xmlns="" xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="" xmlns:a="http://www.w3.org">
+            <e9 xmlns="" xmlns:a="http://www.ietf.org"/>
+         </e8>
+      </e7>
+   </e6>
+</doc>`
+	esperado := `<doc>
+   <e1></e1>
+   <e2></e2>
+   <e3 id="elem3" name="elem3"></e3>
+   <e4 id="elem4" name="elem4"></e4>
+   <e5 xmlns="http://example.org" xmlns:a="http://www.w3.org" xmlns:b="http://www.ietf.org" attr="I'm" attr2="all" b:attr="sorted" a:attr="out"></e5>
+   <e6 xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="">
+            <e9 xmlns:a="http://www.ietf.org"></e9>
+         </e8>
+      </e7>
+   </e6>
+</doc>`
+
+	raiz, err := lerArvore([]byte(entrada))
+	require.NoError(t, err)
+	assert.Equal(t, esperado, string(canonicalizar(raiz, nil)))
+
+	// Subárvore: o ápice recebe os namespaces herdados
+	e7 := raiz.buscar(func(e *elemento) bool { return e.local == "e7" })
+	assert.Equal(t, `<e7 xmlns="http://www.ietf.org" xmlns:a="http://www.w3.org">`, strings.SplitN(string(canonicalizar(e7, nil)), "\n", 2)[0])
+}
+
+const cnpjAssinante = "12345678000195"
+
+// acTeste é uma AC raiz de teste, gravada em diretorio para o verificador
+type acTeste struct {
+	certificado *x509.Certificate
+	chave       *rsa.PrivateKey
+	diretorio   string
+}
+
+func novaACTeste(t *testing.T) *acTeste {
+	t.Helper()
+
+	chave, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(100),
+		Subject:               pkix.Name{CommonName: "AC RAIZ TESTE"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &chave.PublicKey, chave)
+	require.NoError(t, err)
+	certificado, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ac_teste.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644))
+	return &acTeste{certificado: certificado, chave: chave, diretorio: dir}
+}
+
+// assinarCTe assina o infCte do modelo com um certificado autoassinado de teste
+func assinarCTe(t *testing.T, modelo string) string {
+	return assinarCTePor(t, modelo, nil)
+}
+
+// assinarCTePor assina o infCte do modelo com um certificado emitido pela AC
+// informada (autoassinado quando ac é nil)
+func assinarCTePor(t *testing.T, modelo string, ac *acTeste) string {
+	t.Helper()
+
+	chave, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	// Subject Alternative Name com o otherName do CNPJ
+	valorCNPJ, err := asn1.Marshal([]byte(cnpjAssinante))
+	require.NoError(t, err)
+	explicito, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: valorCNPJ})
+	require.NoError(t, err)
+	outro, err := asn1.MarshalWithParams(otherName{TypeID: oidCNPJ, Value: asn1.RawValue{FullBytes: explicito}}, "tag:0")
+	require.NoError(t, err)
+	san, err := asn1.Marshal([]asn1.RawValue{{FullBytes: outro}})
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		Subject:         pkix.Name{CommonName: "TRANSPORTADORA TESTE LTDA"},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: oidSubjectAltName, Value: san}},
+	}
+	emissor, chaveEmissor := template, chave
+	if ac != nil {
+		emissor, chaveEmissor = ac.certificado, ac.chave
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, emissor, &chave.PublicKey, chaveEmissor)
+	require.NoError(t, err)
+
+	// Digest do infCte
+	semAssinatura := strings.Replace(modelo, "{{ASSINATURA}}", "", 1)
+	raiz, err := lerArvore([]byte(semAssinatura))
+	require.NoError(t, err)
+	info := raiz.buscar(func(e *elemento) bool { return e.local == "infCte" })
+	digest := sha1.Sum(canonicalizar(info, nil))
+	digestBase64 := base64.StdEncoding.EncodeToString(digest[:])
+
+	signedInfo := `<SignedInfo><CanonicalizationMethod Algorithm="` + algC14N + `"></CanonicalizationMethod>` +
+		`<SignatureMethod Algorithm="` + algRSASHA1 + `"></SignatureMethod>` +
+		`<Reference URI="#` + info.atributo("Id") + `"><Transforms>` +
+		`<Transform Algorithm="` + algEnvelopedSignature + `"></Transform><Transform Algorithm="` + algC14N + `"></Transform>` +
+		`</Transforms><DigestMethod Algorithm="` + algSHA1 + `"></DigestMethod><DigestValue>` + digestBase64 + `</DigestValue></Reference></SignedInfo>`
+	assinatura := `<Signature xmlns="http://www.w3.org/2000/09/xmldsig#">` + signedInfo +
+		`<SignatureValue>{{VALOR}}</SignatureValue><KeyInfo><X509Data><X509Certificate>` +
+		base64.StdEncoding.EncodeToString(der) + `</X509Certificate></X509Data></KeyInfo></Signature>`
+
+	documento := strings.Replace(modelo, "{{ASSINATURA}}", assinatura, 1)
+	documento = strings.ReplaceAll(documento, "{{DIGEST}}", digestBase64)
+
+	// Assinar o SignedInfo canonicalizado no contexto do documento
+	raiz, err = lerArvore([]byte(documento))
+	require.NoError(t, err)
+	si := raiz.buscar(func(e *elemento) bool { return e.local == "SignedInfo" })
+	hash := sha1.Sum(canonicalizar(si, nil))
+	valor, err := rsa.SignPKCS1v15(rand.Reader, chave, crypto.SHA1, hash[:])
+	require.NoError(t, err)
+
+	return strings.Replace(documento, "{{VALOR}}", base64.StdEncoding.EncodeToString(valor), 1)
+}
+
+const modeloCTe = `<?xml version="1.0" encoding="UTF-8"?>
+<cteProc xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><CTe xmlns="http://www.portalfiscal.inf.br/cte">` +
+	`<infCte Id="CTe35240112345678000195570010000001231000001230" versao="4.00">` +
+	`<ide><cUF>35</cUF><mod>57</mod></ide><emit><CNPJ>12345678000195</CNPJ><xNome>A &amp; B</xNome></emit>` +
+	`<vPrest><vTPrest>1500.00</vTPrest></vPrest></infCte>{{ASSINATURA}}</CTe>` +
+	`<protCTe versao="4.00"><infProt><chCTe>35240112345678000195570010000001231000001230</chCTe>` +
+	`<digVal>{{DIGEST}}</digVal><cStat>100</cStat></infProt></protCTe></cteProc>`
+
+func TestVerificar(t *testing.T) {
+	documento := assinarCTe(t, modeloCTe)
+
+	resultado := Verificar([]byte(documento))
+	require.Equal(t, StatusValida, resultado.Status, resultado.Motivo)
+	assert.Equal(t, cnpjAssinante, resultado.CNPJ)
+	assert.Equal(t, "TRANSPORTADORA TESTE LTDA", resultado.Titular)
+	assert.NoError(t, resultado.Erro())
+
+	// Certificado de outra empresa
+	resultado.ConferirEmitente("12345678000276")
+	assert.NoError(t, resultado.Erro())
+	resultado.ConferirEmitente("98765432000198")
+	assert.ErrorIs(t, resultado.Erro(), ErrAssinaturaInvalida)
+
+	// Certificado sem CNPJ não comprova a autoria do emitente
+	resultado = &Resultado{Status: StatusValida, Titular: "FULANO DE TAL"}
+	resultado.ConferirEmitente(cnpjAssinante)
+	assert.ErrorIs(t, resultado.Erro(), ErrAssinaturaInvalida)
+
+	// Valor da prestação alterado depois da assinatura
+	adulterado := strings.Replace(documento, "<vTPrest>1500.00</vTPrest>", "<vTPrest>15000.00</vTPrest>", 1)
+	resultado = Verificar([]byte(adulterado))
+	assert.Equal(t, StatusInvalida, resultado.Status)
+	assert.Contains(t, resultado.Motivo, "DigestValue")
+	assert.ErrorIs(t, resultado.Erro(), ErrAssinaturaInvalida)
+
+	// Documento reassinado com outro certificado não confere com o protocolo
+	outro := assinarCTe(t, strings.Replace(modeloCTe, "<vTPrest>1500.00</vTPrest>", "<vTPrest>15000.00</vTPrest>", 1))
+	i := strings.Index(outro, "<protCTe")
+	reassinado := outro[:i] + documento[strings.Index(documento, "<protCTe"):]
+	resultado = Verificar([]byte(reassinado))
+	assert.Equal(t, StatusInvalida, resultado.Status)
+	assert.Contains(t, resultado.Motivo, "digVal")
+
+	// Sem assinatura
+	resultado = Verificar([]byte(strings.NewReplacer("{{ASSINATURA}}", "", "{{DIGEST}}", "").Replace(modeloCTe)))
+	assert.Equal(t, StatusAusente, resultado.Status)
+	assert.NoError(t, resultado.Erro())
+}
+
+func TestVerificarElementoExtraido(t *testing.T) {
+	// Namespace declarado somente no cteProc: o CTe extraído continua com a mesma assinatura
+	modelo := strings.Replace(modeloCTe, `<CTe xmlns="http://www.portalfiscal.inf.br/cte">`, "<CTe>", 1)
+	documento := assinarCTe(t, modelo)
+
+	cte, err := parsers.ExtrairElemento([]byte(documento), "CTe")
+	require.NoError(t, err)
+	resultado := Verificar(cte)
+	require.Equal(t, StatusValida, resultado.Status, resultado.Motivo)
+}
+
+func TestVerificarCadeiaICPBrasil(t *testing.T) {
+	ac := novaACTeste(t)
+	v, err := NewVerificador(Config{Modo: ModoEstrito, DiretorioRaizes: ac.diretorio})
+	require.NoError(t, err)
+
+	resultado := v.Verificar([]byte(assinarCTePor(t, modeloCTe, ac)))
+	require.Equal(t, StatusValida, resultado.Status, resultado.Motivo)
+	assert.Equal(t, cnpjAssinante, resultado.CNPJ)
+
+	// Documento alterado e reassinado com certificado próprio, com o CNPJ do emitente
+	resultado = v.Verificar([]byte(assinarCTe(t, strings.Replace(modeloCTe, "<vTPrest>1500.00</vTPrest>", "<vTPrest>15000.00</vTPrest>", 1))))
+	assert.Equal(t, StatusInvalida, resultado.Status)
+	assert.Contains(t, resultado.Motivo, "cadeia ICP-Brasil")
+
+	// Documento autorizado sem assinatura
+	resultado = v.Verificar([]byte(strings.NewReplacer("{{ASSINATURA}}", "", "{{DIGEST}}", "").Replace(modeloCTe)))
+	assert.Equal(t, StatusInvalida, resultado.Status)
+	assert.ErrorIs(t, resultado.Erro(), ErrAssinaturaInvalida)
+
+	// Sem protocolo, a falta de assinatura também é erro
+	semProtocolo := modeloCTe[:strings.Index(modeloCTe, "<protCTe")] + "</cteProc>"
+	resultado = v.Verificar([]byte(strings.Replace(semProtocolo, "{{ASSINATURA}}", "", 1)))
+	assert.Equal(t, StatusInvalida, resultado.Status)
+	assert.ErrorIs(t, resultado.Erro(), ErrAssinaturaInvalida)
+
+	// Protocolo sem o digVal não comprova a autorização
+	semDigVal := strings.Replace(modeloCTe, "<digVal>{{DIGEST}}</digVal>", "", 1)
+	resultado = v.Verificar([]byte(assinarCTePor(t, semDigVal, ac)))
+	assert.Equal(t, StatusInvalida, resultado.Status)
+	assert.Contains(t, resultado.Motivo, "digVal")
+
+	// O modo estrito exige as raízes
+	_, err = NewVerificador(Config{Modo: ModoEstrito, DiretorioRaizes: t.TempDir()})
+	assert.ErrorIs(t, err, ErrRaizesIndisponiveis)
+}
+
+func TestAssinar(t *testing.T) {
+	ac := novaACTeste(t)
+	v, err := NewVerificador(Config{Modo: ModoEstrito, DiretorioRaizes: ac.diretorio})
+	require.NoError(t, err)
+
+	// Certificado emitido pela AC de teste, com o CNPJ no CN
+	chave, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "TRANSPORTADORA TESTE LTDA:" + cnpjAssinante},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ac.certificado, &chave.PublicKey, ac.chave)
+	require.NoError(t, err)
+	certificado, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	semProtocolo := modeloCTe[:strings.Index(modeloCTe, "<protCTe")] + "</cteProc>"
+	assinado, err := Assinar([]byte(strings.Replace(semProtocolo, "{{ASSINATURA}}", "", 1)), chave, certificado)
+	require.NoError(t, err)
+	resultado := v.Verificar(assinado)
+	require.Equal(t, StatusValida, resultado.Status, resultado.Motivo)
+	assert.Equal(t, cnpjAssinante, resultado.CNPJ)
+
+	_, err = Assinar(assinado, chave, certificado)
+	assert.Error(t, err)
+}
+
+// O CT-e de testdata foi canonicalizado pelo xmllint e assinado pelo OpenSSL
+// (ver testdata/gerar.sh), sem passar pelo código do pacote
+func TestVerificarAssinaturaExterna(t *testing.T) {
+	documento, err := os.ReadFile(filepath.Join("testdata", "cte_assinado_externo.xml"))
+	require.NoError(t, err)
+
+	v, err := NewVerificador(Config{Modo: ModoEstrito, DiretorioRaizes: filepath.Join("testdata", "raizes")})
+	require.NoError(t, err)
+
+	resultado := v.Verificar(documento)
+	require.Equal(t, StatusValida, resultado.Status, resultado.Motivo)
+	assert.Equal(t, cnpjAssinante, resultado.CNPJ)
+	assert.Equal(t, "TRANSPORTADORA EXTERNA LTDA:12345678000195", resultado.Titular)
+
+	resultado = v.Verificar([]byte(strings.Replace(string(documento), "2750.40", "2750.41", 1)))
+	assert.Equal(t, StatusInvalida, resultado.Status)
+}
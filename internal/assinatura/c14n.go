@@ -0,0 +1,334 @@
+package assinatura
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// nsXML é o namespace reservado do prefixo xml
+const nsXML = "http://www.w3.org/XML/1998/namespace"
+
+// atributo é um atributo comum (não declaração de namespace) com o namespace resolvido
+type atributo struct {
+	prefixo string
+	local   string
+	espaco  string
+	valor   string
+}
+
+// conteudo é um item do conteúdo misto de um elemento: elemento, texto ou instrução de processamento
+type conteudo struct {
+	elemento  *elemento
+	texto     string
+	instrucao *xml.ProcInst
+}
+
+// elemento é um nó do documento com os prefixos originais preservados, necessários à canonicalização
+type elemento struct {
+	prefixo string
+	local   string
+	espaco  string
+	attrs   []atributo
+	// declaracoes são as declarações de namespace feitas no próprio elemento
+	declaracoes map[string]string
+	conteudo    []conteudo
+	pai         *elemento
+}
+
+// lerArvore monta a árvore do documento. Comentários são descartados, pois a
+// canonicalização usada pelos documentos fiscais é a C14N sem comentários.
+func lerArvore(dados []byte) (*elemento, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(dados))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var raiz, atual *elemento
+	for {
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			e := &elemento{prefixo: t.Name.Space, local: t.Name.Local, declaracoes: make(map[string]string), pai: atual}
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					e.declaracoes[attr.Name.Local] = attr.Value
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					e.declaracoes[""] = attr.Value
+				default:
+					// Normalização de valores de atributo (XML 1.0, seção 3.3.3)
+					valor := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(attr.Value)
+					e.attrs = append(e.attrs, atributo{prefixo: attr.Name.Space, local: attr.Name.Local, valor: valor})
+				}
+			}
+			e.espaco = e.namespace(e.prefixo)
+			for i := range e.attrs {
+				if e.attrs[i].prefixo != "" {
+					e.attrs[i].espaco = e.namespace(e.attrs[i].prefixo)
+				}
+			}
+
+			if atual == nil {
+				if raiz != nil {
+					return nil, errors.New("mais de um elemento raiz")
+				}
+				raiz = e
+			} else {
+				atual.conteudo = append(atual.conteudo, conteudo{elemento: e})
+			}
+			atual = e
+		case xml.EndElement:
+			if atual == nil {
+				return nil, errors.New("elemento de fechamento inesperado")
+			}
+			atual = atual.pai
+		case xml.CharData:
+			if atual != nil {
+				atual.conteudo = append(atual.conteudo, conteudo{texto: string(t)})
+			}
+		case xml.ProcInst:
+			if atual != nil {
+				instrucao := t.Copy()
+				atual.conteudo = append(atual.conteudo, conteudo{instrucao: &instrucao})
+			}
+		}
+	}
+
+	if raiz == nil {
+		return nil, errors.New("elemento raiz não encontrado")
+	}
+	return raiz, nil
+}
+
+// namespace resolve o prefixo no escopo do elemento
+func (e *elemento) namespace(prefixo string) string {
+	if prefixo == "xml" {
+		return nsXML
+	}
+	for n := e; n != nil; n = n.pai {
+		if uri, ok := n.declaracoes[prefixo]; ok {
+			return uri
+		}
+	}
+	return ""
+}
+
+// escopo retorna todas as declarações de namespace visíveis no elemento
+func (e *elemento) escopo() map[string]string {
+	escopo := make(map[string]string)
+	var cadeia []*elemento
+	for n := e; n != nil; n = n.pai {
+		cadeia = append(cadeia, n)
+	}
+	for i := len(cadeia) - 1; i >= 0; i-- {
+		for prefixo, uri := range cadeia[i].declaracoes {
+			escopo[prefixo] = uri
+		}
+	}
+	return escopo
+}
+
+// atributo retorna o valor do atributo sem namespace
+func (e *elemento) atributo(local string) string {
+	for _, attr := range e.attrs {
+		if attr.prefixo == "" && attr.local == local {
+			return attr.valor
+		}
+	}
+	return ""
+}
+
+// filhos retorna os elementos filhos com o namespace e nome local informados
+func (e *elemento) filhos(espaco, local string) []*elemento {
+	var encontrados []*elemento
+	for _, c := range e.conteudo {
+		if c.elemento != nil && c.elemento.espaco == espaco && c.elemento.local == local {
+			encontrados = append(encontrados, c.elemento)
+		}
+	}
+	return encontrados
+}
+
+// filho retorna o primeiro elemento filho com o namespace e nome local informados
+func (e *elemento) filho(espaco, local string) *elemento {
+	if encontrados := e.filhos(espaco, local); len(encontrados) > 0 {
+		return encontrados[0]
+	}
+	return nil
+}
+
+// texto retorna o conteúdo textual do elemento, sem espaços nas extremidades
+func (e *elemento) texto() string {
+	var b strings.Builder
+	for _, c := range e.conteudo {
+		if c.elemento == nil && c.instrucao == nil {
+			b.WriteString(c.texto)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// buscar percorre a subárvore em profundidade e retorna o primeiro elemento aceito
+func (e *elemento) buscar(aceitar func(*elemento) bool) *elemento {
+	if aceitar(e) {
+		return e
+	}
+	for _, c := range e.conteudo {
+		if c.elemento != nil {
+			if encontrado := c.elemento.buscar(aceitar); encontrado != nil {
+				return encontrado
+			}
+		}
+	}
+	return nil
+}
+
+// canonicalizar serializa a subárvore do elemento em Canonical XML 1.0 sem
+// comentários (http://www.w3.org/TR/2001/REC-xml-c14n-20010315). O elemento
+// excluido, se informado, é omitido (transformação enveloped-signature).
+func canonicalizar(e *elemento, excluido *elemento) []byte {
+	var b bytes.Buffer
+
+	// O elemento ápice recebe todos os namespaces em escopo e os atributos xml:*
+	// herdados dos ancestrais
+	herdados := make(map[string]atributo)
+	for n := e.pai; n != nil; n = n.pai {
+		for _, attr := range n.attrs {
+			if attr.espaco == nsXML {
+				if _, ok := herdados[attr.local]; !ok {
+					herdados[attr.local] = attr
+				}
+			}
+		}
+	}
+	for _, attr := range e.attrs {
+		delete(herdados, attr.local)
+	}
+
+	c := &canonicalizador{b: &b, excluido: excluido}
+	c.elemento(e, e.escopo(), map[string]string{"": ""}, herdados)
+	return b.Bytes()
+}
+
+type canonicalizador struct {
+	b        *bytes.Buffer
+	excluido *elemento
+}
+
+func (c *canonicalizador) elemento(e *elemento, escopo, renderizados map[string]string, herdados map[string]atributo) {
+	nomeQualificado := e.local
+	if e.prefixo != "" {
+		nomeQualificado = e.prefixo + ":" + e.local
+	}
+
+	// Declarações de namespace que mudam em relação ao ancestral renderizado
+	var prefixos []string
+	for prefixo, uri := range escopo {
+		if prefixo == "xml" {
+			continue
+		}
+		anterior, ok := renderizados[prefixo]
+		if prefixo == "" {
+			if uri != anterior {
+				prefixos = append(prefixos, prefixo)
+			}
+			continue
+		}
+		if uri != "" && (!ok || anterior != uri) {
+			prefixos = append(prefixos, prefixo)
+		}
+	}
+	sort.Strings(prefixos) // o namespace padrão ("") vem primeiro
+
+	proximos := renderizados
+	if len(prefixos) > 0 {
+		proximos = make(map[string]string, len(renderizados)+len(prefixos))
+		for prefixo, uri := range renderizados {
+			proximos[prefixo] = uri
+		}
+	}
+
+	c.b.WriteString("<" + nomeQualificado)
+	for _, prefixo := range prefixos {
+		uri := escopo[prefixo]
+		proximos[prefixo] = uri
+		if prefixo == "" {
+			c.b.WriteString(` xmlns="` + escaparAtributo(uri) + `"`)
+		} else {
+			c.b.WriteString(" xmlns:" + prefixo + `="` + escaparAtributo(uri) + `"`)
+		}
+	}
+
+	attrs := append([]atributo(nil), e.attrs...)
+	for _, attr := range herdados {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].espaco != attrs[j].espaco {
+			return attrs[i].espaco < attrs[j].espaco
+		}
+		return attrs[i].local < attrs[j].local
+	})
+	for _, attr := range attrs {
+		nome := attr.local
+		if attr.prefixo != "" {
+			nome = attr.prefixo + ":" + attr.local
+		}
+		c.b.WriteString(" " + nome + `="` + escaparAtributo(attr.valor) + `"`)
+	}
+	c.b.WriteString(">")
+
+	for _, item := range e.conteudo {
+		switch {
+		case item.elemento != nil:
+			if item.elemento == c.excluido {
+				continue
+			}
+			filhoEscopo := escopo
+			if len(item.elemento.declaracoes) > 0 {
+				filhoEscopo = make(map[string]string, len(escopo))
+				for prefixo, uri := range escopo {
+					filhoEscopo[prefixo] = uri
+				}
+				for prefixo, uri := range item.elemento.declaracoes {
+					filhoEscopo[prefixo] = uri
+				}
+			}
+			c.elemento(item.elemento, filhoEscopo, proximos, nil)
+		case item.instrucao != nil:
+			c.b.WriteString("<?" + item.instrucao.Target)
+			if len(item.instrucao.Inst) > 0 {
+				c.b.WriteString(" " + string(item.instrucao.Inst))
+			}
+			c.b.WriteString("?>")
+		default:
+			c.b.WriteString(escaparTexto(item.texto))
+		}
+	}
+
+	c.b.WriteString("</" + nomeQualificado + ">")
+}
+
+var substituicoesTexto = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+
+var substituicoesAtributo = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;",
+	"\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+
+func escaparTexto(s string) string {
+	return substituicoesTexto.Replace(s)
+}
+
+func escaparAtributo(s string) string {
+	return substituicoesAtributo.Replace(s)
+}
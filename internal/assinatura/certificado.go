@@ -0,0 +1,61 @@
+package assinatura
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"regexp"
+	"strings"
+)
+
+var (
+	// oidSubjectAltName é a extensão Subject Alternative Name
+	oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+	// oidCNPJ é o otherName com o CNPJ da pessoa jurídica nos certificados ICP-Brasil
+	oidCNPJ = asn1.ObjectIdentifier{2, 16, 76, 1, 3, 3}
+)
+
+var regexCNPJ = regexp.MustCompile(`[0-9]{14}`)
+
+// otherName do GeneralName (RFC 5280)
+type otherName struct {
+	TypeID asn1.ObjectIdentifier
+	Value  asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// cnpjDoCertificado extrai o CNPJ do titular do certificado: primeiro do otherName
+// 2.16.76.1.3.3 (padrão ICP-Brasil) e, na falta dele, do CN no formato "RAZAO SOCIAL:CNPJ"
+func cnpjDoCertificado(certificado *x509.Certificate) string {
+	for _, extensao := range certificado.Extensions {
+		if !extensao.Id.Equal(oidSubjectAltName) {
+			continue
+		}
+		var nomes []asn1.RawValue
+		if _, err := asn1.Unmarshal(extensao.Value, &nomes); err != nil {
+			break
+		}
+		for _, nome := range nomes {
+			// otherName é o GeneralName [0]
+			if nome.Class != asn1.ClassContextSpecific || nome.Tag != 0 {
+				continue
+			}
+			var outro otherName
+			if _, err := asn1.UnmarshalWithParams(nome.FullBytes, &outro, "tag:0"); err != nil {
+				continue
+			}
+			if !outro.TypeID.Equal(oidCNPJ) {
+				continue
+			}
+			// O valor é uma OCTET STRING ou string imprimível com os 14 dígitos
+			if cnpj := regexCNPJ.FindString(string(outro.Value.Bytes)); cnpj != "" {
+				return cnpj
+			}
+		}
+	}
+
+	if i := strings.LastIndex(certificado.Subject.CommonName, ":"); i >= 0 {
+		if cnpj := certificado.Subject.CommonName[i+1:]; regexCNPJ.MatchString(cnpj) && len(cnpj) == 14 {
+			return cnpj
+		}
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+#!/usr/bin/env bash
+# Gera o CT-e assinado usado em TestVerificarAssinaturaExterna sem o código do
+# pacote: a canonicalização é feita pelo xmllint (libxml2) e a assinatura pelo
+# OpenSSL, com um certificado emitido por uma AC de teste.
+#
+# Uso: ./gerar.sh (a partir de internal/assinatura/testdata)
+set -euo pipefail
+
+CHAVE=35261012345678000195570010000007891000007894
+NS_CTE=http://www.portalfiscal.inf.br/cte
+NS_DSIG=http://www.w3.org/2000/09/xmldsig#
+
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+# AC raiz de teste e certificado da transportadora com o CNPJ no otherName 2.16.76.1.3.3
+mkdir -p raizes
+openssl req -x509 -newkey rsa:2048 -nodes -days 3650 -keyout "$tmp/ac.key" -out raizes/ac_teste.pem \
+  -subj "/C=BR/O=ICP-Brasil/CN=AC RAIZ TESTE" \
+  -addext "basicConstraints=critical,CA:TRUE" -addext "keyUsage=critical,keyCertSign,cRLSign"
+openssl req -newkey rsa:2048 -nodes -keyout "$tmp/emitente.key" -out "$tmp/emitente.csr" \
+  -subj "/C=BR/O=ICP-Brasil/CN=TRANSPORTADORA EXTERNA LTDA:12345678000195"
+printf 'subjectAltName=otherName:2.16.76.1.3.3;OCTETSTRING:12345678000195\nkeyUsage=critical,digitalSignature,nonRepudiation\n' > "$tmp/ext.cnf"
+openssl x509 -req -in "$tmp/emitente.csr" -CA raizes/ac_teste.pem -CAkey "$tmp/ac.key" -CAcreateserial \
+  -CAserial "$tmp/ac.srl" -days 3650 -extfile "$tmp/ext.cnf" -out "$tmp/emitente.pem"
+certificado=$(grep -v -- '-----' "$tmp/emitente.pem" | tr -d '\n')
+
+# Grupo assinado, com comentário, referências de caractere, atributos fora de
+# ordem, elemento vazio e espaços que a canonicalização deve tratar
+inf_cte='<infCte versao="4.00"   Id="CTe'$CHAVE'">
+  <ide><cUF>35</cUF><mod>57</mod><!-- série 1 --><natOp>PRESTA&#199;&#195;O DE SERVI&#xC7;O</natOp></ide>
+  <emit><CNPJ>12345678000195</CNPJ><xNome>EXTERNA &amp; CIA "LTDA" &gt; 1</xNome><xFant/></emit>
+  <vPrest><vTPrest>2750.40</vTPrest></vPrest>
+</infCte>'
+
+# Digest do infCte canonicalizado como subárvore (o namespace herdado vai no ápice).
+# O xmllint --c14n mantém os comentários, que a referência URI="#Id" exclui; na
+# saída canônica o "<" do texto é sempre escapado, então basta remover os nós.
+printf '%s' "${inf_cte/<infCte /<infCte xmlns=\"$NS_CTE\" }" > "$tmp/infCte.xml"
+digest=$(xmllint --c14n "$tmp/infCte.xml" | sed 's/<!--[^>]*-->//g' | openssl dgst -sha1 -binary | base64)
+
+signed_info='<SignedInfo><CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/><SignatureMethod Algorithm="http://www.w3.org/2000/09/xmldsig#rsa-sha1"/><Reference URI="#CTe'$CHAVE'"><Transforms><Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"/><Transform Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/></Transforms><DigestMethod Algorithm="http://www.w3.org/2000/09/xmldsig#sha1"/><DigestValue>'$digest'</DigestValue></Reference></SignedInfo>'
+printf '%s' "${signed_info/<SignedInfo>/<SignedInfo xmlns=\"$NS_DSIG\">}" > "$tmp/SignedInfo.xml"
+valor=$(xmllint --c14n "$tmp/SignedInfo.xml" | openssl dgst -sha1 -sign "$tmp/emitente.key" | base64 | tr -d '\n')
+
+cat > cte_assinado_externo.xml <<EOF
+<?xml version="1.0" encoding="UTF-8"?>
+<cteProc xmlns="$NS_CTE" versao="4.00"><CTe xmlns="$NS_CTE">$inf_cte<Signature xmlns="$NS_DSIG">$signed_info<SignatureValue>$valor</SignatureValue><KeyInfo><X509Data><X509Certificate>$certificado</X509Certificate></X509Data></KeyInfo></Signature></CTe><protCTe versao="4.00"><infProt><tpAmb>2</tpAmb><chCTe>$CHAVE</chCTe><dhRecbto>2026-10-20T10:00:00-03:00</dhRecbto><nProt>135260000000789</nProt><digVal>$digest</digVal><cStat>100</cStat></infProt></protCTe></cteProc>
+EOF
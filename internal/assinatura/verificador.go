@@ -0,0 +1,200 @@
+package assinatura
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modos de verificação
+const (
+	// ModoEstrito exige assinatura em todos os documentos e cadeia até uma raiz ICP-Brasil
+	ModoEstrito = "estrito"
+	// ModoTolerante aceita documentos sem assinatura e só confere a cadeia se houver raízes configuradas
+	ModoTolerante = "tolerante"
+)
+
+// ErrRaizesIndisponiveis indica que o modo estrito foi configurado sem certificados raiz
+var ErrRaizesIndisponiveis = errors.New("nenhum certificado raiz ICP-Brasil configurado")
+
+// extensoesCertificado são os arquivos lidos do diretório de certificados
+var extensoesCertificado = map[string]bool{
+	".pem": true,
+	".crt": true,
+	".cer": true,
+	".der": true,
+}
+
+// Config define o modo e o diretório com as ACs raiz e intermediárias da ICP-Brasil
+type Config struct {
+	Modo string
+	// DiretorioRaizes contém os certificados (PEM ou DER) das ACs confiáveis; os
+	// autoassinados são raízes e os demais, intermediários
+	DiretorioRaizes string
+}
+
+// Verificador confere as assinaturas com as raízes confiáveis configuradas
+type Verificador struct {
+	modo           string
+	raizes         *x509.CertPool
+	intermediarias *x509.CertPool
+	totalRaizes    int
+}
+
+// NewVerificador cria um verificador com a configuração informada. No modo
+// estrito, o diretório deve conter ao menos um certificado raiz.
+func NewVerificador(config Config) (*Verificador, error) {
+	modo := config.Modo
+	if modo == "" {
+		modo = ModoTolerante
+	}
+	if modo != ModoEstrito && modo != ModoTolerante {
+		return nil, fmt.Errorf("modo de verificação de assinatura inválido: %s", config.Modo)
+	}
+
+	v := &Verificador{modo: modo, raizes: x509.NewCertPool(), intermediarias: x509.NewCertPool()}
+	if config.DiretorioRaizes != "" {
+		if err := v.carregarCertificados(config.DiretorioRaizes); err != nil {
+			return nil, err
+		}
+	}
+	if modo == ModoEstrito && v.totalRaizes == 0 {
+		return nil, fmt.Errorf("%w: informe o diretório com as ACs raiz em ASSINATURA_RAIZES_DIR", ErrRaizesIndisponiveis)
+	}
+	return v, nil
+}
+
+// carregarCertificados lê os certificados do diretório, separando raízes e intermediárias
+func (v *Verificador) carregarCertificados(dir string) error {
+	arquivos, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("diretório de certificados raiz: %w", err)
+	}
+
+	for _, arquivo := range arquivos {
+		if arquivo.IsDir() || !extensoesCertificado[strings.ToLower(filepath.Ext(arquivo.Name()))] {
+			continue
+		}
+		conteudo, err := os.ReadFile(filepath.Join(dir, arquivo.Name()))
+		if err != nil {
+			return err
+		}
+		certificados, err := lerCertificados(conteudo)
+		if err != nil {
+			return fmt.Errorf("certificado %s: %w", arquivo.Name(), err)
+		}
+		for _, certificado := range certificados {
+			if autoassinado(certificado) {
+				v.raizes.AddCert(certificado)
+				v.totalRaizes++
+			} else {
+				v.intermediarias.AddCert(certificado)
+			}
+		}
+	}
+	return nil
+}
+
+// lerCertificados lê os certificados de um arquivo PEM (um ou mais blocos) ou DER
+func lerCertificados(conteudo []byte) ([]*x509.Certificate, error) {
+	if !bytes.Contains(conteudo, []byte("-----BEGIN")) {
+		certificado, err := x509.ParseCertificate(conteudo)
+		if err != nil {
+			return nil, err
+		}
+		return []*x509.Certificate{certificado}, nil
+	}
+
+	var certificados []*x509.Certificate
+	for {
+		var bloco *pem.Block
+		bloco, conteudo = pem.Decode(conteudo)
+		if bloco == nil {
+			break
+		}
+		if bloco.Type != "CERTIFICATE" {
+			continue
+		}
+		certificado, err := x509.ParseCertificate(bloco.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certificados = append(certificados, certificado)
+	}
+	return certificados, nil
+}
+
+// autoassinado indica se o certificado foi emitido e assinado por ele mesmo
+func autoassinado(certificado *x509.Certificate) bool {
+	return bytes.Equal(certificado.RawSubject, certificado.RawIssuer) && certificado.CheckSignatureFrom(certificado) == nil
+}
+
+// conferirCadeia verifica se o certificado do assinante tem cadeia até uma raiz
+// confiável, na data da assinatura. Sem raízes configuradas não há verificação.
+func (v *Verificador) conferirCadeia(certificado *x509.Certificate, adicionais []*x509.Certificate, momento time.Time) error {
+	if v.totalRaizes == 0 {
+		return nil
+	}
+
+	intermediarias := v.intermediarias.Clone()
+	for _, adicional := range adicionais {
+		intermediarias.AddCert(adicional)
+	}
+	_, err := certificado.Verify(x509.VerifyOptions{
+		Roots:         v.raizes,
+		Intermediates: intermediarias,
+		CurrentTime:   momento,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("o certificado de %s não tem cadeia ICP-Brasil válida: %v", certificado.Subject.CommonName, err)
+	}
+	return nil
+}
+
+// momentoAssinatura é a data do recebimento pela SEFAZ, usada para conferir a
+// validade do certificado; sem protocolo, a data atual
+func momentoAssinatura(raiz *elemento) time.Time {
+	registro := raiz.buscar(func(e *elemento) bool { return e.local == "dhRecbto" || e.local == "dhRegEvento" })
+	if registro != nil {
+		if momento, err := time.Parse(time.RFC3339, strings.TrimSpace(registro.texto())); err == nil {
+			return momento
+		}
+	}
+	return time.Now()
+}
+
+var (
+	padraoMu sync.RWMutex
+	padrao   *Verificador
+)
+
+// Configurar define o verificador usado pelo processamento dos XMLs
+func Configurar(config Config) error {
+	v, err := NewVerificador(config)
+	if err != nil {
+		return err
+	}
+	padraoMu.Lock()
+	padrao = v
+	padraoMu.Unlock()
+	return nil
+}
+
+// Padrao retorna o verificador configurado (modo tolerante sem raízes, se não configurado)
+func Padrao() *Verificador {
+	padraoMu.RLock()
+	v := padrao
+	padraoMu.RUnlock()
+	if v != nil {
+		return v
+	}
+	return &Verificador{modo: ModoTolerante, raizes: x509.NewCertPool(), intermediarias: x509.NewCertPool()}
+}
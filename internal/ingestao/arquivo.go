@@ -0,0 +1,231 @@
+package ingestao
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Tipos de arquivo aceitos na ingestão
+const (
+	TipoXML   = "xml"
+	TipoZIP   = "zip"
+	TipoTarGz = "tar.gz"
+)
+
+var (
+	// ErrLimiteExcedido indica que o arquivo compactado ultrapassou algum limite configurado
+	ErrLimiteExcedido = errors.New("limite do arquivo compactado excedido")
+	// ErrArquivoInvalido indica que o arquivo compactado não pôde ser lido
+	ErrArquivoInvalido = errors.New("arquivo compactado inválido")
+)
+
+// Limites define os limites aplicados aos arquivos compactados
+type Limites struct {
+	// MaxEntradas é o número máximo de XMLs por arquivo compactado
+	MaxEntradas int
+	// MaxTamanhoEntrada é o tamanho máximo descompactado de cada XML, em bytes
+	MaxTamanhoEntrada int64
+	// MaxTamanhoTotal é o tamanho máximo descompactado somando todos os XMLs, em bytes
+	MaxTamanhoTotal int64
+}
+
+// EntradaFunc recebe cada XML extraído do arquivo compactado
+type EntradaFunc func(nome string, conteudo []byte) error
+
+// TipoArquivo identifica o tipo do arquivo pela extensão
+func TipoArquivo(nome string) string {
+	nome = strings.ToLower(nome)
+	switch {
+	case strings.HasSuffix(nome, ".xml"):
+		return TipoXML
+	case strings.HasSuffix(nome, ".zip"):
+		return TipoZIP
+	case strings.HasSuffix(nome, ".tar.gz"), strings.HasSuffix(nome, ".tgz"):
+		return TipoTarGz
+	}
+	return ""
+}
+
+// LerXML lê um XML avulso aplicando o limite de tamanho de cada arquivo (MaxTamanhoEntrada)
+func LerXML(nome string, r io.Reader, limites Limites) ([]byte, error) {
+	if limites.MaxTamanhoEntrada <= 0 {
+		return io.ReadAll(r)
+	}
+	conteudo, err := io.ReadAll(io.LimitReader(r, limites.MaxTamanhoEntrada+1))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler %s: %w", nome, err)
+	}
+	if int64(len(conteudo)) > limites.MaxTamanhoEntrada {
+		return nil, fmt.Errorf("%w: %s excede %d bytes", ErrLimiteExcedido, nome, limites.MaxTamanhoEntrada)
+	}
+	return conteudo, nil
+}
+
+// PercorrerCompactado percorre os XMLs de um arquivo .zip ou .tar.gz, um por vez,
+// aplicando os limites sobre o conteúdo efetivamente descompactado
+func PercorrerCompactado(tipo string, r io.Reader, tamanho int64, limites Limites, fn EntradaFunc) error {
+	switch tipo {
+	case TipoZIP:
+		return percorrerZip(r, tamanho, limites, fn)
+	case TipoTarGz:
+		return percorrerTarGz(r, limites, fn)
+	}
+	return fmt.Errorf("tipo de arquivo compactado não suportado: %s", tipo)
+}
+
+// percorrerZip lê as entradas pelo diretório central; se o leitor não permitir
+// acesso aleatório, o conteúdo é copiado para um arquivo temporário
+func percorrerZip(r io.Reader, tamanho int64, limites Limites, fn EntradaFunc) error {
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		tmp, err := os.CreateTemp("", "upload-*.zip")
+		if err != nil {
+			return fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if tamanho, err = io.Copy(tmp, r); err != nil {
+			return fmt.Errorf("erro ao copiar arquivo zip: %w", err)
+		}
+		ra = tmp
+	}
+
+	zr, err := zip.NewReader(ra, tamanho)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrArquivoInvalido, err)
+	}
+
+	// O diretório central permite recusar o arquivo antes de extrair qualquer entrada
+	entradas := 0
+	for _, f := range zr.File {
+		if entradaXML(f.Name, f.FileInfo().IsDir()) {
+			entradas++
+		}
+	}
+	if limites.MaxEntradas > 0 && entradas > limites.MaxEntradas {
+		return fmt.Errorf("%w: %d XMLs (máximo %d)", ErrLimiteExcedido, entradas, limites.MaxEntradas)
+	}
+
+	leitor := leitorLimitado{limites: limites}
+	for _, f := range zr.File {
+		if !entradaXML(f.Name, f.FileInfo().IsDir()) {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%w: erro ao abrir %s: %v", ErrArquivoInvalido, f.Name, err)
+		}
+		conteudo, err := leitor.ler(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+
+		if err := fn(f.Name, conteudo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// percorrerTarGz lê o arquivo em fluxo, sem precisar do conteúdo inteiro
+func percorrerTarGz(r io.Reader, limites Limites, fn EntradaFunc) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrArquivoInvalido, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	leitor := leitorLimitado{limites: limites}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrArquivoInvalido, err)
+		}
+		if header.Typeflag != tar.TypeReg || !entradaXML(header.Name, false) {
+			continue
+		}
+
+		if limites.MaxEntradas > 0 && leitor.entradas >= limites.MaxEntradas {
+			return fmt.Errorf("%w: mais de %d XMLs", ErrLimiteExcedido, limites.MaxEntradas)
+		}
+
+		conteudo, err := leitor.ler(header.Name, tr)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(header.Name, conteudo); err != nil {
+			return err
+		}
+	}
+}
+
+// entradaXML indica se a entrada do arquivo compactado deve ser importada
+func entradaXML(nome string, diretorio bool) bool {
+	if diretorio {
+		return false
+	}
+	base := path.Base(nome)
+	// Ignorar metadados criados pelo macOS
+	if strings.HasPrefix(nome, "__MACOSX/") || strings.HasPrefix(base, "._") {
+		return false
+	}
+	return TipoArquivo(base) == TipoXML
+}
+
+// leitorLimitado lê as entradas controlando o tamanho individual e acumulado.
+// Os tamanhos declarados no cabeçalho não são confiáveis, por isso a contagem
+// é feita sobre os bytes lidos.
+type leitorLimitado struct {
+	limites  Limites
+	entradas int
+	total    int64
+}
+
+// ler lê o conteúdo de uma entrada respeitando os limites
+func (l *leitorLimitado) ler(nome string, r io.Reader) ([]byte, error) {
+	// limite < 0 significa sem limite
+	limite := int64(-1)
+	if l.limites.MaxTamanhoEntrada > 0 {
+		limite = l.limites.MaxTamanhoEntrada
+	}
+	if l.limites.MaxTamanhoTotal > 0 {
+		restante := l.limites.MaxTamanhoTotal - l.total
+		if limite < 0 || restante < limite {
+			limite = restante
+		}
+	}
+
+	if limite >= 0 {
+		r = io.LimitReader(r, limite+1)
+	}
+	conteudo, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: erro ao ler %s: %v", ErrArquivoInvalido, nome, err)
+	}
+	if limite >= 0 && int64(len(conteudo)) > limite {
+		if l.limites.MaxTamanhoEntrada > 0 && int64(len(conteudo)) > l.limites.MaxTamanhoEntrada {
+			return nil, fmt.Errorf("%w: %s excede %d bytes descompactados", ErrLimiteExcedido, nome, l.limites.MaxTamanhoEntrada)
+		}
+		return nil, fmt.Errorf("%w: conteúdo descompactado excede %d bytes", ErrLimiteExcedido, l.limites.MaxTamanhoTotal)
+	}
+
+	l.entradas++
+	l.total += int64(len(conteudo))
+	return conteudo, nil
+}
@@ -0,0 +1,91 @@
+package ingestao
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func criarZip(t *testing.T, entradas map[string]string) *bytes.Reader {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for nome, conteudo := range entradas {
+		f, err := w.Create(nome)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(conteudo))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return bytes.NewReader(buf.Bytes())
+}
+
+func criarTarGz(t *testing.T, entradas map[string]string) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for nome, conteudo := range entradas {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: nome, Mode: 0644, Size: int64(len(conteudo)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(conteudo))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf
+}
+
+func TestPercorrerCompactado(t *testing.T) {
+	entradas := map[string]string{
+		"mes/cte1.xml":         "<CTe/>",
+		"mes/cte2.XML":         "<CTe/>",
+		"leia-me.txt":          "ignorado",
+		"__MACOSX/mes/._x.xml": "ignorado",
+	}
+
+	var nomes []string
+	coletar := func(nome string, conteudo []byte) error {
+		nomes = append(nomes, nome)
+		return nil
+	}
+
+	zr := criarZip(t, entradas)
+	require.NoError(t, PercorrerCompactado(TipoZIP, zr, zr.Size(), Limites{}, coletar))
+	assert.ElementsMatch(t, []string{"mes/cte1.xml", "mes/cte2.XML"}, nomes)
+
+	nomes = nil
+	require.NoError(t, PercorrerCompactado(TipoTarGz, criarTarGz(t, entradas), 0, Limites{}, coletar))
+	assert.ElementsMatch(t, []string{"mes/cte1.xml", "mes/cte2.XML"}, nomes)
+}
+
+func TestPercorrerCompactadoLimites(t *testing.T) {
+	ignorar := func(string, []byte) error { return nil }
+	entradas := map[string]string{"a.xml": "<CTe>1234567890</CTe>", "b.xml": "<CTe/>"}
+
+	zr := criarZip(t, entradas)
+	err := PercorrerCompactado(TipoZIP, zr, zr.Size(), Limites{MaxEntradas: 1}, ignorar)
+	assert.True(t, errors.Is(err, ErrLimiteExcedido))
+
+	err = PercorrerCompactado(TipoTarGz, criarTarGz(t, entradas), 0, Limites{MaxTamanhoEntrada: 10}, ignorar)
+	assert.True(t, errors.Is(err, ErrLimiteExcedido))
+
+	zr = criarZip(t, entradas)
+	err = PercorrerCompactado(TipoZIP, zr, zr.Size(), Limites{MaxTamanhoTotal: 25}, ignorar)
+	assert.True(t, errors.Is(err, ErrLimiteExcedido))
+
+	err = PercorrerCompactado(TipoZIP, bytes.NewReader([]byte("não é zip")), 9, Limites{}, ignorar)
+	assert.True(t, errors.Is(err, ErrArquivoInvalido))
+}
+
+func TestLerXML(t *testing.T) {
+	conteudo, err := LerXML("cte.xml", bytes.NewReader([]byte("<CTe/>")), Limites{MaxTamanhoEntrada: 6})
+	require.NoError(t, err)
+	assert.Equal(t, "<CTe/>", string(conteudo))
+
+	_, err = LerXML("cte.xml", bytes.NewReader([]byte("<CTe></CTe>")), Limites{MaxTamanhoEntrada: 6})
+	assert.True(t, errors.Is(err, ErrLimiteExcedido))
+}
@@ -0,0 +1,269 @@
+package ingestao
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/internal/jobs"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/services"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// ErrArquivoVazio indica que o arquivo compactado não possui nenhum XML
+var ErrArquivoVazio = errors.New("nenhum XML encontrado no arquivo compactado")
+
+// RegistrarXML registra o upload de um XML e enfileira o seu processamento
+func RegistrarXML(db *gorm.DB, nome string, conteudo []byte) (*models.Upload, error) {
+	var upload *models.Upload
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		upload, err = registrarXML(tx, nome, conteudo, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	jobs.Notificar()
+	return upload, nil
+}
+
+// NovoLote cria um lote vazio, ao qual os arquivos são adicionados depois. O lote
+// fica em registro, sem poder ser concluído, até a chamada de FinalizarRegistro.
+func NovoLote(db *gorm.DB, nome string) (*models.UploadBatch, error) {
+	batch := models.UploadBatch{
+		NomeArquivo: nome,
+		Status:      services.StatusLoteRecebido,
+		DataUpload:  time.Now(),
+		Registrando: true,
+	}
+	if err := db.Create(&batch).Error; err != nil {
+		return nil, fmt.Errorf("erro ao registrar lote: %w", err)
+	}
+	return &batch, nil
+}
+
+// FinalizarRegistro encerra o registro de arquivos do lote e recalcula o status,
+// que pode passar a concluído se todos os uploads já foram processados
+func FinalizarRegistro(db *gorm.DB, batchID uuid.UUID) error {
+	if err := encerrarRegistro(db, batchID); err != nil {
+		return err
+	}
+	return services.AtualizarLote(db, batchID)
+}
+
+// encerrarRegistro marca o lote como completo
+func encerrarRegistro(db *gorm.DB, batchID uuid.UUID) error {
+	err := db.Model(&models.UploadBatch{}).Where("id = ?", batchID).Update("registrando", false).Error
+	if err != nil {
+		return fmt.Errorf("erro ao finalizar registro do lote: %w", err)
+	}
+	return nil
+}
+
+// AdicionarXML registra o upload de um XML como parte de um lote existente
+func AdicionarXML(db *gorm.DB, batchID uuid.UUID, nome string, conteudo []byte) (*models.Upload, error) {
+	var upload *models.Upload
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if upload, err = registrarXML(tx, nome, conteudo, &batchID); err != nil {
+			return err
+		}
+		return somarAoLote(tx, batchID, 1, int64(len(conteudo)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	jobs.Notificar()
+	atualizarLote(db, batchID)
+	return upload, nil
+}
+
+// Os XMLs de um arquivo compactado são gravados em blocos, cada um em uma
+// transação, para não manter bloqueios e WAL durante todo o upload
+var (
+	arquivosPorBloco       = 200
+	bytesPorBloco    int64 = 32 << 20
+)
+
+// RegistrarCompactado registra um lote com um upload para cada XML do arquivo compactado.
+// Os XMLs são gravados em blocos e o lote fica em registro, sem poder ser concluído, até
+// o último bloco. Se a leitura ou a gravação falhar, os XMLs já gravados são mantidos e o
+// lote é devolvido junto com o erro; sem nenhum XML gravado, o lote é removido.
+func RegistrarCompactado(db *gorm.DB, nome, tipo string, r io.Reader, tamanho int64, limites Limites) (*models.UploadBatch, error) {
+	batch, err := NovoLote(db, nome)
+	if err != nil {
+		return nil, err
+	}
+
+	arquivos, errRegistro := registrarCompactado(db, batch.ID, tipo, r, tamanho, limites)
+	if arquivos == 0 {
+		if err := db.Delete(batch).Error; err != nil {
+			log := logger.GetLogger()
+			log.Error().Err(err).Str("batch_id", batch.ID.String()).Msg("Erro ao remover lote vazio")
+		}
+		return nil, errRegistro
+	}
+
+	// Último bloco gravado: o lote pode ser concluído
+	if err := encerrarRegistro(db, batch.ID); err != nil {
+		return nil, err
+	}
+
+	// Recarregar para devolver os contadores, inclusive os duplicados
+	atualizarLote(db, batch.ID)
+	if err := db.First(batch, "id = ?", batch.ID).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar lote: %w", err)
+	}
+	return batch, errRegistro
+}
+
+// AdicionarCompactado registra os XMLs do arquivo compactado em um lote existente,
+// em blocos como RegistrarCompactado. Retorna o número de XMLs registrados, mesmo
+// quando parte do arquivo falhou.
+func AdicionarCompactado(db *gorm.DB, batchID uuid.UUID, tipo string, r io.Reader, tamanho int64, limites Limites) (int, error) {
+	total, err := registrarCompactado(db, batchID, tipo, r, tamanho, limites)
+	if total > 0 {
+		atualizarLote(db, batchID)
+	}
+	return total, err
+}
+
+// entradaCompactada é um XML lido do arquivo compactado e ainda não gravado
+type entradaCompactada struct {
+	nome     string
+	conteudo []byte
+}
+
+// registrarCompactado registra cada XML do arquivo compactado no lote, um bloco por
+// transação, e retorna quantos foram gravados. Os XMLs lidos antes de um erro de
+// leitura ou de limite também são gravados.
+func registrarCompactado(db *gorm.DB, batchID uuid.UUID, tipo string, r io.Reader, tamanho int64, limites Limites) (int, error) {
+	var arquivos int
+	var bloco []entradaCompactada
+	var tamanhoBloco int64
+
+	gravar := func() error {
+		if len(bloco) == 0 {
+			return nil
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, entrada := range bloco {
+				if _, err := registrarXML(tx, entrada.nome, entrada.conteudo, &batchID); err != nil {
+					return fmt.Errorf("%s: %w", entrada.nome, err)
+				}
+			}
+			return somarAoLote(tx, batchID, len(bloco), tamanhoBloco)
+		})
+		if err != nil {
+			return err
+		}
+		arquivos += len(bloco)
+		bloco, tamanhoBloco = nil, 0
+		jobs.Notificar()
+		return nil
+	}
+
+	err := PercorrerCompactado(tipo, r, tamanho, limites, func(entrada string, conteudo []byte) error {
+		bloco = append(bloco, entradaCompactada{nome: entrada, conteudo: conteudo})
+		tamanhoBloco += int64(len(conteudo))
+		if len(bloco) >= arquivosPorBloco || tamanhoBloco >= bytesPorBloco {
+			return gravar()
+		}
+		return nil
+	})
+	if errGravar := gravar(); err == nil {
+		err = errGravar
+	}
+	if err == nil && arquivos == 0 {
+		err = ErrArquivoVazio
+	}
+	return arquivos, err
+}
+
+// somarAoLote incrementa os totais do lote; o incremento no banco permite
+// adicionar arquivos ao mesmo lote em transações separadas
+func somarAoLote(tx *gorm.DB, batchID uuid.UUID, arquivos int, tamanho int64) error {
+	return tx.Model(&models.UploadBatch{}).Where("id = ?", batchID).Updates(map[string]interface{}{
+		"total_arquivos": gorm.Expr("total_arquivos + ?", arquivos),
+		"tamanho_total":  gorm.Expr("tamanho_total + ?", tamanho),
+	}).Error
+}
+
+// atualizarLote recalcula os contadores após o registro. Os uploads já estão
+// gravados e enfileirados, por isso uma falha aqui não desfaz o registro.
+func atualizarLote(db *gorm.DB, batchID uuid.UUID) {
+	if err := services.AtualizarLote(db, batchID); err != nil {
+		log := logger.GetLogger()
+		log.Error().Err(err).Str("batch_id", batchID.String()).Msg("Erro ao atualizar contadores do lote")
+	}
+}
+
+// registrarXML cria o upload e o job dentro da transação informada. Se o mesmo
+// conteúdo já foi enviado e não terminou em erro, o upload é registrado como
+// DUPLICADO, apontando para o original, e nenhum job é criado.
+func registrarXML(tx *gorm.DB, nome string, conteudo []byte, batchID *uuid.UUID) (*models.Upload, error) {
+	hash := HashConteudo(conteudo)
+
+	upload := models.Upload{
+		BaseModel: models.BaseModel{
+			ID: uuid.New(),
+		},
+		NomeArquivo: nome,
+		Status:      "PENDENTE",
+		DataUpload:  time.Now(),
+		BatchID:     batchID,
+		HashSHA256:  hash,
+	}
+
+	original, err := buscarOriginal(tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if original != nil {
+		upload.Status = "DUPLICADO"
+		upload.DuplicadoDeID = &original.ID
+		upload.ChaveDocProcessado = original.ChaveDocProcessado
+		upload.DetalhesProcessamento = fmt.Sprintf("Conteúdo idêntico ao upload %s", original.ID)
+	}
+
+	if err := tx.Create(&upload).Error; err != nil {
+		return nil, fmt.Errorf("erro ao registrar upload: %w", err)
+	}
+	if original != nil {
+		return &upload, nil
+	}
+	if _, err := jobs.EnfileirarXML(tx, upload.ID, conteudo); err != nil {
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+// HashConteudo calcula o SHA-256 do conteúdo em hexadecimal
+func HashConteudo(conteudo []byte) string {
+	soma := sha256.Sum256(conteudo)
+	return hex.EncodeToString(soma[:])
+}
+
+// buscarOriginal retorna o primeiro upload com o mesmo conteúdo que foi ou será
+// processado. Uploads com erro não contam, para que o arquivo possa ser reenviado.
+func buscarOriginal(tx *gorm.DB, hash string) (*models.Upload, error) {
+	var original models.Upload
+	err := tx.Where("hash_sha256 = ? AND status IN ?", hash, []string{"PENDENTE", "PROCESSANDO", "CONCLUIDO"}).
+		Order("data_upload").
+		Take(&original).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar upload original: %w", err)
+	}
+	return &original, nil
+}
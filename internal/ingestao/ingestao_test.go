@@ -0,0 +1,97 @@
+package ingestao
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRegistrarXMLDuplicado(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "ingestao.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.UploadBatch{}, &models.Upload{}, &models.Job{}))
+
+	original, err := RegistrarXML(db, "cte.xml", []byte("<CTe/>"))
+	require.NoError(t, err)
+	assert.Equal(t, "PENDENTE", original.Status)
+	assert.Len(t, original.HashSHA256, 64)
+
+	// O mesmo conteúdo dentro de um arquivo compactado também é reconhecido
+	zr := criarZip(t, map[string]string{"copia.xml": "<CTe/>", "outro.xml": "<MDFe/>"})
+	batch, err := RegistrarCompactado(db, "lote.zip", TipoZIP, zr, zr.Size(), Limites{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, batch.TotalArquivos)
+	assert.Equal(t, 1, batch.Duplicados)
+	assert.Equal(t, 1, batch.Pendentes)
+
+	var copia models.Upload
+	require.NoError(t, db.First(&copia, "nome_arquivo = ?", "copia.xml").Error)
+	assert.Equal(t, "DUPLICADO", copia.Status)
+	require.NotNil(t, copia.DuplicadoDeID)
+	assert.Equal(t, original.ID, *copia.DuplicadoDeID)
+
+	var jobs int64
+	db.Model(&models.Job{}).Count(&jobs)
+	assert.Equal(t, int64(2), jobs)
+
+	// Após um erro, o reenvio volta a ser processado
+	db.Model(original).Update("status", "ERRO")
+	db.Model(&models.Upload{}).Where("nome_arquivo = ?", "outro.xml").Update("status", "ERRO")
+	reenvio, err := RegistrarXML(db, "cte.xml", []byte("<CTe/>"))
+	require.NoError(t, err)
+	assert.Equal(t, "PENDENTE", reenvio.Status)
+	assert.Nil(t, reenvio.DuplicadoDeID)
+}
+
+func TestRegistrarCompactadoEmBlocos(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "blocos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.UploadBatch{}, &models.Upload{}, &models.Job{}))
+
+	padrao := arquivosPorBloco
+	arquivosPorBloco = 2
+	t.Cleanup(func() { arquivosPorBloco = padrao })
+
+	entradas := func(prefixo string) map[string]string {
+		arquivos := map[string]string{}
+		for i := 1; i <= 5; i++ {
+			arquivos[fmt.Sprintf("%s%d.xml", prefixo, i)] = fmt.Sprintf("<CTe n=\"%s%d\"/>", prefixo, i)
+		}
+		return arquivos
+	}
+
+	// Cinco XMLs em três blocos: o lote só deixa o registro depois do último
+	zr := criarZip(t, entradas("a"))
+	batch, err := RegistrarCompactado(db, "lote.zip", TipoZIP, zr, zr.Size(), Limites{})
+	require.NoError(t, err)
+	assert.Equal(t, 5, batch.TotalArquivos)
+	assert.Equal(t, 5, batch.Pendentes)
+	assert.False(t, batch.Registrando)
+
+	// Limite excedido no quarto XML: os três lidos antes são mantidos
+	zr = criarZip(t, entradas("b"))
+	batch, err = RegistrarCompactado(db, "limite.zip", TipoZIP, zr, zr.Size(), Limites{MaxTamanhoTotal: 3 * 13})
+	assert.ErrorIs(t, err, ErrLimiteExcedido)
+	require.NotNil(t, batch)
+	assert.Equal(t, 3, batch.TotalArquivos)
+	assert.False(t, batch.Registrando)
+
+	var jobs int64
+	require.NoError(t, db.Model(&models.Job{}).Count(&jobs).Error)
+	assert.Equal(t, int64(8), jobs)
+
+	// Nenhum XML gravado: o lote não é mantido
+	zr = criarZip(t, map[string]string{"grande.xml": "<CTe>muito grande</CTe>"})
+	batch, err = RegistrarCompactado(db, "vazio.zip", TipoZIP, zr, zr.Size(), Limites{MaxTamanhoEntrada: 5})
+	assert.ErrorIs(t, err, ErrLimiteExcedido)
+	assert.Nil(t, batch)
+	var lotes int64
+	require.NoError(t, db.Model(&models.UploadBatch{}).Count(&lotes).Error)
+	assert.Equal(t, int64(2), lotes)
+}
@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Tipos de job suportados
+const (
+	TipoProcessarXML = "PROCESSAR_XML"
+)
+
+// Status possíveis de um job
+const (
+	StatusPendente    = "PENDENTE"
+	StatusProcessando = "PROCESSANDO"
+	StatusConcluido   = "CONCLUIDO"
+	StatusFalhou      = "FALHOU"
+)
+
+// novoJob avisa os workers que há trabalho disponível sem esperar o próximo polling
+var novoJob = make(chan struct{}, 1)
+
+// EnfileirarXML registra um job de processamento do XML de um upload.
+// O db pode ser uma transação, para que upload e job sejam gravados juntos;
+// por isso os workers não são avisados aqui: chame Notificar após o commit.
+func EnfileirarXML(db *gorm.DB, uploadID uuid.UUID, conteudo []byte) (*models.Job, error) {
+	job := models.Job{
+		Tipo:            TipoProcessarXML,
+		Status:          StatusPendente,
+		UploadID:        &uploadID,
+		Conteudo:        string(conteudo),
+		ProximaExecucao: time.Now(),
+	}
+
+	if err := db.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("erro ao enfileirar job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// Notificar acorda um worker ocioso, se houver. Deve ser chamado depois do
+// commit dos jobs enfileirados, para que o worker já os encontre gravados.
+func Notificar() {
+	select {
+	case novoJob <- struct{}{}:
+	default:
+	}
+}
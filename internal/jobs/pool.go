@@ -0,0 +1,353 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/internal/assinatura"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/italosilva18/destack-transport-api/internal/services"
+	"github.com/italosilva18/destack-transport-api/internal/validacao"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+// backoffMaximo limita o intervalo entre tentativas
+const backoffMaximo = 10 * time.Minute
+
+// Config define os parâmetros do pool de workers
+type Config struct {
+	Workers          int
+	MaxTentativas    int
+	BackoffBase      time.Duration
+	IntervaloPolling time.Duration
+}
+
+// Pool executa os jobs persistidos com um número limitado de workers
+type Pool struct {
+	db     *gorm.DB
+	config Config
+	logger zerolog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool cria uma nova instância de Pool
+func NewPool(db *gorm.DB, config Config) *Pool {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.MaxTentativas <= 0 {
+		config.MaxTentativas = 1
+	}
+	if config.BackoffBase <= 0 {
+		config.BackoffBase = 5 * time.Second
+	}
+	if config.IntervaloPolling <= 0 {
+		config.IntervaloPolling = 2 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pool{
+		db:     db,
+		config: config,
+		logger: logger.GetLogger(),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start recupera o trabalho interrompido e inicia os workers
+func (p *Pool) Start() error {
+	if err := p.recuperar(); err != nil {
+		return err
+	}
+
+	for i := 0; i < p.config.Workers; i++ {
+		p.wg.Add(1)
+		go p.worker(i + 1)
+	}
+
+	p.logger.Info().Int("workers", p.config.Workers).Msg("Pool de jobs iniciado")
+	return nil
+}
+
+// Shutdown para de reservar novos jobs e aguarda os jobs em execução terminarem
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.cancel()
+
+	concluido := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(concluido)
+	}()
+
+	select {
+	case <-concluido:
+		p.logger.Info().Msg("Pool de jobs finalizado")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("jobs em execução não terminaram a tempo: %w", ctx.Err())
+	}
+}
+
+// recuperar devolve à fila os jobs interrompidos por uma parada anterior,
+// marca como erro os uploads pendentes que não possuem job (conteúdo perdido)
+// e recalcula os contadores dos lotes em andamento
+func (p *Pool) recuperar() error {
+	agora := time.Now()
+
+	result := p.db.Model(&models.Job{}).
+		Where("status = ?", StatusProcessando).
+		Updates(map[string]interface{}{
+			"status":           StatusPendente,
+			"proxima_execucao": agora,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("erro ao recuperar jobs interrompidos: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		p.logger.Warn().Int64("jobs", result.RowsAffected).Msg("Jobs interrompidos devolvidos à fila")
+	}
+
+	result = p.db.Model(&models.Upload{}).
+		Where("status = ?", "PROCESSANDO").
+		Update("status", "PENDENTE")
+	if result.Error != nil {
+		return fmt.Errorf("erro ao recuperar uploads interrompidos: %w", result.Error)
+	}
+
+	result = p.db.Model(&models.Upload{}).
+		Where("status = ?", "PENDENTE").
+		Where("NOT EXISTS (SELECT 1 FROM jobs WHERE jobs.upload_id = uploads.id AND jobs.deleted_at IS NULL)").
+		Updates(map[string]interface{}{
+			"status":                 "ERRO",
+			"detalhes_processamento": "Processamento interrompido antes da conclusão. Envie o arquivo novamente.",
+		})
+	if result.Error != nil {
+		return fmt.Errorf("erro ao recuperar uploads pendentes: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		p.logger.Warn().Int64("uploads", result.RowsAffected).Msg("Uploads pendentes sem job marcados como erro")
+	}
+
+	// Lotes cujo registro foi interrompido ficam com os arquivos já gravados
+	result = p.db.Model(&models.UploadBatch{}).Where("registrando = ?", true).Update("registrando", false)
+	if result.Error != nil {
+		return fmt.Errorf("erro ao recuperar lotes em registro: %w", result.Error)
+	}
+
+	// Os contadores dos lotes em andamento podem ter ficado desatualizados
+	var lotes []uuid.UUID
+	err := p.db.Model(&models.UploadBatch{}).
+		Where("status IN ?", []string{services.StatusLoteRecebido, services.StatusLoteProcessando}).
+		Pluck("id", &lotes).Error
+	if err != nil {
+		return fmt.Errorf("erro ao buscar lotes em andamento: %w", err)
+	}
+	for _, id := range lotes {
+		if err := services.AtualizarLote(p.db, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// worker reserva e executa jobs até o pool ser finalizado
+func (p *Pool) worker(numero int) {
+	defer p.wg.Done()
+	log := p.logger.With().Int("worker", numero).Logger()
+
+	for {
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		job, err := p.reservar()
+		if err != nil {
+			log.Error().Err(err).Msg("Erro ao reservar job")
+		}
+		if job != nil {
+			p.executar(job)
+			continue
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-novoJob:
+		case <-time.After(p.config.IntervaloPolling):
+		}
+	}
+}
+
+// reservar seleciona o próximo job disponível e o marca como em processamento.
+// A atualização condicional garante que apenas um worker fique com o job.
+func (p *Pool) reservar() (*models.Job, error) {
+	for {
+		var job models.Job
+		err := p.db.Where("status = ? AND proxima_execucao <= ?", StatusPendente, time.Now()).
+			Order("proxima_execucao").
+			Take(&job).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		agora := time.Now()
+		result := p.db.Model(&models.Job{}).
+			Where("id = ? AND status = ?", job.ID, StatusPendente).
+			Updates(map[string]interface{}{
+				"status":      StatusProcessando,
+				"tentativas":  gorm.Expr("tentativas + 1"),
+				"iniciado_em": agora,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Outro worker reservou o mesmo job; tentar o próximo
+			continue
+		}
+
+		job.Status = StatusProcessando
+		job.Tentativas++
+		job.IniciadoEm = &agora
+		p.atualizarUpload(&job, map[string]interface{}{"status": "PROCESSANDO"})
+		return &job, nil
+	}
+}
+
+// executar processa o job e registra o resultado
+func (p *Pool) executar(job *models.Job) {
+	log := p.logger.With().Str("job_id", job.ID.String()).Int("tentativa", job.Tentativas).Logger()
+
+	err := p.processar(job)
+	agora := time.Now()
+
+	if err == nil {
+		p.atualizarJob(job, map[string]interface{}{
+			"status":        StatusConcluido,
+			"conteudo":      "",
+			"ultimo_erro":   "",
+			"finalizado_em": agora,
+		})
+		p.atualizarUpload(job, nil)
+		return
+	}
+
+	log.Error().Err(err).Msg("Erro ao executar job")
+
+	// Erros de conteúdo se repetiriam a cada tentativa; só falhas transitórias voltam à fila
+	if job.Tentativas < p.config.MaxTentativas && !permanente(err) {
+		p.atualizarJob(job, map[string]interface{}{
+			"status":           StatusPendente,
+			"ultimo_erro":      err.Error(),
+			"proxima_execucao": agora.Add(p.backoff(job.Tentativas)),
+		})
+		p.atualizarUpload(job, map[string]interface{}{"status": "PENDENTE"})
+		return
+	}
+
+	// Tentativas esgotadas ou XML rejeitado: o job fica em FALHOU para análise
+	p.atualizarJob(job, map[string]interface{}{
+		"status":        StatusFalhou,
+		"ultimo_erro":   err.Error(),
+		"finalizado_em": agora,
+	})
+	p.atualizarUpload(job, map[string]interface{}{
+		"status":                 "ERRO",
+		"detalhes_processamento": err.Error(),
+	})
+}
+
+// atualizarJob grava o resultado da execução do job. Uma falha aqui deixa o job
+// em PROCESSANDO até a próxima inicialização do pool, que o devolve à fila.
+func (p *Pool) atualizarJob(job *models.Job, campos map[string]interface{}) {
+	if err := p.db.Model(&models.Job{}).Where("id = ?", job.ID).Updates(campos).Error; err != nil {
+		p.logger.Error().Err(err).Str("job_id", job.ID.String()).Interface("status", campos["status"]).
+			Msg("Erro ao registrar resultado do job")
+	}
+}
+
+// atualizarUpload aplica os campos ao upload do job, se houver, e recalcula
+// os contadores do lote ao qual ele pertence
+func (p *Pool) atualizarUpload(job *models.Job, campos map[string]interface{}) {
+	if job.UploadID == nil {
+		return
+	}
+	if len(campos) > 0 {
+		if err := p.db.Model(&models.Upload{}).Where("id = ?", *job.UploadID).Updates(campos).Error; err != nil {
+			p.logger.Error().Err(err).Str("upload_id", job.UploadID.String()).Msg("Erro ao atualizar status do upload")
+		}
+	}
+	if err := services.AtualizarLoteDoUpload(p.db, *job.UploadID); err != nil {
+		p.logger.Error().Err(err).Str("upload_id", job.UploadID.String()).Msg("Erro ao atualizar lote do upload")
+	}
+}
+
+// processar executa o job conforme o tipo, convertendo panics em erro
+func (p *Pool) processar(job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic durante o processamento: %v", r)
+		}
+	}()
+
+	switch job.Tipo {
+	case TipoProcessarXML:
+		uploadID := ""
+		if job.UploadID != nil {
+			uploadID = job.UploadID.String()
+		}
+		_, err = services.ProcessarXML(p.db, uploadID, []byte(job.Conteudo))
+		return err
+	default:
+		return fmt.Errorf("%w: tipo de job não suportado: %s", errTipoJob, job.Tipo)
+	}
+}
+
+// errTipoJob indica um job que nenhuma versão deste pool sabe executar
+var errTipoJob = errors.New("job inválido")
+
+// permanente indica os erros que dependem apenas do conteúdo do job (XML
+// malformado, fora do schema, com assinatura inválida, chave inconsistente ou
+// evento de outro autor); erros de banco e de E/S são transitórios
+func permanente(err error) bool {
+	for _, definitivo := range []error{
+		errTipoJob,
+		services.ErrDocumentoRejeitado,
+		services.ErrAutorEvento,
+		validacao.ErrDocumentoInvalido,
+		assinatura.ErrAssinaturaInvalida,
+		parsers.ErrChaveInconsistente,
+	} {
+		if errors.Is(err, definitivo) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff calcula a espera exponencial antes da próxima tentativa
+func (p *Pool) backoff(tentativa int) time.Duration {
+	espera := p.config.BackoffBase
+	for i := 1; i < tentativa; i++ {
+		espera *= 2
+		if espera >= backoffMaximo {
+			return backoffMaximo
+		}
+	}
+	return espera
+}
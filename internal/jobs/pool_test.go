@@ -0,0 +1,121 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/internal/assinatura"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/italosilva18/destack-transport-api/internal/services"
+	"github.com/italosilva18/destack-transport-api/internal/validacao"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func novoBancoTeste(t *testing.T) *gorm.DB {
+	logger.InitLogger()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "jobs.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.UploadBatch{}, &models.Upload{}, &models.Job{}))
+	return db
+}
+
+// CT-e válido: sem as tabelas de documentos no banco, o processamento falha por
+// um erro de banco, que é transitório
+const cteValido = `<cteProc xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><CTe><infCte Id="CTe35240112345678000195570010000001231000001236" versao="4.00">
+<ide><cUF>35</cUF><cCT>00000123</cCT><CFOP>6353</CFOP><mod>57</mod><serie>1</serie><nCT>123</nCT><dhEmi>2024-01-10T08:30:00-03:00</dhEmi>
+<tpEmis>1</tpEmis><cDV>6</cDV><UFIni>SP</UFIni><UFFim>RJ</UFFim><toma3><toma>0</toma></toma3></ide>
+<emit><CNPJ>12345678000195</CNPJ><xNome>TRANSPORTADORA</xNome></emit>
+<rem><CNPJ>11111111000191</CNPJ><xNome>REMETENTE</xNome></rem>
+<dest><CNPJ>22222222000191</CNPJ><xNome>DESTINATARIO</xNome></dest>
+<vPrest><vTPrest>1500.00</vTPrest></vPrest>
+<infCTeNorm><infCarga><vCarga>10000.00</vCarga></infCarga></infCTeNorm>
+</infCte></CTe></cteProc>`
+
+// executarAteFalhar processa o conteúdo em um upload e retorna o job e o upload finais
+func executarAteFalhar(t *testing.T, db *gorm.DB, conteudo string, maxTentativas int) (models.Job, models.Upload) {
+	t.Helper()
+
+	upload := models.Upload{NomeArquivo: "documento.xml", Status: "PENDENTE", DataUpload: time.Now()}
+	require.NoError(t, db.Create(&upload).Error)
+	job, err := EnfileirarXML(db, upload.ID, []byte(conteudo))
+	require.NoError(t, err)
+
+	pool := NewPool(db, Config{Workers: 1, MaxTentativas: maxTentativas, BackoffBase: time.Millisecond, IntervaloPolling: 10 * time.Millisecond})
+	require.NoError(t, pool.Start())
+
+	assert.Eventually(t, func() bool {
+		var atual models.Job
+		db.First(&atual, "id = ?", job.ID)
+		return atual.Status == StatusFalhou
+	}, 5*time.Second, 20*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, pool.Shutdown(ctx))
+
+	var atual models.Job
+	require.NoError(t, db.First(&atual, "id = ?", job.ID).Error)
+	require.NoError(t, db.First(&upload, "id = ?", upload.ID).Error)
+	return atual, upload
+}
+
+func TestPoolFalhaAposTentativas(t *testing.T) {
+	db := novoBancoTeste(t)
+
+	job, upload := executarAteFalhar(t, db, cteValido, 2)
+	assert.Equal(t, 2, job.Tentativas)
+	assert.Contains(t, job.UltimoErro, "no such table")
+	assert.Equal(t, "ERRO", upload.Status)
+}
+
+func TestPoolRejeitaSemNovaTentativa(t *testing.T) {
+	db := novoBancoTeste(t)
+
+	// XML que não é documento fiscal: nenhuma tentativa mudaria o resultado
+	job, upload := executarAteFalhar(t, db, "<nada/>", 3)
+	assert.Equal(t, 1, job.Tentativas)
+	assert.NotEmpty(t, job.UltimoErro)
+	assert.Equal(t, "ERRO", upload.Status)
+}
+
+func TestPermanente(t *testing.T) {
+	assert.True(t, permanente(fmt.Errorf("CT-e 123: %w", assinatura.ErrAssinaturaInvalida)))
+	assert.True(t, permanente(fmt.Errorf("%w: cStat", services.ErrAutorEvento)))
+	assert.True(t, permanente(fmt.Errorf("%w\nAvisos", validacao.ErrDocumentoInvalido)))
+	assert.True(t, permanente(parsers.ErrChaveInconsistente))
+	assert.False(t, permanente(errors.New("database is locked")))
+	assert.False(t, permanente(fmt.Errorf("panic durante o processamento: %v", "nil pointer")))
+}
+
+func TestPoolRecuperaTrabalhoInterrompido(t *testing.T) {
+	db := novoBancoTeste(t)
+
+	// Upload sem job: conteúdo perdido em uma parada anterior
+	orfao := models.Upload{NomeArquivo: "orfao.xml", Status: "PENDENTE", DataUpload: time.Now()}
+	require.NoError(t, db.Create(&orfao).Error)
+
+	// Job interrompido no meio do processamento
+	uploadID := uuid.New()
+	interrompido := models.Job{Tipo: TipoProcessarXML, Status: StatusProcessando, UploadID: &uploadID, ProximaExecucao: time.Now()}
+	require.NoError(t, db.Create(&interrompido).Error)
+
+	pool := NewPool(db, Config{Workers: 1})
+	require.NoError(t, pool.recuperar())
+
+	require.NoError(t, db.First(&orfao, "id = ?", orfao.ID).Error)
+	assert.Equal(t, "ERRO", orfao.Status)
+
+	require.NoError(t, db.First(&interrompido, "id = ?", interrompido.ID).Error)
+	assert.Equal(t, StatusPendente, interrompido.Status)
+}
@@ -0,0 +1,32 @@
+package models
+
+import "github.com/google/uuid"
+
+// CTEComponente representa um componente do valor da prestação (frete peso, frete valor, pedágio, GRIS, TDE, ...)
+type CTEComponente struct {
+	BaseModel
+	CTEID uuid.UUID `json:"cte_id" gorm:"type:uuid;index;not null"`
+	Nome  string    `json:"nome" gorm:"size:15"`                // xNome como informado no CT-e
+	Tipo  string    `json:"tipo" gorm:"size:20;index;not null"` // FRETE_PESO, FRETE_VALOR, PEDAGIO, GRIS, TDE, ..., OUTROS
+	Valor float64   `json:"valor"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (CTEComponente) TableName() string {
+	return "cte_componentes"
+}
+
+// CTEQuantidade representa uma quantidade da carga transportada (infQ)
+type CTEQuantidade struct {
+	BaseModel
+	CTEID         uuid.UUID `json:"cte_id" gorm:"type:uuid;index;not null"`
+	CodigoUnidade string    `json:"codigo_unidade" gorm:"size:2"` // 00-M3, 01-KG, 02-TON, 03-UNIDADE, 04-LITROS, 05-MMBTU
+	Unidade       string    `json:"unidade" gorm:"size:10"`
+	TipoMedida    string    `json:"tipo_medida" gorm:"size:20"`
+	Quantidade    float64   `json:"quantidade"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (CTEQuantidade) TableName() string {
+	return "cte_quantidades"
+}
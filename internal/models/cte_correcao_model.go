@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StatusEventoRegistrado são os cStat dos eventos registrados pela SEFAZ
+var StatusEventoRegistrado = []string{"134", "135", "136"}
+
+// CTECorrecao representa um campo corrigido por uma carta de correção (infCorrecao).
+// Cada CC-e substitui as anteriores e traz todas as correções vigentes; as
+// sequências anteriores ficam guardadas como histórico.
+type CTECorrecao struct {
+	BaseModel
+	EventoID        uuid.UUID `json:"evento_id" gorm:"type:uuid;uniqueIndex:idx_cte_correcao;not null"`
+	Ordem           int       `json:"ordem" gorm:"uniqueIndex:idx_cte_correcao;not null"`
+	GrupoAlterado   string    `json:"grupo_alterado" gorm:"size:20;not null"`
+	CampoAlterado   string    `json:"campo_alterado" gorm:"size:20;not null"`
+	ValorAlterado   string    `json:"valor_alterado" gorm:"type:text"`
+	NroItemAlterado int       `json:"nro_item_alterado" gorm:"default:1"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (CTECorrecao) TableName() string {
+	return "cte_correcoes"
+}
+
+// UltimaCartaCorrecao retorna a CC-e registrada e aplicada de maior sequência do
+// CT-e, com as correções em ordem, ou nil se o CT-e não tem carta de correção
+// válida. Só contam as aplicadas, que passaram pela verificação da assinatura e
+// do autor: o DACTE, o XML corrigido e os campos gravados usam a mesma carta.
+func UltimaCartaCorrecao(db *gorm.DB, chave string) (*DocumentoEvento, error) {
+	var evento DocumentoEvento
+	err := db.Preload("Correcoes", func(db *gorm.DB) *gorm.DB { return db.Order("ordem") }).
+		Where("chave_documento = ? AND tipo_evento = ? AND status IN ? AND aplicado = ?", chave, EventoCartaCorrecao, StatusEventoRegistrado, true).
+		Order("sequencia DESC").First(&evento).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &evento, nil
+}
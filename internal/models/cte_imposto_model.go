@@ -0,0 +1,58 @@
+package models
+
+import "github.com/google/uuid"
+
+// CTEImposto representa a tributação de um tributo (ICMS, ICMS-ST, partilha do ICMS, IBS ou CBS) de um CT-e
+type CTEImposto struct {
+	BaseModel
+	CTEID               uuid.UUID `json:"cte_id" gorm:"type:uuid;index;not null"`
+	Tributo             string    `json:"tributo" gorm:"size:10;index;not null"` // ICMS, ICMS_ST, ICMS_UFFIM, ICMS_UFINI, FCP_UFFIM, IBS_UF, IBS_MUN, CBS
+	Grupo               string    `json:"grupo" gorm:"size:20"`                  // ICMS00, ICMS20, ..., ICMSOutraUF, ICMSSN, ICMSUFFim, IBSCBS
+	CST                 string    `json:"cst" gorm:"size:3"`
+	ClassTrib           string    `json:"class_trib" gorm:"size:6"`
+	BaseCalculo         float64   `json:"base_calculo"`
+	PercentualReducaoBC float64   `json:"percentual_reducao_bc"`
+	Aliquota            float64   `json:"aliquota"`
+	Valor               float64   `json:"valor"`
+	ValorCredito        float64   `json:"valor_credito"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (CTEImposto) TableName() string {
+	return "cte_impostos"
+}
+
+// ResumoImpostos totaliza os tributos de um ou mais CT-es
+type ResumoImpostos struct {
+	ValorICMS          float64 `json:"valor_icms"`
+	ValorICMSST        float64 `json:"valor_icms_st"`
+	ValorICMSUFFim     float64 `json:"valor_icms_uf_fim"`
+	ValorICMSUFIni     float64 `json:"valor_icms_uf_ini"`
+	ValorFCPUFFim      float64 `json:"valor_fcp_uf_fim"`
+	ValorIBSUF         float64 `json:"valor_ibs_uf"`
+	ValorIBSMun        float64 `json:"valor_ibs_mun"`
+	ValorCBS           float64 `json:"valor_cbs"`
+	ValorTotalTributos float64 `json:"valor_total_tributos"`
+}
+
+// Somar acumula o valor de um tributo no resumo
+func (r *ResumoImpostos) Somar(tributo string, valor float64) {
+	switch tributo {
+	case "ICMS":
+		r.ValorICMS += valor
+	case "ICMS_ST":
+		r.ValorICMSST += valor
+	case "ICMS_UFFIM":
+		r.ValorICMSUFFim += valor
+	case "ICMS_UFINI":
+		r.ValorICMSUFIni += valor
+	case "FCP_UFFIM":
+		r.ValorFCPUFFim += valor
+	case "IBS_UF":
+		r.ValorIBSUF += valor
+	case "IBS_MUN":
+		r.ValorIBSMun += valor
+	case "CBS":
+		r.ValorCBS += valor
+	}
+}
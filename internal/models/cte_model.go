@@ -14,21 +14,29 @@ type CTE struct {
 	// Relacionamentos
 	Emitente     *Empresa `gorm:"foreignKey:EmitenteID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"emitente,omitempty"`
 	Remetente    *Empresa `gorm:"foreignKey:RemetenteID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"remetente,omitempty"`
+	Expedidor    *Empresa `gorm:"foreignKey:ExpedidorID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"expedidor,omitempty"`
+	Recebedor    *Empresa `gorm:"foreignKey:RecebedorID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"recebedor,omitempty"`
 	Destinatario *Empresa `gorm:"foreignKey:DestinatarioID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"destinatario,omitempty"`
 	Tomador      *Empresa `gorm:"foreignKey:TomadorID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"tomador,omitempty"`
 
 	// Campos específicos de CT-e
 	RemetenteID     uuid.UUID  `json:"remetente_id" gorm:"type:uuid;index"`
 	DestinatarioID  uuid.UUID  `json:"destinatario_id" gorm:"type:uuid;index"`
+	ExpedidorID     *uuid.UUID `json:"expedidor_id" gorm:"type:uuid;index"`
+	RecebedorID     *uuid.UUID `json:"recebedor_id" gorm:"type:uuid;index"`
 	TomadorID       *uuid.UUID `json:"tomador_id" gorm:"type:uuid;index"`
-	ModalidadeFrete string     `json:"modalidade_frete" gorm:"size:3;index"` // CIF, FOB
+	TipoTomador     string     `json:"tipo_tomador" gorm:"size:1"`           // 0-remetente, 1-expedidor, 2-recebedor, 3-destinatário, 4-outros
+	ModalidadeFrete string     `json:"modalidade_frete" gorm:"size:3;index"` // CIF, FOB, TER (terceiro)
 	CFOP            string     `json:"cfop" gorm:"size:4;index"`
+	TipoCTe         string     `json:"tipo_cte" gorm:"column:tipo_cte;size:1;index;default:'0'"` // 0-normal, 1-complementar, 2-anulação, 3-substituto
 
 	// Valores específicos
-	ValorICMS     float64 `json:"valor_icms"`
-	ValorCarga    float64 `json:"valor_carga"`
-	ValorPedagio  float64 `json:"valor_pedagio"`
-	OutrosValores float64 `json:"outros_valores"`
+	ValorICMS          float64 `json:"valor_icms"`
+	ValorTotalTributos float64 `json:"valor_total_tributos"` // vTotTrib (Lei da Transparência)
+	ValorCarga         float64 `json:"valor_carga"`
+	ValorPedagio       float64 `json:"valor_pedagio"`
+	OutrosValores      float64 `json:"outros_valores"` // componentes além de frete peso, frete valor e pedágio
+	PesoKg             float64 `json:"peso_kg"`        // peso taxado da carga, para o preço por kg
 
 	// Informações adicionais
 	PlacaVeiculo string `json:"placa_veiculo" gorm:"size:10;index"`
@@ -37,6 +45,19 @@ type CTE struct {
 
 	// Documentos vinculados ao MDF-e
 	MDFes []MDFE `gorm:"many2many:mdfe_ctes;" json:"mdfes,omitempty"`
+
+	// NF-es transportadas (infDoc/infNFe)
+	NFes []NFe `gorm:"many2many:cte_nfes;" json:"nfes,omitempty"`
+
+	// CT-es complementados, anulado ou substituído por este CT-e
+	Referencias []CTEReferencia `gorm:"foreignKey:CTEID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"referencias,omitempty"`
+
+	// Tributação (grupo imp)
+	Impostos []CTEImposto `gorm:"foreignKey:CTEID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"impostos,omitempty"`
+
+	// Composição do frete e quantidades da carga
+	Componentes []CTEComponente `gorm:"foreignKey:CTEID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"componentes,omitempty"`
+	Quantidades []CTEQuantidade `gorm:"foreignKey:CTEID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"quantidades,omitempty"`
 }
 
 // TableName define o nome da tabela no banco de dados
@@ -0,0 +1,25 @@
+package models
+
+import "github.com/google/uuid"
+
+// Relações entre um CT-e e o CT-e original, conforme o tpCTe de quem referencia
+const (
+	RelacaoComplemento  = "COMPLEMENTO"  // tpCTe 1: soma-se ao original
+	RelacaoAnulacao     = "ANULACAO"     // tpCTe 2: anula o original
+	RelacaoSubstituicao = "SUBSTITUICAO" // tpCTe 3: substitui o original
+)
+
+// CTEReferencia representa a referência de um CT-e complementar, de anulação ou
+// substituto ao CT-e original. A referência é pela chave, pois o original pode
+// ainda não ter sido importado.
+type CTEReferencia struct {
+	BaseModel
+	CTEID         uuid.UUID `json:"cte_id" gorm:"type:uuid;index;not null"`
+	Relacao       string    `json:"relacao" gorm:"size:12;index;not null"` // COMPLEMENTO, ANULACAO, SUBSTITUICAO
+	ChaveOriginal string    `json:"chave_original" gorm:"size:44;index;not null"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (CTEReferencia) TableName() string {
+	return "cte_referencias"
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CTEOS representa um Conhecimento de Transporte Eletrônico para Outros Serviços
+// (modelo 67), emitido no transporte de pessoas, de valores e de excesso de bagagem
+type CTEOS struct {
+	DocumentoFiscal // Embedar DocumentoFiscal para herdar seus campos
+
+	// Relacionamentos
+	Emitente *Empresa `gorm:"foreignKey:EmitenteID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"emitente,omitempty"`
+	Tomador  *Empresa `gorm:"foreignKey:TomadorID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"tomador,omitempty"`
+
+	// Campos específicos de CT-e OS
+	TomadorID        *uuid.UUID `json:"tomador_id" gorm:"type:uuid;index"`
+	CFOP             string     `json:"cfop" gorm:"size:4;index"`
+	TipoServico      string     `json:"tipo_servico" gorm:"size:1;index"` // 6-transporte de pessoas, 7-transporte de valores, 8-excesso de bagagem
+	DescricaoServico string     `json:"descricao_servico" gorm:"size:255"`
+	Quantidade       float64    `json:"quantidade"` // passageiros ou volumes
+
+	// Valores específicos
+	ValorReceber       float64 `json:"valor_receber"`
+	ValorICMS          float64 `json:"valor_icms"`
+	ValorTotalTributos float64 `json:"valor_total_tributos"` // vTotTrib (Lei da Transparência)
+
+	// Modal rodoviário
+	TAF            string `json:"taf" gorm:"size:12"`
+	NroRegEstadual string `json:"nro_reg_estadual" gorm:"size:25"`
+	PlacaVeiculo   string `json:"placa_veiculo" gorm:"size:10;index"`
+	TipoFretamento string `json:"tipo_fretamento" gorm:"size:1"` // 1-eventual, 2-contínuo
+
+	// Informações adicionais
+	ObsGerais string `json:"obs_gerais" gorm:"type:text"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (CTEOS) TableName() string {
+	return "cteos"
+}
+
+// BeforeCreate hook do GORM
+func (c *CTEOS) BeforeCreate(tx *gorm.DB) error {
+	// Chamar o BeforeCreate do DocumentoFiscal
+	if err := c.DocumentoFiscal.BeforeCreate(tx); err != nil {
+		return err
+	}
+
+	// Validações específicas do CT-e OS
+	if c.CFOP == "" {
+		return errors.New("CFOP é obrigatório")
+	}
+	if c.TipoServico == "" {
+		return errors.New("tipo de serviço é obrigatório")
+	}
+
+	return nil
+}
+
+// IsValid verifica se o CT-e OS está válido
+func (c *CTEOS) IsValid() bool {
+	return c.Status == "100" && !c.Cancelado
+}
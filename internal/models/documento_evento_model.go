@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tipos de evento aplicados aos documentos
+const (
+	EventoCartaCorrecao    = "110110"
+	EventoCancelamento     = "110111"
+	EventoEncerramento     = "110112"
+	EventoEPEC             = "110113"
+	EventoInclusaoCondutor = "110114"
+	EventoInclusaoDFe      = "110115"
+)
+
+// DocumentoEvento guarda cada evento recebido de um CT-e, CT-e OS ou MDF-e.
+// O evento é gravado mesmo antes da importação do documento (ex.: EPEC) e
+// aplicado quando o documento chega; DocumentoID fica nulo até lá.
+type DocumentoEvento struct {
+	BaseModel
+	DocumentoTipo  string     `json:"documento_tipo" gorm:"size:10;index;not null"` // CTE, CTEOS ou MDFE
+	ChaveDocumento string     `json:"chave_documento" gorm:"size:44;uniqueIndex:idx_documento_evento;not null"`
+	DocumentoID    *uuid.UUID `json:"documento_id" gorm:"type:uuid;index"`
+	TipoEvento     string     `json:"tipo_evento" gorm:"size:6;uniqueIndex:idx_documento_evento;not null"`
+	Sequencia      int        `json:"sequencia" gorm:"uniqueIndex:idx_documento_evento;not null"`
+	Descricao      string     `json:"descricao" gorm:"size:60"`
+	DataEvento     time.Time  `json:"data_evento" gorm:"index;not null"`
+	DataRegistro   *time.Time `json:"data_registro"`
+	Autor          string     `json:"autor" gorm:"size:14"` // CNPJ ou CPF
+
+	// Retorno da SEFAZ
+	Protocolo string `json:"protocolo" gorm:"size:20"`
+	Status    string `json:"status" gorm:"size:3;index"`
+	Motivo    string `json:"motivo" gorm:"size:255"`
+
+	ProtocoloReferencia string `json:"protocolo_referencia,omitempty" gorm:"size:20"`
+	Justificativa       string `json:"justificativa,omitempty" gorm:"type:text"`
+	Payload             string `json:"payload" gorm:"type:text"` // XML do grupo detEvento
+	XMLOriginal         string `json:"-" gorm:"type:text"`
+
+	// Aplicado indica que o evento registrado já foi aplicado ao documento importado
+	Aplicado      bool       `json:"aplicado" gorm:"default:false"`
+	DataAplicacao *time.Time `json:"data_aplicacao"`
+
+	// Campos corrigidos, quando o evento é uma carta de correção
+	Correcoes []CTECorrecao `gorm:"foreignKey:EventoID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"correcoes,omitempty"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (DocumentoEvento) TableName() string {
+	return "documento_eventos"
+}
+
+// EventosDocumento retorna os eventos da chave em ordem cronológica, para a linha do tempo do documento
+func EventosDocumento(db *gorm.DB, chave string) ([]DocumentoEvento, error) {
+	var eventos []DocumentoEvento
+	err := db.Preload("Correcoes", func(db *gorm.DB) *gorm.DB { return db.Order("ordem") }).
+		Where("chave_documento = ?", chave).Order("data_evento, tipo_evento, sequencia").Find(&eventos).Error
+	return eventos, err
+}
@@ -8,11 +8,11 @@ import (
 	"gorm.io/gorm"
 )
 
-// DocumentoFiscal representa a base para CT-e e MDF-e
+// DocumentoFiscal representa a base para CT-e, CT-e OS e MDF-e
 type DocumentoFiscal struct {
 	BaseModel
 	Chave       string    `json:"chave" gorm:"uniqueIndex;not null;size:44"`
-	Tipo        string    `json:"tipo" gorm:"index;not null;size:10"` // CTE, MDFE
+	Tipo        string    `json:"tipo" gorm:"index;not null;size:10"` // CTE, CTEOS, MDFE
 	Numero      int       `json:"numero" gorm:"not null"`
 	Serie       string    `json:"serie" gorm:"not null;size:3"`
 	DataEmissao time.Time `json:"data_emissao" gorm:"index;not null"`
@@ -24,6 +24,12 @@ type DocumentoFiscal struct {
 	Protocolo       string     `json:"protocolo" gorm:"size:20"`
 	DataAutorizacao *time.Time `json:"data_autorizacao"`
 
+	// Verificação da assinatura digital (VALIDA ou AUSENTE; assinaturas inválidas são rejeitadas).
+	// Documentos AUSENTE só existem no modo tolerante e são sinalizados nos relatórios.
+	AssinaturaStatus  string `json:"assinatura_status" gorm:"size:10;index"`
+	AssinaturaCNPJ    string `json:"assinatura_cnpj" gorm:"size:14"`
+	AssinaturaTitular string `json:"assinatura_titular" gorm:"size:255"`
+
 	// Entidades principais
 	EmitenteID uuid.UUID `json:"emitente_id" gorm:"type:uuid;index;not null"`
 
@@ -33,6 +39,17 @@ type DocumentoFiscal struct {
 	MunicipioInicio string `json:"municipio_inicio" gorm:"size:100"`
 	MunicipioFim    string `json:"municipio_fim" gorm:"size:100"`
 
+	// Modal de transporte (01 a 06); documentos anteriores à leitura do modal eram rodoviários
+	Modal string `json:"modal" gorm:"size:2;index;default:'01'"`
+
+	// Informações específicas dos modais não rodoviários (sem chave estrangeira:
+	// as tabelas de detalhe atendem CT-e e MDF-e)
+	ModalAereo       *ModalAereo       `gorm:"foreignKey:DocumentoID;constraint:-" json:"modal_aereo,omitempty"`
+	ModalAquaviario  *ModalAquaviario  `gorm:"foreignKey:DocumentoID;constraint:-" json:"modal_aquaviario,omitempty"`
+	ModalFerroviario *ModalFerroviario `gorm:"foreignKey:DocumentoID;constraint:-" json:"modal_ferroviario,omitempty"`
+	ModalDutoviario  *ModalDutoviario  `gorm:"foreignKey:DocumentoID;constraint:-" json:"modal_dutoviario,omitempty"`
+	ModalMultimodal  *ModalMultimodal  `gorm:"foreignKey:DocumentoID;constraint:-" json:"modal_multimodal,omitempty"`
+
 	// Metadados de processamento
 	DataProcessamento *time.Time `json:"data_processamento"`
 	XMLOriginal       string     `json:"-" gorm:"type:text"` // Armazenar XML completo
@@ -44,14 +61,19 @@ type DocumentoFiscal struct {
 
 // BeforeCreate hook do GORM para DocumentoFiscal
 func (d *DocumentoFiscal) BeforeCreate(tx *gorm.DB) error {
+	// Gerar o ID: este hook sobrepõe o do BaseModel
+	if err := d.BaseModel.BeforeCreate(tx); err != nil {
+		return err
+	}
+
 	// Validar chave de acesso
 	if len(d.Chave) != 44 {
 		return errors.New("chave de acesso deve ter 44 caracteres")
 	}
 
 	// Validar tipo
-	if d.Tipo != "CTE" && d.Tipo != "MDFE" {
-		return errors.New("tipo deve ser CTE ou MDFE")
+	if d.Tipo != "CTE" && d.Tipo != "CTEOS" && d.Tipo != "MDFE" {
+		return errors.New("tipo deve ser CTE, CTEOS ou MDFE")
 	}
 
 	// Validar UFs
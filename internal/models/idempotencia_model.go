@@ -0,0 +1,20 @@
+package models
+
+// ChaveIdempotencia guarda a resposta de uma requisição enviada com o header
+// Idempotency-Key, para que novas tentativas do cliente recebam a mesma resposta.
+// A chave é única por usuário e endpoint: clientes diferentes podem gerar a mesma chave.
+// O hash do conteúdo enviado impede que a chave seja reutilizada com outro arquivo.
+type ChaveIdempotencia struct {
+	BaseModel
+	UserID      string `json:"user_id" gorm:"uniqueIndex:idx_chaves_idempotencia_escopo;size:36;not null;default:''"`
+	Endpoint    string `json:"endpoint" gorm:"uniqueIndex:idx_chaves_idempotencia_escopo;size:255;not null"`
+	Chave       string `json:"chave" gorm:"uniqueIndex:idx_chaves_idempotencia_escopo;size:255;not null"`
+	HashPayload string `json:"hash_payload" gorm:"size:64"` // SHA-256 dos campos e arquivos do formulário
+	StatusHTTP  int    `json:"status_http"`                 // 0 enquanto a requisição original está em andamento
+	Resposta    string `json:"resposta" gorm:"type:text"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (ChaveIdempotencia) TableName() string {
+	return "chaves_idempotencia"
+}
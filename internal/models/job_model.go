@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job representa uma tarefa de processamento persistida na fila
+type Job struct {
+	BaseModel
+	Tipo            string     `json:"tipo" gorm:"index;not null;size:30"`
+	Status          string     `json:"status" gorm:"index;not null;size:15"` // PENDENTE, PROCESSANDO, CONCLUIDO, FALHOU
+	UploadID        *uuid.UUID `json:"upload_id" gorm:"type:uuid;index"`
+	Conteudo        string     `json:"-" gorm:"type:text"`
+	Tentativas      int        `json:"tentativas" gorm:"not null;default:0"`
+	ProximaExecucao time.Time  `json:"proxima_execucao" gorm:"index;not null"`
+	UltimoErro      string     `json:"ultimo_erro" gorm:"type:text"`
+	IniciadoEm      *time.Time `json:"iniciado_em"`
+	FinalizadoEm    *time.Time `json:"finalizado_em"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (Job) TableName() string {
+	return "jobs"
+}
@@ -0,0 +1,33 @@
+package models
+
+import "github.com/google/uuid"
+
+// MDFEDescarga representa um município de descarga do MDF-e; Ordem segue a
+// sequência do XML, começando em 1
+type MDFEDescarga struct {
+	BaseModel
+	MDFEID          uuid.UUID               `json:"mdfe_id" gorm:"type:uuid;uniqueIndex:idx_mdfe_descarga_ordem;not null"`
+	Ordem           int                     `json:"ordem" gorm:"uniqueIndex:idx_mdfe_descarga_ordem;not null"`
+	CodigoMunicipio string                  `json:"codigo_municipio" gorm:"size:7;index"`
+	Municipio       string                  `json:"municipio" gorm:"size:60"`
+	UF              string                  `json:"uf" gorm:"size:2;index"`
+	Documentos      []MDFEDescargaDocumento `json:"documentos,omitempty" gorm:"foreignKey:DescargaID"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (MDFEDescarga) TableName() string {
+	return "mdfe_descargas"
+}
+
+// MDFEDescargaDocumento representa um CT-e ou NF-e entregue no município de descarga
+type MDFEDescargaDocumento struct {
+	BaseModel
+	DescargaID uuid.UUID `json:"descarga_id" gorm:"type:uuid;index;not null"`
+	Tipo       string    `json:"tipo" gorm:"size:10;not null"` // CTE ou NFE
+	Chave      string    `json:"chave" gorm:"size:44;index;not null"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (MDFEDescargaDocumento) TableName() string {
+	return "mdfe_descarga_documentos"
+}
@@ -15,11 +15,23 @@ type MDFE struct {
 	// Relacionamentos
 	VeiculoTracao *Veiculo `gorm:"foreignKey:VeiculoTracaoID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"veiculo_tracao,omitempty"`
 	CTes          []CTE    `gorm:"many2many:mdfe_ctes;" json:"ctes,omitempty"`
+	NFes          []NFe    `gorm:"many2many:mdfe_nfes;" json:"nfes,omitempty"`
+
+	// Composição e partes do modal rodoviário
+	Reboques     []MDFEReboque     `gorm:"foreignKey:MDFEID" json:"reboques,omitempty"`
+	Condutores   []MDFECondutor    `gorm:"foreignKey:MDFEID" json:"condutores,omitempty"`
+	Contratantes []MDFEContratante `gorm:"foreignKey:MDFEID" json:"contratantes,omitempty"`
+
+	// Municípios de descarga, com os documentos entregues em cada um
+	Descargas []MDFEDescarga `gorm:"foreignKey:MDFEID" json:"descargas,omitempty"`
+
+	// Seguros da carga, com todas as averbações
+	Seguros []MDFESeguro `gorm:"foreignKey:MDFEID" json:"seguros,omitempty"`
 
 	// Campos específicos de MDF-e
 	VeiculoTracaoID uuid.UUID `json:"veiculo_tracao_id" gorm:"type:uuid;index"`
 
-	// Condutor
+	// Condutor principal (todos os condutores ficam em Condutores)
 	NomeMotorista string `json:"nome_motorista" gorm:"size:100"`
 	CPFMotorista  string `json:"cpf_motorista" gorm:"size:11;index"`
 
@@ -37,7 +49,7 @@ type MDFE struct {
 	DataEncerramento  *time.Time `json:"data_encerramento"`
 	LocalEncerramento string     `json:"local_encerramento" gorm:"size:100"`
 
-	// Seguro
+	// Primeiro seguro informado (todos ficam em Seguros)
 	SeguradoraNome  string `json:"seguradora_nome" gorm:"size:100"`
 	SeguradoraCNPJ  string `json:"seguradora_cnpj" gorm:"size:14"`
 	NumeroApolice   string `json:"numero_apolice" gorm:"size:50"`
@@ -0,0 +1,49 @@
+package models
+
+import "github.com/google/uuid"
+
+// MDFEReboque vincula um reboque à composição do MDF-e; Posicao segue a ordem
+// do XML, começando em 1 (a tração é o VeiculoTracao do MDF-e)
+type MDFEReboque struct {
+	BaseModel
+	MDFEID        uuid.UUID `json:"mdfe_id" gorm:"type:uuid;uniqueIndex:idx_mdfe_reboque_posicao;not null"`
+	Posicao       int       `json:"posicao" gorm:"uniqueIndex:idx_mdfe_reboque_posicao;not null"`
+	VeiculoID     uuid.UUID `json:"veiculo_id" gorm:"type:uuid;index;not null"`
+	Veiculo       *Veiculo  `json:"veiculo,omitempty" gorm:"foreignKey:VeiculoID"`
+	CodigoInterno string    `json:"codigo_interno,omitempty" gorm:"size:10"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (MDFEReboque) TableName() string {
+	return "mdfe_reboques"
+}
+
+// MDFECondutor representa um condutor do veículo de tração; Ordem 1 é o
+// condutor principal, o mesmo de NomeMotorista e CPFMotorista do MDF-e
+type MDFECondutor struct {
+	BaseModel
+	MDFEID uuid.UUID `json:"mdfe_id" gorm:"type:uuid;index;not null"`
+	Ordem  int       `json:"ordem"`
+	Nome   string    `json:"nome" gorm:"size:60"`
+	CPF    string    `json:"cpf" gorm:"size:11;index"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (MDFECondutor) TableName() string {
+	return "mdfe_condutores"
+}
+
+// MDFEContratante representa um contratante do serviço de transporte (infANTT)
+type MDFEContratante struct {
+	BaseModel
+	MDFEID        uuid.UUID `json:"mdfe_id" gorm:"type:uuid;index;not null"`
+	Nome          string    `json:"nome,omitempty" gorm:"size:60"`
+	CNPJ          string    `json:"cnpj,omitempty" gorm:"size:14;index"`
+	CPF           string    `json:"cpf,omitempty" gorm:"size:11;index"`
+	IdEstrangeiro string    `json:"id_estrangeiro,omitempty" gorm:"size:20"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (MDFEContratante) TableName() string {
+	return "mdfe_contratantes"
+}
@@ -0,0 +1,41 @@
+package models
+
+import "github.com/google/uuid"
+
+// Responsável pelo seguro da carga (infResp/respSeg)
+const (
+	ResponsavelSeguroEmitente    = "1"
+	ResponsavelSeguroContratante = "2"
+)
+
+// MDFESeguro representa um grupo de seguro da carga do MDF-e (seg); Ordem
+// segue a sequência do XML, começando em 1
+type MDFESeguro struct {
+	BaseModel
+	MDFEID          uuid.UUID       `json:"mdfe_id" gorm:"type:uuid;index;not null"`
+	Ordem           int             `json:"ordem"`
+	Responsavel     string          `json:"responsavel" gorm:"size:1"` // 1-emitente, 2-contratante
+	ResponsavelCNPJ string          `json:"responsavel_cnpj,omitempty" gorm:"size:14"`
+	ResponsavelCPF  string          `json:"responsavel_cpf,omitempty" gorm:"size:11"`
+	SeguradoraNome  string          `json:"seguradora_nome" gorm:"size:100"`
+	SeguradoraCNPJ  string          `json:"seguradora_cnpj" gorm:"size:14;index"`
+	NumeroApolice   string          `json:"numero_apolice" gorm:"size:50;index"`
+	Averbacoes      []MDFEAverbacao `json:"averbacoes,omitempty" gorm:"foreignKey:SeguroID"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (MDFESeguro) TableName() string {
+	return "mdfe_seguros"
+}
+
+// MDFEAverbacao representa um número de averbação do seguro
+type MDFEAverbacao struct {
+	BaseModel
+	SeguroID uuid.UUID `json:"seguro_id" gorm:"type:uuid;index;not null"`
+	Numero   string    `json:"numero" gorm:"size:40;index;not null"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (MDFEAverbacao) TableName() string {
+	return "mdfe_averbacoes"
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DescricaoModal é o nome de cada modal de transporte (ide/modal, com dois dígitos também no MDF-e)
+var DescricaoModal = map[string]string{
+	"01": "Rodoviário",
+	"02": "Aéreo",
+	"03": "Aquaviário",
+	"04": "Ferroviário",
+	"05": "Dutoviário",
+	"06": "Multimodal",
+}
+
+// As tabelas de detalhe do modal referenciam o CT-e ou o MDF-e pelo
+// DocumentoID; DocumentoTipo (CTE ou MDFE) indica a tabela do documento.
+
+// ModalAereo representa as informações do modal aéreo: minuta e tarifa do CT-e ou voo do MDF-e
+type ModalAereo struct {
+	BaseModel
+	DocumentoID   uuid.UUID  `json:"documento_id" gorm:"type:uuid;uniqueIndex;not null"`
+	DocumentoTipo string     `json:"documento_tipo" gorm:"size:10;not null"`
+	NumeroMinuta  string     `json:"numero_minuta,omitempty" gorm:"size:9"`
+	NumeroOCA     string     `json:"numero_oca,omitempty" gorm:"size:11"`
+	DataPrevista  *time.Time `json:"data_prevista,omitempty"`
+	ClasseTarifa  string     `json:"classe_tarifa,omitempty" gorm:"size:1"` // M-mínima, G-geral, E-específica
+	CodigoTarifa  string     `json:"codigo_tarifa,omitempty" gorm:"size:4"`
+	ValorTarifa   float64    `json:"valor_tarifa,omitempty"`
+
+	Nacionalidade     string     `json:"nacionalidade,omitempty" gorm:"size:4"`
+	Matricula         string     `json:"matricula,omitempty" gorm:"size:6"`
+	NumeroVoo         string     `json:"numero_voo,omitempty" gorm:"size:9"`
+	AeroportoEmbarque string     `json:"aeroporto_embarque,omitempty" gorm:"size:4"`
+	AeroportoDestino  string     `json:"aeroporto_destino,omitempty" gorm:"size:4"`
+	DataVoo           *time.Time `json:"data_voo,omitempty"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (ModalAereo) TableName() string {
+	return "modais_aereos"
+}
+
+// ModalAquaviario representa as informações do modal aquaviário
+type ModalAquaviario struct {
+	BaseModel
+	DocumentoID      uuid.UUID `json:"documento_id" gorm:"type:uuid;uniqueIndex;not null"`
+	DocumentoTipo    string    `json:"documento_tipo" gorm:"size:10;not null"`
+	Embarcacao       string    `json:"embarcacao" gorm:"size:60"`
+	CodigoEmbarcacao string    `json:"codigo_embarcacao,omitempty" gorm:"size:10"`
+	IRIN             string    `json:"irin" gorm:"size:10;index"`
+	NumeroViagem     string    `json:"numero_viagem,omitempty" gorm:"size:10"`
+	Direcao          string    `json:"direcao,omitempty" gorm:"size:1"`        // N, S, L, O
+	TipoNavegacao    string    `json:"tipo_navegacao,omitempty" gorm:"size:1"` // 0-interior, 1-cabotagem
+	ValorPrestacao   float64   `json:"valor_prestacao,omitempty"`              // base de cálculo do AFRMM
+	ValorAFRMM       float64   `json:"valor_afrmm,omitempty"`
+	PortoEmbarque    string    `json:"porto_embarque,omitempty" gorm:"size:5"`
+	PortoDestino     string    `json:"porto_destino,omitempty" gorm:"size:5"`
+	Balsas           string    `json:"balsas,omitempty" gorm:"type:text"`      // separadas por vírgula
+	Conteineres      string    `json:"conteineres,omitempty" gorm:"type:text"` // separados por vírgula
+}
+
+// TableName define o nome da tabela no banco de dados
+func (ModalAquaviario) TableName() string {
+	return "modais_aquaviarios"
+}
+
+// ModalFerroviario representa as informações do modal ferroviário: tráfego do CT-e ou trem do MDF-e
+type ModalFerroviario struct {
+	BaseModel
+	DocumentoID            uuid.UUID `json:"documento_id" gorm:"type:uuid;uniqueIndex;not null"`
+	DocumentoTipo          string    `json:"documento_tipo" gorm:"size:10;not null"`
+	TipoTrafego            string    `json:"tipo_trafego,omitempty" gorm:"size:1"` // 0-próprio, 1-mútuo, 2-rodoferroviário, 3-rodoviário
+	Fluxo                  string    `json:"fluxo,omitempty" gorm:"size:10"`
+	ResponsavelFaturamento string    `json:"responsavel_faturamento,omitempty" gorm:"size:1"`
+	FerroviaEmitente       string    `json:"ferrovia_emitente,omitempty" gorm:"size:1"`
+	ValorFrete             float64   `json:"valor_frete,omitempty"`
+
+	PrefixoTrem      string     `json:"prefixo_trem,omitempty" gorm:"size:10"`
+	DataHoraTrem     *time.Time `json:"data_hora_trem,omitempty"`
+	Origem           string     `json:"origem,omitempty" gorm:"size:3"`
+	Destino          string     `json:"destino,omitempty" gorm:"size:3"`
+	QuantidadeVagoes int        `json:"quantidade_vagoes,omitempty"`
+	PesoVagoes       float64    `json:"peso_vagoes,omitempty"` // toneladas
+}
+
+// TableName define o nome da tabela no banco de dados
+func (ModalFerroviario) TableName() string {
+	return "modais_ferroviarios"
+}
+
+// ModalDutoviario representa as informações do modal dutoviário
+type ModalDutoviario struct {
+	BaseModel
+	DocumentoID   uuid.UUID  `json:"documento_id" gorm:"type:uuid;uniqueIndex;not null"`
+	DocumentoTipo string     `json:"documento_tipo" gorm:"size:10;not null"`
+	ValorTarifa   float64    `json:"valor_tarifa"`
+	DataInicio    *time.Time `json:"data_inicio,omitempty"`
+	DataFim       *time.Time `json:"data_fim,omitempty"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (ModalDutoviario) TableName() string {
+	return "modais_dutoviarios"
+}
+
+// ModalMultimodal representa as informações do transporte multimodal (COTM)
+type ModalMultimodal struct {
+	BaseModel
+	DocumentoID   uuid.UUID `json:"documento_id" gorm:"type:uuid;uniqueIndex;not null"`
+	DocumentoTipo string    `json:"documento_tipo" gorm:"size:10;not null"`
+	COTM          string    `json:"cotm" gorm:"size:20"`
+	IndNegociavel string    `json:"ind_negociavel" gorm:"size:1"` // 0-não negociável, 1-negociável
+}
+
+// TableName define o nome da tabela no banco de dados
+func (ModalMultimodal) TableName() string {
+	return "modais_multimodais"
+}
+
+// PreloadModal carrega as informações específicas do modal do documento
+func PreloadModal(db *gorm.DB) *gorm.DB {
+	return db.Preload("ModalAereo").Preload("ModalAquaviario").Preload("ModalFerroviario").
+		Preload("ModalDutoviario").Preload("ModalMultimodal")
+}
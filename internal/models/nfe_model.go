@@ -0,0 +1,26 @@
+package models
+
+// NFe representa uma NF-e referenciada por CT-es e MDF-es. Os dados vêm da
+// decomposição da chave de acesso, pois os documentos de transporte não trazem
+// o XML da nota.
+type NFe struct {
+	BaseModel
+	Chave        string `json:"chave" gorm:"uniqueIndex;not null;size:44"`
+	CUF          string `json:"cuf" gorm:"size:2"`
+	AnoMes       string `json:"aamm" gorm:"size:4"`
+	CNPJEmitente string `json:"cnpj_emitente" gorm:"size:14;index"` // CNPJ, ou "000" + CPF do emitente
+	Modelo       string `json:"modelo" gorm:"size:2"`
+	Serie        string `json:"serie" gorm:"size:3"`
+	Numero       int    `json:"numero" gorm:"index"`
+	// Valor da nota, quando conhecido (CT-e que transporta uma única NF-e: o valor da carga)
+	Valor *float64 `json:"valor"`
+
+	// Documentos de transporte que levaram a nota
+	CTes  []CTE  `gorm:"many2many:cte_nfes;" json:"ctes,omitempty"`
+	MDFes []MDFE `gorm:"many2many:mdfe_nfes;" json:"mdfes,omitempty"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (NFe) TableName() string {
+	return "nfes"
+}
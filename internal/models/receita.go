@@ -0,0 +1,45 @@
+package models
+
+// Tipos de documento de receita (prestação de serviço de transporte)
+const (
+	DocumentoCTe   = "CTE"
+	DocumentoCTeOS = "CTEOS"
+)
+
+// colunasReceita são as colunas comuns a CT-e e CT-e OS usadas nos totais de faturamento
+const colunasReceita = "id, tipo, numero, chave, data_emissao, status, cancelado, valor_total, valor_icms, valor_total_tributos, emitente_id, tomador_id, modal, assinatura_status"
+
+// cteSemEfeito exclui o CT-e anulado ou substituído por outro CT-e não cancelado
+const cteSemEfeito = `EXISTS (SELECT 1 FROM cte_referencias r JOIN ctes s ON s.id = r.cte_id
+	WHERE r.chave_original = ctes.chave AND r.relacao IN ('` + RelacaoAnulacao + `', '` + RelacaoSubstituicao + `')
+	AND r.deleted_at IS NULL AND s.deleted_at IS NULL AND s.cancelado = false)`
+
+// DocumentosReceita retorna a subconsulta, para uso em FROM ou Table, com os
+// CT-es e CT-es OS não excluídos nas colunas comuns, em modalidade_frete (nula
+// no CT-e OS, que não tem CIF/FOB) e em tipo_cte. O tipo (CTE ou CTEOS)
+// restringe a um dos modelos; vazio inclui os dois.
+//
+// Os valores já vêm compensados: o CT-e de anulação e o CT-e anulado ou
+// substituído ficam de fora, e o complementar entra somando ao original. Para
+// contar prestações, desconsidere tipo_cte '1'.
+func DocumentosReceita(tipo string) string {
+	ctes := "SELECT " + colunasReceita + ", modalidade_frete, COALESCE(tipo_cte, '0') AS tipo_cte FROM ctes" +
+		" WHERE deleted_at IS NULL AND COALESCE(tipo_cte, '0') <> '2' AND NOT " + cteSemEfeito
+	cteos := "SELECT " + colunasReceita + ", CAST(NULL AS VARCHAR(3)) AS modalidade_frete, '0' AS tipo_cte FROM cteos WHERE deleted_at IS NULL"
+
+	switch tipo {
+	case DocumentoCTe:
+		return "(" + ctes + ")"
+	case DocumentoCTeOS:
+		return "(" + cteos + ")"
+	default:
+		return "(" + ctes + " UNION ALL " + cteos + ")"
+	}
+}
+
+// SQLQuantidadePrestacoes conta as prestações de DocumentosReceita, sem os CT-es complementares
+const SQLQuantidadePrestacoes = "SUM(CASE WHEN tipo_cte = '1' THEN 0 ELSE 1 END)"
+
+// SQLNaoVerificados conta os documentos de DocumentosReceita sem assinatura
+// válida, importados no modo tolerante; os totais que os incluem devem sinalizá-los
+const SQLNaoVerificados = "SUM(CASE WHEN assinatura_status = 'VALIDA' THEN 0 ELSE 1 END)"
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// UploadBatch agrupa os uploads enviados juntos (arquivo compactado ou upload em lote)
+// e mantém os contadores de progresso do processamento
+type UploadBatch struct {
+	BaseModel
+	NomeArquivo   string    `json:"nome_arquivo" gorm:"not null"`
+	Status        string    `json:"status" gorm:"index;not null"`
+	DataUpload    time.Time `json:"data_upload" gorm:"index;not null"`
+	TotalArquivos int       `json:"total_arquivos"`
+	TamanhoTotal  int64     `json:"tamanho_total"`
+
+	// Registrando indica que os arquivos do lote ainda estão sendo registrados;
+	// enquanto isso o lote não é dado como concluído
+	Registrando bool `json:"registrando" gorm:"default:false"`
+
+	// Contadores por status dos uploads
+	Pendentes   int `json:"pendentes"`
+	Processando int `json:"processando"`
+	Concluidos  int `json:"concluidos"`
+	Erros       int `json:"erros"`
+	Duplicados  int `json:"duplicados"`
+
+	// Relacionamentos
+	Uploads []Upload `gorm:"foreignKey:BatchID" json:"uploads,omitempty"`
+}
+
+// TableName define o nome da tabela no banco de dados
+func (UploadBatch) TableName() string {
+	return "upload_batches"
+}
+
+// Finalizados retorna o número de uploads que não serão mais processados
+func (b UploadBatch) Finalizados() int {
+	return b.Concluidos + b.Erros + b.Duplicados
+}
+
+// Progresso retorna o percentual de uploads finalizados
+func (b UploadBatch) Progresso() float64 {
+	if b.TotalArquivos == 0 {
+		return 0
+	}
+	return float64(b.Finalizados()) * 100 / float64(b.TotalArquivos)
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Upload representa um registro de upload de arquivo
@@ -12,6 +14,14 @@ type Upload struct {
 	DataUpload            time.Time `json:"data_upload" gorm:"index;not null"`
 	ChaveDocProcessado    *string   `json:"chave_doc_processado" gorm:"index"`
 	DetalhesProcessamento string    `json:"detalhes_processamento"`
+
+	// SHA-256 do conteúdo enviado, usado para identificar reenvios do mesmo XML
+	HashSHA256 string `json:"hash_sha256" gorm:"size:64;index"`
+	// Upload original quando este for um reenvio (status DUPLICADO)
+	DuplicadoDeID *uuid.UUID `json:"duplicado_de_id" gorm:"type:uuid;index"`
+
+	// Lote de origem quando o XML veio de um arquivo compactado
+	BatchID *uuid.UUID `json:"batch_id" gorm:"type:uuid;index"`
 }
 
 // TableName define o nome da tabela no banco de dados
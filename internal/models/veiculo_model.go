@@ -1,5 +1,12 @@
 package models
 
+// Tipos de vínculo do veículo com a transportadora
+const (
+	VeiculoProprio  = "PROPRIO"
+	VeiculoAgregado = "AGREGADO"
+	VeiculoTerceiro = "TERCEIRO"
+)
+
 // Veiculo representa um veículo no sistema
 type Veiculo struct {
 	BaseModel
@@ -7,7 +14,20 @@ type Veiculo struct {
 	RENAVAM *string `json:"renavam"`
 	Tipo    string  `json:"tipo" gorm:"size:10;index"` // PROPRIO, AGREGADO, TERCEIRO
 
-	// Campos adicionais podem ser incluídos
+	// Dados do veículo informados no MDF-e
+	UF             string `json:"uf,omitempty" gorm:"size:2"`
+	Tara           int    `json:"tara,omitempty"`
+	CapacidadeKg   int    `json:"capacidade_kg,omitempty"`
+	CapacidadeM3   int    `json:"capacidade_m3,omitempty"`
+	TipoRodado     string `json:"tipo_rodado,omitempty" gorm:"size:2"`
+	TipoCarroceria string `json:"tipo_carroceria,omitempty" gorm:"size:2"`
+
+	// Proprietário do veículo de terceiro (grupo prop do MDF-e)
+	ProprietarioNome  string `json:"proprietario_nome,omitempty" gorm:"size:100"`
+	ProprietarioCNPJ  string `json:"proprietario_cnpj,omitempty" gorm:"size:14"`
+	ProprietarioCPF   string `json:"proprietario_cpf,omitempty" gorm:"size:11"`
+	ProprietarioRNTRC string `json:"proprietario_rntrc,omitempty" gorm:"size:8;index"`
+	TipoProprietario  string `json:"tipo_proprietario,omitempty" gorm:"size:1"` // 0-TAC agregado, 1-TAC independente, 2-outros
 }
 
 // TableName define o nome da tabela no banco de dados
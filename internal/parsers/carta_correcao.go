@@ -0,0 +1,158 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// CorrecaoParsed campo corrigido pela carta de correção (infCorrecao)
+type CorrecaoParsed struct {
+	Grupo   string `json:"grupo_alterado"`
+	Campo   string `json:"campo_alterado"`
+	Valor   string `json:"valor_alterado"`
+	NroItem int    `json:"nro_item_alterado"` // ocorrência do grupo, a partir de 1
+}
+
+// CorrecaoAplicada resultado da correção aplicada ao XML do documento
+type CorrecaoAplicada struct {
+	CorrecaoParsed
+	ValorOriginal string `json:"valor_original"`
+	// Aplicada é falsa quando o grupo não existe no XML do documento ou a correção não é permitida
+	Aplicada bool `json:"aplicada"`
+}
+
+// CorrigirXML aplica as correções, na ordem, ao XML do documento e retorna o XML
+// corrigido. O valor do campo é substituído na ocorrência NroItem do grupo; o
+// campo ausente é incluído no fim do grupo. O XML resultante não tem mais a
+// assinatura válida e serve apenas para leitura dos dados corrigidos.
+func CorrigirXML(xmlContent []byte, correcoes []CorrecaoParsed) ([]byte, []CorrecaoAplicada) {
+	resultado := xmlContent
+	aplicadas := make([]CorrecaoAplicada, 0, len(correcoes))
+	for _, correcao := range correcoes {
+		aplicada := CorrecaoAplicada{CorrecaoParsed: correcao}
+		if corrigido, original, ok := corrigirCampo(resultado, correcao); ok {
+			resultado = corrigido
+			aplicada.ValorOriginal = original
+			aplicada.Aplicada = true
+		}
+		aplicadas = append(aplicadas, aplicada)
+	}
+	return resultado, aplicadas
+}
+
+// corrigirCampo substitui ou inclui o campo na ocorrência do grupo, retornando o valor anterior
+func corrigirCampo(xmlContent []byte, correcao CorrecaoParsed) ([]byte, string, bool) {
+	item := correcao.NroItem
+	if item < 1 {
+		item = 1
+	}
+
+	decoder := newDecoder(xmlContent)
+	ocorrencia := 0
+	profundidade := 0 // dentro do grupo quando maior que zero
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err != nil {
+			return nil, "", false
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			fim := decoder.InputOffset()
+			vazio := bytes.HasSuffix(xmlContent[offset:fim], []byte("/>"))
+			nome := nomeQualificado(t.Name)
+
+			if profundidade == 0 {
+				if t.Name.Local != correcao.Grupo {
+					continue
+				}
+				ocorrencia++
+				if ocorrencia < item {
+					continue
+				}
+				if vazio {
+					// Grupo vazio (<grupo/>): abrir o grupo com o campo
+					conteudo := ">" + elementoXML(correcao.Campo, correcao.Valor) + "</" + nome + ">"
+					return substituir(xmlContent, fim-2, fim, conteudo), "", true
+				}
+				profundidade = 1
+				continue
+			}
+
+			if t.Name.Local == correcao.Campo {
+				if vazio {
+					return substituir(xmlContent, offset, fim, elementoXML(nome, correcao.Valor)), "", true
+				}
+				original, fimConteudo, ok := conteudoElemento(decoder)
+				if !ok {
+					return nil, "", false
+				}
+				return substituir(xmlContent, fim, fimConteudo, escaparXML(correcao.Valor)), original, true
+			}
+			profundidade++
+		case xml.EndElement:
+			if profundidade == 0 {
+				continue
+			}
+			profundidade--
+			if profundidade == 0 {
+				// Campo ausente no grupo: incluído antes do fechamento
+				return substituir(xmlContent, offset, offset, elementoXML(correcao.Campo, correcao.Valor)), "", true
+			}
+		}
+	}
+}
+
+// conteudoElemento lê até o fechamento do elemento corrente, retornando o texto e o offset do fechamento
+func conteudoElemento(decoder *xml.Decoder) (string, int64, bool) {
+	var texto strings.Builder
+	profundidade := 1
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err != nil {
+			return "", 0, false
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			texto.Write(t)
+		case xml.StartElement:
+			profundidade++
+		case xml.EndElement:
+			profundidade--
+			if profundidade == 0 {
+				return strings.TrimSpace(texto.String()), offset, true
+			}
+		}
+	}
+}
+
+// nomeQualificado retorna o nome do elemento com o prefixo de namespace, como está no XML
+func nomeQualificado(nome xml.Name) string {
+	if nome.Space != "" {
+		return nome.Space + ":" + nome.Local
+	}
+	return nome.Local
+}
+
+// elementoXML monta o elemento simples com o valor escapado
+func elementoXML(nome, valor string) string {
+	return "<" + nome + ">" + escaparXML(valor) + "</" + nome + ">"
+}
+
+// escaparXML escapa o texto para uso no conteúdo de um elemento
+func escaparXML(valor string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(valor))
+	return buf.String()
+}
+
+// substituir troca o trecho [inicio, fim) do XML pelo conteúdo informado
+func substituir(xmlContent []byte, inicio, fim int64, conteudo string) []byte {
+	resultado := make([]byte, 0, len(xmlContent)+len(conteudo))
+	resultado = append(resultado, xmlContent[:inicio]...)
+	resultado = append(resultado, conteudo...)
+	return append(resultado, xmlContent[fim:]...)
+}
@@ -0,0 +1,31 @@
+package parsers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrigirXML(t *testing.T) {
+	original := `<CTe xmlns="http://www.portalfiscal.inf.br/cte"><infCte><ide><CFOP>6353</CFOP></ide><compl/>` +
+		`<infCarga><infQ><qCarga>10</qCarga></infQ><infQ><qCarga>20</qCarga></infQ></infCarga><rem><xNome>A &amp; B</xNome></rem></infCte></CTe>`
+
+	corrigido, aplicadas := CorrigirXML([]byte(original), []CorrecaoParsed{
+		{Grupo: "ide", Campo: "CFOP", Valor: "5353", NroItem: 1},
+		{Grupo: "compl", Campo: "xObs", Valor: "Entrega <agendada>", NroItem: 1},
+		{Grupo: "infQ", Campo: "qCarga", Valor: "25", NroItem: 2},
+		{Grupo: "rem", Campo: "xFant", Valor: "AB", NroItem: 1},
+		{Grupo: "exped", Campo: "xNome", Valor: "EXPEDIDOR", NroItem: 1},
+	})
+
+	assert.Equal(t, `<CTe xmlns="http://www.portalfiscal.inf.br/cte"><infCte><ide><CFOP>5353</CFOP></ide><compl><xObs>Entrega &lt;agendada&gt;</xObs></compl>`+
+		`<infCarga><infQ><qCarga>10</qCarga></infQ><infQ><qCarga>25</qCarga></infQ></infCarga><rem><xNome>A &amp; B</xNome><xFant>AB</xFant></rem></infCte></CTe>`, string(corrigido))
+
+	require.Len(t, aplicadas, 5)
+	assert.Equal(t, "6353", aplicadas[0].ValorOriginal)
+	assert.Equal(t, "20", aplicadas[2].ValorOriginal)
+	assert.True(t, aplicadas[3].Aplicada)
+	// Grupo inexistente no documento: a correção não é aplicada
+	assert.False(t, aplicadas[4].Aplicada)
+}
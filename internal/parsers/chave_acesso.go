@@ -0,0 +1,138 @@
+package parsers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrChaveInconsistente indica chave de acesso com DV inválido ou que não
+// corresponde aos dados de identificação (ide/emit) do documento
+var ErrChaveInconsistente = errors.New("chave de acesso inconsistente com o documento")
+
+// ChaveAcesso é a decomposição dos 44 dígitos da chave de acesso:
+// cUF(2) AAMM(4) CNPJ/CPF(14) mod(2) serie(3) número(9) tpEmis(1) código numérico(8) DV(1)
+type ChaveAcesso struct {
+	Chave  string `json:"chave"`
+	CUF    string `json:"cuf"`
+	AnoMes string `json:"aamm"`
+	// CNPJCPF é o CNPJ do emitente ou, para pessoa física, o CPF precedido de "000"
+	CNPJCPF           string `json:"cnpj_cpf"`
+	Modelo            string `json:"modelo"`
+	Serie             string `json:"serie"`
+	Numero            string `json:"numero"`
+	TipoEmissao       string `json:"tp_emis"`
+	CodigoNumerico    string `json:"codigo_numerico"`
+	DigitoVerificador string `json:"dv"`
+	DVValido          bool   `json:"dv_valido"`
+
+	// Divergencias lista os campos da chave que não conferem com o documento
+	Divergencias []string `json:"divergencias,omitempty"`
+}
+
+// CamposChave são os dados de identificação do documento refletidos na chave
+type CamposChave struct {
+	CUF               string
+	Modelo            string
+	Serie             string
+	Numero            int
+	CNPJ              string
+	CPF               string
+	DataEmissao       time.Time
+	TipoEmissao       string
+	CodigoNumerico    string
+	DigitoVerificador string
+}
+
+// DecomporChaveAcesso separa os campos da chave de acesso. Somente o formato
+// (44 dígitos) é exigido; o DV é conferido e informado em DVValido.
+func DecomporChaveAcesso(chave string) (*ChaveAcesso, error) {
+	if len(chave) != 44 {
+		return nil, fmt.Errorf("chave deve ter 44 dígitos, tem %d", len(chave))
+	}
+	for _, c := range chave {
+		if c < '0' || c > '9' {
+			return nil, errors.New("chave deve conter apenas números")
+		}
+	}
+
+	c := &ChaveAcesso{
+		Chave:             chave,
+		CUF:               chave[0:2],
+		AnoMes:            chave[2:6],
+		CNPJCPF:           chave[6:20],
+		Modelo:            chave[20:22],
+		Serie:             chave[22:25],
+		Numero:            chave[25:34],
+		TipoEmissao:       chave[34:35],
+		CodigoNumerico:    chave[35:43],
+		DigitoVerificador: chave[43:44],
+	}
+	c.DVValido = c.DigitoVerificador == strconv.Itoa(CalcularDigitoVerificador(chave[:43]))
+	if !c.DVValido {
+		c.Divergencias = append(c.Divergencias, fmt.Sprintf("dígito verificador %s inválido (calculado: %d)",
+			c.DigitoVerificador, CalcularDigitoVerificador(chave[:43])))
+	}
+
+	return c, nil
+}
+
+// Conferir compara a chave com os dados de identificação do documento e
+// acrescenta em Divergencias cada campo que não confere. Campos não informados
+// no documento são ignorados.
+func (c *ChaveAcesso) Conferir(campos CamposChave) {
+	divergencia := func(campo, naChave, noDocumento string) {
+		c.Divergencias = append(c.Divergencias, fmt.Sprintf("%s: %s na chave, %s no documento", campo, naChave, noDocumento))
+	}
+
+	if campos.CUF != "" && campos.CUF != c.CUF {
+		divergencia("cUF", c.CUF, campos.CUF)
+	}
+	if !campos.DataEmissao.IsZero() {
+		if anoMes := campos.DataEmissao.Format("0601"); anoMes != c.AnoMes {
+			divergencia("AAMM da emissão", c.AnoMes, anoMes)
+		}
+	}
+	switch {
+	case campos.CNPJ != "":
+		if campos.CNPJ != c.CNPJCPF {
+			divergencia("CNPJ do emitente", c.CNPJCPF, campos.CNPJ)
+		}
+	case campos.CPF != "":
+		if "000"+campos.CPF != c.CNPJCPF {
+			divergencia("CPF do emitente", strings.TrimPrefix(c.CNPJCPF, "000"), campos.CPF)
+		}
+	}
+	if campos.Modelo != "" && campos.Modelo != c.Modelo {
+		divergencia("modelo", c.Modelo, campos.Modelo)
+	}
+	if serie, err := strconv.Atoi(campos.Serie); err == nil {
+		if naChave, _ := strconv.Atoi(c.Serie); naChave != serie {
+			divergencia("série", c.Serie, campos.Serie)
+		}
+	}
+	if naChave, _ := strconv.Atoi(c.Numero); naChave != campos.Numero {
+		divergencia("número", c.Numero, strconv.Itoa(campos.Numero))
+	}
+	if campos.TipoEmissao != "" && campos.TipoEmissao != c.TipoEmissao {
+		divergencia("tpEmis", c.TipoEmissao, campos.TipoEmissao)
+	}
+	if codigo, err := strconv.Atoi(campos.CodigoNumerico); err == nil {
+		if naChave, _ := strconv.Atoi(c.CodigoNumerico); naChave != codigo {
+			divergencia("código numérico", c.CodigoNumerico, campos.CodigoNumerico)
+		}
+	}
+	if campos.DigitoVerificador != "" && campos.DigitoVerificador != c.DigitoVerificador {
+		divergencia("cDV", c.DigitoVerificador, campos.DigitoVerificador)
+	}
+}
+
+// Erro retorna ErrChaveInconsistente com as divergências, ou nil se a chave conferir
+func (c *ChaveAcesso) Erro() error {
+	if len(c.Divergencias) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w %s: %s", ErrChaveInconsistente, c.Chave, strings.Join(c.Divergencias, "; "))
+}
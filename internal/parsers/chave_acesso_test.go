@@ -0,0 +1,49 @@
+package parsers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecomporChaveAcesso(t *testing.T) {
+	chave, err := DecomporChaveAcesso("35240112345678000195570010000001231000001236")
+	require.NoError(t, err)
+	assert.Equal(t, "35", chave.CUF)
+	assert.Equal(t, "2401", chave.AnoMes)
+	assert.Equal(t, "12345678000195", chave.CNPJCPF)
+	assert.Equal(t, "57", chave.Modelo)
+	assert.Equal(t, "001", chave.Serie)
+	assert.Equal(t, "000000123", chave.Numero)
+	assert.Equal(t, "1", chave.TipoEmissao)
+	assert.Equal(t, "00000123", chave.CodigoNumerico)
+	assert.True(t, chave.DVValido)
+
+	emissao, _ := ParseDate("2024-01-31T22:00:00-03:00")
+	campos := CamposChave{CUF: "35", Modelo: "57", Serie: "1", Numero: 123, CNPJ: "12345678000195",
+		DataEmissao: emissao, TipoEmissao: "1", CodigoNumerico: "00000123", DigitoVerificador: "6"}
+	chave.Conferir(campos)
+	assert.NoError(t, chave.Erro())
+
+	// Número, série, emitente e mês de emissão divergentes
+	chave, err = DecomporChaveAcesso("35240112345678000195570010000001231000001236")
+	require.NoError(t, err)
+	campos.Numero, campos.Serie, campos.CNPJ = 124, "2", "98765432000198"
+	campos.DataEmissao = emissao.Add(3 * time.Hour).In(emissao.Location())
+	chave.Conferir(campos)
+	require.Len(t, chave.Divergencias, 4)
+	assert.Contains(t, chave.Divergencias[0], "AAMM da emissão: 2401 na chave, 2402 no documento")
+	assert.True(t, errors.Is(chave.Erro(), ErrChaveInconsistente))
+
+	// DV inválido
+	chave, err = DecomporChaveAcesso("35240112345678000195570010000001231000001230")
+	require.NoError(t, err)
+	assert.False(t, chave.DVValido)
+	assert.Error(t, chave.Erro())
+
+	_, err = DecomporChaveAcesso("3524011234567800019557001000000123100000123X")
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package parsers
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Tipos de componente do valor da prestação. O xNome do Comp é texto livre
+// (até 15 caracteres), então o tipo é inferido pelas abreviações usuais.
+const (
+	ComponenteFretePeso  = "FRETE_PESO"
+	ComponenteFreteValor = "FRETE_VALOR"
+	ComponentePedagio    = "PEDAGIO"
+	ComponenteGRIS       = "GRIS"
+	ComponenteTDE        = "TDE"
+	ComponenteTDA        = "TDA"
+	ComponenteTRT        = "TRT"
+	ComponenteDespacho   = "DESPACHO"
+	ComponenteSECCAT     = "SEC_CAT"
+	ComponenteImposto    = "IMPOSTO"
+	ComponenteOutros     = "OUTROS"
+)
+
+// padroesComponente associa termos do xNome (sem acentos, em maiúsculas) ao tipo,
+// na ordem em que são testados
+var padroesComponente = []struct {
+	termos []string
+	tipo   string
+}{
+	{[]string{"PEDAGIO", "PEDAG"}, ComponentePedagio},
+	{[]string{"GRIS"}, ComponenteGRIS},
+	{[]string{"TDE"}, ComponenteTDE},
+	{[]string{"TDA"}, ComponenteTDA},
+	{[]string{"TRT"}, ComponenteTRT},
+	{[]string{"SEC/CAT", "SECCAT", "SEC", "CAT"}, ComponenteSECCAT},
+	{[]string{"DESPACHO", "DESP"}, ComponenteDespacho},
+	{[]string{"ICMS", "IMPOSTO", "IMPOSTOS"}, ComponenteImposto},
+	{[]string{"FRETE VALOR", "FRETE-VALOR", "FRETEVALOR", "AD VALOREM", "ADVALOREM", "AD-VALOREM", "VALOR", "F.VALOR"}, ComponenteFreteValor},
+	{[]string{"FRETE PESO", "FRETE-PESO", "FRETEPESO", "PESO", "F.PESO", "FRETE"}, ComponenteFretePeso},
+}
+
+// ClassificarComponente infere o tipo do componente a partir do xNome
+func ClassificarComponente(nome string) string {
+	nome = removerAcentos(strings.ToUpper(strings.TrimSpace(nome)))
+	palavras := strings.FieldsFunc(nome, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '/' && r != '.' && r != '-'
+	})
+
+	for _, padrao := range padroesComponente {
+		for _, termo := range padrao.termos {
+			// Termos compostos comparam com o nome inteiro; simples, com cada palavra
+			if strings.ContainsAny(termo, " ") {
+				if strings.Contains(nome, termo) {
+					return padrao.tipo
+				}
+				continue
+			}
+			for _, palavra := range palavras {
+				if palavra == termo {
+					return padrao.tipo
+				}
+			}
+		}
+	}
+	return ComponenteOutros
+}
+
+// removerAcentos decompõe os caracteres e descarta as marcas diacríticas
+func removerAcentos(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// unidadesCarga descreve o cUnid do infQ
+var unidadesCarga = map[string]string{
+	"00": "M3",
+	"01": "KG",
+	"02": "TON",
+	"03": "UNIDADE",
+	"04": "LITROS",
+	"05": "MMBTU",
+}
+
+// pesoTaxado retorna o maior peso informado em KG ou TON, convertido para KG.
+// O frete é cobrado sobre o maior entre o peso real e o cubado, e o peso base
+// de cálculo, quando informado, já é esse valor.
+func pesoTaxado(quantidades []QuantidadeParsed) float64 {
+	peso := 0.0
+	for _, q := range quantidades {
+		var kg float64
+		switch q.CodigoUnidade {
+		case "01":
+			kg = q.Quantidade
+		case "02":
+			kg = q.Quantidade * 1000
+		default:
+			continue
+		}
+		if strings.Contains(removerAcentos(strings.ToUpper(q.TipoMedida)), "BASE DE CALCULO") {
+			return kg
+		}
+		if kg > peso {
+			peso = kg
+		}
+	}
+	return peso
+}
@@ -0,0 +1,41 @@
+package parsers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassificarComponente(t *testing.T) {
+	casos := map[string]string{
+		"FRETE PESO":  ComponenteFretePeso,
+		"Frete-Peso":  ComponenteFretePeso,
+		"FRETE VALOR": ComponenteFreteValor,
+		"AD VALOREM":  ComponenteFreteValor,
+		"Pedágio":     ComponentePedagio,
+		"VLR PEDAGIO": ComponentePedagio,
+		"GRIS":        ComponenteGRIS,
+		"TX TDE":      ComponenteTDE,
+		"SEC/CAT":     ComponenteSECCAT,
+		"DESPACHO":    ComponenteDespacho,
+		"DESCARGA":    ComponenteOutros,
+	}
+	for nome, tipo := range casos {
+		assert.Equal(t, tipo, ClassificarComponente(nome), nome)
+	}
+}
+
+func TestPesoTaxado(t *testing.T) {
+	// Maior entre o peso real e o cubado
+	assert.Equal(t, 1250.0, pesoTaxado([]QuantidadeParsed{
+		{CodigoUnidade: "01", TipoMedida: "PESO BRUTO", Quantidade: 1000},
+		{CodigoUnidade: "02", TipoMedida: "PESO CUBADO", Quantidade: 1.25},
+		{CodigoUnidade: "03", TipoMedida: "VOLUMES", Quantidade: 40},
+	}))
+	// O peso base de cálculo prevalece
+	assert.Equal(t, 800.0, pesoTaxado([]QuantidadeParsed{
+		{CodigoUnidade: "01", TipoMedida: "PESO BRUTO", Quantidade: 1000},
+		{CodigoUnidade: "01", TipoMedida: "PESO BASE DE CÁLCULO", Quantidade: 800},
+	}))
+	assert.Zero(t, pesoTaxado([]QuantidadeParsed{{CodigoUnidade: "00", Quantidade: 3}}))
+}
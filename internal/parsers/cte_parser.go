@@ -11,57 +11,154 @@ import (
 
 // CTeParsed é o resultado do parsing de CT-e
 type CTeParsed struct {
-	Chave           string
-	Numero          int
-	Serie           string
-	DataEmissao     time.Time
-	CFOP            string
-	ModalidadeFrete string
-	ValorTotal      float64
-	ValorCarga      float64
-	UFInicio        string
-	UFDestino       string
-	MunicipioInicio string
-	MunicipioFim    string
-	Status          string
-	Protocolo       string
+	Chave           string    `json:"chave"`
+	Numero          int       `json:"numero"`
+	Serie           string    `json:"serie"`
+	DataEmissao     time.Time `json:"data_emissao"`
+	CFOP            string    `json:"cfop"`
+	ModalidadeFrete string    `json:"modalidade_frete"`
+	Modal           string    `json:"modal"` // 01-rodoviário, 02-aéreo, 03-aquaviário, 04-ferroviário, 05-dutoviário, 06-multimodal
+	ValorTotal      float64   `json:"valor_total"`
+	ValorCarga      float64   `json:"valor_carga"`
+	UFInicio        string    `json:"uf_inicio"`
+	UFDestino       string    `json:"uf_destino"`
+	MunicipioInicio string    `json:"municipio_inicio"`
+	MunicipioFim    string    `json:"municipio_fim"`
+	Status          string    `json:"status"`
+	Protocolo       string    `json:"protocolo"`
+
+	// TipoCTe é o tpCTe: 0-normal, 1-complementar, 2-anulação, 3-substituto
+	TipoCTe string `json:"tipo_cte"`
+	// ChavesReferenciadas são os CT-es complementados, anulado ou substituído
+	ChavesReferenciadas []string `json:"chaves_referenciadas,omitempty"`
+
+	// Decomposição da chave de acesso e divergências com ide/emit
+	ChaveAcesso *ChaveAcesso `json:"chave_acesso,omitempty"`
 
 	// Entidades
-	Emitente     EmpresaParsed
-	Remetente    EmpresaParsed
-	Destinatario EmpresaParsed
-	Tomador      *EmpresaParsed // Pode ser null
+	Emitente     EmpresaParsed  `json:"emitente"`
+	Remetente    EmpresaParsed  `json:"remetente"`
+	Destinatario EmpresaParsed  `json:"destinatario"`
+	Expedidor    *EmpresaParsed `json:"expedidor,omitempty"`
+	Recebedor    *EmpresaParsed `json:"recebedor,omitempty"`
+	Tomador      *EmpresaParsed `json:"tomador,omitempty"` // Pode ser null
+	// TipoTomador é o indicador toma: 0-remetente, 1-expedidor, 2-recebedor, 3-destinatário, 4-outros
+	TipoTomador string `json:"tipo_tomador"`
 
 	// Informações adicionais
-	RNTRC             string
-	PlacaVeiculo      string
-	ObservacoesGerais string
+	RNTRC             string `json:"rntrc"`
+	PlacaVeiculo      string `json:"placa_veiculo"`
+	ObservacoesGerais string `json:"observacoes_gerais"`
+
+	// Modais não rodoviários e multimodal
+	DetalheModal DetalheModalParsed `json:"detalhe_modal"`
 
 	// Documentos vinculados
-	ChavesNFe []string
+	ChavesNFe []string `json:"chaves_nfe,omitempty"`
+
+	// Composição do valor da prestação e quantidades da carga
+	ValorPedagio  float64            `json:"valor_pedagio"`
+	OutrosValores float64            `json:"outros_valores"`
+	PesoKg        float64            `json:"peso_kg"`
+	Componentes   []ComponenteParsed `json:"componentes,omitempty"`
+	Quantidades   []QuantidadeParsed `json:"quantidades,omitempty"`
+
+	// Impostos
+	ValorICMS          float64         `json:"valor_icms"`
+	ValorTotalTributos float64         `json:"valor_total_tributos"`
+	Impostos           []ImpostoParsed `json:"impostos,omitempty"`
+}
+
+// Tipos de CT-e (ide/tpCTe)
+const (
+	CTeNormal       = "0"
+	CTeComplementar = "1"
+	CTeAnulacao     = "2" // apenas na versão 3.00
+	CTeSubstituto   = "3"
+)
+
+// ComponenteParsed representa um componente do valor da prestação (vPrest/Comp)
+type ComponenteParsed struct {
+	Nome  string  `json:"nome"`
+	Tipo  string  `json:"tipo"` // classificação do xNome, ver ClassificarComponente
+	Valor float64 `json:"valor"`
+}
+
+// QuantidadeParsed representa uma quantidade da carga (infCarga/infQ)
+type QuantidadeParsed struct {
+	CodigoUnidade string  `json:"codigo_unidade"` // cUnid: 00-M3, 01-KG, 02-TON, 03-UNIDADE, 04-LITROS, 05-MMBTU
+	Unidade       string  `json:"unidade"`
+	TipoMedida    string  `json:"tipo_medida"` // PESO BRUTO, PESO CUBADO, PESO BASE DE CALCULO, ...
+	Quantidade    float64 `json:"quantidade"`
+}
+
+// Tributos informados no grupo imp do CT-e
+const (
+	TributoICMS   = "ICMS"
+	TributoICMSST = "ICMS_ST"
+	TributoIBSUF  = "IBS_UF"
+	TributoIBSMun = "IBS_MUN"
+	TributoCBS    = "CBS"
+	// Partilha do ICMS interestadual (ICMSUFFim)
+	TributoICMSUFFim = "ICMS_UFFIM"
+	TributoICMSUFIni = "ICMS_UFINI"
+	TributoFCPUFFim  = "FCP_UFFIM"
+)
+
+// ImpostoParsed representa a tributação de um tributo do CT-e
+type ImpostoParsed struct {
+	Tributo             string  `json:"tributo"`
+	Grupo               string  `json:"grupo"` // ICMS00, ICMS20, ..., ICMSOutraUF, ICMSSN, ICMSUFFim, IBSCBS
+	CST                 string  `json:"cst"`
+	ClassTrib           string  `json:"class_trib,omitempty"`
+	BaseCalculo         float64 `json:"base_calculo"`
+	PercentualReducaoBC float64 `json:"percentual_reducao_bc"`
+	Aliquota            float64 `json:"aliquota"`
+	Valor               float64 `json:"valor"`
+	ValorCredito        float64 `json:"valor_credito"`
 }
 
 // EmpresaParsed representa dados simplificados de uma empresa
 type EmpresaParsed struct {
-	CNPJ        string
-	CPF         string
-	RazaoSocial string
-	IE          string
-	UF          string
-	Municipio   string
-	CEP         string
+	CNPJ        string `json:"cnpj"`
+	CPF         string `json:"cpf"`
+	RazaoSocial string `json:"razao_social"`
+	IE          string `json:"ie"`
+	UF          string `json:"uf"`
+	Municipio   string `json:"municipio"`
+	CEP         string `json:"cep"`
 }
 
-// ParseCTe faz o parsing de um XML de CT-e
-func ParseCTe(xmlContent []byte) (*CTeParsed, error) {
-	var cteProc CTeProc
+// DecodificarCTe faz o unmarshal do XML de CT-e, aceitando o cteProc ou o CTe avulso
+func DecodificarCTe(xmlContent []byte) (*CTeProc, error) {
+	raiz, err := ElementoRaiz(xmlContent)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do XML: %w", err)
+	}
 
-	// Tentar fazer unmarshal do XML
-	err := xml.Unmarshal(xmlContent, &cteProc)
+	var cteProc CTeProc
+	switch raiz {
+	case "cteProc":
+		err = xml.Unmarshal(xmlContent, &cteProc)
+	case "CTe":
+		err = xml.Unmarshal(xmlContent, &cteProc.CTe)
+	default:
+		return nil, fmt.Errorf("elemento raiz inesperado para CT-e: %s", raiz)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("erro ao fazer parse do XML: %w", err)
 	}
 
+	return &cteProc, nil
+}
+
+// ParseCTe faz o parsing de um XML de CT-e
+func ParseCTe(xmlContent []byte) (*CTeParsed, error) {
+	cteProc, err := DecodificarCTe(xmlContent)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validar estrutura básica
 	if cteProc.CTe.InfCte.Id == "" {
 		return nil, errors.New("XML inválido: ID do CT-e não encontrado")
@@ -69,8 +166,9 @@ func ParseCTe(xmlContent []byte) (*CTeParsed, error) {
 
 	// Extrair a chave do ID (remover prefixo "CTe")
 	chave := strings.TrimPrefix(cteProc.CTe.InfCte.Id, "CTe")
-	if len(chave) != 44 {
-		return nil, fmt.Errorf("chave de acesso inválida: %s", chave)
+	chaveAcesso, err := DecomporChaveAcesso(chave)
+	if err != nil {
+		return nil, fmt.Errorf("chave de acesso inválida %s: %w", chave, err)
 	}
 
 	// Parsear data de emissão
@@ -99,8 +197,12 @@ func ParseCTe(xmlContent []byte) (*CTeParsed, error) {
 		}
 	}
 
-	// Determinar modalidade de frete baseado no tomador
-	modalidadeFrete := determinarModalidadeFrete(cteProc.CTe.InfCte.Ide.Toma3.Toma)
+	// Determinar modalidade de frete baseado no tomador (toma3 ou, para terceiros, toma4)
+	tipoTomador := cteProc.CTe.InfCte.Ide.Toma3.Toma
+	if toma4 := cteProc.CTe.InfCte.Ide.Toma4; toma4 != nil {
+		tipoTomador = toma4.Toma
+	}
+	modalidadeFrete := determinarModalidadeFrete(tipoTomador)
 
 	// Criar resultado parseado
 	result := &CTeParsed{
@@ -110,6 +212,9 @@ func ParseCTe(xmlContent []byte) (*CTeParsed, error) {
 		DataEmissao:     dataEmissao,
 		CFOP:            cteProc.CTe.InfCte.Ide.CFOP,
 		ModalidadeFrete: modalidadeFrete,
+		Modal:           NormalizarModal(cteProc.CTe.InfCte.Ide.Modal),
+		TipoTomador:     tipoTomador,
+		TipoCTe:         cteProc.CTe.InfCte.Ide.TpCTe,
 		ValorTotal:      valorTotal,
 		ValorCarga:      valorCarga,
 		UFInicio:        cteProc.CTe.InfCte.Ide.UFIni,
@@ -121,6 +226,7 @@ func ParseCTe(xmlContent []byte) (*CTeParsed, error) {
 	// Parsear emitente
 	result.Emitente = EmpresaParsed{
 		CNPJ:        cteProc.CTe.InfCte.Emit.CNPJ,
+		CPF:         cteProc.CTe.InfCte.Emit.CPF,
 		RazaoSocial: cteProc.CTe.InfCte.Emit.XNome,
 		IE:          cteProc.CTe.InfCte.Emit.IE,
 		UF:          cteProc.CTe.InfCte.Emit.EnderEmit.UF,
@@ -128,6 +234,21 @@ func ParseCTe(xmlContent []byte) (*CTeParsed, error) {
 		CEP:         cteProc.CTe.InfCte.Emit.EnderEmit.CEP,
 	}
 
+	// Conferir a chave de acesso com a identificação e o emitente
+	chaveAcesso.Conferir(CamposChave{
+		CUF:               cteProc.CTe.InfCte.Ide.CUF,
+		Modelo:            cteProc.CTe.InfCte.Ide.Mod,
+		Serie:             cteProc.CTe.InfCte.Ide.Serie,
+		Numero:            numero,
+		CNPJ:              cteProc.CTe.InfCte.Emit.CNPJ,
+		CPF:               cteProc.CTe.InfCte.Emit.CPF,
+		DataEmissao:       dataEmissao,
+		TipoEmissao:       cteProc.CTe.InfCte.Ide.TpEmis,
+		CodigoNumerico:    cteProc.CTe.InfCte.Ide.CCT,
+		DigitoVerificador: cteProc.CTe.InfCte.Ide.CDV,
+	})
+	result.ChaveAcesso = chaveAcesso
+
 	// Parsear remetente
 	result.Remetente = EmpresaParsed{
 		CNPJ:        cteProc.CTe.InfCte.Rem.CNPJ,
@@ -150,22 +271,64 @@ func ParseCTe(xmlContent []byte) (*CTeParsed, error) {
 		CEP:         cteProc.CTe.InfCte.Dest.EnderDest.CEP,
 	}
 
+	// Parsear expedidor e recebedor
+	if exped := cteProc.CTe.InfCte.Exped; exped != nil {
+		result.Expedidor = &EmpresaParsed{
+			CNPJ:        exped.CNPJ,
+			CPF:         exped.CPF,
+			RazaoSocial: exped.XNome,
+			IE:          exped.IE,
+			UF:          exped.EnderExped.UF,
+			Municipio:   exped.EnderExped.XMun,
+			CEP:         exped.EnderExped.CEP,
+		}
+	}
+	if receb := cteProc.CTe.InfCte.Receb; receb != nil {
+		result.Recebedor = &EmpresaParsed{
+			CNPJ:        receb.CNPJ,
+			CPF:         receb.CPF,
+			RazaoSocial: receb.XNome,
+			IE:          receb.IE,
+			UF:          receb.EnderReceb.UF,
+			Municipio:   receb.EnderReceb.XMun,
+			CEP:         receb.EnderReceb.CEP,
+		}
+	}
+
 	// Determinar tomador baseado no indicador
-	switch cteProc.CTe.InfCte.Ide.Toma3.Toma {
+	switch tipoTomador {
 	case "0": // Remetente
 		tomador := result.Remetente
 		result.Tomador = &tomador
 	case "1": // Expedidor
-		// Não temos expedidor no XML, usar remetente
-		tomador := result.Remetente
+		tomador := result.Remetente // o expedidor é obrigatório; remetente só se ausente
+		if result.Expedidor != nil {
+			tomador = *result.Expedidor
+		}
 		result.Tomador = &tomador
 	case "2": // Recebedor
-		// Não temos recebedor no XML, usar destinatário
-		tomador := result.Destinatario
+		tomador := result.Destinatario // o recebedor é obrigatório; destinatário só se ausente
+		if result.Recebedor != nil {
+			tomador = *result.Recebedor
+		}
 		result.Tomador = &tomador
 	case "3": // Destinatário
 		tomador := result.Destinatario
 		result.Tomador = &tomador
+	case "4": // Outros (terceiro informado em toma4)
+		toma4 := cteProc.CTe.InfCte.Ide.Toma4
+		if toma4 == nil {
+			return nil, errors.New("XML inválido: tomador 4 (outros) sem o grupo toma4")
+		}
+		result.Tomador = &EmpresaParsed{
+			CNPJ:        toma4.CNPJ,
+			CPF:         toma4.CPF,
+			RazaoSocial: toma4.XNome,
+			IE:          toma4.IE,
+			UF:          toma4.EnderToma.UF,
+			Municipio:   toma4.EnderToma.XMun,
+			CEP:         toma4.EnderToma.CEP,
+		}
 	}
 
 	// Informações do protocolo
@@ -179,6 +342,11 @@ func ParseCTe(xmlContent []byte) (*CTeParsed, error) {
 		result.RNTRC = cteProc.CTe.InfCte.InfCTeNorm.InfModal.Rodo.RNTRC
 	}
 
+	// Demais modais
+	if result.DetalheModal, err = parseModalCTe(cteProc.CTe.InfCte.InfCTeNorm.InfModal); err != nil {
+		return nil, err
+	}
+
 	// Placa do veículo (buscar nas observações)
 	for _, obs := range cteProc.CTe.InfCte.Compl.ObsCont {
 		if obs.XCampo == "PLACA" {
@@ -197,18 +365,175 @@ func ParseCTe(xmlContent []byte) (*CTeParsed, error) {
 		}
 	}
 
+	// CT-es complementados, anulado ou substituído
+	if result.TipoCTe == "" {
+		result.TipoCTe = CTeNormal
+	}
+	for _, comp := range cteProc.CTe.InfCte.InfCteComp {
+		if comp.ChCTe != "" {
+			result.ChavesReferenciadas = append(result.ChavesReferenciadas, comp.ChCTe)
+		}
+	}
+	if anu := cteProc.CTe.InfCte.InfCteAnu; anu != nil && anu.ChCte != "" {
+		result.ChavesReferenciadas = append(result.ChavesReferenciadas, anu.ChCte)
+	}
+	if sub := cteProc.CTe.InfCte.InfCTeNorm.InfCteSub; sub != nil && sub.ChCte != "" {
+		result.ChavesReferenciadas = append(result.ChavesReferenciadas, sub.ChCte)
+	}
+
+	// Componentes do valor da prestação
+	if result.Componentes, err = parseComponentes(cteProc.CTe.InfCte.VPrest.Comp); err != nil {
+		return nil, err
+	}
+	for _, componente := range result.Componentes {
+		switch componente.Tipo {
+		case ComponentePedagio:
+			result.ValorPedagio += componente.Valor
+		case ComponenteFretePeso, ComponenteFreteValor:
+		default:
+			result.OutrosValores += componente.Valor
+		}
+	}
+
+	// Quantidades da carga
+	for _, q := range cteProc.CTe.InfCte.InfCTeNorm.InfCarga.InfQ {
+		quantidade, err := parseFloat(q.QCarga)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao parsear quantidade da carga: %w", err)
+		}
+		result.Quantidades = append(result.Quantidades, QuantidadeParsed{
+			CodigoUnidade: q.CUnid,
+			Unidade:       unidadesCarga[q.CUnid],
+			TipoMedida:    strings.TrimSpace(q.TpMed),
+			Quantidade:    quantidade,
+		})
+	}
+	result.PesoKg = pesoTaxado(result.Quantidades)
+
+	// Impostos
+	impostos, err := parseImpostosCTe(cteProc.CTe.InfCte.Imp)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao parsear impostos: %w", err)
+	}
+	result.Impostos = impostos
+	for _, imposto := range impostos {
+		if imposto.Tributo == TributoICMS {
+			result.ValorICMS += imposto.Valor
+		}
+	}
+	if result.ValorTotalTributos, err = parseFloat(cteProc.CTe.InfCte.Imp.VTotTrib); err != nil {
+		return nil, fmt.Errorf("erro ao parsear vTotTrib: %w", err)
+	}
+
 	return result, nil
 }
 
-// determinarModalidadeFrete determina CIF ou FOB baseado no tomador
+// parseComponentes converte os componentes do valor da prestação (vPrest/Comp)
+func parseComponentes(comps []Comp) ([]ComponenteParsed, error) {
+	var componentes []ComponenteParsed
+	for _, comp := range comps {
+		valor, err := parseFloat(comp.VComp)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao parsear componente %s: %w", comp.XNome, err)
+		}
+		componentes = append(componentes, ComponenteParsed{Nome: strings.TrimSpace(comp.XNome), Tipo: ClassificarComponente(comp.XNome), Valor: valor})
+	}
+	return componentes, nil
+}
+
+// parseImpostosCTe converte o grupo ICMS informado (apenas um por CT-e), a
+// partilha do ICMS com a UF de término e o grupo IBSCBS da reforma tributária
+// em uma linha por tributo
+func parseImpostosCTe(imp ImpCTe) ([]ImpostoParsed, error) {
+	var impostos []ImpostoParsed
+	var erro error
+	// adicionar converte os campos na ordem base, redução da base, alíquota, valor e crédito
+	adicionar := func(imposto ImpostoParsed, campos ...string) {
+		for j, destino := range []*float64{&imposto.BaseCalculo, &imposto.PercentualReducaoBC, &imposto.Aliquota, &imposto.Valor, &imposto.ValorCredito} {
+			if j >= len(campos) {
+				break
+			}
+			valor, err := parseFloat(campos[j])
+			if err != nil && erro == nil {
+				erro = fmt.Errorf("%s %s: %w", imposto.Grupo, imposto.Tributo, err)
+			}
+			*destino = valor
+		}
+		impostos = append(impostos, imposto)
+	}
+
+	icms := imp.ICMS
+	switch {
+	case icms.ICMS00 != nil:
+		g := icms.ICMS00
+		adicionar(ImpostoParsed{Tributo: TributoICMS, Grupo: "ICMS00", CST: g.CST}, g.VBC, "", g.PICMS, g.VICMS)
+	case icms.ICMS20 != nil:
+		g := icms.ICMS20
+		adicionar(ImpostoParsed{Tributo: TributoICMS, Grupo: "ICMS20", CST: g.CST}, g.VBC, g.PRedBC, g.PICMS, g.VICMS)
+	case icms.ICMS45 != nil:
+		adicionar(ImpostoParsed{Tributo: TributoICMS, Grupo: "ICMS45", CST: icms.ICMS45.CST})
+	case icms.ICMS60 != nil:
+		g := icms.ICMS60
+		adicionar(ImpostoParsed{Tributo: TributoICMSST, Grupo: "ICMS60", CST: g.CST}, g.VBCSTRet, "", g.PICMSSTRet, g.VICMSSTRet, g.VCred)
+	case icms.ICMS90 != nil:
+		g := icms.ICMS90
+		adicionar(ImpostoParsed{Tributo: TributoICMS, Grupo: "ICMS90", CST: g.CST}, g.VBC, g.PRedBC, g.PICMS, g.VICMS, g.VCred)
+	case icms.ICMSOutraUF != nil:
+		g := icms.ICMSOutraUF
+		adicionar(ImpostoParsed{Tributo: TributoICMS, Grupo: "ICMSOutraUF", CST: g.CST}, g.VBCOutraUF, g.PRedBCOutraUF, g.PICMSOutraUF, g.VICMSOutraUF)
+	case icms.ICMSSN != nil:
+		adicionar(ImpostoParsed{Tributo: TributoICMS, Grupo: "ICMSSN", CST: icms.ICMSSN.CST})
+	}
+
+	if g := imp.ICMSUFFim; g != nil {
+		// Valores da UF de término, da UF de início (alíquota interestadual) e do FCP
+		adicionar(ImpostoParsed{Tributo: TributoICMSUFFim, Grupo: "ICMSUFFim"}, g.VBCUFFim, "", g.PICMSUFFim, g.VICMSUFFim)
+		adicionar(ImpostoParsed{Tributo: TributoICMSUFIni, Grupo: "ICMSUFFim"}, g.VBCUFFim, "", g.PICMSInter, g.VICMSUFIni)
+		adicionar(ImpostoParsed{Tributo: TributoFCPUFFim, Grupo: "ICMSUFFim"}, g.VBCUFFim, "", g.PFCPUFFim, g.VFCPUFFim)
+	}
+
+	if ibscbs := imp.IBSCBS; ibscbs != nil {
+		base := ImpostoParsed{Grupo: "IBSCBS", CST: ibscbs.CST, ClassTrib: ibscbs.CClassTrib}
+		if g := ibscbs.GIBSCBS; g != nil {
+			ibsUF, ibsMun, cbs := base, base, base
+			ibsUF.Tributo, ibsMun.Tributo, cbs.Tributo = TributoIBSUF, TributoIBSMun, TributoCBS
+			adicionar(ibsUF, g.VBC, "", g.GIBSUF.PIBSUF, g.GIBSUF.VIBSUF)
+			adicionar(ibsMun, g.VBC, "", g.GIBSMun.PIBSMun, g.GIBSMun.VIBSMun)
+			adicionar(cbs, g.VBC, "", g.GCBS.PCBS, g.GCBS.VCBS)
+		} else {
+			// Sem o grupo de valores (ex.: isenção), registrar apenas a classificação
+			ibsUF, ibsMun, cbs := base, base, base
+			ibsUF.Tributo, ibsMun.Tributo, cbs.Tributo = TributoIBSUF, TributoIBSMun, TributoCBS
+			adicionar(ibsUF)
+			adicionar(ibsMun)
+			adicionar(cbs)
+		}
+	}
+
+	if erro != nil {
+		return nil, erro
+	}
+	return impostos, nil
+}
+
+// Modalidades de frete, conforme quem paga o serviço
+const (
+	ModalidadeCIF      = "CIF" // remetente ou expedidor
+	ModalidadeFOB      = "FOB" // destinatário ou recebedor
+	ModalidadeTerceiro = "TER" // terceiro (toma4)
+)
+
+// determinarModalidadeFrete determina CIF, FOB ou terceiro baseado no tomador
 func determinarModalidadeFrete(toma string) string {
 	switch toma {
 	case "0", "1": // Remetente ou Expedidor
-		return "CIF"
+		return ModalidadeCIF
 	case "2", "3": // Recebedor ou Destinatário
-		return "FOB"
+		return ModalidadeFOB
+	case "4": // Outros
+		return ModalidadeTerceiro
 	default:
-		return "CIF" // Padrão
+		return ModalidadeCIF // Padrão
 	}
 }
 
@@ -0,0 +1,102 @@
+package parsers
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImpostosCTe(t *testing.T) {
+	var imp ImpCTe
+	require.NoError(t, xml.Unmarshal([]byte(`<imp>
+<ICMS><ICMS20><CST>20</CST><pRedBC>10.00</pRedBC><vBC>900.00</vBC><pICMS>12.00</pICMS><vICMS>108.00</vICMS></ICMS20></ICMS>
+<vTotTrib>250.00</vTotTrib>
+<IBSCBS><CST>000</CST><cClassTrib>000001</cClassTrib><gIBSCBS><vBC>1000.00</vBC>
+<gIBSUF><pIBSUF>0.10</pIBSUF><vIBSUF>1.00</vIBSUF></gIBSUF><gIBSMun><pIBSMun>0.00</pIBSMun><vIBSMun>0.00</vIBSMun></gIBSMun>
+<vIBS>1.00</vIBS><gCBS><pCBS>0.90</pCBS><vCBS>9.00</vCBS></gCBS></gIBSCBS></IBSCBS>
+</imp>`), &imp))
+
+	impostos, err := parseImpostosCTe(imp)
+	require.NoError(t, err)
+	require.Len(t, impostos, 4)
+	assert.Equal(t, ImpostoParsed{Tributo: TributoICMS, Grupo: "ICMS20", CST: "20",
+		BaseCalculo: 900, PercentualReducaoBC: 10, Aliquota: 12, Valor: 108}, impostos[0])
+	assert.Equal(t, TributoIBSUF, impostos[1].Tributo)
+	assert.Equal(t, "000001", impostos[1].ClassTrib)
+	assert.Equal(t, 1.0, impostos[1].Valor)
+	assert.Equal(t, TributoCBS, impostos[3].Tributo)
+	assert.Equal(t, 0.9, impostos[3].Aliquota)
+	assert.Equal(t, 9.0, impostos[3].Valor)
+
+	// ICMS devido à UF de origem e Simples Nacional
+	impostos, err = parseImpostosCTe(ImpCTe{ICMS: ICMS{ICMSOutraUF: &ICMSOutraUF{CST: "90", VBCOutraUF: "500.00", PICMSOutraUF: "7.00", VICMSOutraUF: "35.00"}}})
+	require.NoError(t, err)
+	require.Len(t, impostos, 1)
+	assert.Equal(t, "ICMSOutraUF", impostos[0].Grupo)
+	assert.Equal(t, 35.0, impostos[0].Valor)
+
+	impostos, err = parseImpostosCTe(ImpCTe{ICMS: ICMS{ICMSSN: &ICMSSN{CST: "90", IndSN: "1"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []ImpostoParsed{{Tributo: TributoICMS, Grupo: "ICMSSN", CST: "90"}}, impostos)
+
+	// Partilha do ICMS com a UF de término
+	impostos, err = parseImpostosCTe(ImpCTe{ICMS: ICMS{ICMS00: &ICMS00{CST: "00", VBC: "1000.00", PICMS: "12.00", VICMS: "120.00"}},
+		ICMSUFFim: &ICMSUFFim{VBCUFFim: "1000.00", PFCPUFFim: "2.00", PICMSUFFim: "18.00", PICMSInter: "12.00", VFCPUFFim: "20.00", VICMSUFFim: "60.00", VICMSUFIni: "0.00"}})
+	require.NoError(t, err)
+	require.Len(t, impostos, 4)
+	assert.Equal(t, ImpostoParsed{Tributo: TributoICMSUFFim, Grupo: "ICMSUFFim", BaseCalculo: 1000, Aliquota: 18, Valor: 60}, impostos[1])
+	assert.Equal(t, ImpostoParsed{Tributo: TributoICMSUFIni, Grupo: "ICMSUFFim", BaseCalculo: 1000, Aliquota: 12}, impostos[2])
+	assert.Equal(t, ImpostoParsed{Tributo: TributoFCPUFFim, Grupo: "ICMSUFFim", BaseCalculo: 1000, Aliquota: 2, Valor: 20}, impostos[3])
+
+	// IBSCBS sem valores: a classificação é registrada para cada tributo
+	impostos, err = parseImpostosCTe(ImpCTe{IBSCBS: &IBSCBS{CST: "410", CClassTrib: "410001"}})
+	require.NoError(t, err)
+	require.Len(t, impostos, 3)
+	assert.Equal(t, []string{TributoIBSUF, TributoIBSMun, TributoCBS}, []string{impostos[0].Tributo, impostos[1].Tributo, impostos[2].Tributo})
+	assert.Equal(t, "410001", impostos[1].ClassTrib)
+
+	_, err = parseImpostosCTe(ImpCTe{ICMS: ICMS{ICMS00: &ICMS00{CST: "00", VICMS: "abc"}}})
+	assert.Error(t, err)
+}
+
+const cteTerceiro = `<cteProc xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><CTe><infCte Id="CTe35240112345678000195570010000001231000001236" versao="4.00">
+<ide><cUF>35</cUF><cCT>00000123</cCT><CFOP>6353</CFOP><mod>57</mod><serie>1</serie><nCT>123</nCT><dhEmi>2024-01-10T08:30:00-03:00</dhEmi>
+<tpEmis>1</tpEmis><cDV>6</cDV><UFIni>SP</UFIni><UFFim>RJ</UFFim>{{TOMADOR}}</ide>
+<emit><CNPJ>12345678000195</CNPJ><xNome>TRANSPORTADORA</xNome></emit>
+<rem><CNPJ>11111111000191</CNPJ><xNome>REMETENTE</xNome></rem>
+<exped><CNPJ>33333333000191</CNPJ><xNome>EXPEDIDOR</xNome><enderExped><xMun>CAMPINAS</xMun><UF>SP</UF></enderExped></exped>
+<receb><CNPJ>44444444000191</CNPJ><xNome>RECEBEDOR</xNome></receb>
+<dest><CNPJ>22222222000191</CNPJ><xNome>DESTINATARIO</xNome></dest>
+<vPrest><vTPrest>1500.00</vTPrest></vPrest></infCte></CTe></cteProc>`
+
+func TestParseCTeTomador(t *testing.T) {
+	toma4 := `<toma4><toma>4</toma><CNPJ>55555555000191</CNPJ><xNome>PAGADOR TERCEIRO</xNome><enderToma><UF>MG</UF></enderToma></toma4>`
+	cte, err := ParseCTe([]byte(strings.Replace(cteTerceiro, "{{TOMADOR}}", toma4, 1)))
+	require.NoError(t, err)
+	require.NoError(t, cte.ChaveAcesso.Erro())
+	assert.Equal(t, ModalidadeTerceiro, cte.ModalidadeFrete)
+	assert.Equal(t, "4", cte.TipoTomador)
+	require.NotNil(t, cte.Tomador)
+	assert.Equal(t, "55555555000191", cte.Tomador.CNPJ)
+	assert.Equal(t, "MG", cte.Tomador.UF)
+	require.NotNil(t, cte.Expedidor)
+	assert.Equal(t, "CAMPINAS", cte.Expedidor.Municipio)
+
+	// Expedidor e recebedor tomadores são as próprias partes, não remetente/destinatário
+	cte, err = ParseCTe([]byte(strings.Replace(cteTerceiro, "{{TOMADOR}}", "<toma3><toma>1</toma></toma3>", 1)))
+	require.NoError(t, err)
+	assert.Equal(t, ModalidadeCIF, cte.ModalidadeFrete)
+	assert.Equal(t, "33333333000191", cte.Tomador.CNPJ)
+
+	cte, err = ParseCTe([]byte(strings.Replace(cteTerceiro, "{{TOMADOR}}", "<toma3><toma>2</toma></toma3>", 1)))
+	require.NoError(t, err)
+	assert.Equal(t, ModalidadeFOB, cte.ModalidadeFrete)
+	assert.Equal(t, "44444444000191", cte.Tomador.CNPJ)
+
+	// Tomador "outros" sem o grupo toma4 é rejeitado, sem pânico
+	_, err = ParseCTe([]byte(strings.Replace(cteTerceiro, "{{TOMADOR}}", "<toma3><toma>4</toma></toma3>", 1)))
+	assert.ErrorContains(t, err, "toma4")
+}
@@ -0,0 +1,238 @@
+package parsers
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tipos de serviço do CT-e OS (ide/tpServ)
+const (
+	ServicoTransportePessoas = "6"
+	ServicoTransporteValores = "7"
+	ServicoExcessoBagagem    = "8"
+)
+
+// CTeOSParsed é o resultado do parsing de CT-e OS (modelo 67)
+type CTeOSParsed struct {
+	Chave       string    `json:"chave"`
+	Numero      int       `json:"numero"`
+	Serie       string    `json:"serie"`
+	DataEmissao time.Time `json:"data_emissao"`
+	CFOP        string    `json:"cfop"`
+	Modal       string    `json:"modal"` // 01-rodoviário, 02-aéreo, 03-aquaviário, 04-ferroviário
+	// TipoServico é o tpServ: 6-transporte de pessoas, 7-transporte de valores, 8-excesso de bagagem
+	TipoServico      string  `json:"tipo_servico"`
+	DescricaoServico string  `json:"descricao_servico"`
+	Quantidade       float64 `json:"quantidade"` // passageiros ou volumes (infServico/infQ)
+	ValorTotal       float64 `json:"valor_total"`
+	ValorReceber     float64 `json:"valor_receber"`
+	UFInicio         string  `json:"uf_inicio"`
+	UFDestino        string  `json:"uf_destino"`
+	MunicipioInicio  string  `json:"municipio_inicio"`
+	MunicipioFim     string  `json:"municipio_fim"`
+	Status           string  `json:"status"`
+	Protocolo        string  `json:"protocolo"`
+
+	// Decomposição da chave de acesso e divergências com ide/emit
+	ChaveAcesso *ChaveAcesso `json:"chave_acesso,omitempty"`
+
+	// Entidades
+	Emitente EmpresaParsed  `json:"emitente"`
+	Tomador  *EmpresaParsed `json:"tomador,omitempty"`
+
+	// Modal rodoviário (rodoOS)
+	TAF            string `json:"taf"`
+	NroRegEstadual string `json:"nro_reg_estadual"`
+	PlacaVeiculo   string `json:"placa_veiculo"`
+	TipoFretamento string `json:"tipo_fretamento"` // 1-eventual, 2-contínuo
+
+	ObservacoesGerais string `json:"observacoes_gerais"`
+
+	// Composição do valor da prestação
+	Componentes []ComponenteParsed `json:"componentes,omitempty"`
+
+	// Impostos
+	ValorICMS          float64         `json:"valor_icms"`
+	ValorTotalTributos float64         `json:"valor_total_tributos"`
+	Impostos           []ImpostoParsed `json:"impostos,omitempty"`
+}
+
+// DecodificarCTeOS faz o unmarshal do XML de CT-e OS, aceitando o cteOSProc ou o CTeOS avulso
+func DecodificarCTeOS(xmlContent []byte) (*CTeOSProc, error) {
+	raiz, err := ElementoRaiz(xmlContent)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do XML: %w", err)
+	}
+
+	var cteOSProc CTeOSProc
+	switch raiz {
+	case "cteOSProc":
+		err = xml.Unmarshal(xmlContent, &cteOSProc)
+	case "CTeOS":
+		err = xml.Unmarshal(xmlContent, &cteOSProc.CTeOS)
+	default:
+		return nil, fmt.Errorf("elemento raiz inesperado para CT-e OS: %s", raiz)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do XML: %w", err)
+	}
+
+	return &cteOSProc, nil
+}
+
+// ParseCTeOS faz o parsing de um XML de CT-e OS
+func ParseCTeOS(xmlContent []byte) (*CTeOSParsed, error) {
+	cteOSProc, err := DecodificarCTeOS(xmlContent)
+	if err != nil {
+		return nil, err
+	}
+	inf := cteOSProc.CTeOS.InfCte
+
+	// Validar estrutura básica
+	if inf.Id == "" {
+		return nil, errors.New("XML inválido: ID do CT-e OS não encontrado")
+	}
+
+	// Extrair a chave do ID (remover prefixo "CTe")
+	chave := strings.TrimPrefix(inf.Id, "CTe")
+	chaveAcesso, err := DecomporChaveAcesso(chave)
+	if err != nil {
+		return nil, fmt.Errorf("chave de acesso inválida %s: %w", chave, err)
+	}
+
+	// Parsear data de emissão
+	dataEmissao, err := ParseDate(inf.Ide.DhEmi)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao parsear data de emissão: %w", err)
+	}
+
+	// Parsear número do CT-e OS
+	numero, err := strconv.Atoi(inf.Ide.NCT)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao parsear número do CT-e OS: %w", err)
+	}
+
+	// Parsear valores
+	valorTotal, err := parseFloat(inf.VPrest.VTPrest)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao parsear valor total: %w", err)
+	}
+	valorReceber, err := parseFloat(inf.VPrest.VRec)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao parsear valor a receber: %w", err)
+	}
+	quantidade, err := parseFloat(inf.InfCTeNorm.InfServico.InfQ.QCarga)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao parsear quantidade do serviço: %w", err)
+	}
+
+	result := &CTeOSParsed{
+		Chave:             chave,
+		Numero:            numero,
+		Serie:             inf.Ide.Serie,
+		DataEmissao:       dataEmissao,
+		CFOP:              inf.Ide.CFOP,
+		Modal:             NormalizarModal(inf.Ide.Modal),
+		TipoServico:       inf.Ide.TpServ,
+		DescricaoServico:  strings.TrimSpace(inf.InfCTeNorm.InfServico.XDescServ),
+		Quantidade:        quantidade,
+		ValorTotal:        valorTotal,
+		ValorReceber:      valorReceber,
+		UFInicio:          inf.Ide.UFIni,
+		UFDestino:         inf.Ide.UFFim,
+		MunicipioInicio:   inf.Ide.XMunIni,
+		MunicipioFim:      inf.Ide.XMunFim,
+		ObservacoesGerais: inf.Compl.XObs,
+	}
+
+	// Início e fim da prestação são opcionais (ex.: excesso de bagagem); usar o local de envio
+	if result.UFInicio == "" {
+		result.UFInicio, result.MunicipioInicio = inf.Ide.UFEnv, inf.Ide.XMunEnv
+	}
+	if result.UFDestino == "" {
+		result.UFDestino, result.MunicipioFim = inf.Ide.UFEnv, inf.Ide.XMunEnv
+	}
+
+	// Parsear emitente
+	result.Emitente = EmpresaParsed{
+		CNPJ:        inf.Emit.CNPJ,
+		CPF:         inf.Emit.CPF,
+		RazaoSocial: inf.Emit.XNome,
+		IE:          inf.Emit.IE,
+		UF:          inf.Emit.EnderEmit.UF,
+		Municipio:   inf.Emit.EnderEmit.XMun,
+		CEP:         inf.Emit.EnderEmit.CEP,
+	}
+
+	// Conferir a chave de acesso com a identificação e o emitente
+	chaveAcesso.Conferir(CamposChave{
+		CUF:               inf.Ide.CUF,
+		Modelo:            inf.Ide.Mod,
+		Serie:             inf.Ide.Serie,
+		Numero:            numero,
+		CNPJ:              inf.Emit.CNPJ,
+		CPF:               inf.Emit.CPF,
+		DataEmissao:       dataEmissao,
+		TipoEmissao:       inf.Ide.TpEmis,
+		CodigoNumerico:    inf.Ide.CCT,
+		DigitoVerificador: inf.Ide.CDV,
+	})
+	result.ChaveAcesso = chaveAcesso
+
+	// Parsear tomador do serviço
+	if toma := inf.Toma; toma != nil {
+		result.Tomador = &EmpresaParsed{
+			CNPJ:        toma.CNPJ,
+			CPF:         toma.CPF,
+			RazaoSocial: toma.XNome,
+			IE:          toma.IE,
+			UF:          toma.EnderToma.UF,
+			Municipio:   toma.EnderToma.XMun,
+			CEP:         toma.EnderToma.CEP,
+		}
+	}
+
+	// Informações do protocolo
+	if cteOSProc.ProtCTe.InfProt.CStat != "" {
+		result.Status = cteOSProc.ProtCTe.InfProt.CStat
+		result.Protocolo = cteOSProc.ProtCTe.InfProt.NProt
+	}
+
+	// Modal rodoviário
+	if rodo := inf.InfCTeNorm.InfModal.RodoOS; rodo != nil {
+		result.TAF = rodo.TAF
+		result.NroRegEstadual = rodo.NroRegEstadual
+		if rodo.Veic != nil {
+			result.PlacaVeiculo = rodo.Veic.Placa
+		}
+		if rodo.InfFretamento != nil {
+			result.TipoFretamento = rodo.InfFretamento.TpFretamento
+		}
+	}
+
+	// Componentes do valor da prestação
+	if result.Componentes, err = parseComponentes(inf.VPrest.Comp); err != nil {
+		return nil, err
+	}
+
+	// Impostos
+	impostos, err := parseImpostosCTe(inf.Imp)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao parsear impostos: %w", err)
+	}
+	result.Impostos = impostos
+	for _, imposto := range impostos {
+		if imposto.Tributo == TributoICMS {
+			result.ValorICMS += imposto.Valor
+		}
+	}
+	if result.ValorTotalTributos, err = parseFloat(inf.Imp.VTotTrib); err != nil {
+		return nil, fmt.Errorf("erro ao parsear vTotTrib: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,51 @@
+package parsers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cteOSFretamento = `<?xml version="1.0" encoding="UTF-8"?>
+<cteOSProc xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><CTeOS versao="4.00">
+<infCte Id="CTe35240112345678000195670010000004561000004561" versao="4.00">
+<ide><cUF>35</cUF><cCT>00000456</cCT><CFOP>5357</CFOP><natOp>TRANSPORTE DE PASSAGEIROS</natOp><mod>67</mod><serie>1</serie><nCT>456</nCT>
+<dhEmi>2024-01-20T08:00:00-03:00</dhEmi><tpImp>1</tpImp><tpEmis>1</tpEmis><cDV>1</cDV><tpAmb>2</tpAmb><tpCTe>0</tpCTe>
+<cMunEnv>3550308</cMunEnv><xMunEnv>SAO PAULO</xMunEnv><UFEnv>SP</UFEnv><modal>01</modal><tpServ>6</tpServ><indIEToma>1</indIEToma>
+<cMunIni>3550308</cMunIni><xMunIni>SAO PAULO</xMunIni><UFIni>SP</UFIni><cMunFim>3304557</cMunFim><xMunFim>RIO DE JANEIRO</xMunFim><UFFim>RJ</UFFim></ide>
+<emit><CNPJ>12345678000195</CNPJ><IE>111111111111</IE><xNome>VIACAO TESTE LTDA</xNome><enderEmit><xMun>SAO PAULO</xMun><UF>SP</UF></enderEmit></emit>
+<toma><CNPJ>33333333000191</CNPJ><IE>222222222222</IE><xNome>EMPRESA CONTRATANTE</xNome><enderToma><xMun>CAMPINAS</xMun><UF>SP</UF></enderToma></toma>
+<vPrest><vTPrest>4800.00</vTPrest><vRec>4800.00</vRec><Comp><xNome>FRETAMENTO</xNome><vComp>4500.00</vComp></Comp><Comp><xNome>PEDAGIO</xNome><vComp>300.00</vComp></Comp></vPrest>
+<imp><ICMS><ICMS00><CST>00</CST><vBC>4800.00</vBC><pICMS>12.00</pICMS><vICMS>576.00</vICMS></ICMS00></ICMS><vTotTrib>900.00</vTotTrib></imp>
+<infCTeNorm><infServico><xDescServ>FRETAMENTO EVENTUAL</xDescServ><infQ><qCarga>42.0000</qCarga></infQ></infServico>
+<infModal versaoModal="4.00"><rodoOS><TAF>123456789012</TAF><veic><placa>ABC1D23</placa><UF>SP</UF></veic><infFretamento><tpFretamento>1</tpFretamento></infFretamento></rodoOS></infModal></infCTeNorm>
+</infCte></CTeOS>
+<protCTe versao="4.00"><infProt><chCTe>35240112345678000195670010000004561000004561</chCTe><nProt>135240000000001</nProt><cStat>100</cStat></infProt></protCTe></cteOSProc>`
+
+func TestParseCTeOS(t *testing.T) {
+	descritor, err := DetectarDocumento([]byte(cteOSFretamento))
+	require.NoError(t, err)
+	assert.Equal(t, TipoCTeOS, descritor.Tipo)
+	assert.Equal(t, ModeloCTeOS, descritor.Modelo)
+
+	cteOS, err := ParseCTeOS([]byte(cteOSFretamento))
+	require.NoError(t, err)
+	require.NoError(t, cteOS.ChaveAcesso.Erro())
+
+	assert.Equal(t, 456, cteOS.Numero)
+	assert.Equal(t, ServicoTransportePessoas, cteOS.TipoServico)
+	assert.Equal(t, "FRETAMENTO EVENTUAL", cteOS.DescricaoServico)
+	assert.Equal(t, 42.0, cteOS.Quantidade)
+	assert.Equal(t, 4800.0, cteOS.ValorTotal)
+	assert.Equal(t, "RJ", cteOS.UFDestino)
+	assert.Equal(t, "100", cteOS.Status)
+	require.NotNil(t, cteOS.Tomador)
+	assert.Equal(t, "33333333000191", cteOS.Tomador.CNPJ)
+	assert.Equal(t, "ABC1D23", cteOS.PlacaVeiculo)
+	assert.Equal(t, "1", cteOS.TipoFretamento)
+	require.Len(t, cteOS.Componentes, 2)
+	assert.Equal(t, ComponentePedagio, cteOS.Componentes[1].Tipo)
+	assert.Equal(t, 576.0, cteOS.ValorICMS)
+	assert.Equal(t, 900.0, cteOS.ValorTotalTributos)
+}
@@ -0,0 +1,209 @@
+package parsers
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TipoDocumento identifica o tipo de XML recebido
+type TipoDocumento string
+
+// Tipos de XML reconhecidos
+const (
+	TipoCTe          TipoDocumento = "CTE"
+	TipoCTeOS        TipoDocumento = "CTEOS"
+	TipoGTVe         TipoDocumento = "GTVE"
+	TipoMDFe         TipoDocumento = "MDFE"
+	TipoNFe          TipoDocumento = "NFE"
+	TipoEventoCTe    TipoDocumento = "EVENTO_CTE"
+	TipoEventoMDFe   TipoDocumento = "EVENTO_MDFE"
+	TipoEventoNFe    TipoDocumento = "EVENTO_NFE"
+	TipoConsultaCTe  TipoDocumento = "CONSULTA_CTE"
+	TipoConsultaMDFe TipoDocumento = "CONSULTA_MDFE"
+)
+
+// Modelos de documento fiscal
+const (
+	ModeloNFe   = "55"
+	ModeloCTe   = "57"
+	ModeloMDFe  = "58"
+	ModeloGTVe  = "64"
+	ModeloCTeOS = "67"
+)
+
+// ErrDocumentoDesconhecido indica que o elemento raiz não corresponde a nenhum documento suportado
+var ErrDocumentoDesconhecido = errors.New("tipo de documento não identificado")
+
+// DescritorDocumento descreve o XML a partir do elemento raiz, sem fazer o parse completo
+type DescritorDocumento struct {
+	Tipo TipoDocumento `json:"tipo"`
+	// Modelo do documento fiscal (57, 67, 64, 58 ou 55); nos eventos, o do documento referenciado
+	Modelo string `json:"modelo"`
+	Versao string `json:"versao"`
+	// Processado indica o XML com protocolo de autorização (cteProc, procEventoCTe, ...)
+	Processado bool `json:"processado"`
+	// TipoEvento é o tpEvento dos eventos (ex.: 110111 para cancelamento)
+	TipoEvento string `json:"tipo_evento,omitempty"`
+	Chave      string `json:"chave,omitempty"`
+	Raiz       string `json:"raiz"`
+	Namespace  string `json:"namespace"`
+}
+
+// EhEvento indica se o XML é um evento
+func (d DescritorDocumento) EhEvento() bool {
+	return d.Tipo == TipoEventoCTe || d.Tipo == TipoEventoMDFe || d.Tipo == TipoEventoNFe
+}
+
+// raizDocumento descreve cada elemento raiz aceito
+type raizDocumento struct {
+	tipo       TipoDocumento
+	modelo     string
+	processado bool
+}
+
+// raizesDocumento mapeia o nome local do elemento raiz para o tipo de documento
+var raizesDocumento = map[string]raizDocumento{
+	"CTe":            {TipoCTe, ModeloCTe, false},
+	"cteProc":        {TipoCTe, ModeloCTe, true},
+	"CTeOS":          {TipoCTeOS, ModeloCTeOS, false},
+	"cteOSProc":      {TipoCTeOS, ModeloCTeOS, true},
+	"GTVe":           {TipoGTVe, ModeloGTVe, false},
+	"GTVeProc":       {TipoGTVe, ModeloGTVe, true},
+	"MDFe":           {TipoMDFe, ModeloMDFe, false},
+	"mdfeProc":       {TipoMDFe, ModeloMDFe, true},
+	"NFe":            {TipoNFe, ModeloNFe, false},
+	"nfeProc":        {TipoNFe, ModeloNFe, true},
+	"eventoCTe":      {TipoEventoCTe, ModeloCTe, false},
+	"procEventoCTe":  {TipoEventoCTe, ModeloCTe, true},
+	"eventoMDFe":     {TipoEventoMDFe, ModeloMDFe, false},
+	"procEventoMDFe": {TipoEventoMDFe, ModeloMDFe, true},
+	"evento":         {TipoEventoNFe, ModeloNFe, false},
+	"procEventoNFe":  {TipoEventoNFe, ModeloNFe, true},
+	"retConsSitCTe":  {TipoConsultaCTe, ModeloCTe, false},
+	"retConsSitMDFe": {TipoConsultaMDFe, ModeloMDFe, false},
+}
+
+// elementosChave contém a chave de acesso nos eventos e nas consultas
+var elementosChave = map[string]bool{"chCTe": true, "chMDFe": true, "chNFe": true}
+
+// elementosInformacao têm a chave de acesso no atributo Id (ex.: Id="CTe3519...")
+var elementosInformacao = map[string]bool{"infCte": true, "infCTe": true, "infMDFe": true, "infNFe": true}
+
+// DetectarDocumento identifica o documento lendo os tokens do XML. Prefixos de
+// namespace, comentários e textos que contenham nomes de elementos não afetam o resultado.
+func DetectarDocumento(xmlContent []byte) (*DescritorDocumento, error) {
+	decoder := newDecoder(xmlContent)
+
+	var descritor *DescritorDocumento
+	var caminho []string
+	modeloLido := false
+leitura:
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if descritor != nil {
+				// Conteúdo malformado após o elemento raiz fica para o parser
+				break
+			}
+			return nil, fmt.Errorf("erro ao ler XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if descritor == nil {
+				raiz, ok := raizesDocumento[t.Name.Local]
+				if !ok {
+					return nil, fmt.Errorf("%w: elemento raiz %s", ErrDocumentoDesconhecido, t.Name.Local)
+				}
+				descritor = &DescritorDocumento{
+					Tipo:       raiz.tipo,
+					Modelo:     raiz.modelo,
+					Versao:     atributo(t, "versao"),
+					Processado: raiz.processado,
+					Raiz:       t.Name.Local,
+					Namespace:  t.Name.Space,
+				}
+			} else if elementosInformacao[t.Name.Local] {
+				if descritor.Chave == "" {
+					descritor.Chave = chaveDoID(atributo(t, "Id"))
+				}
+				// Documentos sem protocolo têm a versão apenas no grupo de informações
+				if descritor.Versao == "" {
+					descritor.Versao = atributo(t, "versao")
+				}
+			}
+			caminho = append(caminho, t.Name.Local)
+
+		case xml.EndElement:
+			if len(caminho) > 0 {
+				caminho = caminho[:len(caminho)-1]
+			}
+
+		case xml.CharData:
+			if len(caminho) < 2 {
+				continue
+			}
+			texto := strings.TrimSpace(string(t))
+			elemento, pai := caminho[len(caminho)-1], caminho[len(caminho)-2]
+			switch {
+			case elemento == "mod" && pai == "ide" && !modeloLido:
+				descritor.Modelo = texto
+				modeloLido = true
+			case elemento == "tpEvento" && descritor.TipoEvento == "":
+				descritor.TipoEvento = texto
+			case elementosChave[elemento] && descritor.Chave == "":
+				descritor.Chave = texto
+			}
+		}
+
+		// Interromper a leitura assim que as informações necessárias forem encontradas
+		if descritor != nil && descritor.Chave != "" {
+			switch {
+			case descritor.EhEvento():
+				if descritor.TipoEvento != "" {
+					break leitura
+				}
+			case descritor.Tipo == TipoConsultaCTe || descritor.Tipo == TipoConsultaMDFe:
+				break leitura
+			case modeloLido:
+				break leitura
+			}
+		}
+	}
+
+	if descritor == nil {
+		return nil, fmt.Errorf("%w: elemento raiz não encontrado", ErrDocumentoDesconhecido)
+	}
+
+	// Nos eventos, o modelo do documento referenciado está na chave de acesso
+	if descritor.EhEvento() && len(descritor.Chave) == 44 {
+		descritor.Modelo = descritor.Chave[20:22]
+	}
+
+	return descritor, nil
+}
+
+// atributo retorna o valor do atributo pelo nome local
+func atributo(elemento xml.StartElement, nome string) string {
+	for _, attr := range elemento.Attr {
+		if attr.Name.Local == nome {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// chaveDoID extrai a chave de acesso do atributo Id (prefixo CTe, MDFe, NFe, ...)
+func chaveDoID(id string) string {
+	chave := strings.TrimLeft(id, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+	if len(chave) != 44 {
+		return ""
+	}
+	return chave
+}
@@ -0,0 +1,74 @@
+package parsers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const chaveCTeTeste = "35240112345678000195570010000001231000001230"
+
+func TestDetectarDocumento(t *testing.T) {
+	casos := []struct {
+		nome     string
+		xml      string
+		esperado DescritorDocumento
+	}{
+		{
+			nome: "cteProc",
+			xml:  xmlProcTeste,
+			esperado: DescritorDocumento{Tipo: TipoCTe, Modelo: ModeloCTe, Versao: "4.00", Processado: true,
+				Raiz: "cteProc", Namespace: "http://www.portalfiscal.inf.br/cte"},
+		},
+		{
+			nome: "CT-e com prefixo de namespace",
+			xml: `<cte:CTe xmlns:cte="http://www.portalfiscal.inf.br/cte"><cte:infCte Id="CTe` + chaveCTeTeste + `" versao="4.00">` +
+				`<cte:ide><cte:mod>57</cte:mod></cte:ide></cte:infCte></cte:CTe>`,
+			esperado: DescritorDocumento{Tipo: TipoCTe, Modelo: ModeloCTe, Versao: "4.00", Chave: chaveCTeTeste,
+				Raiz: "CTe", Namespace: "http://www.portalfiscal.inf.br/cte"},
+		},
+		{
+			nome: "comentário antes do elemento raiz",
+			xml:  `<?xml version="1.0"?><!-- <CTe> exportado --><mdfeProc versao="3.00"><MDFe><infMDFe><ide><mod>58</mod></ide></infMDFe></MDFe></mdfeProc>`,
+			esperado: DescritorDocumento{Tipo: TipoMDFe, Modelo: ModeloMDFe, Versao: "3.00", Processado: true,
+				Raiz: "mdfeProc"},
+		},
+		{
+			nome: "evento com texto contendo <CTe",
+			xml: `<eventoCTe versao="4.00"><infEvento><chCTe>` + chaveCTeTeste + `</chCTe><tpEvento>110111</tpEvento>` +
+				`<detEvento><evCancCTe><xJust>&lt;CTe emitido em duplicidade</xJust></evCancCTe></detEvento></infEvento></eventoCTe>`,
+			esperado: DescritorDocumento{Tipo: TipoEventoCTe, Modelo: ModeloCTe, Versao: "4.00", TipoEvento: "110111",
+				Chave: chaveCTeTeste, Raiz: "eventoCTe"},
+		},
+		{
+			nome: "CT-e OS",
+			xml:  `<cteOSProc versao="4.00"><CTeOS><infCte><ide><mod>67</mod></ide></infCte></CTeOS></cteOSProc>`,
+			esperado: DescritorDocumento{Tipo: TipoCTeOS, Modelo: ModeloCTeOS, Versao: "4.00", Processado: true,
+				Raiz: "cteOSProc"},
+		},
+		{
+			nome: "consulta de situação",
+			xml:  `<retConsSitCTe versao="4.00"><cStat>100</cStat><protCTe><infProt><chCTe>` + chaveCTeTeste + `</chCTe></infProt></protCTe></retConsSitCTe>`,
+			esperado: DescritorDocumento{Tipo: TipoConsultaCTe, Modelo: ModeloCTe, Versao: "4.00", Chave: chaveCTeTeste,
+				Raiz: "retConsSitCTe"},
+		},
+		{
+			nome:     "NF-e",
+			xml:      `<nfeProc versao="4.00"><NFe><infNFe><ide><mod>55</mod></ide></infNFe></NFe></nfeProc>`,
+			esperado: DescritorDocumento{Tipo: TipoNFe, Modelo: ModeloNFe, Versao: "4.00", Processado: true, Raiz: "nfeProc"},
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			descritor, err := DetectarDocumento([]byte(caso.xml))
+			require.NoError(t, err)
+			assert.Equal(t, caso.esperado, *descritor)
+		})
+	}
+
+	_, err := DetectarDocumento([]byte(`<!-- <CTe> --><outro/>`))
+	assert.True(t, errors.Is(err, ErrDocumentoDesconhecido))
+}
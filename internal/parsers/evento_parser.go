@@ -3,59 +3,245 @@ package parsers
 import (
 	"encoding/xml"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// ParseEventoCTe faz o parse de um evento de CT-e
+// descricaoEventoCTe mapeia o tpEvento dos eventos de CT-e e CT-e OS
+var descricaoEventoCTe = map[string]string{
+	"110110": "Carta de Correção",
+	"110111": "Cancelamento",
+	"110113": "EPEC",
+	"110160": "Registro Multimodal",
+	"110170": "Informações da GTV",
+	"110180": "Comprovante de Entrega",
+	"110181": "Cancelamento do Comprovante de Entrega",
+	"110190": "Insucesso na Entrega",
+	"110191": "Cancelamento do Insucesso na Entrega",
+	"610110": "Prestação do Serviço em Desacordo",
+	"610111": "Cancelamento da Prestação do Serviço em Desacordo",
+}
+
+// descricaoEventoMDFe mapeia o tpEvento dos eventos de MDF-e
+var descricaoEventoMDFe = map[string]string{
+	"110111": "Cancelamento",
+	"110112": "Encerramento",
+	"110114": "Inclusão de Condutor",
+	"110115": "Inclusão de DF-e",
+	"110116": "Pagamento da Operação de Transporte",
+	"110117": "Confirmação do Serviço de Transporte",
+	"110118": "Alteração do Pagamento do Serviço",
+	"310620": "Registro de Passagem",
+	"510620": "Registro de Passagem Automático",
+}
+
+// EventoParsed resultado do parsing de evento
+type EventoParsed struct {
+	Chave          string     `json:"chave"`
+	TipoEvento     string     `json:"tipo_evento"`
+	TipoEventoDesc string     `json:"tipo_evento_desc"`
+	Sequencia      int        `json:"sequencia"`
+	DataEvento     time.Time  `json:"data_evento"`
+	DataRegistro   *time.Time `json:"data_registro,omitempty"`
+	Autor          string     `json:"autor"` // CNPJ ou CPF do autor do evento
+	Protocolo      string     `json:"protocolo"`
+	ProtocoloRef   string     `json:"protocolo_ref"` // Protocolo referenciado (cancelamento)
+	Status         string     `json:"status"`
+	Motivo         string     `json:"motivo"`
+	Justificativa  string     `json:"justificativa"`
+
+	// Payload é o XML do grupo específico do evento (detEvento)
+	Payload string `json:"payload"`
+
+	// Campos corrigidos pela carta de correção do CT-e
+	Correcoes []CorrecaoParsed `json:"correcoes,omitempty"`
+
+	// Dados dos eventos de MDF-e aplicados ao documento
+	Encerramento        *EncerramentoParsed `json:"encerramento,omitempty"`
+	CondutorIncluido    *CondutorParsed     `json:"condutor_incluido,omitempty"`
+	DocumentosIncluidos []DescargaParsed    `json:"documentos_incluidos,omitempty"`
+}
+
+// EncerramentoParsed data e local do encerramento do MDF-e
+type EncerramentoParsed struct {
+	Data            *time.Time `json:"data,omitempty"`
+	UF              string     `json:"uf"`
+	CodigoMunicipio string     `json:"codigo_municipio"`
+}
+
+// Registrado indica se a SEFAZ registrou o evento (134, 135 ou 136); eventos
+// rejeitados ou sem retorno não alteram o documento
+func (e *EventoParsed) Registrado() bool {
+	return e.Status == "134" || e.Status == "135" || e.Status == "136"
+}
+
+// ParseEventoCTe faz o parse de um evento de CT-e, aceitando o procEventoCTe ou o eventoCTe avulso
 func ParseEventoCTe(xmlContent []byte) (*EventoParsed, error) {
+	raiz, err := ElementoRaiz(xmlContent)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do evento: %w", err)
+	}
+
 	var procEvento ProcEventoCTe
+	switch raiz {
+	case "procEventoCTe":
+		err = xml.Unmarshal(xmlContent, &procEvento)
+	case "eventoCTe":
+		err = xml.Unmarshal(xmlContent, &procEvento.EventoCTe)
+	default:
+		return nil, fmt.Errorf("elemento raiz inesperado para evento de CT-e: %s", raiz)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do evento: %w", err)
+	}
+
+	inf := procEvento.EventoCTe.InfEvento
+	result, err := novoEventoParsed(inf.ChCTe, inf, procEvento.RetEventoCTe.InfEvento, descricaoEventoCTe)
+	if err != nil {
+		return nil, err
+	}
+
+	// Detalhes específicos do evento
+	if canc := inf.DetEvento.EvCancCTe; canc != nil {
+		result.Justificativa = canc.XJust
+		result.ProtocoloRef = canc.NProt
+	}
+	if cce := inf.DetEvento.EvCCeCTe; cce != nil {
+		for _, correcao := range cce.InfCorrecao {
+			// Sem nroItemAlterado, a correção é da primeira ocorrência do grupo
+			item := 1
+			if correcao.NroItemAlterado != "" {
+				if item, err = strconv.Atoi(correcao.NroItemAlterado); err != nil || item < 1 {
+					return nil, fmt.Errorf("item alterado inválido na carta de correção: %s", correcao.NroItemAlterado)
+				}
+			}
+			result.Correcoes = append(result.Correcoes, CorrecaoParsed{
+				Grupo:   correcao.GrupoAlterado,
+				Campo:   correcao.CampoAlterado,
+				Valor:   correcao.ValorAlterado,
+				NroItem: item,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// ParseEventoMDFe faz o parsing de um evento de MDF-e, aceitando o procEventoMDFe ou o eventoMDFe avulso
+func ParseEventoMDFe(xmlContent []byte) (*EventoParsed, error) {
+	raiz, err := ElementoRaiz(xmlContent)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do evento: %w", err)
+	}
 
-	// Tentar fazer unmarshal do XML
-	err := xml.Unmarshal(xmlContent, &procEvento)
+	var procEvento ProcEventoMDFe
+	switch raiz {
+	case "procEventoMDFe":
+		err = xml.Unmarshal(xmlContent, &procEvento)
+	case "eventoMDFe":
+		err = xml.Unmarshal(xmlContent, &procEvento.EventoMDFe)
+	default:
+		return nil, fmt.Errorf("elemento raiz inesperado para evento de MDF-e: %s", raiz)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("erro ao fazer parse do evento: %w", err)
 	}
 
-	// Extrair chave do CT-e
-	chave := procEvento.EventoCTe.InfEvento.ChCTe
+	inf := procEvento.EventoMDFe.InfEvento
+	result, err := novoEventoParsed(inf.ChMDFe, inf, procEvento.RetEventoMDFe.InfEvento, descricaoEventoMDFe)
+	if err != nil {
+		return nil, err
+	}
+
+	// Detalhes específicos do evento
+	det := inf.DetEvento
+	if canc := det.EvCancMDFe; canc != nil {
+		result.Justificativa = canc.XJust
+		result.ProtocoloRef = canc.NProt
+	}
+	if enc := det.EvEncMDFe; enc != nil {
+		result.ProtocoloRef = enc.NProt
+		result.Encerramento = &EncerramentoParsed{
+			Data:            parseDataOpcional(enc.DtEnc),
+			UF:              enc.CUF,
+			CodigoMunicipio: enc.CMun,
+		}
+		if uf := UFMunicipio(enc.CMun); uf != "" {
+			result.Encerramento.UF = uf
+		}
+	}
+	if inc := det.EvIncCondutorMDFe; inc != nil {
+		result.CondutorIncluido = &CondutorParsed{
+			Nome: inc.Condutor.XNome,
+			CPF:  inc.Condutor.CPF,
+		}
+	}
+	if inc := det.EvIncDFeMDFe; inc != nil {
+		result.ProtocoloRef = inc.NProt
+		// Agrupar as NF-es pelo município de descarga, na ordem do evento
+		posicao := make(map[string]int)
+		for _, doc := range inc.InfDoc {
+			i, ok := posicao[doc.CMunDescarga]
+			if !ok {
+				i = len(result.DocumentosIncluidos)
+				posicao[doc.CMunDescarga] = i
+				result.DocumentosIncluidos = append(result.DocumentosIncluidos, DescargaParsed{
+					CodigoMunicipio: doc.CMunDescarga,
+					Municipio:       doc.XMunDescarga,
+					UF:              UFMunicipio(doc.CMunDescarga),
+				})
+			}
+			if doc.ChNFe != "" {
+				result.DocumentosIncluidos[i].ChavesNFe = append(result.DocumentosIncluidos[i].ChavesNFe, doc.ChNFe)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// novoEventoParsed monta os campos comuns aos eventos de CT-e e MDF-e
+func novoEventoParsed(chave string, inf InfEvento, ret InfEventoRet, descricoes map[string]string) (*EventoParsed, error) {
 	if len(chave) != 44 {
 		return nil, fmt.Errorf("chave de acesso inválida: %s", chave)
 	}
 
 	// Parsear data do evento
-	dataEvento, err := ParseDate(procEvento.EventoCTe.InfEvento.DhEvento)
+	dataEvento, err := ParseDate(inf.DhEvento)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao parsear data do evento: %w", err)
 	}
 
-	result := &EventoParsed{
-		Chave:      chave,
-		TipoEvento: procEvento.EventoCTe.InfEvento.TpEvento,
-		Sequencia:  procEvento.EventoCTe.InfEvento.NSeqEvento,
-		DataEvento: dataEvento,
-		Protocolo:  procEvento.RetEventoCTe.InfEvento.NProt,
-		Status:     procEvento.RetEventoCTe.InfEvento.CStat,
-		Motivo:     procEvento.RetEventoCTe.InfEvento.XMotivo,
+	// Sem sequência informada, o evento é o primeiro do tipo
+	sequencia := 1
+	if inf.NSeqEvento != "" {
+		if sequencia, err = strconv.Atoi(inf.NSeqEvento); err != nil {
+			return nil, fmt.Errorf("sequência do evento inválida: %s", inf.NSeqEvento)
+		}
 	}
 
-	// Mapear tipo de evento
-	tipoEventoMap := map[string]string{
-		"110111": "Cancelamento",
-		"110110": "Carta de Correção",
-		"110140": "EPEC",
-		"110170": "Cancelamento por Substituição",
+	result := &EventoParsed{
+		Chave:        chave,
+		TipoEvento:   inf.TpEvento,
+		Sequencia:    sequencia,
+		DataEvento:   dataEvento,
+		DataRegistro: parseDataOpcional(ret.DhRegEvento),
+		Autor:        inf.CNPJ,
+		Protocolo:    ret.NProt,
+		Status:       ret.CStat,
+		Motivo:       ret.XMotivo,
+		Payload:      strings.TrimSpace(inf.DetEvento.Conteudo),
+	}
+	if result.Autor == "" {
+		result.Autor = inf.CPF
 	}
 
-	if desc, ok := tipoEventoMap[result.TipoEvento]; ok {
+	if desc, ok := descricoes[result.TipoEvento]; ok {
 		result.TipoEventoDesc = desc
 	} else {
 		result.TipoEventoDesc = "Evento " + result.TipoEvento
 	}
 
-	// Detalhes específicos do evento
-	if procEvento.EventoCTe.InfEvento.DetEvento.EvCancCTe != nil {
-		result.Justificativa = procEvento.EventoCTe.InfEvento.DetEvento.EvCancCTe.XJust
-		result.ProtocoloRef = procEvento.EventoCTe.InfEvento.DetEvento.EvCancCTe.NProt
-	}
-
 	return result, nil
 }
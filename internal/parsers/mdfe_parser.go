@@ -11,68 +11,156 @@ import (
 
 // MDFeParsed é o resultado do parsing de MDF-e
 type MDFeParsed struct {
-	Chave            string
-	Numero           int
-	Serie            string
-	DataEmissao      time.Time
-	UFInicio         string
-	UFDestino        string
-	MunicipioCarrega string
-	Status           string
-	Protocolo        string
-	Encerrado        bool
-	DataEncerramento *time.Time
+	Chave            string     `json:"chave"`
+	Numero           int        `json:"numero"`
+	Serie            string     `json:"serie"`
+	DataEmissao      time.Time  `json:"data_emissao"`
+	UFInicio         string     `json:"uf_inicio"`
+	UFDestino        string     `json:"uf_destino"`
+	MunicipioCarrega string     `json:"municipio_carrega"`
+	Status           string     `json:"status"`
+	Protocolo        string     `json:"protocolo"`
+	Encerrado        bool       `json:"encerrado"`
+	DataEncerramento *time.Time `json:"data_encerramento,omitempty"`
+	Modal            string     `json:"modal"` // 01-rodoviário, 02-aéreo, 03-aquaviário, 04-ferroviário
+
+	// Decomposição da chave de acesso e divergências com ide/emit
+	ChaveAcesso *ChaveAcesso `json:"chave_acesso,omitempty"`
 
 	// Emitente
-	Emitente EmpresaParsed
+	Emitente EmpresaParsed `json:"emitente"`
 
-	// Veículo
-	PlacaVeiculo string
-	UfVeiculo    string
-	RNTRC        string
-	TaraVeiculo  int
-	CapacidadeKg int
+	// Veículo de tração (resumo de Tracao)
+	PlacaVeiculo string `json:"placa_veiculo"`
+	UfVeiculo    string `json:"uf_veiculo"`
+	RNTRC        string `json:"rntrc"`
+	TaraVeiculo  int    `json:"tara_veiculo"`
+	CapacidadeKg int    `json:"capacidade_kg"`
 
-	// Condutor
-	NomeMotorista string
-	CPFMotorista  string
+	// Primeiro condutor
+	NomeMotorista string `json:"nome_motorista"`
+	CPFMotorista  string `json:"cpf_motorista"`
 
-	// Documentos transportados
-	ChavesCTe []string
-	ChavesNFe []string
+	// Modal rodoviário completo: composição, condutores e contratantes
+	Tracao       *VeiculoParsed      `json:"tracao,omitempty"`
+	Reboques     []VeiculoParsed     `json:"reboques,omitempty"`
+	Condutores   []CondutorParsed    `json:"condutores,omitempty"`
+	Contratantes []ContratanteParsed `json:"contratantes,omitempty"`
+
+	// Modais aéreo, aquaviário e ferroviário
+	DetalheModal DetalheModalParsed `json:"detalhe_modal"`
+
+	// Documentos transportados, de todos os municípios de descarga
+	ChavesCTe []string `json:"chaves_cte,omitempty"`
+	ChavesNFe []string `json:"chaves_nfe,omitempty"`
+
+	// Municípios de descarga na ordem do XML, com os documentos entregues em cada um
+	Descargas    []DescargaParsed `json:"descargas,omitempty"`
+	MunicipioFim string           `json:"municipio_fim"` // último município de descarga
 
 	// Totalizadores
-	QtdCTe          int
-	QtdNFe          int
-	ValorTotalCarga float64
-	PesoBrutoTotal  float64
+	QtdCTe          int     `json:"qtd_cte"`
+	QtdNFe          int     `json:"qtd_nfe"`
+	ValorTotalCarga float64 `json:"valor_total_carga"`
+	PesoBrutoTotal  float64 `json:"peso_bruto_total"`
 
 	// Produto predominante
-	ProdutoPredominante string
-	TipoCarga           string
+	ProdutoPredominante string `json:"produto_predominante"`
+	TipoCarga           string `json:"tipo_carga"`
 
 	// Seguro
-	Seguradoras []SeguradoraParsed
+	Seguradoras []SeguradoraParsed `json:"seguradoras,omitempty"`
 }
 
-// SeguradoraParsed informações da seguradora
+// SeguradoraParsed informações do seguro da carga
 type SeguradoraParsed struct {
-	Nome      string
-	CNPJ      string
-	Apolice   string
-	Averbacao string
+	Responsavel     string   `json:"responsavel"` // 1-emitente do MDF-e, 2-contratante do serviço
+	ResponsavelCNPJ string   `json:"responsavel_cnpj,omitempty"`
+	ResponsavelCPF  string   `json:"responsavel_cpf,omitempty"`
+	Nome            string   `json:"nome"`
+	CNPJ            string   `json:"cnpj"` // CNPJ da seguradora
+	Apolice         string   `json:"apolice"`
+	Averbacoes      []string `json:"averbacoes,omitempty"`
 }
 
-// ParseMDFe faz o parsing de um XML de MDF-e
-func ParseMDFe(xmlContent []byte) (*MDFeParsed, error) {
-	var mdfeProc MDFeProc
+// DescargaParsed município de descarga e os documentos entregues nele
+type DescargaParsed struct {
+	CodigoMunicipio string   `json:"codigo_municipio"`
+	Municipio       string   `json:"municipio"`
+	UF              string   `json:"uf"` // derivada do código IBGE
+	ChavesCTe       []string `json:"chaves_cte,omitempty"`
+	ChavesNFe       []string `json:"chaves_nfe,omitempty"`
+}
+
+// VeiculoParsed veículo de tração ou reboque do modal rodoviário
+type VeiculoParsed struct {
+	CodigoInterno  string              `json:"codigo_interno,omitempty"`
+	Placa          string              `json:"placa"`
+	RENAVAM        string              `json:"renavam,omitempty"`
+	UF             string              `json:"uf"`
+	Tara           int                 `json:"tara"`
+	CapacidadeKg   int                 `json:"capacidade_kg"`
+	CapacidadeM3   int                 `json:"capacidade_m3"`
+	TipoRodado     string              `json:"tipo_rodado,omitempty"` // apenas na tração
+	TipoCarroceria string              `json:"tipo_carroceria"`
+	Proprietario   *ProprietarioParsed `json:"proprietario,omitempty"` // nil quando o veículo é do emitente
+}
+
+// ProprietarioParsed proprietário do veículo de terceiro
+type ProprietarioParsed struct {
+	CNPJ             string `json:"cnpj,omitempty"`
+	CPF              string `json:"cpf,omitempty"`
+	Nome             string `json:"nome"`
+	IE               string `json:"ie,omitempty"`
+	UF               string `json:"uf"`
+	RNTRC            string `json:"rntrc"`
+	TipoProprietario string `json:"tipo_proprietario"` // 0-TAC agregado, 1-TAC independente, 2-outros
+}
+
+// CondutorParsed condutor do veículo de tração
+type CondutorParsed struct {
+	Nome string `json:"nome"`
+	CPF  string `json:"cpf"`
+}
+
+// ContratanteParsed contratante do serviço de transporte (infANTT/infContratante)
+type ContratanteParsed struct {
+	Nome          string `json:"nome,omitempty"`
+	CNPJ          string `json:"cnpj,omitempty"`
+	CPF           string `json:"cpf,omitempty"`
+	IdEstrangeiro string `json:"id_estrangeiro,omitempty"`
+}
 
-	// Tentar fazer unmarshal do XML
-	err := xml.Unmarshal(xmlContent, &mdfeProc)
+// DecodificarMDFe faz o unmarshal do XML de MDF-e, aceitando o mdfeProc ou o MDFe avulso
+func DecodificarMDFe(xmlContent []byte) (*MDFeProc, error) {
+	raiz, err := ElementoRaiz(xmlContent)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao fazer parse do XML: %w", err)
 	}
 
+	var mdfeProc MDFeProc
+	switch raiz {
+	case "mdfeProc":
+		err = xml.Unmarshal(xmlContent, &mdfeProc)
+	case "MDFe":
+		err = xml.Unmarshal(xmlContent, &mdfeProc.MDFe)
+	default:
+		return nil, fmt.Errorf("elemento raiz inesperado para MDF-e: %s", raiz)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do XML: %w", err)
+	}
+
+	return &mdfeProc, nil
+}
+
+// ParseMDFe faz o parsing de um XML de MDF-e
+func ParseMDFe(xmlContent []byte) (*MDFeParsed, error) {
+	mdfeProc, err := DecodificarMDFe(xmlContent)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validar estrutura básica
 	if mdfeProc.MDFe.InfMDFe.Id == "" {
 		return nil, errors.New("XML inválido: ID do MDF-e não encontrado")
@@ -80,8 +168,9 @@ func ParseMDFe(xmlContent []byte) (*MDFeParsed, error) {
 
 	// Extrair a chave do ID (remover prefixo "MDFe")
 	chave := strings.TrimPrefix(mdfeProc.MDFe.InfMDFe.Id, "MDFe")
-	if len(chave) != 44 {
-		return nil, fmt.Errorf("chave de acesso inválida: %s", chave)
+	chaveAcesso, err := DecomporChaveAcesso(chave)
+	if err != nil {
+		return nil, fmt.Errorf("chave de acesso inválida %s: %w", chave, err)
 	}
 
 	// Parsear data de emissão
@@ -98,19 +187,25 @@ func ParseMDFe(xmlContent []byte) (*MDFeParsed, error) {
 
 	// Criar resultado parseado
 	result := &MDFeParsed{
-		Chave:            chave,
-		Numero:           numero,
-		Serie:            mdfeProc.MDFe.InfMDFe.Ide.Serie,
-		DataEmissao:      dataEmissao,
-		UFInicio:         mdfeProc.MDFe.InfMDFe.Ide.UFIni,
-		UFDestino:        mdfeProc.MDFe.InfMDFe.Ide.UFFim,
-		MunicipioCarrega: mdfeProc.MDFe.InfMDFe.Ide.InfMunCarrega.XMunCarrega,
-		Encerrado:        false,
+		Chave:       chave,
+		Numero:      numero,
+		Serie:       mdfeProc.MDFe.InfMDFe.Ide.Serie,
+		DataEmissao: dataEmissao,
+		UFInicio:    mdfeProc.MDFe.InfMDFe.Ide.UFIni,
+		UFDestino:   mdfeProc.MDFe.InfMDFe.Ide.UFFim,
+		Modal:       NormalizarModal(mdfeProc.MDFe.InfMDFe.Ide.Modal),
+		Encerrado:   false,
+	}
+
+	// Município de carregamento (o primeiro informado)
+	if len(mdfeProc.MDFe.InfMDFe.Ide.InfMunCarrega) > 0 {
+		result.MunicipioCarrega = mdfeProc.MDFe.InfMDFe.Ide.InfMunCarrega[0].XMunCarrega
 	}
 
 	// Parsear emitente
 	result.Emitente = EmpresaParsed{
 		CNPJ:        mdfeProc.MDFe.InfMDFe.Emit.CNPJ,
+		CPF:         mdfeProc.MDFe.InfMDFe.Emit.CPF,
 		RazaoSocial: mdfeProc.MDFe.InfMDFe.Emit.XNome,
 		IE:          mdfeProc.MDFe.InfMDFe.Emit.IE,
 		UF:          mdfeProc.MDFe.InfMDFe.Emit.EnderEmit.UF,
@@ -118,50 +213,100 @@ func ParseMDFe(xmlContent []byte) (*MDFeParsed, error) {
 		CEP:         mdfeProc.MDFe.InfMDFe.Emit.EnderEmit.CEP,
 	}
 
+	// Conferir a chave de acesso com a identificação e o emitente
+	chaveAcesso.Conferir(CamposChave{
+		CUF:               mdfeProc.MDFe.InfMDFe.Ide.CUF,
+		Modelo:            mdfeProc.MDFe.InfMDFe.Ide.Mod,
+		Serie:             mdfeProc.MDFe.InfMDFe.Ide.Serie,
+		Numero:            numero,
+		CNPJ:              mdfeProc.MDFe.InfMDFe.Emit.CNPJ,
+		CPF:               mdfeProc.MDFe.InfMDFe.Emit.CPF,
+		DataEmissao:       dataEmissao,
+		TipoEmissao:       mdfeProc.MDFe.InfMDFe.Ide.TpEmis,
+		CodigoNumerico:    mdfeProc.MDFe.InfMDFe.Ide.CMDF,
+		DigitoVerificador: mdfeProc.MDFe.InfMDFe.Ide.CDV,
+	})
+	result.ChaveAcesso = chaveAcesso
+
 	// Informações do protocolo
 	if mdfeProc.ProtMDFe.InfProt.CStat != "" {
 		result.Status = mdfeProc.ProtMDFe.InfProt.CStat
 		result.Protocolo = mdfeProc.ProtMDFe.InfProt.NProt
 	}
 
-	// Informações do veículo
-	if mdfeProc.MDFe.InfMDFe.InfModal.Rodo.VeicTracao.Placa != "" {
-		result.PlacaVeiculo = mdfeProc.MDFe.InfMDFe.InfModal.Rodo.VeicTracao.Placa
-		result.UfVeiculo = mdfeProc.MDFe.InfMDFe.InfModal.Rodo.VeicTracao.UF
+	// Modal rodoviário: composição, RNTRC, condutores e contratantes
+	if rodo := mdfeProc.MDFe.InfMDFe.InfModal.Rodo; rodo != nil {
+		if rodo.VeicTracao.Placa != "" {
+			tracao := parseVeiculoMDFe(rodo.VeicTracao.CInt, rodo.VeicTracao.Placa, rodo.VeicTracao.RENAVAM,
+				rodo.VeicTracao.UF, rodo.VeicTracao.Tara, rodo.VeicTracao.CapKG, rodo.VeicTracao.CapM3,
+				rodo.VeicTracao.TpCar, rodo.VeicTracao.Prop)
+			tracao.TipoRodado = rodo.VeicTracao.TpRod
+			result.Tracao = &tracao
+
+			result.PlacaVeiculo = tracao.Placa
+			result.UfVeiculo = tracao.UF
+			result.TaraVeiculo = tracao.Tara
+			result.CapacidadeKg = tracao.CapacidadeKg
+		}
 
-		// Parsear tara e capacidade
-		if tara, err := strconv.Atoi(mdfeProc.MDFe.InfMDFe.InfModal.Rodo.VeicTracao.Tara); err == nil {
-			result.TaraVeiculo = tara
+		for _, reboque := range rodo.VeicReboque {
+			result.Reboques = append(result.Reboques, parseVeiculoMDFe(reboque.CInt, reboque.Placa, reboque.RENAVAM,
+				reboque.UF, reboque.Tara, reboque.CapKG, reboque.CapM3, reboque.TpCar, reboque.Prop))
 		}
-		if capKg, err := strconv.Atoi(mdfeProc.MDFe.InfMDFe.InfModal.Rodo.VeicTracao.CapKG); err == nil {
-			result.CapacidadeKg = capKg
+
+		result.RNTRC = rodo.InfANTT.RNTRC
+
+		for _, condutor := range rodo.VeicTracao.Condutor {
+			result.Condutores = append(result.Condutores, CondutorParsed{
+				Nome: condutor.XNome,
+				CPF:  condutor.CPF,
+			})
+		}
+		if len(result.Condutores) > 0 {
+			result.NomeMotorista = result.Condutores[0].Nome
+			result.CPFMotorista = result.Condutores[0].CPF
 		}
-	}
 
-	// RNTRC
-	result.RNTRC = mdfeProc.MDFe.InfMDFe.InfModal.Rodo.InfANTT.RNTRC
+		for _, contratante := range rodo.InfANTT.InfContratante {
+			result.Contratantes = append(result.Contratantes, ContratanteParsed{
+				Nome:          contratante.XNome,
+				CNPJ:          contratante.CNPJ,
+				CPF:           contratante.CPF,
+				IdEstrangeiro: contratante.IdEstrangeiro,
+			})
+		}
+	}
 
-	// Condutor
-	if len(mdfeProc.MDFe.InfMDFe.InfModal.Rodo.VeicTracao.Condutor) > 0 {
-		condutor := mdfeProc.MDFe.InfMDFe.InfModal.Rodo.VeicTracao.Condutor[0]
-		result.NomeMotorista = condutor.XNome
-		result.CPFMotorista = condutor.CPF
+	// Demais modais
+	if result.DetalheModal, err = parseModalMDFe(mdfeProc.MDFe.InfMDFe.InfModal); err != nil {
+		return nil, err
 	}
 
-	// Documentos transportados
+	// Documentos transportados, por município de descarga
 	for _, munDescarga := range mdfeProc.MDFe.InfMDFe.InfDoc.InfMunDescarga {
+		descarga := DescargaParsed{
+			CodigoMunicipio: munDescarga.CMunDescarga,
+			Municipio:       munDescarga.XMunDescarga,
+			UF:              UFMunicipio(munDescarga.CMunDescarga),
+		}
 		// CT-es
 		for _, cte := range munDescarga.InfCTe {
 			if cte.ChCTe != "" {
+				descarga.ChavesCTe = append(descarga.ChavesCTe, cte.ChCTe)
 				result.ChavesCTe = append(result.ChavesCTe, cte.ChCTe)
 			}
 		}
 		// NF-es
 		for _, nfe := range munDescarga.InfNFe {
-			if nfe.Chave != "" {
-				result.ChavesNFe = append(result.ChavesNFe, nfe.Chave)
+			if nfe.ChNFe != "" {
+				descarga.ChavesNFe = append(descarga.ChavesNFe, nfe.ChNFe)
+				result.ChavesNFe = append(result.ChavesNFe, nfe.ChNFe)
 			}
 		}
+		result.Descargas = append(result.Descargas, descarga)
+	}
+	if len(result.Descargas) > 0 {
+		result.MunicipioFim = result.Descargas[len(result.Descargas)-1].Municipio
 	}
 
 	// Totalizadores
@@ -182,13 +327,20 @@ func ParseMDFe(xmlContent []byte) (*MDFeParsed, error) {
 	result.ProdutoPredominante = mdfeProc.MDFe.InfMDFe.ProdPred.XProd
 	result.TipoCarga = mdfeProc.MDFe.InfMDFe.ProdPred.TpCarga
 
-	// Seguradoras
+	// Seguradoras, com todas as averbações
 	for _, seg := range mdfeProc.MDFe.InfMDFe.Seg {
 		seguradora := SeguradoraParsed{
-			Nome:      seg.InfSeg.XSeg,
-			CNPJ:      seg.InfResp.CNPJ,
-			Apolice:   seg.NApol,
-			Averbacao: seg.NAver,
+			Responsavel:     seg.InfResp.RespSeg,
+			ResponsavelCNPJ: seg.InfResp.CNPJ,
+			ResponsavelCPF:  seg.InfResp.CPF,
+			Nome:            seg.InfSeg.XSeg,
+			CNPJ:            seg.InfSeg.CNPJ,
+			Apolice:         strings.TrimSpace(seg.NApol),
+		}
+		for _, averbacao := range seg.NAver {
+			if averbacao = strings.TrimSpace(averbacao); averbacao != "" {
+				seguradora.Averbacoes = append(seguradora.Averbacoes, averbacao)
+			}
 		}
 		result.Seguradoras = append(result.Seguradoras, seguradora)
 	}
@@ -196,58 +348,32 @@ func ParseMDFe(xmlContent []byte) (*MDFeParsed, error) {
 	return result, nil
 }
 
-// ParseEventoMDFe faz o parsing de um evento de MDF-e
-func ParseEventoMDFe(xmlContent []byte) (*EventoParsed, error) {
-	var procEvento ProcEventoMDFe
-
-	// Tentar fazer unmarshal do XML
-	err := xml.Unmarshal(xmlContent, &procEvento)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao fazer parse do evento: %w", err)
-	}
-
-	// Extrair chave do MDF-e
-	chave := procEvento.EventoMDFe.InfEvento.ChMDFe
-	if len(chave) != 44 {
-		return nil, fmt.Errorf("chave de acesso inválida: %s", chave)
-	}
-
-	// Parsear data do evento
-	dataEvento, err := ParseDate(procEvento.EventoMDFe.InfEvento.DhEvento)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao parsear data do evento: %w", err)
+// parseVeiculoMDFe monta o veículo de tração ou reboque; tara e capacidades
+// inválidas ficam zeradas
+func parseVeiculoMDFe(codigoInterno, placa, renavam, uf, tara, capKG, capM3, tpCar string, prop Prop) VeiculoParsed {
+	veiculo := VeiculoParsed{
+		CodigoInterno:  codigoInterno,
+		Placa:          placa,
+		RENAVAM:        renavam,
+		UF:             uf,
+		TipoCarroceria: tpCar,
 	}
-
-	result := &EventoParsed{
-		Chave:      chave,
-		TipoEvento: procEvento.EventoMDFe.InfEvento.TpEvento,
-		Sequencia:  procEvento.EventoMDFe.InfEvento.NSeqEvento,
-		DataEvento: dataEvento,
-		Protocolo:  procEvento.RetEventoMDFe.InfEvento.NProt,
-		Status:     procEvento.RetEventoMDFe.InfEvento.CStat,
-		Motivo:     procEvento.RetEventoMDFe.InfEvento.XMotivo,
-	}
-
-	// Detalhes específicos do evento
-	if procEvento.EventoMDFe.InfEvento.DetEvento.EvCancMDFe != nil {
-		result.TipoEventoDesc = "Cancelamento"
-		result.Justificativa = procEvento.EventoMDFe.InfEvento.DetEvento.EvCancMDFe.XJust
-		result.ProtocoloRef = procEvento.EventoMDFe.InfEvento.DetEvento.EvCancMDFe.NProt
+	veiculo.Tara, _ = strconv.Atoi(tara)
+	veiculo.CapacidadeKg, _ = strconv.Atoi(capKG)
+	veiculo.CapacidadeM3, _ = strconv.Atoi(capM3)
+
+	// O grupo prop só é informado quando o veículo não pertence ao emitente
+	if prop.CNPJ != "" || prop.CPF != "" {
+		veiculo.Proprietario = &ProprietarioParsed{
+			CNPJ:             prop.CNPJ,
+			CPF:              prop.CPF,
+			Nome:             prop.XNome,
+			IE:               prop.IE,
+			UF:               prop.UF,
+			RNTRC:            prop.RNTRC,
+			TipoProprietario: prop.TpProp,
+		}
 	}
 
-	return result, nil
-}
-
-// EventoParsed resultado do parsing de evento
-type EventoParsed struct {
-	Chave          string
-	TipoEvento     string
-	TipoEventoDesc string
-	Sequencia      string
-	DataEvento     time.Time
-	Protocolo      string
-	ProtocoloRef   string // Protocolo referenciado (cancelamento)
-	Status         string
-	Motivo         string
-	Justificativa  string
+	return veiculo
 }
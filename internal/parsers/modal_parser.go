@@ -0,0 +1,247 @@
+package parsers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Modais de transporte (ide/modal). O MDF-e informa o código com um dígito.
+const (
+	ModalRodoviario  = "01"
+	ModalAereo       = "02"
+	ModalAquaviario  = "03"
+	ModalFerroviario = "04"
+	ModalDutoviario  = "05"
+	ModalMultimodal  = "06"
+)
+
+// NormalizarModal completa com zero à esquerda o modal de um dígito do MDF-e;
+// sem modal informado, assume o rodoviário
+func NormalizarModal(modal string) string {
+	modal = strings.TrimSpace(modal)
+	switch len(modal) {
+	case 0:
+		return ModalRodoviario
+	case 1:
+		return "0" + modal
+	default:
+		return modal
+	}
+}
+
+// DetalheModalParsed são as informações específicas dos modais não rodoviários
+type DetalheModalParsed struct {
+	Aereo       *ModalAereoParsed       `json:"aereo,omitempty"`
+	Aquaviario  *ModalAquaviarioParsed  `json:"aquaviario,omitempty"`
+	Ferroviario *ModalFerroviarioParsed `json:"ferroviario,omitempty"`
+	Dutoviario  *ModalDutoviarioParsed  `json:"dutoviario,omitempty"`
+	Multimodal  *ModalMultimodalParsed  `json:"multimodal,omitempty"`
+}
+
+// ModalAereoParsed modal aéreo: minuta e tarifa (CT-e) ou voo (MDF-e)
+type ModalAereoParsed struct {
+	NumeroMinuta string     `json:"numero_minuta,omitempty"`
+	NumeroOCA    string     `json:"numero_oca,omitempty"`
+	DataPrevista *time.Time `json:"data_prevista,omitempty"`
+	ClasseTarifa string     `json:"classe_tarifa,omitempty"`
+	CodigoTarifa string     `json:"codigo_tarifa,omitempty"`
+	ValorTarifa  float64    `json:"valor_tarifa,omitempty"`
+
+	Nacionalidade     string     `json:"nacionalidade,omitempty"`
+	Matricula         string     `json:"matricula,omitempty"`
+	NumeroVoo         string     `json:"numero_voo,omitempty"`
+	AeroportoEmbarque string     `json:"aeroporto_embarque,omitempty"`
+	AeroportoDestino  string     `json:"aeroporto_destino,omitempty"`
+	DataVoo           *time.Time `json:"data_voo,omitempty"`
+}
+
+// ModalAquaviarioParsed modal aquaviário
+type ModalAquaviarioParsed struct {
+	Embarcacao       string   `json:"embarcacao"`
+	CodigoEmbarcacao string   `json:"codigo_embarcacao,omitempty"`
+	IRIN             string   `json:"irin"`
+	NumeroViagem     string   `json:"numero_viagem,omitempty"`
+	Direcao          string   `json:"direcao,omitempty"`        // N, S, L, O
+	TipoNavegacao    string   `json:"tipo_navegacao,omitempty"` // 0-interior, 1-cabotagem
+	ValorPrestacao   float64  `json:"valor_prestacao,omitempty"`
+	ValorAFRMM       float64  `json:"valor_afrmm,omitempty"`
+	PortoEmbarque    string   `json:"porto_embarque,omitempty"`
+	PortoDestino     string   `json:"porto_destino,omitempty"`
+	Balsas           []string `json:"balsas,omitempty"`
+	Conteineres      []string `json:"conteineres,omitempty"`
+}
+
+// ModalFerroviarioParsed modal ferroviário: tráfego (CT-e) ou trem e vagões (MDF-e)
+type ModalFerroviarioParsed struct {
+	TipoTrafego            string  `json:"tipo_trafego,omitempty"` // 0-próprio, 1-mútuo, 2-rodoferroviário, 3-rodoviário
+	Fluxo                  string  `json:"fluxo,omitempty"`
+	ResponsavelFaturamento string  `json:"responsavel_faturamento,omitempty"`
+	FerroviaEmitente       string  `json:"ferrovia_emitente,omitempty"`
+	ValorFrete             float64 `json:"valor_frete,omitempty"`
+
+	PrefixoTrem      string     `json:"prefixo_trem,omitempty"`
+	DataHoraTrem     *time.Time `json:"data_hora_trem,omitempty"`
+	Origem           string     `json:"origem,omitempty"`
+	Destino          string     `json:"destino,omitempty"`
+	QuantidadeVagoes int        `json:"quantidade_vagoes,omitempty"`
+	PesoVagoes       float64    `json:"peso_vagoes,omitempty"` // soma do peso real (pesoR), em toneladas
+}
+
+// ModalDutoviarioParsed modal dutoviário
+type ModalDutoviarioParsed struct {
+	ValorTarifa float64    `json:"valor_tarifa"`
+	DataInicio  *time.Time `json:"data_inicio,omitempty"`
+	DataFim     *time.Time `json:"data_fim,omitempty"`
+}
+
+// ModalMultimodalParsed transporte multimodal
+type ModalMultimodalParsed struct {
+	COTM          string `json:"cotm"`
+	IndNegociavel string `json:"ind_negociavel"` // 0-não negociável, 1-negociável
+}
+
+// parseModalCTe extrai as informações específicas dos modais não rodoviários do CT-e
+func parseModalCTe(modal InfModal) (DetalheModalParsed, error) {
+	var detalhe DetalheModalParsed
+
+	if aereo := modal.Aereo; aereo != nil {
+		valorTarifa, err := parseFloat(aereo.Tarifa.VTarifa)
+		if err != nil {
+			return detalhe, fmt.Errorf("erro ao parsear tarifa aérea: %w", err)
+		}
+		detalhe.Aereo = &ModalAereoParsed{
+			NumeroMinuta: aereo.NMinu,
+			NumeroOCA:    aereo.NOCA,
+			DataPrevista: parseDataOpcional(aereo.DPrevAereo),
+			ClasseTarifa: aereo.Tarifa.CL,
+			CodigoTarifa: aereo.Tarifa.CTar,
+			ValorTarifa:  valorTarifa,
+		}
+	}
+
+	if aquav := modal.Aquav; aquav != nil {
+		valorPrestacao, err := parseFloat(aquav.VPrest)
+		if err != nil {
+			return detalhe, fmt.Errorf("erro ao parsear valor da prestação aquaviária: %w", err)
+		}
+		valorAFRMM, err := parseFloat(aquav.VAFRMM)
+		if err != nil {
+			return detalhe, fmt.Errorf("erro ao parsear AFRMM: %w", err)
+		}
+		detalhe.Aquaviario = &ModalAquaviarioParsed{
+			Embarcacao:     aquav.XNavio,
+			IRIN:           aquav.Irin,
+			NumeroViagem:   aquav.NViag,
+			Direcao:        aquav.Direc,
+			TipoNavegacao:  aquav.TpNav,
+			ValorPrestacao: valorPrestacao,
+			ValorAFRMM:     valorAFRMM,
+		}
+		for _, balsa := range aquav.Balsa {
+			detalhe.Aquaviario.Balsas = append(detalhe.Aquaviario.Balsas, balsa.XBalsa)
+		}
+		for _, cont := range aquav.DetCont {
+			detalhe.Aquaviario.Conteineres = append(detalhe.Aquaviario.Conteineres, cont.NCont)
+		}
+	}
+
+	if ferrov := modal.Ferrov; ferrov != nil {
+		detalhe.Ferroviario = &ModalFerroviarioParsed{
+			TipoTrafego: ferrov.TpTraf,
+			Fluxo:       ferrov.Fluxo,
+		}
+		if mut := ferrov.TrafMut; mut != nil {
+			valorFrete, err := parseFloat(mut.VFrete)
+			if err != nil {
+				return detalhe, fmt.Errorf("erro ao parsear frete do tráfego mútuo: %w", err)
+			}
+			detalhe.Ferroviario.ResponsavelFaturamento = mut.RespFat
+			detalhe.Ferroviario.FerroviaEmitente = mut.FerrEmi
+			detalhe.Ferroviario.ValorFrete = valorFrete
+		}
+	}
+
+	if duto := modal.Duto; duto != nil {
+		valorTarifa, err := parseFloat(duto.VTar)
+		if err != nil {
+			return detalhe, fmt.Errorf("erro ao parsear tarifa dutoviária: %w", err)
+		}
+		detalhe.Dutoviario = &ModalDutoviarioParsed{
+			ValorTarifa: valorTarifa,
+			DataInicio:  parseDataOpcional(duto.DIni),
+			DataFim:     parseDataOpcional(duto.DFim),
+		}
+	}
+
+	if multi := modal.Multimodal; multi != nil {
+		detalhe.Multimodal = &ModalMultimodalParsed{
+			COTM:          multi.COTM,
+			IndNegociavel: multi.IndNegociavel,
+		}
+	}
+
+	return detalhe, nil
+}
+
+// parseModalMDFe extrai as informações específicas dos modais não rodoviários do MDF-e
+func parseModalMDFe(modal InfModalMDFe) (DetalheModalParsed, error) {
+	var detalhe DetalheModalParsed
+
+	if aereo := modal.Aereo; aereo != nil {
+		detalhe.Aereo = &ModalAereoParsed{
+			Nacionalidade:     aereo.Nac,
+			Matricula:         aereo.Matr,
+			NumeroVoo:         aereo.NVoo,
+			AeroportoEmbarque: aereo.CAerEmb,
+			AeroportoDestino:  aereo.CAerDes,
+			DataVoo:           parseDataOpcional(aereo.DVoo),
+		}
+	}
+
+	if aquav := modal.Aquav; aquav != nil {
+		detalhe.Aquaviario = &ModalAquaviarioParsed{
+			Embarcacao:       aquav.XEmbar,
+			CodigoEmbarcacao: aquav.CEmbar,
+			IRIN:             aquav.Irin,
+			NumeroViagem:     aquav.NViag,
+			TipoNavegacao:    aquav.TpNav,
+			PortoEmbarque:    aquav.CPrtEmb,
+			PortoDestino:     aquav.CPrtDest,
+		}
+	}
+
+	if ferrov := modal.Ferrov; ferrov != nil {
+		detalhe.Ferroviario = &ModalFerroviarioParsed{
+			PrefixoTrem:      ferrov.Trem.XPref,
+			DataHoraTrem:     parseDataOpcional(ferrov.Trem.DhTrem),
+			Origem:           ferrov.Trem.XOri,
+			Destino:          ferrov.Trem.XDest,
+			QuantidadeVagoes: len(ferrov.Vag),
+		}
+		if qtd, err := strconv.Atoi(ferrov.Trem.QVag); err == nil {
+			detalhe.Ferroviario.QuantidadeVagoes = qtd
+		}
+		for _, vag := range ferrov.Vag {
+			peso, err := parseFloat(vag.PesoR)
+			if err != nil {
+				return detalhe, fmt.Errorf("erro ao parsear peso do vagão %s: %w", vag.NVag, err)
+			}
+			detalhe.Ferroviario.PesoVagoes += peso
+		}
+	}
+
+	return detalhe, nil
+}
+
+// parseDataOpcional aceita data (AAAA-MM-DD) ou data e hora, com ou sem fuso;
+// vazio ou inválido retorna nil
+func parseDataOpcional(valor string) *time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if data, err := time.Parse(layout, valor); err == nil {
+			return &data
+		}
+	}
+	return nil
+}
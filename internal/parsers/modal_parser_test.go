@@ -0,0 +1,39 @@
+package parsers
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizarModal(t *testing.T) {
+	assert.Equal(t, ModalFerroviario, NormalizarModal("4"))
+	assert.Equal(t, ModalDutoviario, NormalizarModal("05"))
+	assert.Equal(t, ModalRodoviario, NormalizarModal(""))
+}
+
+func TestParseModalMDFeFerroviario(t *testing.T) {
+	var modal InfModalMDFe
+	require.NoError(t, xml.Unmarshal([]byte(`<infModal versaoModal="3.00"><ferrov>
+<trem><xPref>PRF1234</xPref><dhTrem>2024-01-10T06:00:00</dhTrem><xOri>ZUM</xOri><xDest>ZEF</xDest><qVag>2</qVag></trem>
+<vag><pesoBC>60.000</pesoBC><pesoR>58.500</pesoR><serie>HFE</serie><nVag>100</nVag></vag>
+<vag><pesoBC>60.000</pesoBC><pesoR>61.250</pesoR><serie>HFE</serie><nVag>101</nVag></vag>
+</ferrov></infModal>`), &modal))
+
+	detalhe, err := parseModalMDFe(modal)
+	require.NoError(t, err)
+	assert.Nil(t, detalhe.Aereo)
+	require.NotNil(t, detalhe.Ferroviario)
+	assert.Equal(t, "PRF1234", detalhe.Ferroviario.PrefixoTrem)
+	require.NotNil(t, detalhe.Ferroviario.DataHoraTrem)
+	assert.Equal(t, 6, detalhe.Ferroviario.DataHoraTrem.Hour())
+	assert.Equal(t, 2, detalhe.Ferroviario.QuantidadeVagoes)
+	assert.InDelta(t, 119.75, detalhe.Ferroviario.PesoVagoes, 0.001)
+
+	// Peso inválido
+	modal.Ferrov.Vag[0].PesoR = "abc"
+	_, err = parseModalMDFe(modal)
+	assert.Error(t, err)
+}
@@ -0,0 +1,20 @@
+package parsers
+
+// siglasUF são as siglas das UFs pelo código IBGE (os dois primeiros dígitos
+// do código do município)
+var siglasUF = map[string]string{
+	"11": "RO", "12": "AC", "13": "AM", "14": "RR", "15": "PA", "16": "AP", "17": "TO",
+	"21": "MA", "22": "PI", "23": "CE", "24": "RN", "25": "PB", "26": "PE", "27": "AL", "28": "SE", "29": "BA",
+	"31": "MG", "32": "ES", "33": "RJ", "35": "SP",
+	"41": "PR", "42": "SC", "43": "RS",
+	"50": "MS", "51": "MT", "52": "GO", "53": "DF",
+}
+
+// UFMunicipio retorna a sigla da UF do município pelo código IBGE; vazio se o
+// código não for reconhecido
+func UFMunicipio(codigoMunicipio string) string {
+	if len(codigoMunicipio) != 7 {
+		return ""
+	}
+	return siglasUF[codigoMunicipio[:2]]
+}
@@ -25,18 +25,22 @@ type CTe struct {
 
 // InfCte contém as informações do CT-e
 type InfCte struct {
-	XMLName    xml.Name   `xml:"infCte"`
-	Versao     string     `xml:"versao,attr"`
-	Id         string     `xml:"Id,attr"`
-	Ide        IdeCTe     `xml:"ide"`
-	Compl      ComplCTe   `xml:"compl"`
-	Emit       Emit       `xml:"emit"`
-	Rem        Rem        `xml:"rem"`
-	Dest       Dest       `xml:"dest"`
-	VPrest     VPrest     `xml:"vPrest"`
-	Imp        ImpCTe     `xml:"imp"`
-	InfCTeNorm InfCTeNorm `xml:"infCTeNorm"`
-	InfRespTec InfRespTec `xml:"infRespTec"`
+	XMLName    xml.Name     `xml:"infCte"`
+	Versao     string       `xml:"versao,attr"`
+	Id         string       `xml:"Id,attr"`
+	Ide        IdeCTe       `xml:"ide"`
+	Compl      ComplCTe     `xml:"compl"`
+	Emit       Emit         `xml:"emit"`
+	Rem        Rem          `xml:"rem"`
+	Exped      *Exped       `xml:"exped"`
+	Receb      *Receb       `xml:"receb"`
+	Dest       Dest         `xml:"dest"`
+	VPrest     VPrest       `xml:"vPrest"`
+	Imp        ImpCTe       `xml:"imp"`
+	InfCTeNorm InfCTeNorm   `xml:"infCTeNorm"`
+	InfCteComp []InfCteComp `xml:"infCteComp"`
+	InfCteAnu  *InfCteAnu   `xml:"infCteAnu"`
+	InfRespTec InfRespTec   `xml:"infRespTec"`
 }
 
 // IdeCTe identificação do CT-e
@@ -70,6 +74,7 @@ type IdeCTe struct {
 	Retira    string `xml:"retira"`
 	IndIEToma string `xml:"indIEToma"`
 	Toma3     Toma3  `xml:"toma3"`
+	Toma4     *Toma4 `xml:"toma4"`
 }
 
 // ComplCTe complemento do CT-e
@@ -88,6 +93,7 @@ type ObsCont struct {
 // Emit emitente
 type Emit struct {
 	CNPJ      string   `xml:"CNPJ"`
+	CPF       string   `xml:"CPF"`
 	IE        string   `xml:"IE"`
 	XNome     string   `xml:"xNome"`
 	XFant     string   `xml:"xFant"`
@@ -101,9 +107,33 @@ type Rem struct {
 	CPF       string   `xml:"CPF"`
 	IE        string   `xml:"IE"`
 	XNome     string   `xml:"xNome"`
+	XFant     string   `xml:"xFant"`
+	Fone      string   `xml:"fone"`
 	EnderReme Endereco `xml:"enderReme"`
 }
 
+// Exped expedidor
+type Exped struct {
+	CNPJ       string   `xml:"CNPJ"`
+	CPF        string   `xml:"CPF"`
+	IE         string   `xml:"IE"`
+	XNome      string   `xml:"xNome"`
+	Fone       string   `xml:"fone"`
+	EnderExped Endereco `xml:"enderExped"`
+	Email      string   `xml:"email"`
+}
+
+// Receb recebedor
+type Receb struct {
+	CNPJ       string   `xml:"CNPJ"`
+	CPF        string   `xml:"CPF"`
+	IE         string   `xml:"IE"`
+	XNome      string   `xml:"xNome"`
+	Fone       string   `xml:"fone"`
+	EnderReceb Endereco `xml:"enderReceb"`
+	Email      string   `xml:"email"`
+}
+
 // Dest destinatário
 type Dest struct {
 	CNPJ      string   `xml:"CNPJ"`
@@ -124,6 +154,7 @@ type Endereco struct {
 	XMun    string `xml:"xMun"`
 	CEP     string `xml:"CEP"`
 	UF      string `xml:"UF"`
+	Fone    string `xml:"fone"`
 	CPais   string `xml:"cPais"`
 	XPais   string `xml:"xPais"`
 	Email   string `xml:"email"`
@@ -144,9 +175,27 @@ type Comp struct {
 
 // InfCTeNorm informações normais do CT-e
 type InfCTeNorm struct {
-	InfCarga InfCarga `xml:"infCarga"`
-	InfDoc   InfDoc   `xml:"infDoc"`
-	InfModal InfModal `xml:"infModal"`
+	InfCarga  InfCarga   `xml:"infCarga"`
+	InfDoc    InfDoc     `xml:"infDoc"`
+	InfModal  InfModal   `xml:"infModal"`
+	InfCteSub *InfCteSub `xml:"infCteSub"`
+}
+
+// InfCteSub CT-e substituído pelo CT-e substituto (tpCTe 3)
+type InfCteSub struct {
+	ChCte         string `xml:"chCte"`
+	IndAlteraToma string `xml:"indAlteraToma"`
+}
+
+// InfCteComp CT-e complementado pelo CT-e complementar (tpCTe 1)
+type InfCteComp struct {
+	ChCTe string `xml:"chCTe"`
+}
+
+// InfCteAnu CT-e anulado pelo CT-e de anulação (tpCTe 2, versão 3.00)
+type InfCteAnu struct {
+	ChCte string `xml:"chCte"`
+	DEmi  string `xml:"dEmi"`
 }
 
 // InfCarga informações da carga
@@ -166,7 +215,25 @@ type InfQ struct {
 
 // InfDoc documentos
 type InfDoc struct {
-	InfNFe []InfNFe `xml:"infNFe"`
+	InfNF     []InfNF     `xml:"infNF"`
+	InfNFe    []InfNFe    `xml:"infNFe"`
+	InfOutros []InfOutros `xml:"infOutros"`
+}
+
+// InfNF nota fiscal em papel (modelos 01 e 04)
+type InfNF struct {
+	NRoma string `xml:"nRoma"`
+	NPed  string `xml:"nPed"`
+	Mod   string `xml:"mod"`
+	Serie string `xml:"serie"`
+	NDoc  string `xml:"nDoc"`
+	DEmi  string `xml:"dEmi"`
+	VBC   string `xml:"vBC"`
+	VICMS string `xml:"vICMS"`
+	VProd string `xml:"vProd"`
+	VNF   string `xml:"vNF"`
+	NCFOP string `xml:"nCFOP"`
+	NPeso string `xml:"nPeso"`
 }
 
 // InfNFe informações da NFe
@@ -174,6 +241,15 @@ type InfNFe struct {
 	Chave string `xml:"chave"`
 }
 
+// InfOutros demais documentos originários (declaração, dutoviário, outros)
+type InfOutros struct {
+	TpDoc      string `xml:"tpDoc"` // 00-declaração, 10-dutoviário, 59-CF-e SAT, 65-NFC-e, 99-outros
+	DescOutros string `xml:"descOutros"`
+	NDoc       string `xml:"nDoc"`
+	DEmi       string `xml:"dEmi"`
+	VDocFisc   string `xml:"vDocFisc"`
+}
+
 // ProtCTe protocolo de autorização
 type ProtCTe struct {
 	InfProt InfProt `xml:"infProt"`
@@ -226,23 +302,24 @@ type InfMDFe struct {
 
 // IdeMDFe identificação do MDF-e
 type IdeMDFe struct {
-	CUF           string        `xml:"cUF"`
-	TpAmb         string        `xml:"tpAmb"`
-	TpEmit        string        `xml:"tpEmit"`
-	TpTransp      string        `xml:"tpTransp"`
-	Mod           string        `xml:"mod"`
-	Serie         string        `xml:"serie"`
-	NMDF          string        `xml:"nMDF"`
-	CMDF          string        `xml:"cMDF"`
-	CDV           string        `xml:"cDV"`
-	Modal         string        `xml:"modal"`
-	DhEmi         string        `xml:"dhEmi"`
-	TpEmis        string        `xml:"tpEmis"`
-	ProcEmi       string        `xml:"procEmi"`
-	VerProc       string        `xml:"verProc"`
-	UFIni         string        `xml:"UFIni"`
-	UFFim         string        `xml:"UFFim"`
-	InfMunCarrega InfMunCarrega `xml:"infMunCarrega"`
+	CUF           string          `xml:"cUF"`
+	TpAmb         string          `xml:"tpAmb"`
+	TpEmit        string          `xml:"tpEmit"`
+	TpTransp      string          `xml:"tpTransp"`
+	Mod           string          `xml:"mod"`
+	Serie         string          `xml:"serie"`
+	NMDF          string          `xml:"nMDF"`
+	CMDF          string          `xml:"cMDF"`
+	CDV           string          `xml:"cDV"`
+	Modal         string          `xml:"modal"`
+	DhEmi         string          `xml:"dhEmi"`
+	TpEmis        string          `xml:"tpEmis"`
+	ProcEmi       string          `xml:"procEmi"`
+	VerProc       string          `xml:"verProc"`
+	UFIni         string          `xml:"UFIni"`
+	UFFim         string          `xml:"UFFim"`
+	InfMunCarrega []InfMunCarrega `xml:"infMunCarrega"`
+	InfPercurso   []InfPercurso   `xml:"infPercurso"`
 }
 
 // InfMunCarrega município de carregamento
@@ -251,16 +328,71 @@ type InfMunCarrega struct {
 	XMunCarrega string `xml:"xMunCarrega"`
 }
 
+// InfPercurso UF de percurso
+type InfPercurso struct {
+	UFPer string `xml:"UFPer"`
+}
+
 // InfModalMDFe modal do MDF-e
 type InfModalMDFe struct {
-	VersaoModal string `xml:"versaoModal,attr"`
-	Rodo        Rodo   `xml:"rodo"`
+	VersaoModal string      `xml:"versaoModal,attr"`
+	Rodo        *Rodo       `xml:"rodo"`
+	Aereo       *AereoMDFe  `xml:"aereo"`
+	Aquav       *AquavMDFe  `xml:"aquav"`
+	Ferrov      *FerrovMDFe `xml:"ferrov"`
+}
+
+// AereoMDFe modal aéreo do MDF-e
+type AereoMDFe struct {
+	Nac     string `xml:"nac"`
+	Matr    string `xml:"matr"`
+	NVoo    string `xml:"nVoo"`
+	CAerEmb string `xml:"cAerEmb"`
+	CAerDes string `xml:"cAerDes"`
+	DVoo    string `xml:"dVoo"`
+}
+
+// AquavMDFe modal aquaviário do MDF-e
+type AquavMDFe struct {
+	Irin     string `xml:"irin"`
+	TpEmb    string `xml:"tpEmb"`
+	CEmbar   string `xml:"cEmbar"`
+	XEmbar   string `xml:"xEmbar"`
+	NViag    string `xml:"nViag"`
+	CPrtEmb  string `xml:"cPrtEmb"`
+	CPrtDest string `xml:"cPrtDest"`
+	TpNav    string `xml:"tpNav"`
+}
+
+// FerrovMDFe modal ferroviário do MDF-e
+type FerrovMDFe struct {
+	Trem Trem  `xml:"trem"`
+	Vag  []Vag `xml:"vag"`
+}
+
+// Trem composição ferroviária
+type Trem struct {
+	XPref  string `xml:"xPref"`
+	DhTrem string `xml:"dhTrem"`
+	XOri   string `xml:"xOri"`
+	XDest  string `xml:"xDest"`
+	QVag   string `xml:"qVag"`
+}
+
+// Vag vagão da composição
+type Vag struct {
+	PesoBC string `xml:"pesoBC"`
+	PesoR  string `xml:"pesoR"`
+	TpVag  string `xml:"tpVag"`
+	Serie  string `xml:"serie"`
+	NVag   string `xml:"nVag"`
 }
 
 // Rodo modal rodoviário
 type Rodo struct {
-	InfANTT    InfANTT    `xml:"infANTT"`
-	VeicTracao VeicTracao `xml:"veicTracao"`
+	InfANTT     InfANTT       `xml:"infANTT"`
+	VeicTracao  VeicTracao    `xml:"veicTracao"`
+	VeicReboque []VeicReboque `xml:"veicReboque"`
 }
 
 // InfANTT informações da ANTT
@@ -271,16 +403,20 @@ type InfANTT struct {
 
 // InfContratante contratante
 type InfContratante struct {
-	CNPJ string `xml:"CNPJ"`
-	CPF  string `xml:"CPF"`
+	XNome         string `xml:"xNome"`
+	CNPJ          string `xml:"CNPJ"`
+	CPF           string `xml:"CPF"`
+	IdEstrangeiro string `xml:"idEstrangeiro"`
 }
 
 // VeicTracao veículo de tração
 type VeicTracao struct {
+	CInt     string     `xml:"cInt"`
 	Placa    string     `xml:"placa"`
 	RENAVAM  string     `xml:"RENAVAM"`
 	Tara     string     `xml:"tara"`
 	CapKG    string     `xml:"capKG"`
+	CapM3    string     `xml:"capM3"`
 	Prop     Prop       `xml:"prop"`
 	Condutor []Condutor `xml:"condutor"`
 	TpRod    string     `xml:"tpRod"`
@@ -288,6 +424,19 @@ type VeicTracao struct {
 	UF       string     `xml:"UF"`
 }
 
+// VeicReboque veículo reboque
+type VeicReboque struct {
+	CInt    string `xml:"cInt"`
+	Placa   string `xml:"placa"`
+	RENAVAM string `xml:"RENAVAM"`
+	Tara    string `xml:"tara"`
+	CapKG   string `xml:"capKG"`
+	CapM3   string `xml:"capM3"`
+	Prop    Prop   `xml:"prop"`
+	TpCar   string `xml:"tpCar"`
+	UF      string `xml:"UF"`
+}
+
 // Prop proprietário
 type Prop struct {
 	CNPJ   string `xml:"CNPJ"`
@@ -312,10 +461,10 @@ type InfDocMDFe struct {
 
 // InfMunDescarga município de descarga
 type InfMunDescarga struct {
-	CMunDescarga string   `xml:"cMunDescarga"`
-	XMunDescarga string   `xml:"xMunDescarga"`
-	InfCTe       []InfCTe `xml:"infCTe"`
-	InfNFe       []InfNFe `xml:"infNFe"`
+	CMunDescarga string       `xml:"cMunDescarga"`
+	XMunDescarga string       `xml:"xMunDescarga"`
+	InfCTe       []InfCTe     `xml:"infCTe"`
+	InfNFe       []InfNFeMDFe `xml:"infNFe"`
 }
 
 // InfCTe CT-e vinculado
@@ -323,12 +472,17 @@ type InfCTe struct {
 	ChCTe string `xml:"chCTe"`
 }
 
+// InfNFeMDFe NF-e vinculada ao MDF-e
+type InfNFeMDFe struct {
+	ChNFe string `xml:"chNFe"`
+}
+
 // Seg seguro
 type Seg struct {
-	InfResp InfResp `xml:"infResp"`
-	InfSeg  InfSeg  `xml:"infSeg"`
-	NApol   string  `xml:"nApol"`
-	NAver   string  `xml:"nAver"`
+	InfResp InfResp  `xml:"infResp"`
+	InfSeg  InfSeg   `xml:"infSeg"`
+	NApol   string   `xml:"nApol"`
+	NAver   []string `xml:"nAver"`
 }
 
 // InfResp responsável pelo seguro
@@ -412,24 +566,29 @@ type InfProtMDFe struct {
 	XMotivo  string `xml:"xMotivo"`
 }
 
-// Signature assinatura digital (simplificado)
+// Signature assinatura digital (apenas marcador; a verificação é feita pelo pacote assinatura)
 type Signature struct {
 	XMLName xml.Name `xml:"Signature"`
 }
 
 // ImpCTe impostos do CT-e
 type ImpCTe struct {
-	ICMS     ICMS   `xml:"ICMS"`
-	VTotTrib string `xml:"vTotTrib"`
+	ICMS       ICMS       `xml:"ICMS"`
+	VTotTrib   string     `xml:"vTotTrib"`
+	InfAdFisco string     `xml:"infAdFisco"`
+	ICMSUFFim  *ICMSUFFim `xml:"ICMSUFFim"`
+	IBSCBS     *IBSCBS    `xml:"IBSCBS"`
 }
 
 // ICMS imposto
 type ICMS struct {
-	ICMS00 *ICMS00 `xml:"ICMS00"`
-	ICMS20 *ICMS20 `xml:"ICMS20"`
-	ICMS45 *ICMS45 `xml:"ICMS45"`
-	ICMS60 *ICMS60 `xml:"ICMS60"`
-	ICMS90 *ICMS90 `xml:"ICMS90"`
+	ICMS00      *ICMS00      `xml:"ICMS00"`
+	ICMS20      *ICMS20      `xml:"ICMS20"`
+	ICMS45      *ICMS45      `xml:"ICMS45"`
+	ICMS60      *ICMS60      `xml:"ICMS60"`
+	ICMS90      *ICMS90      `xml:"ICMS90"`
+	ICMSOutraUF *ICMSOutraUF `xml:"ICMSOutraUF"`
+	ICMSSN      *ICMSSN      `xml:"ICMSSN"`
 }
 
 // ICMS00 tributação normal
@@ -473,10 +632,76 @@ type ICMS90 struct {
 	VCred  string `xml:"vCred"`
 }
 
+// ICMSOutraUF ICMS devido à UF de origem da prestação, quando diferente da UF do emitente
+type ICMSOutraUF struct {
+	CST           string `xml:"CST"`
+	PRedBCOutraUF string `xml:"pRedBCOutraUF"`
+	VBCOutraUF    string `xml:"vBCOutraUF"`
+	PICMSOutraUF  string `xml:"pICMSOutraUF"`
+	VICMSOutraUF  string `xml:"vICMSOutraUF"`
+}
+
+// ICMSSN emitente optante pelo Simples Nacional
+type ICMSSN struct {
+	CST   string `xml:"CST"`
+	IndSN string `xml:"indSN"`
+}
+
+// ICMSUFFim partilha do ICMS entre a UF de início e a UF de término da prestação
+// (EC 87/2015), com o FCP da UF de término
+type ICMSUFFim struct {
+	VBCUFFim   string `xml:"vBCUFFim"`
+	PFCPUFFim  string `xml:"pFCPUFFim"`
+	PICMSUFFim string `xml:"pICMSUFFim"`
+	PICMSInter string `xml:"pICMSInter"`
+	VFCPUFFim  string `xml:"vFCPUFFim"`
+	VICMSUFFim string `xml:"vICMSUFFim"`
+	VICMSUFIni string `xml:"vICMSUFIni"`
+}
+
+// IBSCBS tributação do IBS e da CBS (reforma tributária, NT 2025.001)
+type IBSCBS struct {
+	CST        string   `xml:"CST"`
+	CClassTrib string   `xml:"cClassTrib"`
+	GIBSCBS    *GIBSCBS `xml:"gIBSCBS"`
+}
+
+// GIBSCBS valores do IBS e da CBS
+type GIBSCBS struct {
+	VBC     string  `xml:"vBC"`
+	GIBSUF  GIBSUF  `xml:"gIBSUF"`
+	GIBSMun GIBSMun `xml:"gIBSMun"`
+	VIBS    string  `xml:"vIBS"`
+	GCBS    GCBS    `xml:"gCBS"`
+}
+
+// GIBSUF IBS de competência da UF
+type GIBSUF struct {
+	PIBSUF string `xml:"pIBSUF"`
+	VIBSUF string `xml:"vIBSUF"`
+}
+
+// GIBSMun IBS de competência do município
+type GIBSMun struct {
+	PIBSMun string `xml:"pIBSMun"`
+	VIBSMun string `xml:"vIBSMun"`
+}
+
+// GCBS contribuição sobre bens e serviços
+type GCBS struct {
+	PCBS string `xml:"pCBS"`
+	VCBS string `xml:"vCBS"`
+}
+
 // InfModal informações do modal
 type InfModal struct {
-	VersaoModal string   `xml:"versaoModal,attr"`
-	Rodo        *RodoCTe `xml:"rodo"`
+	VersaoModal string         `xml:"versaoModal,attr"`
+	Rodo        *RodoCTe       `xml:"rodo"`
+	Aereo       *AereoCTe      `xml:"aereo"`
+	Aquav       *AquavCTe      `xml:"aquav"`
+	Ferrov      *FerrovCTe     `xml:"ferrov"`
+	Duto        *DutoCTe       `xml:"duto"`
+	Multimodal  *MultimodalCTe `xml:"multimodal"`
 }
 
 // RodoCTe modal rodoviário do CT-e
@@ -484,11 +709,210 @@ type RodoCTe struct {
 	RNTRC string `xml:"RNTRC"`
 }
 
+// AereoCTe modal aéreo do CT-e
+type AereoCTe struct {
+	NMinu      string      `xml:"nMinu"`
+	NOCA       string      `xml:"nOCA"`
+	DPrevAereo string      `xml:"dPrevAereo"`
+	Tarifa     TarifaAereo `xml:"tarifa"`
+}
+
+// TarifaAereo tarifa do transporte aéreo
+type TarifaAereo struct {
+	CL      string `xml:"CL"`
+	CTar    string `xml:"cTar"`
+	VTarifa string `xml:"vTarifa"`
+}
+
+// AquavCTe modal aquaviário do CT-e
+type AquavCTe struct {
+	VPrest  string    `xml:"vPrest"` // base de cálculo do AFRMM
+	VAFRMM  string    `xml:"vAFRMM"`
+	XNavio  string    `xml:"xNavio"`
+	Balsa   []Balsa   `xml:"balsa"`
+	NViag   string    `xml:"nViag"`
+	Direc   string    `xml:"direc"`
+	Irin    string    `xml:"irin"`
+	DetCont []DetCont `xml:"detCont"`
+	TpNav   string    `xml:"tpNav"`
+}
+
+// Balsa balsa do comboio
+type Balsa struct {
+	XBalsa string `xml:"xBalsa"`
+}
+
+// DetCont contêiner transportado
+type DetCont struct {
+	NCont string `xml:"nCont"`
+}
+
+// FerrovCTe modal ferroviário do CT-e
+type FerrovCTe struct {
+	TpTraf  string   `xml:"tpTraf"`
+	TrafMut *TrafMut `xml:"trafMut"`
+	Fluxo   string   `xml:"fluxo"`
+}
+
+// TrafMut tráfego mútuo entre ferrovias
+type TrafMut struct {
+	RespFat string `xml:"respFat"`
+	FerrEmi string `xml:"ferrEmi"`
+	VFrete  string `xml:"vFrete"`
+}
+
+// DutoCTe modal dutoviário do CT-e
+type DutoCTe struct {
+	VTar string `xml:"vTar"`
+	DIni string `xml:"dIni"`
+	DFim string `xml:"dFim"`
+}
+
+// MultimodalCTe transporte multimodal (Conhecimento de Transporte Multimodal de Cargas)
+type MultimodalCTe struct {
+	COTM          string `xml:"COTM"`
+	IndNegociavel string `xml:"indNegociavel"`
+}
+
 // Toma3 tomador do serviço
 type Toma3 struct {
 	Toma string `xml:"toma"`
 }
 
+// Toma4 tomador do serviço que não é remetente, expedidor, recebedor nem destinatário
+type Toma4 struct {
+	Toma      string   `xml:"toma"`
+	CNPJ      string   `xml:"CNPJ"`
+	CPF       string   `xml:"CPF"`
+	IE        string   `xml:"IE"`
+	XNome     string   `xml:"xNome"`
+	XFant     string   `xml:"xFant"`
+	Fone      string   `xml:"fone"`
+	EnderToma Endereco `xml:"enderToma"`
+	Email     string   `xml:"email"`
+}
+
+// ============ Estruturas para CT-e OS ============
+
+// CTeOSProc representa o processo do CT-e OS (modelo 67) completo
+type CTeOSProc struct {
+	XMLName xml.Name `xml:"cteOSProc"`
+	Versao  string   `xml:"versao,attr"`
+	CTeOS   CTeOS    `xml:"CTeOS"`
+	ProtCTe ProtCTe  `xml:"protCTe"`
+}
+
+// CTeOS representa o CT-e Outros Serviços
+type CTeOS struct {
+	XMLName    xml.Name   `xml:"CTeOS"`
+	InfCte     InfCteOS   `xml:"infCte"`
+	InfCTeSupl InfCTeSupl `xml:"infCTeSupl"`
+	Signature  Signature  `xml:"Signature"`
+}
+
+// InfCteOS contém as informações do CT-e OS
+type InfCteOS struct {
+	XMLName    xml.Name     `xml:"infCte"`
+	Versao     string       `xml:"versao,attr"`
+	Id         string       `xml:"Id,attr"`
+	Ide        IdeCTeOS     `xml:"ide"`
+	Compl      ComplCTe     `xml:"compl"`
+	Emit       Emit         `xml:"emit"`
+	Toma       *TomaCTeOS   `xml:"toma"`
+	VPrest     VPrest       `xml:"vPrest"`
+	Imp        ImpCTe       `xml:"imp"`
+	InfCTeNorm InfCTeNormOS `xml:"infCTeNorm"`
+	InfRespTec InfRespTec   `xml:"infRespTec"`
+}
+
+// IdeCTeOS identificação do CT-e OS
+type IdeCTeOS struct {
+	CUF         string        `xml:"cUF"`
+	CCT         string        `xml:"cCT"`
+	CFOP        string        `xml:"CFOP"`
+	NatOp       string        `xml:"natOp"`
+	Mod         string        `xml:"mod"`
+	Serie       string        `xml:"serie"`
+	NCT         string        `xml:"nCT"`
+	DhEmi       string        `xml:"dhEmi"`
+	TpImp       string        `xml:"tpImp"`
+	TpEmis      string        `xml:"tpEmis"`
+	CDV         string        `xml:"cDV"`
+	TpAmb       string        `xml:"tpAmb"`
+	TpCTe       string        `xml:"tpCTe"`
+	ProcEmi     string        `xml:"procEmi"`
+	VerProc     string        `xml:"verProc"`
+	CMunEnv     string        `xml:"cMunEnv"`
+	XMunEnv     string        `xml:"xMunEnv"`
+	UFEnv       string        `xml:"UFEnv"`
+	Modal       string        `xml:"modal"`
+	TpServ      string        `xml:"tpServ"`
+	IndIEToma   string        `xml:"indIEToma"`
+	CMunIni     string        `xml:"cMunIni"`
+	XMunIni     string        `xml:"xMunIni"`
+	UFIni       string        `xml:"UFIni"`
+	CMunFim     string        `xml:"cMunFim"`
+	XMunFim     string        `xml:"xMunFim"`
+	UFFim       string        `xml:"UFFim"`
+	InfPercurso []InfPercurso `xml:"infPercurso"`
+}
+
+// TomaCTeOS tomador do serviço do CT-e OS
+type TomaCTeOS struct {
+	CNPJ      string   `xml:"CNPJ"`
+	CPF       string   `xml:"CPF"`
+	IE        string   `xml:"IE"`
+	XNome     string   `xml:"xNome"`
+	XFant     string   `xml:"xFant"`
+	Fone      string   `xml:"fone"`
+	EnderToma Endereco `xml:"enderToma"`
+	Email     string   `xml:"email"`
+}
+
+// InfCTeNormOS informações do CT-e OS normal
+type InfCTeNormOS struct {
+	InfServico InfServico `xml:"infServico"`
+	InfModal   InfModalOS `xml:"infModal"`
+}
+
+// InfServico serviço prestado
+type InfServico struct {
+	XDescServ string      `xml:"xDescServ"`
+	InfQ      InfQServico `xml:"infQ"`
+}
+
+// InfQServico quantidade transportada (passageiros ou volumes)
+type InfQServico struct {
+	QCarga string `xml:"qCarga"`
+}
+
+// InfModalOS informações do modal do CT-e OS
+type InfModalOS struct {
+	VersaoModal string  `xml:"versaoModal,attr"`
+	RodoOS      *RodoOS `xml:"rodoOS"`
+}
+
+// RodoOS modal rodoviário do CT-e OS
+type RodoOS struct {
+	TAF            string         `xml:"TAF"`
+	NroRegEstadual string         `xml:"NroRegEstadual"`
+	Veic           *VeicOS        `xml:"veic"`
+	InfFretamento  *InfFretamento `xml:"infFretamento"`
+}
+
+// VeicOS veículo do transporte rodoviário
+type VeicOS struct {
+	Placa   string `xml:"placa"`
+	RENAVAM string `xml:"RENAVAM"`
+	UF      string `xml:"UF"`
+}
+
+// InfFretamento fretamento de passageiros
+type InfFretamento struct {
+	TpFretamento string `xml:"tpFretamento"` // 1-eventual, 2-contínuo
+	DhViagem     string `xml:"dhViagem"`
+}
+
 // ============ Estruturas para Eventos ============
 
 // ProcEventoCTe processo de evento CT-e
@@ -523,9 +947,14 @@ type InfEvento struct {
 
 // DetEvento detalhes do evento
 type DetEvento struct {
-	VersaoEvento string      `xml:"versaoEvento,attr"`
-	EvCancCTe    *EvCancCTe  `xml:"evCancCTe"`
-	EvCancMDFe   *EvCancMDFe `xml:"evCancMDFe"`
+	VersaoEvento      string             `xml:"versaoEvento,attr"`
+	Conteudo          string             `xml:",innerxml"` // XML do grupo específico do evento
+	EvCancCTe         *EvCancCTe         `xml:"evCancCTe"`
+	EvCCeCTe          *EvCCeCTe          `xml:"evCCeCTe"`
+	EvCancMDFe        *EvCancMDFe        `xml:"evCancMDFe"`
+	EvEncMDFe         *EvEncMDFe         `xml:"evEncMDFe"`
+	EvIncCondutorMDFe *EvIncCondutorMDFe `xml:"evIncCondutorMDFe"`
+	EvIncDFeMDFe      *EvIncDFeMDFe      `xml:"evIncDFeMDFe"`
 }
 
 // EvCancCTe cancelamento CT-e
@@ -535,6 +964,21 @@ type EvCancCTe struct {
 	XJust      string `xml:"xJust"`
 }
 
+// EvCCeCTe carta de correção do CT-e
+type EvCCeCTe struct {
+	DescEvento  string        `xml:"descEvento"`
+	InfCorrecao []InfCorrecao `xml:"infCorrecao"`
+	XCondUso    string        `xml:"xCondUso"`
+}
+
+// InfCorrecao campo corrigido pela carta de correção
+type InfCorrecao struct {
+	GrupoAlterado   string `xml:"grupoAlterado"`
+	CampoAlterado   string `xml:"campoAlterado"`
+	ValorAlterado   string `xml:"valorAlterado"`
+	NroItemAlterado string `xml:"nroItemAlterado"`
+}
+
 // EvCancMDFe cancelamento MDF-e
 type EvCancMDFe struct {
 	DescEvento string `xml:"descEvento"`
@@ -542,6 +986,37 @@ type EvCancMDFe struct {
 	XJust      string `xml:"xJust"`
 }
 
+// EvEncMDFe encerramento MDF-e
+type EvEncMDFe struct {
+	DescEvento string `xml:"descEvento"`
+	NProt      string `xml:"nProt"`
+	DtEnc      string `xml:"dtEnc"`
+	CUF        string `xml:"cUF"`
+	CMun       string `xml:"cMun"`
+}
+
+// EvIncCondutorMDFe inclusão de condutor no MDF-e
+type EvIncCondutorMDFe struct {
+	DescEvento string   `xml:"descEvento"`
+	Condutor   Condutor `xml:"condutor"`
+}
+
+// EvIncDFeMDFe inclusão de DF-e (NF-e) no MDF-e
+type EvIncDFeMDFe struct {
+	DescEvento  string      `xml:"descEvento"`
+	NProt       string      `xml:"nProt"`
+	CMunCarrega string      `xml:"cMunCarrega"`
+	XMunCarrega string      `xml:"xMunCarrega"`
+	InfDoc      []InfDocInc `xml:"infDoc"`
+}
+
+// InfDocInc NF-e incluída e o município de descarga
+type InfDocInc struct {
+	CMunDescarga string `xml:"cMunDescarga"`
+	XMunDescarga string `xml:"xMunDescarga"`
+	ChNFe        string `xml:"chNFe"`
+}
+
 // RetEventoCTe retorno do evento
 type RetEventoCTe struct {
 	InfEvento InfEventoRet `xml:"infEvento"`
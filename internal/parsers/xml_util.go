@@ -0,0 +1,185 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// regexEncoding captura o atributo encoding da declaração XML
+var regexEncoding = regexp.MustCompile(`^\s*<\?xml[^>]*encoding\s*=\s*["']([A-Za-z0-9._-]+)["']`)
+
+// regexDeclaracao captura a declaração XML completa
+var regexDeclaracao = regexp.MustCompile(`^\s*<\?xml[^>]*\?>`)
+
+// CharsetXML retorna o charset declarado no XML (padrão utf-8)
+func CharsetXML(xmlContent []byte) string {
+	if m := regexEncoding.FindSubmatch(xmlContent); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	return "utf-8"
+}
+
+// DeclaracaoXML retorna a declaração XML original, se existir
+func DeclaracaoXML(xmlContent []byte) []byte {
+	return bytes.TrimSpace(regexDeclaracao.Find(xmlContent))
+}
+
+// ElementoRaiz retorna o nome local do elemento raiz do XML
+func ElementoRaiz(xmlContent []byte) (string, error) {
+	decoder := newDecoder(xmlContent)
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", errors.New("elemento raiz não encontrado")
+			}
+			return "", fmt.Errorf("erro ao ler XML: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// ExtrairElemento retorna os bytes originais do primeiro elemento com o nome local informado,
+// preservando o conteúdo exatamente como foi recebido. Os namespaces declarados apenas nos
+// ancestrais (como no cteProc) são redeclarados no elemento extraído, para que ele continue
+// válido e com a mesma forma canônica usada na assinatura.
+func ExtrairElemento(xmlContent []byte, nomeLocal string) ([]byte, error) {
+	decoder := newDecoder(xmlContent)
+
+	inicio := int64(-1)
+	profundidade := 0
+	var herdados []xml.Attr
+	var escopos [][]xml.Attr
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("elemento %s não encontrado", nomeLocal)
+			}
+			return nil, fmt.Errorf("erro ao ler XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if inicio < 0 && t.Name.Local == nomeLocal {
+				inicio = offset
+				profundidade = 0
+				herdados = namespacesHerdados(escopos, t.Attr)
+			}
+			if inicio >= 0 {
+				profundidade++
+			} else {
+				escopos = append(escopos, declaracoesNamespace(t.Attr))
+			}
+		case xml.EndElement:
+			if inicio >= 0 {
+				profundidade--
+				if profundidade == 0 {
+					return redeclararNamespaces(xmlContent[inicio:decoder.InputOffset()], herdados), nil
+				}
+			} else if len(escopos) > 0 {
+				escopos = escopos[:len(escopos)-1]
+			}
+		}
+	}
+}
+
+// declaracoesNamespace retorna os atributos xmlns e xmlns:prefixo de um elemento
+func declaracoesNamespace(attrs []xml.Attr) []xml.Attr {
+	var declaracoes []xml.Attr
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			declaracoes = append(declaracoes, attr)
+		}
+	}
+	return declaracoes
+}
+
+// namespacesHerdados retorna as declarações em escopo nos ancestrais que o
+// próprio elemento não redeclara, com o namespace padrão primeiro
+func namespacesHerdados(escopos [][]xml.Attr, proprios []xml.Attr) []xml.Attr {
+	emEscopo := map[string]string{}
+	for _, declaracoes := range escopos {
+		for _, attr := range declaracoes {
+			emEscopo[prefixoDeclarado(attr)] = attr.Value
+		}
+	}
+	for _, attr := range declaracoesNamespace(proprios) {
+		delete(emEscopo, prefixoDeclarado(attr))
+	}
+
+	prefixos := make([]string, 0, len(emEscopo))
+	for prefixo, uri := range emEscopo {
+		// xmlns="" no ancestral não precisa ser repetido na raiz extraída
+		if prefixo == "" && uri == "" {
+			continue
+		}
+		prefixos = append(prefixos, prefixo)
+	}
+	sort.Strings(prefixos)
+
+	herdados := make([]xml.Attr, 0, len(prefixos))
+	for _, prefixo := range prefixos {
+		nome := xml.Name{Local: "xmlns"}
+		if prefixo != "" {
+			nome = xml.Name{Space: "xmlns", Local: prefixo}
+		}
+		herdados = append(herdados, xml.Attr{Name: nome, Value: emEscopo[prefixo]})
+	}
+	return herdados
+}
+
+// prefixoDeclarado retorna o prefixo de uma declaração de namespace ("" para o padrão)
+func prefixoDeclarado(attr xml.Attr) string {
+	if attr.Name.Space == "xmlns" {
+		return attr.Name.Local
+	}
+	return ""
+}
+
+// redeclararNamespaces insere as declarações herdadas logo após o nome da tag inicial
+func redeclararNamespaces(elemento []byte, herdados []xml.Attr) []byte {
+	if len(herdados) == 0 {
+		return elemento
+	}
+	fimNome := bytes.IndexAny(elemento, " \t\r\n/>")
+	if fimNome < 0 {
+		return elemento
+	}
+
+	var declaracoes bytes.Buffer
+	for _, attr := range herdados {
+		declaracoes.WriteString(" xmlns")
+		if attr.Name.Space == "xmlns" {
+			declaracoes.WriteString(":" + attr.Name.Local)
+		}
+		declaracoes.WriteString(`="`)
+		xml.EscapeText(&declaracoes, []byte(attr.Value))
+		declaracoes.WriteString(`"`)
+	}
+
+	resultado := make([]byte, 0, len(elemento)+declaracoes.Len())
+	resultado = append(resultado, elemento[:fimNome]...)
+	resultado = append(resultado, declaracoes.Bytes()...)
+	return append(resultado, elemento[fimNome:]...)
+}
+
+// newDecoder cria um decoder tolerante a charsets declarados diferentes de UTF-8
+func newDecoder(xmlContent []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(xmlContent))
+	decoder.Strict = false
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// Os documentos fiscais são sempre UTF-8; apenas repassar o conteúdo
+		return input, nil
+	}
+	return decoder
+}
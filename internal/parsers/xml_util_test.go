@@ -0,0 +1,45 @@
+package parsers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const xmlProcTeste = `<?xml version="1.0" encoding="UTF-8"?>
+<cteProc xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><CTe xmlns="http://www.portalfiscal.inf.br/cte"><infCte Id="CTe1" versao="4.00"><ide><cUF>35</cUF></ide><compl/></infCte></CTe><protCTe versao="4.00"><infProt><nProt>1</nProt></infProt></protCTe></cteProc>`
+
+func TestExtrairElemento(t *testing.T) {
+	elemento, err := ExtrairElemento([]byte(xmlProcTeste), "CTe")
+	assert.NoError(t, err)
+	assert.Equal(t, `<CTe xmlns="http://www.portalfiscal.inf.br/cte"><infCte Id="CTe1" versao="4.00"><ide><cUF>35</cUF></ide><compl/></infCte></CTe>`, string(elemento))
+
+	_, err = ExtrairElemento([]byte(xmlProcTeste), "MDFe")
+	assert.Error(t, err)
+}
+
+func TestExtrairElementoNamespaceHerdado(t *testing.T) {
+	// Namespaces declarados apenas no cteProc são redeclarados no CTe extraído
+	proc := `<cteProc xmlns="http://www.portalfiscal.inf.br/cte" xmlns:ds="http://www.w3.org/2000/09/xmldsig#" versao="4.00">` +
+		`<CTe><infCte Id="CTe1"/><ds:Signature/></CTe><protCTe/></cteProc>`
+	elemento, err := ExtrairElemento([]byte(proc), "CTe")
+	assert.NoError(t, err)
+	assert.Equal(t, `<CTe xmlns="http://www.portalfiscal.inf.br/cte" xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><infCte Id="CTe1"/><ds:Signature/></CTe>`, string(elemento))
+
+	// Declarações do próprio elemento prevalecem e não são repetidas
+	proc = `<a:proc xmlns:a="urn:a" xmlns="urn:x"><a:doc xmlns="urn:y"><v/></a:doc></a:proc>`
+	elemento, err = ExtrairElemento([]byte(proc), "doc")
+	assert.NoError(t, err)
+	assert.Equal(t, `<a:doc xmlns:a="urn:a" xmlns="urn:y"><v/></a:doc>`, string(elemento))
+}
+
+func TestElementoRaizECharset(t *testing.T) {
+	raiz, err := ElementoRaiz([]byte(xmlProcTeste))
+	assert.NoError(t, err)
+	assert.Equal(t, "cteProc", raiz)
+
+	assert.Equal(t, "utf-8", CharsetXML([]byte(xmlProcTeste)))
+	assert.Equal(t, "iso-8859-1", CharsetXML([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?><CTe/>`)))
+	assert.Equal(t, "utf-8", CharsetXML([]byte(`<CTe/>`)))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`, string(DeclaracaoXML([]byte(xmlProcTeste))))
+}
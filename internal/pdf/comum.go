@@ -0,0 +1,433 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/go-pdf/fpdf"
+)
+
+// Orientações de impressão suportadas
+const (
+	OrientacaoRetrato  = "P"
+	OrientacaoPaisagem = "L"
+)
+
+// Opcoes define os parâmetros de geração dos documentos auxiliares
+type Opcoes struct {
+	// Orientacao P (retrato) ou L (paisagem). Vazio usa o tpImp do próprio XML
+	Orientacao string
+	// Cancelado indica que o documento foi cancelado e deve receber marca d'água
+	Cancelado bool
+	// CartaCorrecao é a sequência da CC-e já aplicada ao XML impresso; zero se não houver
+	CartaCorrecao int
+}
+
+// Celula representa uma caixa rotulada dentro de uma linha do documento
+type Celula struct {
+	Proporcao   float64
+	Rotulo      string
+	Valor       string
+	Alinhamento string
+	Negrito     bool
+}
+
+// documento encapsula o fpdf com o cursor vertical e helpers de layout
+type documento struct {
+	pdf     *fpdf.Fpdf
+	tr      func(string) string
+	margem  float64
+	largura float64
+	altura  float64
+	y       float64
+}
+
+// aliasTotalPaginas é substituído pelo fpdf pelo total de páginas ao finalizar o documento
+const aliasTotalPaginas = "{nb}"
+
+const (
+	margemPadrao     = 5.0
+	fonteRotulo      = 5.5
+	fonteValor       = 7.5
+	alturaLinhaTexto = 3.2
+)
+
+// novoDocumento cria um documento A4 na orientação informada
+func novoDocumento(orientacao, titulo, marcaDagua string) *documento {
+	if orientacao != OrientacaoPaisagem {
+		orientacao = OrientacaoRetrato
+	}
+
+	p := fpdf.New(orientacao, "mm", "A4", "")
+	p.SetMargins(margemPadrao, margemPadrao, margemPadrao)
+	p.SetAutoPageBreak(false, 0)
+	p.AliasNbPages(aliasTotalPaginas)
+	p.SetCreator("Destack Transport API", true)
+	p.SetTitle(titulo, true)
+
+	larguraPagina, alturaPagina := p.GetPageSize()
+	d := &documento{
+		pdf:     p,
+		tr:      p.UnicodeTranslatorFromDescriptor(""),
+		margem:  margemPadrao,
+		largura: larguraPagina - 2*margemPadrao,
+		altura:  alturaPagina - 2*margemPadrao,
+	}
+
+	// A marca d'água é desenhada no rodapé de cada página, sobre o conteúdo
+	if marcaDagua != "" {
+		p.SetFooterFunc(func() {
+			d.desenharMarcaDagua(marcaDagua)
+		})
+	}
+
+	d.novaPagina()
+	return d
+}
+
+// novaPagina adiciona uma página e reposiciona o cursor
+func (d *documento) novaPagina() {
+	d.pdf.AddPage()
+	d.pdf.SetDrawColor(0, 0, 0)
+	d.pdf.SetLineWidth(0.2)
+	d.y = d.margem
+}
+
+// garantirEspaco quebra a página se a altura solicitada não couber
+func (d *documento) garantirEspaco(altura float64) {
+	if d.y+altura > d.margem+d.altura {
+		d.novaPagina()
+	}
+}
+
+// linha desenha uma sequência de células proporcionais ocupando toda a largura
+func (d *documento) linha(altura float64, celulas ...Celula) {
+	d.garantirEspaco(altura)
+
+	total := 0.0
+	for _, c := range celulas {
+		total += c.Proporcao
+	}
+
+	x := d.margem
+	for _, c := range celulas {
+		w := d.largura * c.Proporcao / total
+		d.caixa(x, d.y, w, altura, c)
+		x += w
+	}
+	d.y += altura
+}
+
+// caixa desenha uma célula com rótulo pequeno e valor abaixo
+func (d *documento) caixa(x, y, w, h float64, c Celula) {
+	d.pdf.Rect(x, y, w, h, "D")
+
+	topo := y + 0.5
+	if c.Rotulo != "" {
+		d.pdf.SetFont("Helvetica", "", fonteRotulo)
+		d.pdf.SetXY(x+0.5, topo)
+		d.pdf.CellFormat(w-1, 2.4, d.tr(strings.ToUpper(c.Rotulo)), "", 0, "L", false, 0, "")
+		topo += 2.4
+	}
+
+	if c.Valor == "" {
+		return
+	}
+
+	estilo := ""
+	if c.Negrito {
+		estilo = "B"
+	}
+	alinhamento := c.Alinhamento
+	if alinhamento == "" {
+		alinhamento = "L"
+	}
+
+	d.pdf.SetFont("Helvetica", estilo, fonteValor)
+	linhas := d.quebrarTexto(c.Valor, w-1.5)
+	maxLinhas := int((y + h - topo) / alturaLinhaTexto)
+	if maxLinhas < 1 {
+		maxLinhas = 1
+	}
+	if len(linhas) > maxLinhas {
+		linhas = linhas[:maxLinhas]
+	}
+	for i, l := range linhas {
+		d.pdf.SetXY(x+0.5, topo+float64(i)*alturaLinhaTexto)
+		d.pdf.CellFormat(w-1, alturaLinhaTexto, l, "", 0, alinhamento, false, 0, "")
+	}
+}
+
+// tituloSecao desenha uma faixa cinza com o título de um bloco
+func (d *documento) tituloSecao(titulo string) {
+	d.garantirEspaco(4)
+	d.pdf.SetFillColor(220, 220, 220)
+	d.pdf.Rect(d.margem, d.y, d.largura, 3.5, "FD")
+	d.pdf.SetFont("Helvetica", "B", 6.5)
+	d.pdf.SetXY(d.margem, d.y)
+	d.pdf.CellFormat(d.largura, 3.5, d.tr(strings.ToUpper(titulo)), "", 0, "C", false, 0, "")
+	d.y += 3.5
+}
+
+// texto escreve um texto livre com quebra de linha em uma caixa
+func (d *documento) texto(x, y, w float64, tamanho float64, estilo, alinhamento, conteudo string) float64 {
+	d.pdf.SetFont("Helvetica", estilo, tamanho)
+	alturaLinha := tamanho * 0.42
+	linhas := d.quebrarTexto(conteudo, w)
+	for i, l := range linhas {
+		d.pdf.SetXY(x, y+float64(i)*alturaLinha)
+		d.pdf.CellFormat(w, alturaLinha, l, "", 0, alinhamento, false, 0, "")
+	}
+	return float64(len(linhas)) * alturaLinha
+}
+
+// quebrarTexto divide o texto em linhas que cabem na largura informada,
+// já convertidas para a codificação da fonte
+func (d *documento) quebrarTexto(conteudo string, w float64) []string {
+	// SplitText mede runas pela tabela de larguras da fonte (0-255), então
+	// caracteres fora do Latin-1 são substituídos antes da quebra
+	conteudo = strings.Map(func(r rune) rune {
+		if r > 255 {
+			return '?'
+		}
+		return r
+	}, conteudo)
+
+	linhas := d.pdf.SplitText(conteudo, w)
+	for i, l := range linhas {
+		linhas[i] = d.tr(l)
+	}
+	return linhas
+}
+
+// codigoBarras128 desenha o código de barras Code-128 do conteúdo informado
+func (d *documento) codigoBarras128(x, y, w, h float64, conteudo string) error {
+	codigo, err := code128.Encode(conteudo)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar código de barras: %w", err)
+	}
+	d.desenharModulos(codigo, x, y, w, h, false)
+	return nil
+}
+
+// desenharModulos desenha os módulos escuros de um código de barras 1D ou 2D
+func (d *documento) desenharModulos(codigo barcode.Barcode, x, y, w, h float64, bidimensional bool) {
+	limites := codigo.Bounds()
+	colunas := limites.Dx()
+	linhas := limites.Dy()
+	if !bidimensional {
+		linhas = 1
+	}
+
+	larguraModulo := w / float64(colunas)
+	alturaModulo := h / float64(linhas)
+
+	d.pdf.SetFillColor(0, 0, 0)
+	for l := 0; l < linhas; l++ {
+		for col := 0; col < colunas; col++ {
+			r, _, _, _ := codigo.At(limites.Min.X+col, limites.Min.Y+l).RGBA()
+			if r != 0 {
+				continue
+			}
+			// Agrupar módulos escuros consecutivos em um único retângulo
+			inicio := col
+			for col+1 < colunas {
+				r, _, _, _ = codigo.At(limites.Min.X+col+1, limites.Min.Y+l).RGBA()
+				if r != 0 {
+					break
+				}
+				col++
+			}
+			d.pdf.Rect(x+float64(inicio)*larguraModulo, y+float64(l)*alturaModulo,
+				float64(col-inicio+1)*larguraModulo, alturaModulo, "F")
+		}
+	}
+}
+
+// desenharMarcaDagua escreve um texto diagonal semitransparente no centro da página
+func (d *documento) desenharMarcaDagua(texto string) {
+	larguraPagina, alturaPagina := d.pdf.GetPageSize()
+	cx, cy := larguraPagina/2, alturaPagina/2
+
+	d.pdf.TransformBegin()
+	d.pdf.SetAlpha(0.25, "Normal")
+	d.pdf.TransformRotate(35, cx, cy)
+	d.pdf.SetTextColor(200, 0, 0)
+	d.pdf.SetFont("Helvetica", "B", 42)
+	linhas := strings.Split(texto, "\n")
+	for i, l := range linhas {
+		largura := d.pdf.GetStringWidth(d.tr(l))
+		d.pdf.Text(cx-largura/2, cy+float64(i-len(linhas)/2)*16, d.tr(l))
+	}
+	d.pdf.SetAlpha(1, "Normal")
+	d.pdf.SetTextColor(0, 0, 0)
+	d.pdf.TransformEnd()
+}
+
+// folha retorna a numeração da página corrente sobre o total de páginas (FL)
+func (d *documento) folha() string {
+	return fmt.Sprintf("%d/%s", d.pdf.PageNo(), aliasTotalPaginas)
+}
+
+// bytes finaliza o documento e retorna o conteúdo do PDF
+func (d *documento) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("erro ao gerar PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// textoMarcaDagua define a marca d'água conforme situação e ambiente do documento
+func textoMarcaDagua(cancelado bool, tpAmb string) string {
+	var partes []string
+	if cancelado {
+		partes = append(partes, "CANCELADO")
+	}
+	if tpAmb == "2" {
+		partes = append(partes, "HOMOLOGAÇÃO\nSEM VALOR FISCAL")
+	}
+	return strings.Join(partes, "\n")
+}
+
+// formatarChave agrupa a chave de acesso em blocos de 4 dígitos
+func formatarChave(chave string) string {
+	var grupos []string
+	for i := 0; i < len(chave); i += 4 {
+		fim := i + 4
+		if fim > len(chave) {
+			fim = len(chave)
+		}
+		grupos = append(grupos, chave[i:fim])
+	}
+	return strings.Join(grupos, " ")
+}
+
+// formatarDocumento formata CNPJ ou CPF
+func formatarDocumento(cnpj, cpf string) string {
+	if len(cnpj) == 14 {
+		return fmt.Sprintf("%s.%s.%s/%s-%s", cnpj[0:2], cnpj[2:5], cnpj[5:8], cnpj[8:12], cnpj[12:14])
+	}
+	if len(cpf) == 11 {
+		return fmt.Sprintf("%s.%s.%s-%s", cpf[0:3], cpf[3:6], cpf[6:9], cpf[9:11])
+	}
+	if cnpj != "" {
+		return cnpj
+	}
+	return cpf
+}
+
+// formatarCEP formata o CEP com hífen
+func formatarCEP(cep string) string {
+	if len(cep) == 8 {
+		return cep[0:5] + "-" + cep[5:8]
+	}
+	return cep
+}
+
+// formatarMoeda formata um valor decimal do XML no padrão brasileiro
+func formatarMoeda(valor string) string {
+	if valor == "" {
+		return ""
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(valor), 64)
+	if err != nil {
+		return valor
+	}
+	return formatarNumero(v, 2)
+}
+
+// formatarNumero formata um número com separador de milhar e casas decimais
+func formatarNumero(v float64, casas int) string {
+	s := strconv.FormatFloat(v, 'f', casas, 64)
+	negativo := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	inteiro, decimal := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		inteiro, decimal = s[:i], s[i+1:]
+	}
+
+	var b strings.Builder
+	for i, r := range inteiro {
+		if i > 0 && (len(inteiro)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	if decimal != "" {
+		b.WriteByte(',')
+		b.WriteString(decimal)
+	}
+	if negativo {
+		return "-" + b.String()
+	}
+	return b.String()
+}
+
+// formatarDataHora converte a data/hora do XML para o formato brasileiro
+func formatarDataHora(valor string) string {
+	if valor == "" {
+		return ""
+	}
+	t, err := time.Parse(time.RFC3339, valor)
+	if err != nil {
+		return valor
+	}
+	return t.Format("02/01/2006 15:04:05")
+}
+
+// formatarData converte a data (AAAA-MM-DD) do XML para o formato brasileiro
+func formatarData(valor string) string {
+	t, err := time.Parse("2006-01-02", valor)
+	if err != nil {
+		return valor
+	}
+	return t.Format("02/01/2006")
+}
+
+// enderecoCompleto monta o endereço em uma linha
+func enderecoCompleto(logradouro, numero, complemento, bairro string) string {
+	partes := []string{}
+	if logradouro != "" {
+		l := logradouro
+		if numero != "" {
+			l += ", " + numero
+		}
+		partes = append(partes, l)
+	}
+	if complemento != "" {
+		partes = append(partes, complemento)
+	}
+	if bairro != "" {
+		partes = append(partes, bairro)
+	}
+	return strings.Join(partes, " - ")
+}
+
+// descricao retorna a descrição de um código ou o próprio código se desconhecido
+func descricao(mapa map[string]string, codigo string) string {
+	if d, ok := mapa[codigo]; ok {
+		return d
+	}
+	return codigo
+}
+
+// descricaoModal mapeia o código do modal
+var descricaoModal = map[string]string{
+	"01": "RODOVIÁRIO",
+	"02": "AÉREO",
+	"03": "AQUAVIÁRIO",
+	"04": "FERROVIÁRIO",
+	"05": "DUTOVIÁRIO",
+	"06": "MULTIMODAL",
+	"1":  "RODOVIÁRIO",
+	"2":  "AÉREO",
+	"3":  "AQUAVIÁRIO",
+	"4":  "FERROVIÁRIO",
+}
@@ -0,0 +1,618 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+)
+
+// descricaoTipoCTe mapeia o tpCTe
+var descricaoTipoCTe = map[string]string{
+	"0": "NORMAL",
+	"1": "COMPLEMENTO DE VALORES",
+	"2": "ANULAÇÃO",
+	"3": "SUBSTITUTO",
+}
+
+// descricaoTipoServico mapeia o tpServ
+var descricaoTipoServico = map[string]string{
+	"0": "NORMAL",
+	"1": "SUBCONTRATAÇÃO",
+	"2": "REDESPACHO",
+	"3": "REDESPACHO INTERMEDIÁRIO",
+	"4": "SERVIÇO VINCULADO A MULTIMODAL",
+}
+
+// descricaoTomador mapeia o indicador toma
+var descricaoTomador = map[string]string{
+	"0": "REMETENTE",
+	"1": "EXPEDIDOR",
+	"2": "RECEBEDOR",
+	"3": "DESTINATÁRIO",
+	"4": "OUTROS",
+}
+
+// descricaoUnidade mapeia o cUnid das quantidades de carga
+var descricaoUnidade = map[string]string{
+	"00": "M3",
+	"01": "KG",
+	"02": "TON",
+	"03": "UNIDADE",
+	"04": "LITROS",
+	"05": "MMBTU",
+}
+
+// parteDACTE dados de um participante do CT-e para impressão
+type parteDACTE struct {
+	titulo     string
+	nome       string
+	endereco   string
+	municipio  string
+	uf         string
+	cep        string
+	documento  string
+	ie         string
+	fone       string
+	preenchida bool
+}
+
+// GerarDACTE gera o PDF do DACTE a partir do XML do CT-e
+func GerarDACTE(xmlContent []byte, opcoes Opcoes) ([]byte, error) {
+	cteProc, err := parsers.DecodificarCTe(xmlContent)
+	if err != nil {
+		return nil, err
+	}
+
+	inf := cteProc.CTe.InfCte
+	chave := strings.TrimPrefix(inf.Id, "CTe")
+	if len(chave) != 44 {
+		return nil, fmt.Errorf("chave de acesso inválida: %s", chave)
+	}
+
+	orientacao := opcoes.Orientacao
+	if orientacao == "" && inf.Ide.TpImp == "2" {
+		orientacao = OrientacaoPaisagem
+	}
+
+	d := novoDocumento(orientacao, "DACTE "+chave, textoMarcaDagua(opcoes.Cancelado, inf.Ide.TpAmb))
+
+	if err := d.cabecalhoDACTE(cteProc, chave); err != nil {
+		return nil, err
+	}
+	d.identificacaoDACTE(cteProc)
+	d.partesDACTE(cteProc)
+	d.cargaDACTE(inf)
+	d.componentesDACTE(inf.VPrest)
+	d.impostoDACTE(inf.Imp)
+	d.documentosDACTE(inf.InfCTeNorm.InfDoc)
+	d.observacoesDACTE(inf.Compl, opcoes.CartaCorrecao)
+	d.modalDACTE(inf)
+
+	return d.bytes()
+}
+
+// cabecalhoDACTE desenha emitente, título, código de barras e chave
+func (d *documento) cabecalhoDACTE(cteProc *parsers.CTeProc, chave string) error {
+	inf := cteProc.CTe.InfCte
+	altura := 30.0
+	wEmit := d.largura * 0.40
+	wTitulo := d.largura * 0.18
+	wBarras := d.largura - wEmit - wTitulo
+
+	// Emitente
+	x := d.margem
+	d.pdf.Rect(x, d.y, wEmit, altura, "D")
+	yTexto := d.y + 2
+	yTexto += d.texto(x+1, yTexto, wEmit-2, 9, "B", "C", inf.Emit.XNome) + 1
+	ender := inf.Emit.EnderEmit
+	yTexto += d.texto(x+1, yTexto, wEmit-2, 7, "", "C", enderecoCompleto(ender.XLgr, ender.Nro, ender.XCpl, ender.XBairro))
+	yTexto += d.texto(x+1, yTexto, wEmit-2, 7, "", "C", fmt.Sprintf("%s - %s  CEP: %s", ender.XMun, ender.UF, formatarCEP(ender.CEP)))
+	yTexto += d.texto(x+1, yTexto, wEmit-2, 7, "", "C", fmt.Sprintf("CNPJ: %s  IE: %s", formatarDocumento(inf.Emit.CNPJ, ""), inf.Emit.IE))
+	if ender.Fone != "" {
+		d.texto(x+1, yTexto, wEmit-2, 7, "", "C", "Fone: "+ender.Fone)
+	}
+
+	// Título
+	x += wEmit
+	d.pdf.Rect(x, d.y, wTitulo, altura, "D")
+	d.texto(x+1, d.y+3, wTitulo-2, 12, "B", "C", "DACTE")
+	d.texto(x+1, d.y+9, wTitulo-2, 6.5, "", "C", "Documento Auxiliar do Conhecimento de Transporte Eletrônico")
+	d.texto(x+1, d.y+20, wTitulo-2, 7, "B", "C", "MODAL "+descricao(descricaoModal, inf.Ide.Modal))
+
+	// Código de barras, chave e protocolo
+	x += wTitulo
+	d.pdf.Rect(x, d.y, wBarras, altura, "D")
+	if err := d.codigoBarras128(x+3, d.y+2, wBarras-6, 11, chave); err != nil {
+		return err
+	}
+	d.caixa(x, d.y+14, wBarras, 8, Celula{Rotulo: "Chave de acesso", Valor: formatarChave(chave), Alinhamento: "C", Negrito: true})
+	d.texto(x+1, d.y+23, wBarras-2, 6, "", "C", "Consulta de autenticidade no portal nacional do CT-e ou no site da SEFAZ autorizadora")
+
+	d.y += altura
+
+	// Identificação numérica
+	d.linha(7,
+		Celula{Proporcao: 0.08, Rotulo: "Modelo", Valor: inf.Ide.Mod, Alinhamento: "C"},
+		Celula{Proporcao: 0.07, Rotulo: "Série", Valor: inf.Ide.Serie, Alinhamento: "C"},
+		Celula{Proporcao: 0.12, Rotulo: "Número", Valor: inf.Ide.NCT, Alinhamento: "C", Negrito: true},
+		Celula{Proporcao: 0.06, Rotulo: "FL", Valor: d.folha(), Alinhamento: "C"},
+		Celula{Proporcao: 0.20, Rotulo: "Data e hora de emissão", Valor: formatarDataHora(inf.Ide.DhEmi), Alinhamento: "C"},
+		Celula{Proporcao: 0.47, Rotulo: "Protocolo de autorização de uso", Valor: protocoloDACTE(cteProc.ProtCTe.InfProt), Alinhamento: "C"},
+	)
+	return nil
+}
+
+// protocoloDACTE formata o número e a data do protocolo
+func protocoloDACTE(prot parsers.InfProt) string {
+	if prot.NProt == "" {
+		return ""
+	}
+	return prot.NProt + " - " + formatarDataHora(prot.DhRecbto)
+}
+
+// identificacaoDACTE desenha tipo do CT-e, serviço, CFOP e prestação
+func (d *documento) identificacaoDACTE(cteProc *parsers.CTeProc) {
+	ide := cteProc.CTe.InfCte.Ide
+
+	d.linha(7,
+		Celula{Proporcao: 0.25, Rotulo: "Tipo do CT-e", Valor: descricao(descricaoTipoCTe, ide.TpCTe)},
+		Celula{Proporcao: 0.25, Rotulo: "Tipo do serviço", Valor: descricao(descricaoTipoServico, ide.TpServ)},
+		Celula{Proporcao: 0.50, Rotulo: "CFOP - Natureza da operação", Valor: strings.TrimSpace(ide.CFOP + " - " + ide.NatOp)},
+	)
+	d.linha(7,
+		Celula{Proporcao: 0.5, Rotulo: "Início da prestação", Valor: fmt.Sprintf("%s - %s", ide.XMunIni, ide.UFIni)},
+		Celula{Proporcao: 0.5, Rotulo: "Término da prestação", Valor: fmt.Sprintf("%s - %s", ide.XMunFim, ide.UFFim)},
+	)
+}
+
+// partesDACTE desenha remetente, destinatário, expedidor, recebedor e tomador
+func (d *documento) partesDACTE(cteProc *parsers.CTeProc) {
+	inf := cteProc.CTe.InfCte
+
+	remetente := parteDACTE{
+		titulo:     "Remetente",
+		nome:       inf.Rem.XNome,
+		endereco:   enderecoCompleto(inf.Rem.EnderReme.XLgr, inf.Rem.EnderReme.Nro, inf.Rem.EnderReme.XCpl, inf.Rem.EnderReme.XBairro),
+		municipio:  inf.Rem.EnderReme.XMun,
+		uf:         inf.Rem.EnderReme.UF,
+		cep:        inf.Rem.EnderReme.CEP,
+		documento:  formatarDocumento(inf.Rem.CNPJ, inf.Rem.CPF),
+		ie:         inf.Rem.IE,
+		fone:       inf.Rem.Fone,
+		preenchida: inf.Rem.XNome != "",
+	}
+	destinatario := parteDACTE{
+		titulo:     "Destinatário",
+		nome:       inf.Dest.XNome,
+		endereco:   enderecoCompleto(inf.Dest.EnderDest.XLgr, inf.Dest.EnderDest.Nro, inf.Dest.EnderDest.XCpl, inf.Dest.EnderDest.XBairro),
+		municipio:  inf.Dest.EnderDest.XMun,
+		uf:         inf.Dest.EnderDest.UF,
+		cep:        inf.Dest.EnderDest.CEP,
+		documento:  formatarDocumento(inf.Dest.CNPJ, inf.Dest.CPF),
+		ie:         inf.Dest.IE,
+		fone:       inf.Dest.Fone,
+		preenchida: inf.Dest.XNome != "",
+	}
+
+	d.duasPartes(remetente, destinatario)
+
+	// Expedidor e recebedor, quando informados
+	expedidor := parteDACTE{titulo: "Expedidor"}
+	if exped := inf.Exped; exped != nil {
+		expedidor = parteDACTE{
+			titulo:     "Expedidor",
+			nome:       exped.XNome,
+			endereco:   enderecoCompleto(exped.EnderExped.XLgr, exped.EnderExped.Nro, exped.EnderExped.XCpl, exped.EnderExped.XBairro),
+			municipio:  exped.EnderExped.XMun,
+			uf:         exped.EnderExped.UF,
+			cep:        exped.EnderExped.CEP,
+			documento:  formatarDocumento(exped.CNPJ, exped.CPF),
+			ie:         exped.IE,
+			fone:       exped.Fone,
+			preenchida: exped.XNome != "",
+		}
+	}
+	recebedor := parteDACTE{titulo: "Recebedor"}
+	if receb := inf.Receb; receb != nil {
+		recebedor = parteDACTE{
+			titulo:     "Recebedor",
+			nome:       receb.XNome,
+			endereco:   enderecoCompleto(receb.EnderReceb.XLgr, receb.EnderReceb.Nro, receb.EnderReceb.XCpl, receb.EnderReceb.XBairro),
+			municipio:  receb.EnderReceb.XMun,
+			uf:         receb.EnderReceb.UF,
+			cep:        receb.EnderReceb.CEP,
+			documento:  formatarDocumento(receb.CNPJ, receb.CPF),
+			ie:         receb.IE,
+			fone:       receb.Fone,
+			preenchida: receb.XNome != "",
+		}
+	}
+	if expedidor.preenchida || recebedor.preenchida {
+		d.duasPartes(expedidor, recebedor)
+	}
+
+	// Tomador do serviço
+	toma := inf.Ide.Toma3.Toma
+	tomador := remetente
+	switch {
+	case inf.Ide.Toma4 != nil:
+		toma = inf.Ide.Toma4.Toma
+		tomador = parteDACTE{
+			nome:      inf.Ide.Toma4.XNome,
+			documento: formatarDocumento(inf.Ide.Toma4.CNPJ, inf.Ide.Toma4.CPF),
+			ie:        inf.Ide.Toma4.IE,
+		}
+	case toma == "1" && expedidor.preenchida:
+		tomador = expedidor
+	case toma == "2" && recebedor.preenchida:
+		tomador = recebedor
+	case toma == "2", toma == "3":
+		tomador = destinatario
+	}
+	d.linha(7,
+		Celula{Proporcao: 0.2, Rotulo: "Tomador do serviço", Valor: descricao(descricaoTomador, toma)},
+		Celula{Proporcao: 0.45, Rotulo: "Nome/Razão social", Valor: tomador.nome},
+		Celula{Proporcao: 0.2, Rotulo: "CNPJ/CPF", Valor: tomador.documento},
+		Celula{Proporcao: 0.15, Rotulo: "Inscrição estadual", Valor: tomador.ie},
+	)
+}
+
+// duasPartes desenha dois participantes lado a lado
+func (d *documento) duasPartes(esquerda, direita parteDACTE) {
+	altura := 20.0
+	d.garantirEspaco(altura)
+	metade := d.largura / 2
+	d.parte(d.margem, d.y, metade, altura, esquerda)
+	d.parte(d.margem+metade, d.y, metade, altura, direita)
+	d.y += altura
+}
+
+// parte desenha o bloco de um participante
+func (d *documento) parte(x, y, w, h float64, p parteDACTE) {
+	d.pdf.Rect(x, y, w, h, "D")
+	d.pdf.SetFont("Helvetica", "", fonteRotulo)
+	d.pdf.SetXY(x+0.5, y+0.5)
+	d.pdf.CellFormat(w-1, 2.4, d.tr(strings.ToUpper(p.titulo)), "", 0, "L", false, 0, "")
+	if !p.preenchida {
+		return
+	}
+
+	linhas := []string{
+		p.nome,
+		p.endereco,
+		fmt.Sprintf("%s - %s   CEP: %s", p.municipio, p.uf, formatarCEP(p.cep)),
+		fmt.Sprintf("CNPJ/CPF: %s   IE: %s", p.documento, p.ie),
+	}
+	if p.fone != "" {
+		linhas = append(linhas, "Fone: "+p.fone)
+	}
+
+	yTexto := y + 3.2
+	for i, l := range linhas {
+		estilo := ""
+		if i == 0 {
+			estilo = "B"
+		}
+		yTexto += d.texto(x+1, yTexto, w-2, 7, estilo, "L", l)
+		if yTexto > y+h-2 {
+			break
+		}
+	}
+}
+
+// cargaDACTE desenha produto predominante, valor e quantidades da carga
+func (d *documento) cargaDACTE(inf parsers.InfCte) {
+	carga := inf.InfCTeNorm.InfCarga
+	if carga.ProPred == "" && carga.VCarga == "" && len(carga.InfQ) == 0 {
+		return
+	}
+
+	d.linha(7,
+		Celula{Proporcao: 0.5, Rotulo: "Produto predominante", Valor: carga.ProPred},
+		Celula{Proporcao: 0.25, Rotulo: "Valor total da carga", Valor: formatarMoeda(carga.VCarga), Alinhamento: "R"},
+		Celula{Proporcao: 0.25, Rotulo: "Valor averbado", Valor: formatarMoeda(carga.VCargaAverb), Alinhamento: "R"},
+	)
+
+	if len(carga.InfQ) == 0 {
+		return
+	}
+	celulas := make([]Celula, 0, len(carga.InfQ))
+	for _, q := range carga.InfQ {
+		celulas = append(celulas, Celula{
+			Proporcao:   1,
+			Rotulo:      fmt.Sprintf("%s (%s)", q.TpMed, descricao(descricaoUnidade, q.CUnid)),
+			Valor:       formatarQuantidade(q.QCarga),
+			Alinhamento: "R",
+		})
+	}
+	for len(celulas) < 4 {
+		celulas = append(celulas, Celula{Proporcao: 1})
+	}
+	d.linha(7, celulas...)
+}
+
+// formatarQuantidade formata uma quantidade com 4 casas decimais
+func formatarQuantidade(valor string) string {
+	if valor == "" {
+		return ""
+	}
+	var v float64
+	if _, err := fmt.Sscanf(valor, "%f", &v); err != nil {
+		return valor
+	}
+	return formatarNumero(v, 4)
+}
+
+// componentesDACTE desenha os componentes do valor da prestação
+func (d *documento) componentesDACTE(vPrest parsers.VPrest) {
+	d.tituloSecao("Componentes do valor da prestação do serviço")
+
+	const porLinha = 4
+	comps := vPrest.Comp
+	linhas := (len(comps) + porLinha - 1) / porLinha
+	if linhas == 0 {
+		linhas = 1
+	}
+
+	for l := 0; l < linhas; l++ {
+		celulas := make([]Celula, 0, porLinha+1)
+		for i := 0; i < porLinha; i++ {
+			idx := l*porLinha + i
+			if idx < len(comps) {
+				celulas = append(celulas, Celula{Proporcao: 1, Rotulo: comps[idx].XNome, Valor: formatarMoeda(comps[idx].VComp), Alinhamento: "R"})
+			} else {
+				celulas = append(celulas, Celula{Proporcao: 1})
+			}
+		}
+		if l == 0 {
+			celulas = append(celulas, Celula{Proporcao: 1, Rotulo: "Valor total do serviço", Valor: formatarMoeda(vPrest.VTPrest), Alinhamento: "R", Negrito: true})
+		} else if l == 1 {
+			celulas = append(celulas, Celula{Proporcao: 1, Rotulo: "Valor a receber", Valor: formatarMoeda(vPrest.VRec), Alinhamento: "R", Negrito: true})
+		} else {
+			celulas = append(celulas, Celula{Proporcao: 1})
+		}
+		d.linha(7, celulas...)
+	}
+
+	if linhas == 1 {
+		d.linha(7,
+			Celula{Proporcao: 4},
+			Celula{Proporcao: 1, Rotulo: "Valor a receber", Valor: formatarMoeda(vPrest.VRec), Alinhamento: "R", Negrito: true},
+		)
+	}
+}
+
+// impostoDACTE desenha o bloco de informações do ICMS
+func (d *documento) impostoDACTE(imp parsers.ImpCTe) {
+	d.tituloSecao("Informações relativas ao imposto")
+
+	icms := icmsDACTE(imp.ICMS)
+	d.linha(7,
+		Celula{Proporcao: 0.35, Rotulo: "Situação tributária", Valor: icms.situacao},
+		Celula{Proporcao: 0.14, Rotulo: "Base de cálculo", Valor: formatarMoeda(icms.base), Alinhamento: "R"},
+		Celula{Proporcao: 0.10, Rotulo: "Alíq. ICMS", Valor: formatarMoeda(icms.aliquota), Alinhamento: "R"},
+		Celula{Proporcao: 0.14, Rotulo: "Valor ICMS", Valor: formatarMoeda(icms.valor), Alinhamento: "R"},
+		Celula{Proporcao: 0.12, Rotulo: "% Red. BC ICMS", Valor: formatarMoeda(icms.reducao), Alinhamento: "R"},
+		Celula{Proporcao: 0.15, Rotulo: "ICMS ST", Valor: formatarMoeda(icms.st), Alinhamento: "R"},
+	)
+}
+
+// icmsImpresso valores do grupo de ICMS para impressão
+type icmsImpresso struct {
+	situacao, base, aliquota, valor, reducao, st string
+}
+
+// icmsDACTE extrai do grupo de ICMS informado a situação tributária e os valores
+func icmsDACTE(icms parsers.ICMS) icmsImpresso {
+	switch {
+	case icms.ICMS00 != nil:
+		g := icms.ICMS00
+		return icmsImpresso{situacao: g.CST + " - TRIBUTAÇÃO NORMAL DO ICMS", base: g.VBC, aliquota: g.PICMS, valor: g.VICMS}
+	case icms.ICMS20 != nil:
+		g := icms.ICMS20
+		return icmsImpresso{situacao: g.CST + " - TRIBUTAÇÃO COM BC REDUZIDA DO ICMS", base: g.VBC, aliquota: g.PICMS, valor: g.VICMS, reducao: g.PRedBC}
+	case icms.ICMS45 != nil:
+		return icmsImpresso{situacao: icms.ICMS45.CST + " - ICMS ISENTO, NÃO TRIBUTADO OU DIFERIDO"}
+	case icms.ICMS60 != nil:
+		g := icms.ICMS60
+		return icmsImpresso{situacao: g.CST + " - ICMS COBRADO POR SUBSTITUIÇÃO TRIBUTÁRIA", base: g.VBCSTRet, aliquota: g.PICMSSTRet, st: g.VICMSSTRet}
+	case icms.ICMS90 != nil:
+		g := icms.ICMS90
+		return icmsImpresso{situacao: g.CST + " - ICMS OUTROS", base: g.VBC, aliquota: g.PICMS, valor: g.VICMS, reducao: g.PRedBC}
+	case icms.ICMSOutraUF != nil:
+		g := icms.ICMSOutraUF
+		return icmsImpresso{situacao: g.CST + " - ICMS DEVIDO À UF DE ORIGEM DA PRESTAÇÃO", base: g.VBCOutraUF, aliquota: g.PICMSOutraUF, valor: g.VICMSOutraUF, reducao: g.PRedBCOutraUF}
+	case icms.ICMSSN != nil:
+		return icmsImpresso{situacao: icms.ICMSSN.CST + " - SIMPLES NACIONAL"}
+	}
+	return icmsImpresso{}
+}
+
+// descricaoOutrosDocumentos mapeia o tpDoc de infOutros
+var descricaoOutrosDocumentos = map[string]string{
+	"00": "DECLARAÇÃO",
+	"10": "DUTOVIÁRIO",
+	"59": "CF-E SAT",
+	"65": "NFC-E",
+	"99": "OUTROS",
+}
+
+// documentoOriginario tipo e identificação de um documento originário para impressão
+type documentoOriginario struct {
+	tipo          string
+	identificacao string
+}
+
+// documentosOriginarios lista as NF-es, as notas em papel e os demais documentos do infDoc
+func documentosOriginarios(infDoc parsers.InfDoc) []documentoOriginario {
+	var documentos []documentoOriginario
+	for _, nfe := range infDoc.InfNFe {
+		documentos = append(documentos, documentoOriginario{tipo: "NF-E", identificacao: formatarChave(nfe.Chave)})
+	}
+	for _, nf := range infDoc.InfNF {
+		identificacao := fmt.Sprintf("Mod. %s Série %s Nº %s  Emissão %s  Valor %s", nf.Mod, nf.Serie, nf.NDoc, formatarData(nf.DEmi), formatarMoeda(nf.VNF))
+		documentos = append(documentos, documentoOriginario{tipo: "NF", identificacao: identificacao})
+	}
+	for _, outro := range infDoc.InfOutros {
+		tipo := descricao(descricaoOutrosDocumentos, outro.TpDoc)
+		identificacao := outro.DescOutros
+		if outro.NDoc != "" {
+			identificacao = strings.TrimSpace(identificacao + " Nº " + outro.NDoc)
+		}
+		if outro.VDocFisc != "" {
+			identificacao += "  Valor " + formatarMoeda(outro.VDocFisc)
+		}
+		documentos = append(documentos, documentoOriginario{tipo: tipo, identificacao: identificacao})
+	}
+	return documentos
+}
+
+// documentosDACTE desenha os documentos originários
+func (d *documento) documentosDACTE(infDoc parsers.InfDoc) {
+	documentos := documentosOriginarios(infDoc)
+	if len(documentos) == 0 {
+		return
+	}
+	d.tituloSecao("Documentos originários")
+
+	for i := 0; i < len(documentos); i += 2 {
+		celulas := []Celula{}
+		for j := i; j < i+2; j++ {
+			if j >= len(documentos) {
+				celulas = append(celulas, Celula{Proporcao: 0.1}, Celula{Proporcao: 0.4})
+				continue
+			}
+			celulas = append(celulas,
+				Celula{Proporcao: 0.1, Rotulo: "Tipo doc", Valor: documentos[j].tipo, Alinhamento: "C"},
+				Celula{Proporcao: 0.4, Rotulo: "Identificação", Valor: documentos[j].identificacao},
+			)
+		}
+		d.linha(6.5, celulas...)
+	}
+}
+
+// observacoesDACTE desenha as observações gerais e do contribuinte e a indicação da CC-e aplicada
+func (d *documento) observacoesDACTE(compl parsers.ComplCTe, cartaCorrecao int) {
+	linhas := []string{}
+	if cartaCorrecao > 0 {
+		linhas = append(linhas, fmt.Sprintf("DADOS CORRIGIDOS PELA CARTA DE CORREÇÃO SEQ. %d", cartaCorrecao))
+	}
+	if compl.XObs != "" {
+		linhas = append(linhas, compl.XObs)
+	}
+	for _, obs := range compl.ObsCont {
+		linhas = append(linhas, obs.XCampo+": "+obs.XTexto)
+	}
+	if len(linhas) == 0 {
+		return
+	}
+
+	d.tituloSecao("Observações")
+	texto := strings.Join(linhas, " | ")
+	d.pdf.SetFont("Helvetica", "", fonteValor)
+	qtd := len(d.quebrarTexto(texto, d.largura-1.5))
+	d.linha(float64(qtd)*alturaLinhaTexto+2, Celula{Proporcao: 1, Valor: texto})
+}
+
+// descricaoTrafego mapeia o tpTraf do modal ferroviário
+var descricaoTrafego = map[string]string{
+	"0": "PRÓPRIO",
+	"1": "MÚTUO",
+	"2": "RODOFERROVIÁRIO",
+	"3": "RODOVIÁRIO",
+}
+
+// descricaoNavegacao mapeia o tpNav do modal aquaviário
+var descricaoNavegacao = map[string]string{
+	"0": "INTERIOR",
+	"1": "CABOTAGEM",
+}
+
+// modalDACTE desenha as informações específicas do modal
+func (d *documento) modalDACTE(inf parsers.InfCte) {
+	modal := inf.InfCTeNorm.InfModal
+
+	if rodo := modal.Rodo; rodo != nil {
+		d.tituloSecao("Informações específicas do modal rodoviário")
+		d.linha(7,
+			Celula{Proporcao: 0.3, Rotulo: "RNTRC da empresa", Valor: rodo.RNTRC},
+			Celula{Proporcao: 0.7, Rotulo: "Este conhecimento de transporte atende à legislação de transporte rodoviário em vigor"},
+		)
+	}
+
+	if aereo := modal.Aereo; aereo != nil {
+		d.tituloSecao("Informações específicas do modal aéreo")
+		d.linha(7,
+			Celula{Proporcao: 0.2, Rotulo: "Número da minuta", Valor: aereo.NMinu},
+			Celula{Proporcao: 0.2, Rotulo: "Número OCA", Valor: aereo.NOCA},
+			Celula{Proporcao: 0.2, Rotulo: "Data prevista de entrega", Valor: formatarData(aereo.DPrevAereo)},
+			Celula{Proporcao: 0.1, Rotulo: "Classe", Valor: aereo.Tarifa.CL, Alinhamento: "C"},
+			Celula{Proporcao: 0.1, Rotulo: "Código tarifa", Valor: aereo.Tarifa.CTar, Alinhamento: "C"},
+			Celula{Proporcao: 0.2, Rotulo: "Valor da tarifa", Valor: formatarMoeda(aereo.Tarifa.VTarifa), Alinhamento: "R"},
+		)
+	}
+
+	if aquav := modal.Aquav; aquav != nil {
+		balsas := make([]string, 0, len(aquav.Balsa))
+		for _, balsa := range aquav.Balsa {
+			balsas = append(balsas, balsa.XBalsa)
+		}
+		conteineres := make([]string, 0, len(aquav.DetCont))
+		for _, cont := range aquav.DetCont {
+			conteineres = append(conteineres, cont.NCont)
+		}
+
+		d.tituloSecao("Informações específicas do modal aquaviário")
+		d.linha(7,
+			Celula{Proporcao: 0.3, Rotulo: "Embarcação", Valor: aquav.XNavio},
+			Celula{Proporcao: 0.15, Rotulo: "IRIN", Valor: aquav.Irin},
+			Celula{Proporcao: 0.15, Rotulo: "Viagem", Valor: aquav.NViag},
+			Celula{Proporcao: 0.1, Rotulo: "Direção", Valor: aquav.Direc, Alinhamento: "C"},
+			Celula{Proporcao: 0.15, Rotulo: "Navegação", Valor: descricao(descricaoNavegacao, aquav.TpNav)},
+			Celula{Proporcao: 0.15, Rotulo: "Valor AFRMM", Valor: formatarMoeda(aquav.VAFRMM), Alinhamento: "R"},
+		)
+		if len(balsas) > 0 || len(conteineres) > 0 {
+			d.linha(7,
+				Celula{Proporcao: 0.5, Rotulo: "Balsas", Valor: strings.Join(balsas, ", ")},
+				Celula{Proporcao: 0.5, Rotulo: "Contêineres", Valor: strings.Join(conteineres, ", ")},
+			)
+		}
+	}
+
+	if ferrov := modal.Ferrov; ferrov != nil {
+		celulas := []Celula{
+			{Proporcao: 0.25, Rotulo: "Tipo de tráfego", Valor: descricao(descricaoTrafego, ferrov.TpTraf)},
+			{Proporcao: 0.25, Rotulo: "Fluxo ferroviário", Valor: ferrov.Fluxo},
+		}
+		if mut := ferrov.TrafMut; mut != nil {
+			celulas = append(celulas,
+				Celula{Proporcao: 0.25, Rotulo: "Ferrovia emitente / responsável", Valor: mut.FerrEmi + " / " + mut.RespFat},
+				Celula{Proporcao: 0.25, Rotulo: "Valor do frete do tráfego mútuo", Valor: formatarMoeda(mut.VFrete), Alinhamento: "R"},
+			)
+		}
+		d.tituloSecao("Informações específicas do modal ferroviário")
+		d.linha(7, celulas...)
+	}
+
+	if duto := modal.Duto; duto != nil {
+		d.tituloSecao("Informações específicas do modal dutoviário")
+		d.linha(7,
+			Celula{Proporcao: 0.34, Rotulo: "Valor da tarifa", Valor: formatarMoeda(duto.VTar), Alinhamento: "R"},
+			Celula{Proporcao: 0.33, Rotulo: "Início da prestação", Valor: formatarData(duto.DIni), Alinhamento: "C"},
+			Celula{Proporcao: 0.33, Rotulo: "Fim da prestação", Valor: formatarData(duto.DFim), Alinhamento: "C"},
+		)
+	}
+
+	if multimodal := modal.Multimodal; multimodal != nil {
+		negociavel := "NÃO NEGOCIÁVEL"
+		if multimodal.IndNegociavel == "1" {
+			negociavel = "NEGOCIÁVEL"
+		}
+		d.tituloSecao("Informações específicas do transporte multimodal")
+		d.linha(7,
+			Celula{Proporcao: 0.5, Rotulo: "COTM", Valor: multimodal.COTM},
+			Celula{Proporcao: 0.5, Rotulo: "Indicador de negociabilidade", Valor: negociavel},
+		)
+	}
+}
@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cteTeste = `<?xml version="1.0" encoding="UTF-8"?>
+<cteProc xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><CTe xmlns="http://www.portalfiscal.inf.br/cte"><infCte Id="CTe35240112345678000190570010000001231000001234" versao="4.00">
+<ide><cUF>35</cUF><CFOP>5353</CFOP><natOp>PRESTAÇÃO DE SERVIÇO DE TRANSPORTE</natOp><mod>57</mod><serie>1</serie><nCT>123</nCT><dhEmi>2024-01-15T10:30:00-03:00</dhEmi><tpImp>1</tpImp><tpAmb>2</tpAmb><tpCTe>0</tpCTe><modal>01</modal><tpServ>0</tpServ><xMunIni>SÃO PAULO</xMunIni><UFIni>SP</UFIni><xMunFim>CAMPINAS</xMunFim><UFFim>SP</UFFim><toma3><toma>0</toma></toma3></ide>
+<compl><xObs>Entrega em horário comercial</xObs></compl>
+<emit><CNPJ>12345678000190</CNPJ><IE>123456789</IE><xNome>TRANSPORTADORA TESTE LTDA</xNome><enderEmit><xLgr>RUA A</xLgr><nro>10</nro><xBairro>CENTRO</xBairro><xMun>SÃO PAULO</xMun><CEP>01001000</CEP><UF>SP</UF></enderEmit></emit>
+<rem><CNPJ>11111111000111</CNPJ><xNome>REMETENTE SA</xNome><enderReme><xLgr>RUA B</xLgr><nro>20</nro><xBairro>BRÁS</xBairro><xMun>SÃO PAULO</xMun><CEP>03001000</CEP><UF>SP</UF></enderReme></rem>
+<dest><CNPJ>22222222000122</CNPJ><xNome>DESTINATÁRIO LTDA</xNome><enderDest><xLgr>RUA C</xLgr><nro>30</nro><xBairro>CAMBUÍ</xBairro><xMun>CAMPINAS</xMun><CEP>13025000</CEP><UF>SP</UF></enderDest></dest>
+<vPrest><vTPrest>1500.00</vTPrest><vRec>1500.00</vRec><Comp><xNome>FRETE PESO</xNome><vComp>1200.00</vComp></Comp><Comp><xNome>PEDAGIO</xNome><vComp>300.00</vComp></Comp></vPrest>
+<imp><ICMS><ICMS00><CST>00</CST><vBC>1500.00</vBC><pICMS>12.00</pICMS><vICMS>180.00</vICMS></ICMS00></ICMS></imp>
+<infCTeNorm><infCarga><vCarga>50000.00</vCarga><proPred>ELETRÔNICOS</proPred><infQ><cUnid>01</cUnid><tpMed>PESO BRUTO</tpMed><qCarga>1000.0000</qCarga></infQ></infCarga><infDoc><infNFe><chave>35240111111111000111550010000001231000001234</chave></infNFe></infDoc><infModal versaoModal="4.00"><rodo><RNTRC>12345678</RNTRC></rodo></infModal></infCTeNorm>
+</infCte></CTe><protCTe versao="4.00"><infProt><chCTe>35240112345678000190570010000001231000001234</chCTe><dhRecbto>2024-01-15T10:31:00-03:00</dhRecbto><nProt>135240000000001</nProt><cStat>100</cStat></infProt></protCTe></cteProc>`
+
+func TestGerarDACTE(t *testing.T) {
+	for _, orientacao := range []string{"", OrientacaoRetrato, OrientacaoPaisagem} {
+		conteudo, err := GerarDACTE([]byte(cteTeste), Opcoes{Orientacao: orientacao, Cancelado: true, CartaCorrecao: 1})
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(conteudo, []byte("%PDF")))
+	}
+
+	_, err := GerarDACTE([]byte(`<MDFe/>`), Opcoes{})
+	assert.Error(t, err)
+}
+
+func TestGerarDACTEVariasPaginas(t *testing.T) {
+	// Documentos originários suficientes para ocupar mais de uma folha
+	nfes := strings.Repeat("<infNFe><chave>35240111111111000111550010000001231000001234</chave></infNFe>", 120)
+	xmlCTe := strings.Replace(cteTeste, "<infDoc><infNFe><chave>35240111111111000111550010000001231000001234</chave></infNFe></infDoc>", "<infDoc>"+nfes+"</infDoc>", 1)
+
+	conteudo, err := GerarDACTE([]byte(xmlCTe), Opcoes{})
+	require.NoError(t, err)
+	assert.Contains(t, string(conteudo), "/Count 2")
+}
+
+func TestGerarDACTEModais(t *testing.T) {
+	modais := map[string]string{
+		"02": `<aereo><nMinu>123456789</nMinu><dPrevAereo>2024-01-16</dPrevAereo><tarifa><CL>G</CL><vTarifa>10.50</vTarifa></tarifa></aereo>`,
+		"03": `<aquav><vPrest>1500.00</vPrest><vAFRMM>375.00</vAFRMM><xNavio>NAVIO</xNavio><balsa><xBalsa>B1</xBalsa></balsa><direc>N</direc><irin>PP1234</irin><detCont><nCont>ABCU1234567</nCont></detCont><tpNav>1</tpNav></aquav>`,
+		"04": `<ferrov><tpTraf>1</tpTraf><trafMut><respFat>1</respFat><ferrEmi>1</ferrEmi><vFrete>900.00</vFrete></trafMut><fluxo>F1</fluxo></ferrov>`,
+		"05": `<duto><vTar>1.2</vTar><dIni>2024-01-15</dIni><dFim>2024-01-20</dFim></duto>`,
+		"06": `<multimodal><COTM>123</COTM><indNegociavel>1</indNegociavel></multimodal>`,
+	}
+	for modal, grupo := range modais {
+		xmlCTe := strings.Replace(cteTeste, "<rodo><RNTRC>12345678</RNTRC></rodo>", grupo, 1)
+		xmlCTe = strings.Replace(xmlCTe, "<modal>01</modal>", "<modal>"+modal+"</modal>", 1)
+		conteudo, err := GerarDACTE([]byte(xmlCTe), Opcoes{})
+		assert.NoError(t, err, modal)
+		assert.True(t, bytes.HasPrefix(conteudo, []byte("%PDF")), modal)
+	}
+}
+
+func TestDocumentosOriginarios(t *testing.T) {
+	documentos := documentosOriginarios(parsers.InfDoc{
+		InfNFe:    []parsers.InfNFe{{Chave: "35240111111111000111550010000001231000001234"}},
+		InfNF:     []parsers.InfNF{{Mod: "01", Serie: "1", NDoc: "4567", DEmi: "2024-01-10", VNF: "2500.00"}},
+		InfOutros: []parsers.InfOutros{{TpDoc: "00", DescOutros: "DECLARACAO DE CONTEUDO", NDoc: "99"}},
+	})
+
+	require.Len(t, documentos, 3)
+	assert.Equal(t, "NF-E", documentos[0].tipo)
+	assert.Equal(t, "NF", documentos[1].tipo)
+	assert.Equal(t, "Mod. 01 Série 1 Nº 4567  Emissão 10/01/2024  Valor 2.500,00", documentos[1].identificacao)
+	assert.Equal(t, "DECLARAÇÃO", documentos[2].tipo)
+	assert.Equal(t, "DECLARACAO DE CONTEUDO Nº 99", documentos[2].identificacao)
+}
+
+func TestICMSDACTE(t *testing.T) {
+	icms := icmsDACTE(parsers.ICMS{ICMSOutraUF: &parsers.ICMSOutraUF{CST: "90", VBCOutraUF: "500.00", PICMSOutraUF: "7.00", VICMSOutraUF: "35.00"}})
+	assert.Equal(t, "90 - ICMS DEVIDO À UF DE ORIGEM DA PRESTAÇÃO", icms.situacao)
+	assert.Equal(t, "35.00", icms.valor)
+
+	icms = icmsDACTE(parsers.ICMS{ICMSSN: &parsers.ICMSSN{CST: "90", IndSN: "1"}})
+	assert.Equal(t, "90 - SIMPLES NACIONAL", icms.situacao)
+	assert.Empty(t, icms.valor)
+}
@@ -0,0 +1,262 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boombuler/barcode/qr"
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+)
+
+// descricaoUnidadeMDFe mapeia o cUnid do totalizador de peso do MDF-e
+var descricaoUnidadeMDFe = map[string]string{
+	"01": "KG",
+	"02": "TON",
+}
+
+// descricaoResponsavelSeguro mapeia o respSeg
+var descricaoResponsavelSeguro = map[string]string{
+	"1": "EMITENTE DO MDF-E",
+	"2": "CONTRATANTE DO TRANSPORTE",
+}
+
+// GerarDAMDFE gera o PDF do DAMDFE a partir do XML do MDF-e
+func GerarDAMDFE(xmlContent []byte, opcoes Opcoes) ([]byte, error) {
+	mdfeProc, err := parsers.DecodificarMDFe(xmlContent)
+	if err != nil {
+		return nil, err
+	}
+
+	inf := mdfeProc.MDFe.InfMDFe
+	chave := strings.TrimPrefix(inf.Id, "MDFe")
+	if len(chave) != 44 {
+		return nil, fmt.Errorf("chave de acesso inválida: %s", chave)
+	}
+
+	d := novoDocumento(opcoes.Orientacao, "DAMDFE "+chave, textoMarcaDagua(opcoes.Cancelado, inf.Ide.TpAmb))
+
+	if err := d.cabecalhoDAMDFE(mdfeProc, chave); err != nil {
+		return nil, err
+	}
+	d.identificacaoDAMDFE(inf)
+	if inf.InfModal.Rodo != nil {
+		d.modalDAMDFE(*inf.InfModal.Rodo)
+	}
+	d.segurosDAMDFE(inf.Seg)
+	d.documentosDAMDFE(inf.InfDoc)
+	d.observacoesDAMDFE(inf.InfAdic)
+
+	return d.bytes()
+}
+
+// cabecalhoDAMDFE desenha emitente, título, QR Code, código de barras e chave
+func (d *documento) cabecalhoDAMDFE(mdfeProc *parsers.MDFeProc, chave string) error {
+	inf := mdfeProc.MDFe.InfMDFe
+	altura := 34.0
+	ladoQR := altura - 4
+	wQR := ladoQR + 4
+	wEmit := d.largura - wQR
+
+	// Emitente
+	x := d.margem
+	d.pdf.Rect(x, d.y, wEmit, altura, "D")
+	yTexto := d.y + 2
+	yTexto += d.texto(x+1, yTexto, wEmit-2, 12, "B", "L", "DAMDFE - Documento Auxiliar de Manifesto Eletrônico de Documentos Fiscais") + 1
+	yTexto += d.texto(x+1, yTexto, wEmit-2, 9, "B", "L", inf.Emit.XNome)
+	ender := inf.Emit.EnderEmit
+	yTexto += d.texto(x+1, yTexto, wEmit-2, 7, "", "L", enderecoCompleto(ender.XLgr, ender.Nro, ender.XCpl, ender.XBairro))
+	yTexto += d.texto(x+1, yTexto, wEmit-2, 7, "", "L", fmt.Sprintf("%s - %s  CEP: %s", ender.XMun, ender.UF, formatarCEP(ender.CEP)))
+	yTexto += d.texto(x+1, yTexto, wEmit-2, 7, "", "L", fmt.Sprintf("CNPJ: %s  IE: %s", formatarDocumento(inf.Emit.CNPJ, ""), inf.Emit.IE))
+	if rodo := inf.InfModal.Rodo; rodo != nil && rodo.InfANTT.RNTRC != "" {
+		d.texto(x+1, yTexto, wEmit-2, 7, "", "L", "RNTRC: "+rodo.InfANTT.RNTRC)
+	}
+
+	// QR Code de consulta
+	x += wEmit
+	d.pdf.Rect(x, d.y, wQR, altura, "D")
+	if conteudo := strings.TrimSpace(mdfeProc.MDFe.InfMDFeSupl.QrCodMDFe); conteudo != "" {
+		codigo, err := qr.Encode(conteudo, qr.M, qr.Auto)
+		if err != nil {
+			return fmt.Errorf("erro ao gerar QR Code: %w", err)
+		}
+		d.desenharModulos(codigo, x+2, d.y+2, ladoQR, ladoQR, true)
+	} else {
+		d.texto(x+1, d.y+altura/2-2, wQR-2, 6, "", "C", "QR Code não informado no XML")
+	}
+
+	d.y += altura
+
+	// Código de barras, chave e protocolo
+	alturaBarras := 14.0
+	d.garantirEspaco(alturaBarras + 7)
+	wBarras := d.largura * 0.55
+	d.pdf.Rect(d.margem, d.y, wBarras, alturaBarras, "D")
+	if err := d.codigoBarras128(d.margem+3, d.y+2, wBarras-6, alturaBarras-4, chave); err != nil {
+		return err
+	}
+	d.caixa(d.margem+wBarras, d.y, d.largura-wBarras, alturaBarras/2,
+		Celula{Rotulo: "Chave de acesso", Valor: formatarChave(chave), Alinhamento: "C", Negrito: true})
+	d.caixa(d.margem+wBarras, d.y+alturaBarras/2, d.largura-wBarras, alturaBarras/2,
+		Celula{Rotulo: "Protocolo de autorização de uso", Valor: protocoloDAMDFE(mdfeProc.ProtMDFe.InfProt), Alinhamento: "C"})
+	d.y += alturaBarras
+
+	return nil
+}
+
+// protocoloDAMDFE formata o número e a data do protocolo
+func protocoloDAMDFE(prot parsers.InfProtMDFe) string {
+	if prot.NProt == "" {
+		return ""
+	}
+	return prot.NProt + " - " + formatarDataHora(prot.DhRecbto)
+}
+
+// identificacaoDAMDFE desenha numeração, percurso e totalizadores
+func (d *documento) identificacaoDAMDFE(inf parsers.InfMDFe) {
+	ide := inf.Ide
+
+	d.linha(7,
+		Celula{Proporcao: 0.08, Rotulo: "Modelo", Valor: ide.Mod, Alinhamento: "C"},
+		Celula{Proporcao: 0.07, Rotulo: "Série", Valor: ide.Serie, Alinhamento: "C"},
+		Celula{Proporcao: 0.12, Rotulo: "Número", Valor: ide.NMDF, Alinhamento: "C", Negrito: true},
+		Celula{Proporcao: 0.06, Rotulo: "FL", Valor: d.folha(), Alinhamento: "C"},
+		Celula{Proporcao: 0.22, Rotulo: "Data e hora de emissão", Valor: formatarDataHora(ide.DhEmi), Alinhamento: "C"},
+		Celula{Proporcao: 0.15, Rotulo: "Modal", Valor: descricao(descricaoModal, ide.Modal), Alinhamento: "C"},
+		Celula{Proporcao: 0.15, Rotulo: "UF carregamento", Valor: ide.UFIni, Alinhamento: "C"},
+		Celula{Proporcao: 0.15, Rotulo: "UF descarregamento", Valor: ide.UFFim, Alinhamento: "C"},
+	)
+
+	carregamento := make([]string, 0, len(ide.InfMunCarrega))
+	for _, mun := range ide.InfMunCarrega {
+		carregamento = append(carregamento, mun.XMunCarrega)
+	}
+	descarregamento := make([]string, 0, len(inf.InfDoc.InfMunDescarga))
+	for _, mun := range inf.InfDoc.InfMunDescarga {
+		descarregamento = append(descarregamento, mun.XMunDescarga)
+	}
+	percurso := make([]string, 0, len(ide.InfPercurso))
+	for _, p := range ide.InfPercurso {
+		percurso = append(percurso, p.UFPer)
+	}
+
+	d.linha(7,
+		Celula{Proporcao: 0.4, Rotulo: "Municípios de carregamento", Valor: strings.Join(carregamento, ", ")},
+		Celula{Proporcao: 0.4, Rotulo: "Municípios de descarregamento", Valor: strings.Join(descarregamento, ", ")},
+		Celula{Proporcao: 0.2, Rotulo: "UFs de percurso", Valor: strings.Join(percurso, ", ")},
+	)
+
+	tot := inf.Tot
+	d.linha(7,
+		Celula{Proporcao: 1, Rotulo: "Qtd. CT-e", Valor: tot.QCTe, Alinhamento: "R"},
+		Celula{Proporcao: 1, Rotulo: "Qtd. NF-e", Valor: tot.QNFe, Alinhamento: "R"},
+		Celula{Proporcao: 1, Rotulo: "Peso total (" + descricao(descricaoUnidadeMDFe, tot.CUnid) + ")", Valor: formatarQuantidade(tot.QCarga), Alinhamento: "R"},
+		Celula{Proporcao: 1, Rotulo: "Valor total da carga", Valor: formatarMoeda(tot.VCarga), Alinhamento: "R", Negrito: true},
+		Celula{Proporcao: 2, Rotulo: "Produto predominante", Valor: inf.ProdPred.XProd},
+	)
+}
+
+// modalDAMDFE desenha veículos e condutores do modal rodoviário
+func (d *documento) modalDAMDFE(rodo parsers.Rodo) {
+	d.tituloSecao("Modal rodoviário de carga")
+
+	tracao := rodo.VeicTracao
+	d.linha(7,
+		Celula{Proporcao: 0.12, Rotulo: "Veículo", Valor: "TRAÇÃO"},
+		Celula{Proporcao: 0.14, Rotulo: "Placa", Valor: tracao.Placa, Negrito: true},
+		Celula{Proporcao: 0.06, Rotulo: "UF", Valor: tracao.UF, Alinhamento: "C"},
+		Celula{Proporcao: 0.16, Rotulo: "RENAVAM", Valor: tracao.RENAVAM},
+		Celula{Proporcao: 0.16, Rotulo: "RNTRC", Valor: rntrcVeiculo(tracao.Prop, rodo.InfANTT.RNTRC)},
+		Celula{Proporcao: 0.36, Rotulo: "Proprietário", Valor: tracao.Prop.XNome},
+	)
+	for i, reboque := range rodo.VeicReboque {
+		d.linha(7,
+			Celula{Proporcao: 0.12, Rotulo: "Veículo", Valor: fmt.Sprintf("REBOQUE %d", i+1)},
+			Celula{Proporcao: 0.14, Rotulo: "Placa", Valor: reboque.Placa, Negrito: true},
+			Celula{Proporcao: 0.06, Rotulo: "UF", Valor: reboque.UF, Alinhamento: "C"},
+			Celula{Proporcao: 0.16, Rotulo: "RENAVAM", Valor: reboque.RENAVAM},
+			Celula{Proporcao: 0.16, Rotulo: "RNTRC", Valor: rntrcVeiculo(reboque.Prop, rodo.InfANTT.RNTRC)},
+			Celula{Proporcao: 0.36, Rotulo: "Proprietário", Valor: reboque.Prop.XNome},
+		)
+	}
+
+	for _, condutor := range tracao.Condutor {
+		d.linha(7,
+			Celula{Proporcao: 0.3, Rotulo: "CPF do condutor", Valor: formatarDocumento("", condutor.CPF)},
+			Celula{Proporcao: 0.7, Rotulo: "Nome do condutor", Valor: condutor.XNome},
+		)
+	}
+}
+
+// rntrcVeiculo usa o RNTRC do proprietário quando o veículo é de terceiro
+func rntrcVeiculo(prop parsers.Prop, rntrcEmitente string) string {
+	if prop.RNTRC != "" {
+		return prop.RNTRC
+	}
+	return rntrcEmitente
+}
+
+// segurosDAMDFE desenha as informações de seguro da carga
+func (d *documento) segurosDAMDFE(seguros []parsers.Seg) {
+	if len(seguros) == 0 {
+		return
+	}
+	d.tituloSecao("Informações do seguro da carga")
+
+	for _, seg := range seguros {
+		d.linha(7,
+			Celula{Proporcao: 0.2, Rotulo: "Responsável", Valor: descricao(descricaoResponsavelSeguro, seg.InfResp.RespSeg)},
+			Celula{Proporcao: 0.3, Rotulo: "Seguradora", Valor: seg.InfSeg.XSeg},
+			Celula{Proporcao: 0.18, Rotulo: "CNPJ seguradora", Valor: formatarDocumento(seg.InfSeg.CNPJ, "")},
+			Celula{Proporcao: 0.14, Rotulo: "Apólice", Valor: seg.NApol},
+			Celula{Proporcao: 0.18, Rotulo: "Averbação", Valor: strings.Join(seg.NAver, ", ")},
+		)
+	}
+}
+
+// documentosDAMDFE lista as chaves dos documentos vinculados por município de descarregamento
+func (d *documento) documentosDAMDFE(infDoc parsers.InfDocMDFe) {
+	d.tituloSecao("Documentos fiscais vinculados")
+
+	for _, mun := range infDoc.InfMunDescarga {
+		type vinculado struct{ tipo, chave string }
+		documentos := make([]vinculado, 0, len(mun.InfCTe)+len(mun.InfNFe))
+		for _, cte := range mun.InfCTe {
+			documentos = append(documentos, vinculado{"CT-E", cte.ChCTe})
+		}
+		for _, nfe := range mun.InfNFe {
+			documentos = append(documentos, vinculado{"NF-E", nfe.ChNFe})
+		}
+
+		d.linha(6,
+			Celula{Proporcao: 0.8, Rotulo: "Município de descarregamento", Valor: mun.XMunDescarga, Negrito: true},
+			Celula{Proporcao: 0.2, Rotulo: "Documentos", Valor: fmt.Sprintf("%d", len(documentos)), Alinhamento: "R"},
+		)
+
+		for i := 0; i < len(documentos); i += 2 {
+			celulas := []Celula{}
+			for j := i; j < i+2; j++ {
+				if j >= len(documentos) {
+					celulas = append(celulas, Celula{Proporcao: 0.1}, Celula{Proporcao: 0.4})
+					continue
+				}
+				celulas = append(celulas,
+					Celula{Proporcao: 0.1, Rotulo: "Tipo doc", Valor: documentos[j].tipo, Alinhamento: "C"},
+					Celula{Proporcao: 0.4, Rotulo: "Chave de acesso", Valor: formatarChave(documentos[j].chave)},
+				)
+			}
+			d.linha(6.5, celulas...)
+		}
+	}
+}
+
+// observacoesDAMDFE desenha as informações complementares
+func (d *documento) observacoesDAMDFE(infAdic parsers.InfAdic) {
+	if infAdic.InfCpl == "" {
+		return
+	}
+
+	d.tituloSecao("Observações")
+	d.pdf.SetFont("Helvetica", "", fonteValor)
+	qtd := len(d.quebrarTexto(infAdic.InfCpl, d.largura-1.5))
+	d.linha(float64(qtd)*alturaLinhaTexto+2, Celula{Proporcao: 1, Valor: infAdic.InfCpl})
+}
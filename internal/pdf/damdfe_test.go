@@ -0,0 +1,27 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const mdfeTeste = `<?xml version="1.0" encoding="UTF-8"?>
+<mdfeProc xmlns="http://www.portalfiscal.inf.br/mdfe" versao="3.00"><MDFe xmlns="http://www.portalfiscal.inf.br/mdfe"><infMDFe Id="MDFe35240112345678000190580010000004561000004567" versao="3.00">
+<ide><cUF>35</cUF><tpAmb>1</tpAmb><mod>58</mod><serie>1</serie><nMDF>456</nMDF><modal>1</modal><dhEmi>2024-01-15T08:00:00-03:00</dhEmi><UFIni>SP</UFIni><UFFim>MG</UFFim><infMunCarrega><cMunCarrega>3550308</cMunCarrega><xMunCarrega>SÃO PAULO</xMunCarrega></infMunCarrega><infPercurso><UFPer>RJ</UFPer></infPercurso></ide>
+<emit><CNPJ>12345678000190</CNPJ><IE>123456789</IE><xNome>TRANSPORTADORA TESTE LTDA</xNome><enderEmit><xLgr>RUA A</xLgr><nro>10</nro><xBairro>CENTRO</xBairro><xMun>SÃO PAULO</xMun><CEP>01001000</CEP><UF>SP</UF></enderEmit></emit>
+<infModal versaoModal="3.00"><rodo><infANTT><RNTRC>12345678</RNTRC></infANTT><veicTracao><placa>ABC1D23</placa><tara>8000</tara><condutor><xNome>JOÃO DA SILVA</xNome><CPF>12345678901</CPF></condutor><UF>SP</UF></veicTracao><veicReboque><placa>XYZ9K87</placa><tara>6000</tara><capKG>30000</capKG><UF>SP</UF></veicReboque></rodo></infModal>
+<infDoc><infMunDescarga><cMunDescarga>3106200</cMunDescarga><xMunDescarga>BELO HORIZONTE</xMunDescarga><infCTe><chCTe>35240112345678000190570010000001231000001234</chCTe></infCTe><infNFe><chNFe>35240111111111000111550010000001231000001234</chNFe></infNFe></infMunDescarga></infDoc>
+<seg><infResp><respSeg>1</respSeg></infResp><infSeg><xSeg>SEGURADORA TESTE</xSeg><CNPJ>33333333000133</CNPJ></infSeg><nApol>APOL123</nApol><nAver>AVER456</nAver></seg>
+<tot><qCTe>1</qCTe><qNFe>1</qNFe><vCarga>50000.00</vCarga><cUnid>01</cUnid><qCarga>1000.0000</qCarga></tot>
+</infMDFe><infMDFeSupl><qrCodMDFe>https://dfe-portal.svrs.rs.gov.br/mdfe/qrCode?chMDFe=35240112345678000190580010000004561000004567&amp;tpAmb=1</qrCodMDFe></infMDFeSupl></MDFe><protMDFe versao="3.00"><infProt><chMDFe>35240112345678000190580010000004561000004567</chMDFe><dhRecbto>2024-01-15T08:01:00-03:00</dhRecbto><nProt>935240000000001</nProt><cStat>100</cStat></infProt></protMDFe></mdfeProc>`
+
+func TestGerarDAMDFE(t *testing.T) {
+	conteudo, err := GerarDAMDFE([]byte(mdfeTeste), Opcoes{})
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(conteudo, []byte("%PDF")))
+
+	_, err = GerarDAMDFE([]byte(`<CTe/>`), Opcoes{})
+	assert.Error(t, err)
+}
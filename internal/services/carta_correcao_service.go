@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"gorm.io/gorm"
+)
+
+// CTeCorrigido é o XML do CT-e com as correções da última carta de correção válida
+type CTeCorrigido struct {
+	XML       []byte
+	Carta     *models.DocumentoEvento // nil quando o CT-e não tem CC-e registrada
+	Correcoes []parsers.CorrecaoAplicada
+}
+
+// campoTomador indica a correção que trocaria o tomador (toma3/toma ou toma,
+// CNPJ e CPF do toma4). A CC-e não pode alterá-lo, e a troca deixaria o
+// tomador_id e a modalidade do frete inconsistentes com o XML corrigido.
+func campoTomador(correcao parsers.CorrecaoParsed) bool {
+	switch correcao.Grupo {
+	case "toma3":
+		return true
+	case "toma4":
+		return correcao.Campo == "toma" || correcao.Campo == "CNPJ" || correcao.Campo == "CPF"
+	}
+	return false
+}
+
+// CorrigirCTe aplica ao XML original do CT-e as correções da CC-e registrada e
+// aplicada de maior sequência, exceto as do tomador, que ficam como não aplicadas. Sem
+// carta de correção, o XML é retornado sem alterações.
+func CorrigirCTe(db *gorm.DB, chave string, xmlOriginal []byte) (*CTeCorrigido, error) {
+	carta, err := models.UltimaCartaCorrecao(db, chave)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar carta de correção: %w", err)
+	}
+	if carta == nil {
+		return &CTeCorrigido{XML: xmlOriginal}, nil
+	}
+
+	todas := make([]parsers.CorrecaoParsed, 0, len(carta.Correcoes))
+	var permitidas []parsers.CorrecaoParsed
+	for _, correcao := range carta.Correcoes {
+		correcaoParsed := parsers.CorrecaoParsed{
+			Grupo:   correcao.GrupoAlterado,
+			Campo:   correcao.CampoAlterado,
+			Valor:   correcao.ValorAlterado,
+			NroItem: correcao.NroItemAlterado,
+		}
+		todas = append(todas, correcaoParsed)
+		if !campoTomador(correcaoParsed) {
+			permitidas = append(permitidas, correcaoParsed)
+		}
+	}
+	xmlCorrigido, resultado := parsers.CorrigirXML(xmlOriginal, permitidas)
+
+	// Resultado na ordem da carta, com as correções do tomador não aplicadas
+	aplicadas := make([]parsers.CorrecaoAplicada, 0, len(todas))
+	for _, correcao := range todas {
+		if campoTomador(correcao) {
+			aplicadas = append(aplicadas, parsers.CorrecaoAplicada{CorrecaoParsed: correcao})
+			continue
+		}
+		aplicadas = append(aplicadas, resultado[0])
+		resultado = resultado[1:]
+	}
+
+	return &CTeCorrigido{XML: xmlCorrigido, Carta: carta, Correcoes: aplicadas}, nil
+}
+
+// salvarCorrecoesCTe substitui os campos corrigidos gravados para o evento de carta de correção
+func salvarCorrecoesCTe(tx *gorm.DB, eventoID uuid.UUID, correcoes []parsers.CorrecaoParsed) error {
+	if err := tx.Unscoped().Where("evento_id = ?", eventoID).Delete(&models.CTECorrecao{}).Error; err != nil {
+		return err
+	}
+
+	for i, correcaoParsed := range correcoes {
+		correcao := models.CTECorrecao{
+			EventoID:        eventoID,
+			Ordem:           i + 1,
+			GrupoAlterado:   correcaoParsed.Grupo,
+			CampoAlterado:   correcaoParsed.Campo,
+			ValorAlterado:   correcaoParsed.Valor,
+			NroItemAlterado: correcaoParsed.NroItem,
+		}
+		if err := tx.Create(&correcao).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// aplicarCorrecoesCTe regrava, a partir do XML original corrigido pela última
+// CC-e, os campos do CT-e usados nas consultas e relatórios. Impostos, valores
+// da prestação e partes, inclusive o tomador e a modalidade do frete, não
+// mudam: a CC-e não pode alterá-los.
+func aplicarCorrecoesCTe(tx *gorm.DB, cteID uuid.UUID) error {
+	var cte models.CTE
+	if err := tx.Select("id", "chave", "xml_original").First(&cte, "id = ?", cteID).Error; err != nil {
+		return fmt.Errorf("erro ao buscar CT-e: %w", err)
+	}
+	if cte.XMLOriginal == "" {
+		return nil
+	}
+
+	corrigido, err := CorrigirCTe(tx, cte.Chave, []byte(cte.XMLOriginal))
+	if err != nil {
+		return err
+	}
+	cteParsed, err := parsers.ParseCTe(corrigido.XML)
+	if err != nil {
+		return fmt.Errorf("erro ao ler CT-e corrigido: %w", err)
+	}
+
+	if err := tx.Model(&cte).Updates(map[string]interface{}{
+		"cfop":             cteParsed.CFOP,
+		"municipio_inicio": cteParsed.MunicipioInicio,
+		"municipio_fim":    cteParsed.MunicipioFim,
+		"valor_carga":      cteParsed.ValorCarga,
+		"peso_kg":          cteParsed.PesoKg,
+		"rntrc":            cteParsed.RNTRC,
+		"placa_veiculo":    cteParsed.PlacaVeiculo,
+		"obs_gerais":       cteParsed.ObservacoesGerais,
+	}).Error; err != nil {
+		return fmt.Errorf("erro ao aplicar carta de correção: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/internal/assinatura"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// ErrAutorEvento indica evento cujo autor não é o emitente do documento
+var ErrAutorEvento = errors.New("autor do evento não é o emitente do documento")
+
+// tabelasDocumento mapeia o tipo do documento para a tabela
+var tabelasDocumento = map[string]string{
+	"CTE":   "ctes",
+	"CTEOS": "cteos",
+	"MDFE":  "mdfes",
+}
+
+// tipoDocumentoChave identifica o documento pelo modelo da chave de acesso
+func tipoDocumentoChave(chave string) string {
+	switch chave[20:22] {
+	case parsers.ModeloCTeOS:
+		return "CTEOS"
+	case parsers.ModeloMDFe:
+		return "MDFE"
+	default:
+		return "CTE"
+	}
+}
+
+// processarEventoCTe processa um evento de CT-e ou de CT-e OS
+func processarEventoCTe(db *gorm.DB, xmlContent []byte) (*DocumentoProcessado, error) {
+	evento, err := parsers.ParseEventoCTe(xmlContent)
+	if err != nil {
+		return nil, rejeitar(fmt.Errorf("erro ao fazer parse do evento: %w", err))
+	}
+	return registrarEvento(db, xmlContent, evento, "EVENTO_CTE")
+}
+
+// processarEventoMDFe processa um evento de MDF-e
+func processarEventoMDFe(db *gorm.DB, xmlContent []byte) (*DocumentoProcessado, error) {
+	evento, err := parsers.ParseEventoMDFe(xmlContent)
+	if err != nil {
+		return nil, rejeitar(fmt.Errorf("erro ao fazer parse do evento: %w", err))
+	}
+	return registrarEvento(db, xmlContent, evento, "EVENTO_MDFE")
+}
+
+// verificarEvento confere a assinatura do evento e se o autor e o certificado
+// são do emitente do documento (CNPJ ou CPF da chave de acesso): os eventos
+// aplicados (cancelamento, carta de correção, encerramento, inclusões) são do emitente.
+// Em qualquer modo de verificação, o evento deve ter assinatura válida: o retorno
+// da SEFAZ no procEvento não é assinado e sozinho não comprova o registro.
+func verificarEvento(xmlContent []byte, evento *parsers.EventoParsed) error {
+	chave, err := parsers.DecomporChaveAcesso(evento.Chave)
+	if err != nil {
+		return rejeitar(fmt.Errorf("chave do documento do evento inválida: %w", err))
+	}
+
+	autor := evento.Autor
+	if len(autor) == 11 {
+		autor = "000" + autor
+	}
+	if len(autor) != 14 || autor[:8] != chave.CNPJCPF[:8] {
+		return fmt.Errorf("%w: autor %s, emitente %s", ErrAutorEvento, evento.Autor, chave.CNPJCPF)
+	}
+
+	verificacao := assinatura.Verificar(xmlContent)
+	verificacao.ConferirEmitente(chave.CNPJCPF)
+	if err := verificacao.Erro(); err != nil {
+		return err
+	}
+	if verificacao.Status != assinatura.StatusValida {
+		return fmt.Errorf("%w: evento sem assinatura digital (%s)", assinatura.ErrAssinaturaInvalida, verificacao.Motivo)
+	}
+	return nil
+}
+
+// registrarEvento grava o evento, substituindo o mesmo tipo e sequência já
+// recebido, e o aplica ao documento quando ele já foi importado
+func registrarEvento(db *gorm.DB, xmlContent []byte, evento *parsers.EventoParsed, tipo string) (*DocumentoProcessado, error) {
+	// Eventos adulterados ou de outro autor não são gravados
+	if err := verificarEvento(xmlContent, evento); err != nil {
+		return nil, err
+	}
+
+	// Iniciar transação
+	tx := db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var registro models.DocumentoEvento
+	result := tx.Where("chave_documento = ? AND tipo_evento = ? AND sequencia = ?",
+		evento.Chave, evento.TipoEvento, evento.Sequencia).First(&registro)
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao buscar evento existente: %w", result.Error)
+	}
+
+	registro.DocumentoTipo = tipoDocumentoChave(evento.Chave)
+	registro.ChaveDocumento = evento.Chave
+	registro.TipoEvento = evento.TipoEvento
+	registro.Sequencia = evento.Sequencia
+	registro.Descricao = evento.TipoEventoDesc
+	registro.DataEvento = evento.DataEvento
+	registro.DataRegistro = evento.DataRegistro
+	registro.Autor = evento.Autor
+	registro.Protocolo = evento.Protocolo
+	registro.Status = evento.Status
+	registro.Motivo = evento.Motivo
+	registro.ProtocoloReferencia = evento.ProtocoloRef
+	registro.Justificativa = evento.Justificativa
+	registro.Payload = evento.Payload
+	registro.XMLOriginal = string(xmlContent)
+	registro.Aplicado = false
+	registro.DataAplicacao = nil
+
+	if err := tx.Save(&registro).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao salvar evento: %w", err)
+	}
+	if evento.TipoEvento == models.EventoCartaCorrecao {
+		if err := salvarCorrecoesCTe(tx, registro.ID, evento.Correcoes); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("erro ao salvar correções da carta de correção: %w", err)
+		}
+	}
+
+	if err := aplicarEvento(tx, &registro, evento); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao aplicar evento %s: %w", evento.TipoEventoDesc, err)
+	}
+
+	// Commit da transação
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	mensagem := fmt.Sprintf("Evento %s aplicado ao documento", evento.TipoEventoDesc)
+	switch {
+	case !evento.Registrado():
+		mensagem = fmt.Sprintf("Evento %s gravado sem efeito: não registrado pela SEFAZ (%s)", evento.TipoEventoDesc, evento.Status)
+	case !registro.Aplicado:
+		mensagem = fmt.Sprintf("Evento %s gravado; será aplicado quando o documento for importado", evento.TipoEventoDesc)
+	}
+
+	return &DocumentoProcessado{
+		Chave:    evento.Chave,
+		Tipo:     tipo,
+		Status:   "PROCESSADO",
+		Mensagem: mensagem,
+	}, nil
+}
+
+// aplicarEventosDocumento reaplica, na ordem, os eventos já gravados para o
+// documento recém-importado ou reprocessado, que chega sem os efeitos deles
+func aplicarEventosDocumento(tx *gorm.DB, chave string) error {
+	registros, err := models.EventosDocumento(tx, chave)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar eventos do documento: %w", err)
+	}
+
+	// Eventos gravados antes da verificação de assinatura e autoria não são
+	// reaplicados e deixam de contar como aplicados antes da aplicação dos
+	// demais, para que a última CC-e considerada seja uma das válidas
+	type eventoValido struct {
+		registro *models.DocumentoEvento
+		evento   *parsers.EventoParsed
+	}
+	var validos []eventoValido
+	for i := range registros {
+		registro := &registros[i]
+
+		var evento *parsers.EventoParsed
+		var err error
+		if registro.DocumentoTipo == "MDFE" {
+			evento, err = parsers.ParseEventoMDFe([]byte(registro.XMLOriginal))
+		} else {
+			evento, err = parsers.ParseEventoCTe([]byte(registro.XMLOriginal))
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler evento %s gravado: %w", registro.TipoEvento, err)
+		}
+		if err := verificarEvento([]byte(registro.XMLOriginal), evento); err != nil {
+			log := logger.GetLogger()
+			log.Warn().Err(err).Str("chave", chave).Str("evento", registro.TipoEvento).Msg("Evento gravado não reaplicado")
+			if registro.Aplicado {
+				if err := tx.Model(registro).Updates(map[string]interface{}{"aplicado": false, "data_aplicacao": nil}).Error; err != nil {
+					return fmt.Errorf("erro ao atualizar evento %s: %w", registro.TipoEvento, err)
+				}
+			}
+			continue
+		}
+		validos = append(validos, eventoValido{registro: registro, evento: evento})
+	}
+
+	for _, valido := range validos {
+		if err := aplicarEvento(tx, valido.registro, valido.evento); err != nil {
+			return fmt.Errorf("erro ao aplicar evento %s: %w", valido.registro.Descricao, err)
+		}
+	}
+
+	return nil
+}
+
+// aplicarEvento aplica o evento registrado ao documento, se ele já foi
+// importado. A aplicação é idempotente: o mesmo evento pode ser reaplicado.
+func aplicarEvento(tx *gorm.DB, registro *models.DocumentoEvento, evento *parsers.EventoParsed) error {
+	if !evento.Registrado() {
+		return nil
+	}
+
+	tabela := tabelasDocumento[registro.DocumentoTipo]
+	var documento struct{ ID uuid.UUID }
+	result := tx.Table(tabela).Select("id").Where("chave = ? AND deleted_at IS NULL", registro.ChaveDocumento).Take(&documento)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		// Documento ainda não importado: o evento fica pendente
+		return nil
+	}
+	if result.Error != nil {
+		return fmt.Errorf("erro ao buscar documento: %w", result.Error)
+	}
+
+	// Marcado antes da aplicação: a CC-e aplicada passa a ser a última válida
+	// usada na correção dos campos do CT-e
+	agora := time.Now()
+	registro.DocumentoID = &documento.ID
+	registro.Aplicado = true
+	registro.DataAplicacao = &agora
+	if err := tx.Omit("Correcoes").Save(registro).Error; err != nil {
+		return fmt.Errorf("erro ao atualizar evento: %w", err)
+	}
+
+	switch registro.TipoEvento {
+	case models.EventoCancelamento:
+		if err := tx.Table(tabela).Where("id = ?", documento.ID).
+			Updates(map[string]interface{}{"cancelado": true, "status": "101"}).Error; err != nil {
+			return fmt.Errorf("erro ao cancelar documento: %w", err)
+		}
+	case models.EventoCartaCorrecao:
+		if registro.DocumentoTipo == "CTE" {
+			if err := aplicarCorrecoesCTe(tx, documento.ID); err != nil {
+				return err
+			}
+		}
+	case models.EventoEncerramento:
+		if registro.DocumentoTipo == "MDFE" {
+			if err := encerrarMDFe(tx, documento.ID, evento); err != nil {
+				return err
+			}
+		}
+	case models.EventoInclusaoCondutor:
+		if registro.DocumentoTipo == "MDFE" && evento.CondutorIncluido != nil {
+			if err := incluirCondutorMDFe(tx, documento.ID, *evento.CondutorIncluido); err != nil {
+				return err
+			}
+		}
+	case models.EventoInclusaoDFe:
+		if registro.DocumentoTipo == "MDFE" {
+			if err := incluirDFeMDFe(tx, documento.ID, evento.DocumentosIncluidos); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// encerrarMDFe registra o encerramento com a data e o município do evento
+func encerrarMDFe(tx *gorm.DB, mdfeID uuid.UUID, evento *parsers.EventoParsed) error {
+	var mdfe models.MDFE
+	if err := tx.Select("id", "encerrado").First(&mdfe, "id = ?", mdfeID).Error; err != nil {
+		return fmt.Errorf("erro ao buscar MDF-e: %w", err)
+	}
+	if mdfe.Encerrado {
+		return nil
+	}
+
+	dataEncerramento := evento.DataEvento
+	local := ""
+	if enc := evento.Encerramento; enc != nil {
+		if enc.Data != nil {
+			dataEncerramento = *enc.Data
+		}
+		// Nome do município quando ele é um dos pontos de descarga
+		local = enc.CodigoMunicipio
+		var descarga models.MDFEDescarga
+		if err := tx.Where("mdfe_id = ? AND codigo_municipio = ?", mdfeID, enc.CodigoMunicipio).
+			First(&descarga).Error; err == nil && descarga.Municipio != "" {
+			local = descarga.Municipio
+		}
+		if enc.UF != "" {
+			local += "/" + enc.UF
+		}
+	}
+
+	if err := mdfe.Encerrar(local); err != nil {
+		return err
+	}
+	mdfe.DataEncerramento = &dataEncerramento
+
+	if err := tx.Model(&mdfe).Updates(map[string]interface{}{
+		"encerrado":          true,
+		"data_encerramento":  mdfe.DataEncerramento,
+		"local_encerramento": mdfe.LocalEncerramento,
+	}).Error; err != nil {
+		return fmt.Errorf("erro ao encerrar MDF-e: %w", err)
+	}
+
+	return nil
+}
+
+// incluirCondutorMDFe acrescenta o condutor ao fim da lista, se ainda não consta
+func incluirCondutorMDFe(tx *gorm.DB, mdfeID uuid.UUID, condutorParsed parsers.CondutorParsed) error {
+	var existentes int64
+	if err := tx.Model(&models.MDFECondutor{}).Where("mdfe_id = ? AND cpf = ?", mdfeID, condutorParsed.CPF).
+		Count(&existentes).Error; err != nil {
+		return fmt.Errorf("erro ao buscar condutores: %w", err)
+	}
+	if existentes > 0 {
+		return nil
+	}
+
+	var ordem int
+	if err := tx.Model(&models.MDFECondutor{}).Where("mdfe_id = ?", mdfeID).
+		Select("COALESCE(MAX(ordem), 0)").Scan(&ordem).Error; err != nil {
+		return fmt.Errorf("erro ao buscar condutores: %w", err)
+	}
+
+	condutor := models.MDFECondutor{
+		MDFEID: mdfeID,
+		Ordem:  ordem + 1,
+		Nome:   condutorParsed.Nome,
+		CPF:    condutorParsed.CPF,
+	}
+	if err := tx.Create(&condutor).Error; err != nil {
+		return fmt.Errorf("erro ao incluir condutor: %w", err)
+	}
+
+	return nil
+}
+
+// incluirDFeMDFe acrescenta as NF-es aos municípios de descarga, criando os
+// municípios que ainda não constam, e as vincula ao MDF-e
+func incluirDFeMDFe(tx *gorm.DB, mdfeID uuid.UUID, descargas []parsers.DescargaParsed) error {
+	var chaves []string
+	for _, descargaParsed := range descargas {
+		var descarga models.MDFEDescarga
+		result := tx.Where("mdfe_id = ? AND codigo_municipio = ?", mdfeID, descargaParsed.CodigoMunicipio).First(&descarga)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			var ordem int
+			if err := tx.Model(&models.MDFEDescarga{}).Where("mdfe_id = ?", mdfeID).
+				Select("COALESCE(MAX(ordem), 0)").Scan(&ordem).Error; err != nil {
+				return fmt.Errorf("erro ao buscar descargas: %w", err)
+			}
+			descarga = models.MDFEDescarga{
+				MDFEID:          mdfeID,
+				Ordem:           ordem + 1,
+				CodigoMunicipio: descargaParsed.CodigoMunicipio,
+				Municipio:       descargaParsed.Municipio,
+				UF:              descargaParsed.UF,
+			}
+			if err := tx.Create(&descarga).Error; err != nil {
+				return fmt.Errorf("erro ao criar descarga em %s: %w", descargaParsed.Municipio, err)
+			}
+		} else if result.Error != nil {
+			return fmt.Errorf("erro ao buscar descarga: %w", result.Error)
+		}
+
+		for _, chave := range descargaParsed.ChavesNFe {
+			var existentes int64
+			if err := tx.Model(&models.MDFEDescargaDocumento{}).Where("descarga_id = ? AND chave = ?", descarga.ID, chave).
+				Count(&existentes).Error; err != nil {
+				return fmt.Errorf("erro ao buscar documentos da descarga: %w", err)
+			}
+			if existentes == 0 {
+				documento := models.MDFEDescargaDocumento{DescargaID: descarga.ID, Tipo: "NFE", Chave: chave}
+				if err := tx.Create(&documento).Error; err != nil {
+					return fmt.Errorf("erro ao incluir NF-e %s: %w", chave, err)
+				}
+			}
+			chaves = append(chaves, chave)
+		}
+	}
+
+	// Indexar as NF-es incluídas
+	nfes, err := buscarOuCriarNFes(tx, chaves)
+	if err != nil {
+		return fmt.Errorf("erro ao processar NF-es incluídas: %w", err)
+	}
+	if len(nfes) > 0 {
+		mdfe := models.MDFE{}
+		mdfe.ID = mdfeID
+		if err := tx.Model(&mdfe).Association("NFes").Append(nfes); err != nil {
+			return fmt.Errorf("erro ao vincular NF-es incluídas: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/italosilva18/destack-transport-api/internal/assinatura"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const chaveMDFe = "35240112345678000195580010000001231000001230"
+
+const eventoMDFe = `<procEventoMDFe xmlns="http://www.portalfiscal.inf.br/mdfe" versao="3.00"><eventoMDFe versao="3.00">
+<infEvento Id="ID{{TIPO}}` + chaveMDFe + `01"><cOrgao>35</cOrgao><tpAmb>1</tpAmb><CNPJ>12345678000195</CNPJ><chMDFe>` + chaveMDFe + `</chMDFe>
+<dhEvento>{{DATA}}</dhEvento><tpEvento>{{TIPO}}</tpEvento><nSeqEvento>1</nSeqEvento><detEvento versaoEvento="3.00">{{DETALHE}}</detEvento></infEvento></eventoMDFe>
+<retEventoMDFe versao="3.00"><infEvento><tpAmb>1</tpAmb><cStat>{{STATUS}}</cStat><xMotivo>Evento registrado</xMotivo><chMDFe>` + chaveMDFe + `</chMDFe>
+<tpEvento>{{TIPO}}</tpEvento><nSeqEvento>1</nSeqEvento><dhRegEvento>{{DATA}}</dhRegEvento><nProt>935240000000001</nProt></infEvento></retEventoMDFe></procEventoMDFe>`
+
+// Certificado de teste do emitente, com o CNPJ no CN, criado uma vez para os eventos
+var (
+	certificadoOnce     sync.Once
+	chaveEmitente       *rsa.PrivateKey
+	certificadoEmitente *x509.Certificate
+)
+
+// assinarEvento assina o infEvento como o emitente das chaves de teste
+func assinarEvento(t *testing.T, evento string) []byte {
+	t.Helper()
+	certificadoOnce.Do(func() {
+		chave, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "TRANSPORTADORA TESTE LTDA:12345678000195"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &chave.PublicKey, chave)
+		require.NoError(t, err)
+		certificado, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		chaveEmitente, certificadoEmitente = chave, certificado
+	})
+	require.NotNil(t, certificadoEmitente)
+
+	assinado, err := assinatura.Assinar([]byte(evento), chaveEmitente, certificadoEmitente)
+	require.NoError(t, err)
+	return assinado
+}
+
+func preencherEventoMDFe(tipo, data, status, detalhe string) string {
+	return strings.NewReplacer("{{TIPO}}", tipo, "{{DATA}}", data, "{{STATUS}}", status, "{{DETALHE}}", detalhe).Replace(eventoMDFe)
+}
+
+func montarEventoMDFe(t *testing.T, tipo, data, status, detalhe string) []byte {
+	return assinarEvento(t, preencherEventoMDFe(tipo, data, status, detalhe))
+}
+
+func TestProcessarEventosMDFe(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "eventos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.Veiculo{}, &models.CTE{}, &models.NFe{}, &models.MDFE{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.MDFEReboque{}, &models.MDFECondutor{}, &models.MDFEContratante{}, &models.MDFEDescarga{}, &models.MDFEDescargaDocumento{},
+		&models.MDFESeguro{}, &models.MDFEAverbacao{}, &models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	// Inclusão de condutor recebida antes do MDF-e: fica pendente
+	inclusao := montarEventoMDFe(t, models.EventoInclusaoCondutor, "2024-01-12T10:00:00-03:00", "135",
+		"<evIncCondutorMDFe><descEvento>Inclusao Condutor</descEvento><condutor><xNome>MOTORISTA RESERVA</xNome><CPF>52998224725</CPF></condutor></evIncCondutorMDFe>")
+	resultado, err := ProcessarXML(db, "", inclusao)
+	require.NoError(t, err)
+	assert.Contains(t, resultado.Mensagem, "quando o documento for importado")
+
+	var pendente models.DocumentoEvento
+	require.NoError(t, db.First(&pendente, "chave_documento = ?", chaveMDFe).Error)
+	assert.False(t, pendente.Aplicado)
+	assert.Nil(t, pendente.DocumentoID)
+	assert.Contains(t, pendente.Payload, "MOTORISTA RESERVA")
+
+	// Importado o MDF-e, o evento pendente é aplicado
+	xmlMDFe := strings.Replace(mdfeBitrem, "{{CONDUTORES}}", "<condutor><xNome>MOTORISTA</xNome><CPF>11144477735</CPF></condutor>", 1)
+	_, err = ProcessarXML(db, "", []byte(xmlMDFe))
+	require.NoError(t, err)
+
+	var mdfe models.MDFE
+	require.NoError(t, db.Preload("Condutores").First(&mdfe, "chave = ?", chaveMDFe).Error)
+	require.Len(t, mdfe.Condutores, 2)
+	require.NoError(t, db.First(&pendente, "id = ?", pendente.ID).Error)
+	assert.True(t, pendente.Aplicado)
+	require.NotNil(t, pendente.DocumentoID)
+	assert.Equal(t, mdfe.ID, *pendente.DocumentoID)
+
+	// Encerramento no município de descarga
+	encerramento := montarEventoMDFe(t, models.EventoEncerramento, "2024-01-13T18:00:00-03:00", "135",
+		"<evEncMDFe><descEvento>Encerramento</descEvento><nProt>935240000000000</nProt><dtEnc>2024-01-13</dtEnc><cUF>41</cUF><cMun>4106902</cMun></evEncMDFe>")
+	_, err = ProcessarXML(db, "", encerramento)
+	require.NoError(t, err)
+
+	// Cancelamento rejeitado: gravado na linha do tempo, sem efeito no documento
+	cancelamento := montarEventoMDFe(t, models.EventoCancelamento, "2024-01-13T19:00:00-03:00", "218",
+		"<evCancMDFe><descEvento>Cancelamento</descEvento><nProt>935240000000000</nProt><xJust>Erro na emissao do manifesto</xJust></evCancMDFe>")
+	resultado, err = ProcessarXML(db, "", cancelamento)
+	require.NoError(t, err)
+	assert.Contains(t, resultado.Mensagem, "sem efeito")
+
+	// Reprocessado o MDF-e, os eventos registrados continuam valendo
+	_, err = ProcessarXML(db, "", []byte(xmlMDFe))
+	require.NoError(t, err)
+
+	require.NoError(t, db.Preload("Condutores").First(&mdfe, "chave = ?", chaveMDFe).Error)
+	assert.True(t, mdfe.Encerrado)
+	assert.Equal(t, "CURITIBA/PR", mdfe.LocalEncerramento)
+	assert.False(t, mdfe.Cancelado)
+	assert.Len(t, mdfe.Condutores, 2)
+
+	eventos, err := models.EventosDocumento(db, chaveMDFe)
+	require.NoError(t, err)
+	require.Len(t, eventos, 3)
+	assert.Equal(t, models.EventoInclusaoCondutor, eventos[0].TipoEvento)
+	assert.Equal(t, models.EventoEncerramento, eventos[1].TipoEvento)
+	assert.Equal(t, "Cancelamento", eventos[2].Descricao)
+	assert.False(t, eventos[2].Aplicado)
+}
+
+const eventoCCe = `<procEventoCTe xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><eventoCTe versao="4.00">
+<infEvento Id="ID110110` + chaveCTe + `0{{SEQ}}"><cOrgao>35</cOrgao><tpAmb>1</tpAmb><CNPJ>12345678000195</CNPJ><chCTe>` + chaveCTe + `</chCTe>
+<dhEvento>2024-01-11T09:0{{SEQ}}:00-03:00</dhEvento><tpEvento>110110</tpEvento><nSeqEvento>{{SEQ}}</nSeqEvento>
+<detEvento versaoEvento="4.00"><evCCeCTe><descEvento>Carta de Correcao</descEvento>{{CORRECOES}}<xCondUso>A Carta de Correcao e disciplinada...</xCondUso></evCCeCTe></detEvento></infEvento></eventoCTe>
+<retEventoCTe versao="4.00"><infEvento><tpAmb>1</tpAmb><cStat>{{STATUS}}</cStat><xMotivo>Evento registrado</xMotivo><chCTe>` + chaveCTe + `</chCTe>
+<tpEvento>110110</tpEvento><nSeqEvento>{{SEQ}}</nSeqEvento><dhRegEvento>2024-01-11T09:0{{SEQ}}:30-03:00</dhRegEvento><nProt>135240000000002</nProt></infEvento></retEventoCTe></procEventoCTe>`
+
+func montarCCe(t *testing.T, sequencia, status string, correcoes ...[3]string) []byte {
+	infCorrecao := ""
+	for _, c := range correcoes {
+		infCorrecao += "<infCorrecao><grupoAlterado>" + c[0] + "</grupoAlterado><campoAlterado>" + c[1] + "</campoAlterado><valorAlterado>" + c[2] + "</valorAlterado></infCorrecao>"
+	}
+	return assinarEvento(t, strings.NewReplacer("{{SEQ}}", sequencia, "{{STATUS}}", status, "{{CORRECOES}}", infCorrecao).Replace(eventoCCe))
+}
+
+func TestProcessarCartaCorrecaoCTe(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cce.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.CTE{}, &models.MDFE{},
+		&models.CTEImposto{}, &models.CTEComponente{}, &models.CTEQuantidade{}, &models.CTEReferencia{}, &models.NFe{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	// CC-e recebida antes do CT-e: aplicada na importação
+	_, err = ProcessarXML(db, "", montarCCe(t, "1", "135", [3]string{"ide", "CFOP", "6352"}, [3]string{"infCarga", "vCarga", "12000.00"}))
+	require.NoError(t, err)
+
+	xmlCTe := []byte(strings.Replace(cteComNFes, "{{NFES}}", "", 1))
+	_, err = ProcessarXML(db, "", xmlCTe)
+	require.NoError(t, err)
+
+	var cte models.CTE
+	require.NoError(t, db.First(&cte, "chave = ?", chaveCTe).Error)
+	assert.Equal(t, "6352", cte.CFOP)
+	assert.Equal(t, 12000.0, cte.ValorCarga)
+	assert.Equal(t, string(xmlCTe), cte.XMLOriginal)
+
+	// A sequência 2 substitui a primeira; a sequência 3, rejeitada, não vale
+	_, err = ProcessarXML(db, "", montarCCe(t, "2", "135", [3]string{"ide", "CFOP", "6351"}, [3]string{"compl", "xObs", "Entrega agendada"},
+		[3]string{"toma3", "toma", "3"}))
+	require.NoError(t, err)
+	_, err = ProcessarXML(db, "", montarCCe(t, "3", "217", [3]string{"ide", "CFOP", "6359"}))
+	require.NoError(t, err)
+
+	// Reprocessado o CT-e, a última carta válida continua aplicada
+	_, err = ReprocessarCTe(db, chaveCTe)
+	require.NoError(t, err)
+
+	require.NoError(t, db.First(&cte, "chave = ?", chaveCTe).Error)
+	assert.Equal(t, "6351", cte.CFOP)
+	assert.Equal(t, 10000.0, cte.ValorCarga)
+	// O tomador não é trocado pela CC-e
+	assert.Equal(t, "0", cte.TipoTomador)
+	assert.Equal(t, parsers.ModalidadeCIF, cte.ModalidadeFrete)
+
+	corrigido, err := CorrigirCTe(db, chaveCTe, []byte(cte.XMLOriginal))
+	require.NoError(t, err)
+	require.NotNil(t, corrigido.Carta)
+	assert.Equal(t, 2, corrigido.Carta.Sequencia)
+	require.Len(t, corrigido.Correcoes, 3)
+	assert.Equal(t, "6353", corrigido.Correcoes[0].ValorOriginal)
+	// O grupo compl não existe no CT-e: a correção fica registrada, sem aplicação
+	assert.False(t, corrigido.Correcoes[1].Aplicada)
+	assert.Equal(t, "toma3", corrigido.Correcoes[2].Grupo)
+	assert.False(t, corrigido.Correcoes[2].Aplicada)
+	assert.Contains(t, string(corrigido.XML), "<toma>0</toma>")
+
+	var versoes int64
+	require.NoError(t, db.Model(&models.CTECorrecao{}).Count(&versoes).Error)
+	assert.Equal(t, int64(6), versoes)
+
+	// CC-e sem assinatura gravada como aplicada antes da verificação: no
+	// reprocessamento deixa de valer para os campos gravados e para o XML corrigido
+	semAssinatura := strings.NewReplacer("{{SEQ}}", "4", "{{STATUS}}", "135", "{{CORRECOES}}",
+		"<infCorrecao><grupoAlterado>ide</grupoAlterado><campoAlterado>CFOP</campoAlterado><valorAlterado>6360</valorAlterado></infCorrecao>").Replace(eventoCCe)
+	require.NoError(t, db.Create(&models.DocumentoEvento{DocumentoTipo: "CTE", ChaveDocumento: chaveCTe, DocumentoID: &cte.ID,
+		TipoEvento: models.EventoCartaCorrecao, Sequencia: 4, DataEvento: time.Date(2024, 1, 11, 9, 4, 0, 0, time.UTC),
+		Status: "135", XMLOriginal: semAssinatura, Aplicado: true,
+		Correcoes: []models.CTECorrecao{{Ordem: 1, GrupoAlterado: "ide", CampoAlterado: "CFOP", ValorAlterado: "6360"}}}).Error)
+
+	_, err = ReprocessarCTe(db, chaveCTe)
+	require.NoError(t, err)
+	require.NoError(t, db.First(&cte, "chave = ?", chaveCTe).Error)
+	assert.Equal(t, "6351", cte.CFOP)
+	corrigido, err = CorrigirCTe(db, chaveCTe, []byte(cte.XMLOriginal))
+	require.NoError(t, err)
+	require.NotNil(t, corrigido.Carta)
+	assert.Equal(t, 2, corrigido.Carta.Sequencia)
+}
+
+func TestRejeitarEventoForjado(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "forjado.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	cancelamento := preencherEventoMDFe(models.EventoCancelamento, "2024-01-13T19:00:00-03:00", "135",
+		"<evCancMDFe><descEvento>Cancelamento</descEvento><nProt>935240000000000</nProt><xJust>Cancelamento sem autorizacao</xJust></evCancMDFe>")
+
+	// Sem assinatura, o retorno com cStat 135 não basta, mesmo no modo tolerante
+	_, err = ProcessarXML(db, "", []byte(cancelamento))
+	assert.ErrorIs(t, err, assinatura.ErrAssinaturaInvalida)
+
+	// Autor que não é o emitente do MDF-e
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(cancelamento, "<CNPJ>12345678000195</CNPJ>", "<CNPJ>98765432000198</CNPJ>", 1)))
+	assert.ErrorIs(t, err, ErrAutorEvento)
+
+	// Assinatura que não confere com o conteúdo do evento
+	signature := `<Signature xmlns="http://www.w3.org/2000/09/xmldsig#"><SignedInfo>` +
+		`<CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/>` +
+		`<SignatureMethod Algorithm="http://www.w3.org/2000/09/xmldsig#rsa-sha1"/>` +
+		`<Reference URI="#ID` + models.EventoCancelamento + chaveMDFe + `01"><DigestMethod Algorithm="http://www.w3.org/2000/09/xmldsig#sha1"/>` +
+		`<DigestValue>AAAAAAAAAAAAAAAAAAAAAAAAAAA=</DigestValue></Reference></SignedInfo><SignatureValue>AAAA</SignatureValue></Signature>`
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(cancelamento, "</infEvento></eventoMDFe>", "</infEvento>"+signature+"</eventoMDFe>", 1)))
+	assert.ErrorIs(t, err, assinatura.ErrAssinaturaInvalida)
+
+	var eventos int64
+	require.NoError(t, db.Model(&models.DocumentoEvento{}).Count(&eventos).Error)
+	assert.Zero(t, eventos)
+}
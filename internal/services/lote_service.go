@@ -0,0 +1,62 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Status do lote de uploads, derivados dos contadores
+const (
+	StatusLoteRecebido          = "RECEBIDO"
+	StatusLoteProcessando       = "PROCESSANDO"
+	StatusLoteConcluido         = "CONCLUIDO"
+	StatusLoteConcluidoComErros = "CONCLUIDO_COM_ERROS"
+)
+
+// AtualizarLote recalcula os contadores do lote a partir do status dos uploads.
+// A contagem é feita em uma única instrução para que atualizações concorrentes
+// de workers diferentes não gravem valores parciais.
+func AtualizarLote(db *gorm.DB, batchID uuid.UUID) error {
+	contar := func(status string) *gorm.DB {
+		return db.Model(&models.Upload{}).Select("COUNT(*)").Where("batch_id = ? AND status = ?", batchID, status)
+	}
+
+	err := db.Model(&models.UploadBatch{}).Where("id = ?", batchID).Updates(map[string]interface{}{
+		"pendentes":   contar("PENDENTE"),
+		"processando": contar("PROCESSANDO"),
+		"concluidos":  contar("CONCLUIDO"),
+		"erros":       contar("ERRO"),
+		"duplicados":  contar("DUPLICADO"),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar contadores do lote: %w", err)
+	}
+
+	// O status usa os contadores recém-gravados; o lote em registro não é concluído
+	err = db.Model(&models.UploadBatch{}).Where("id = ?", batchID).Update("status", gorm.Expr(
+		"CASE WHEN pendentes + processando > 0 OR registrando THEN "+
+			"(CASE WHEN pendentes = total_arquivos THEN ? ELSE ? END) "+
+			"WHEN erros > 0 THEN ? ELSE ? END",
+		StatusLoteRecebido, StatusLoteProcessando, StatusLoteConcluidoComErros, StatusLoteConcluido,
+	)).Error
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar status do lote: %w", err)
+	}
+
+	return nil
+}
+
+// AtualizarLoteDoUpload recalcula o lote ao qual o upload pertence, se houver
+func AtualizarLoteDoUpload(db *gorm.DB, uploadID uuid.UUID) error {
+	var upload models.Upload
+	if err := db.Select("id", "batch_id").First(&upload, "id = ?", uploadID).Error; err != nil {
+		return fmt.Errorf("erro ao buscar upload: %w", err)
+	}
+	if upload.BatchID == nil {
+		return nil
+	}
+	return AtualizarLote(db, *upload.BatchID)
+}
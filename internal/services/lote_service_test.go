@@ -0,0 +1,57 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAtualizarLote(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "lote.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.UploadBatch{}, &models.Upload{}))
+
+	batch := models.UploadBatch{NomeArquivo: "lote.zip", Status: StatusLoteRecebido, DataUpload: time.Now(), TotalArquivos: 3}
+	require.NoError(t, db.Create(&batch).Error)
+
+	uploads := make([]models.Upload, 3)
+	for i := range uploads {
+		uploads[i] = models.Upload{NomeArquivo: "cte.xml", Status: "PENDENTE", DataUpload: time.Now(), BatchID: &batch.ID}
+		require.NoError(t, db.Create(&uploads[i]).Error)
+	}
+
+	require.NoError(t, AtualizarLote(db, batch.ID))
+	require.NoError(t, db.First(&batch, "id = ?", batch.ID).Error)
+	assert.Equal(t, StatusLoteRecebido, batch.Status)
+	assert.Equal(t, 3, batch.Pendentes)
+
+	db.Model(&uploads[0]).Update("status", "CONCLUIDO")
+	db.Model(&uploads[1]).Update("status", "PROCESSANDO")
+	require.NoError(t, AtualizarLoteDoUpload(db, uploads[1].ID))
+	require.NoError(t, db.First(&batch, "id = ?", batch.ID).Error)
+	assert.Equal(t, StatusLoteProcessando, batch.Status)
+	assert.Equal(t, 1, batch.Pendentes)
+	assert.Equal(t, 1, batch.Processando)
+	assert.Equal(t, 1, batch.Concluidos)
+
+	db.Model(&uploads[1]).Update("status", "ERRO")
+	db.Model(&uploads[2]).Update("status", "CONCLUIDO")
+	require.NoError(t, AtualizarLote(db, batch.ID))
+	require.NoError(t, db.First(&batch, "id = ?", batch.ID).Error)
+	assert.Equal(t, StatusLoteConcluidoComErros, batch.Status)
+	assert.Equal(t, 2, batch.Concluidos)
+	assert.Equal(t, 1, batch.Erros)
+	assert.InDelta(t, 100, batch.Progresso(), 0.001)
+
+	// Com o registro dos arquivos em andamento, o lote não é concluído
+	require.NoError(t, db.Model(&batch).Update("registrando", true).Error)
+	require.NoError(t, AtualizarLote(db, batch.ID))
+	require.NoError(t, db.First(&batch, "id = ?", batch.ID).Error)
+	assert.Equal(t, StatusLoteProcessando, batch.Status)
+}
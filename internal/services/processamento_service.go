@@ -3,15 +3,34 @@ package services
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/internal/assinatura"
 	"github.com/italosilva18/destack-transport-api/internal/models"
 	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/italosilva18/destack-transport-api/internal/validacao"
 	"github.com/italosilva18/destack-transport-api/pkg/logger"
 	"gorm.io/gorm"
 )
 
+// ErrDocumentoRejeitado indica um XML que falha sempre com o mesmo conteúdo
+// (parse, tipo não suportado), sem sentido em processar novamente
+var ErrDocumentoRejeitado = errors.New("documento rejeitado")
+
+// documentoRejeitado marca o erro como definitivo, mantendo a mensagem e a cadeia original
+type documentoRejeitado struct{ err error }
+
+func (d documentoRejeitado) Error() string        { return d.err.Error() }
+func (d documentoRejeitado) Unwrap() error        { return d.err }
+func (d documentoRejeitado) Is(target error) bool { return target == ErrDocumentoRejeitado }
+
+// rejeitar marca o erro como ErrDocumentoRejeitado
+func rejeitar(err error) error {
+	return documentoRejeitado{err: err}
+}
+
 // DocumentoProcessado representa o resultado do processamento de um XML
 type DocumentoProcessado struct {
 	Chave    string
@@ -26,40 +45,59 @@ func ProcessarXML(db *gorm.DB, uploadID string, xmlContent []byte) (*DocumentoPr
 	log.Info().Str("upload_id", uploadID).Msg("Iniciando processamento de XML")
 
 	// Detectar tipo de documento
-	tipoDoc, err := detectarTipoDocumento(xmlContent)
+	descritor, err := parsers.DetectarDocumento(xmlContent)
 	if err != nil {
 		log.Error().Err(err).Msg("Erro ao detectar tipo de documento")
+		return nil, rejeitar(err)
+	}
+	tipoDoc := string(descritor.Tipo)
+
+	// Validar contra o schema XSD da versão do documento; no modo estrito o XML é rejeitado
+	validacaoXSD, err := validacao.Padrao().Validar(descritor, xmlContent)
+	if err != nil {
+		log.Error().Err(err).Str("tipo", tipoDoc).Msg("XML rejeitado na validação de schema")
 		return nil, err
 	}
+	avisos := avisosValidacao(validacaoXSD)
 
 	// Processar conforme o tipo
 	var resultado *DocumentoProcessado
 
-	switch tipoDoc {
-	case "CTE":
+	switch descritor.Tipo {
+	case parsers.TipoCTe:
 		resultado, err = processarCTe(db, xmlContent, uploadID)
-	case "MDFE":
+	case parsers.TipoCTeOS:
+		resultado, err = processarCTeOS(db, xmlContent, uploadID)
+	case parsers.TipoMDFe:
 		resultado, err = processarMDFe(db, xmlContent, uploadID)
-	case "EVENTO_CTE":
+	case parsers.TipoEventoCTe:
 		resultado, err = processarEventoCTe(db, xmlContent)
-	case "EVENTO_MDFE":
+	case parsers.TipoEventoMDFe:
 		resultado, err = processarEventoMDFe(db, xmlContent)
 	default:
-		err = errors.New("tipo de documento não suportado")
+		err = rejeitar(fmt.Errorf("tipo de documento não suportado: %s (modelo %s)", descritor.Raiz, descritor.Modelo))
 	}
 
 	if err != nil {
 		log.Error().Err(err).Str("tipo", tipoDoc).Msg("Erro ao processar documento")
+		if avisos != "" {
+			// As violações de schema costumam explicar a falha do parse
+			err = fmt.Errorf("%w\n%s", err, avisos)
+		}
 		return nil, err
 	}
 
 	// Atualizar upload com a chave processada
 	if uploadID != "" && resultado != nil {
 		uploadUUID, _ := uuid.Parse(uploadID)
-		db.Model(&models.Upload{}).Where("id = ?", uploadUUID).Updates(map[string]interface{}{
+		campos := map[string]interface{}{
 			"status":               "CONCLUIDO",
 			"chave_doc_processado": &resultado.Chave,
-		})
+		}
+		if avisos != "" {
+			campos["detalhes_processamento"] = avisos
+		}
+		db.Model(&models.Upload{}).Where("id = ?", uploadUUID).Updates(campos)
 	}
 
 	log.Info().Str("upload_id", uploadID).Str("tipo", tipoDoc).Str("chave", resultado.Chave).Msg("Processamento concluído com sucesso")
@@ -67,22 +105,15 @@ func ProcessarXML(db *gorm.DB, uploadID string, xmlContent []byte) (*DocumentoPr
 	return resultado, nil
 }
 
-// detectarTipoDocumento detecta o tipo de documento a partir do conteúdo XML
-func detectarTipoDocumento(xmlContent []byte) (string, error) {
-	content := string(xmlContent)
-
-	// Checar tipos de documento
-	if strings.Contains(content, "<cteProc") || strings.Contains(content, "<CTe") {
-		return "CTE", nil
-	} else if strings.Contains(content, "<mdfeProc") || strings.Contains(content, "<MDFe") {
-		return "MDFE", nil
-	} else if strings.Contains(content, "<procEventoCTe") || strings.Contains(content, "<eventoCTe") {
-		return "EVENTO_CTE", nil
-	} else if strings.Contains(content, "<procEventoMDFe") || strings.Contains(content, "<eventoMDFe") {
-		return "EVENTO_MDFE", nil
+// avisosValidacao formata as violações de schema aceitas no modo tolerante
+func avisosValidacao(resultado *validacao.Resultado) string {
+	if resultado.Aviso != "" {
+		return "Aviso de validação: " + resultado.Aviso
 	}
-
-	return "", errors.New("tipo de documento não identificado")
+	if resultado.Valido() {
+		return ""
+	}
+	return fmt.Sprintf("Avisos de validação (%s):\n%s", resultado.Schema, resultado.Detalhes())
 }
 
 // processarCTe processa um CT-e
@@ -90,7 +121,19 @@ func processarCTe(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoPr
 	// Parser do CT-e
 	cteParsed, err := parsers.ParseCTe(xmlContent)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao fazer parse do CT-e: %w", err)
+		return nil, rejeitar(fmt.Errorf("erro ao fazer parse do CT-e: %w", err))
+	}
+
+	// A chave de acesso deve conferir com o DV e com os dados do documento
+	if err := cteParsed.ChaveAcesso.Erro(); err != nil {
+		return nil, err
+	}
+
+	// Verificar a assinatura digital: documentos adulterados não são importados
+	verificacao := assinatura.Verificar(xmlContent)
+	verificacao.ConferirEmitente(cteParsed.Emitente.CNPJ)
+	if err := verificacao.Erro(); err != nil {
+		return nil, err
 	}
 
 	// Iniciar transação
@@ -108,29 +151,64 @@ func processarCTe(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoPr
 		return nil, fmt.Errorf("erro ao processar emitente: %w", err)
 	}
 
+	// Complementar, anulação e substituto podem omitir as partes do CT-e original
+	var original partesCTe
+	if len(cteParsed.ChavesReferenciadas) > 0 {
+		var cteOriginal models.CTE
+		err := tx.Where("chave = ?", cteParsed.ChavesReferenciadas[0]).First(&cteOriginal).Error
+		if err == nil {
+			original = partesCTe{
+				remetente:    &cteOriginal.RemetenteID,
+				destinatario: &cteOriginal.DestinatarioID,
+				tomador:      cteOriginal.TomadorID,
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			tx.Rollback()
+			return nil, fmt.Errorf("erro ao buscar CT-e original: %w", err)
+		}
+	}
+
 	// Buscar ou criar remetente
-	remetente, err := buscarOuCriarEmpresa(tx, cteParsed.Remetente)
+	remetenteID, err := empresaOuOriginal(tx, cteParsed.Remetente, original.remetente)
 	if err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("erro ao processar remetente: %w", err)
 	}
 
 	// Buscar ou criar destinatário
-	destinatario, err := buscarOuCriarEmpresa(tx, cteParsed.Destinatario)
+	destinatarioID, err := empresaOuOriginal(tx, cteParsed.Destinatario, original.destinatario)
 	if err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("erro ao processar destinatário: %w", err)
 	}
 
+	// Buscar ou criar expedidor e recebedor (opcionais)
+	var expedidorID, recebedorID *uuid.UUID
+	if cteParsed.Expedidor != nil {
+		expedidor, err := buscarOuCriarEmpresa(tx, *cteParsed.Expedidor)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("erro ao processar expedidor: %w", err)
+		}
+		expedidorID = &expedidor.ID
+	}
+	if cteParsed.Recebedor != nil {
+		recebedor, err := buscarOuCriarEmpresa(tx, *cteParsed.Recebedor)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("erro ao processar recebedor: %w", err)
+		}
+		recebedorID = &recebedor.ID
+	}
+
 	// Buscar ou criar tomador (se diferente)
 	var tomadorID *uuid.UUID
 	if cteParsed.Tomador != nil {
-		tomador, err := buscarOuCriarEmpresa(tx, *cteParsed.Tomador)
+		tomadorID, err = empresaOuOriginal(tx, *cteParsed.Tomador, original.tomador)
 		if err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("erro ao processar tomador: %w", err)
 		}
-		tomadorID = &tomador.ID
 	}
 
 	// Converter upload ID para UUID
@@ -145,31 +223,44 @@ func processarCTe(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoPr
 	// Criar ou atualizar o CT-e
 	novoCte := models.CTE{
 		DocumentoFiscal: models.DocumentoFiscal{
-			Chave:           cteParsed.Chave,
-			Tipo:            "CTE",
-			Numero:          cteParsed.Numero,
-			Serie:           cteParsed.Serie,
-			DataEmissao:     cteParsed.DataEmissao,
-			Status:          cteParsed.Status,
-			Protocolo:       cteParsed.Protocolo,
-			ValorTotal:      cteParsed.ValorTotal,
-			EmitenteID:      emitente.ID,
-			UFInicio:        cteParsed.UFInicio,
-			UFDestino:       cteParsed.UFDestino,
-			MunicipioInicio: cteParsed.MunicipioInicio,
-			MunicipioFim:    cteParsed.MunicipioFim,
-			XMLOriginal:     string(xmlContent),
-			UploadID:        uploadUUID,
+			Chave:             cteParsed.Chave,
+			Tipo:              "CTE",
+			Numero:            cteParsed.Numero,
+			Serie:             cteParsed.Serie,
+			DataEmissao:       cteParsed.DataEmissao,
+			Status:            statusDocumento(cteParsed.Status),
+			Protocolo:         cteParsed.Protocolo,
+			ValorTotal:        cteParsed.ValorTotal,
+			EmitenteID:        emitente.ID,
+			Modal:             cteParsed.Modal,
+			UFInicio:          cteParsed.UFInicio,
+			UFDestino:         cteParsed.UFDestino,
+			MunicipioInicio:   cteParsed.MunicipioInicio,
+			MunicipioFim:      cteParsed.MunicipioFim,
+			XMLOriginal:       string(xmlContent),
+			AssinaturaStatus:  verificacao.Status,
+			AssinaturaCNPJ:    verificacao.CNPJ,
+			AssinaturaTitular: verificacao.Titular,
+			UploadID:          uploadUUID,
 		},
-		RemetenteID:     remetente.ID,
-		DestinatarioID:  destinatario.ID,
-		TomadorID:       tomadorID,
-		CFOP:            cteParsed.CFOP,
-		ModalidadeFrete: cteParsed.ModalidadeFrete,
-		ValorCarga:      cteParsed.ValorCarga,
-		RNTRC:           cteParsed.RNTRC,
-		PlacaVeiculo:    cteParsed.PlacaVeiculo,
-		ObsGerais:       cteParsed.ObservacoesGerais,
+		RemetenteID:        *remetenteID,
+		DestinatarioID:     *destinatarioID,
+		ExpedidorID:        expedidorID,
+		RecebedorID:        recebedorID,
+		TomadorID:          tomadorID,
+		TipoTomador:        cteParsed.TipoTomador,
+		TipoCTe:            cteParsed.TipoCTe,
+		CFOP:               cteParsed.CFOP,
+		ModalidadeFrete:    cteParsed.ModalidadeFrete,
+		ValorCarga:         cteParsed.ValorCarga,
+		ValorPedagio:       cteParsed.ValorPedagio,
+		OutrosValores:      cteParsed.OutrosValores,
+		PesoKg:             cteParsed.PesoKg,
+		ValorICMS:          cteParsed.ValorICMS,
+		ValorTotalTributos: cteParsed.ValorTotalTributos,
+		RNTRC:              cteParsed.RNTRC,
+		PlacaVeiculo:       cteParsed.PlacaVeiculo,
+		ObsGerais:          cteParsed.ObservacoesGerais,
 	}
 
 	// Verificar se já existe
@@ -188,13 +279,63 @@ func processarCTe(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoPr
 			return nil, fmt.Errorf("erro ao buscar CT-e existente: %w", result.Error)
 		}
 	} else {
-		// Atualizar existente
-		if err := tx.Model(&existingCte).Updates(novoCte).Error; err != nil {
+		// Atualizar existente regravando todas as colunas, inclusive as que ficaram vazias
+		if err := tx.Model(&existingCte).Select("*").Omit(colunasPreservadas(uploadUUID)...).Updates(&novoCte).Error; err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("erro ao atualizar CT-e: %w", err)
 		}
 	}
 
+	// Substituir a tributação do CT-e
+	cteID := novoCte.ID
+	if cteID == uuid.Nil {
+		cteID = existingCte.ID
+	}
+	if err := salvarImpostosCTe(tx, cteID, cteParsed.Impostos); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao salvar impostos do CT-e: %w", err)
+	}
+	if err := salvarComposicaoCTe(tx, cteID, cteParsed.Componentes, cteParsed.Quantidades); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao salvar composição do frete do CT-e: %w", err)
+	}
+	if err := salvarReferenciasCTe(tx, cteID, cteParsed.TipoCTe, cteParsed.ChavesReferenciadas); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao salvar CT-es referenciados: %w", err)
+	}
+	if err := salvarDetalheModal(tx, cteID, "CTE", cteParsed.DetalheModal); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao salvar informações do modal do CT-e: %w", err)
+	}
+
+	// Indexar as NF-es transportadas
+	nfes, err := buscarOuCriarNFes(tx, cteParsed.ChavesNFe)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao processar NF-es do CT-e: %w", err)
+	}
+	if len(nfes) == 1 && cteParsed.ValorCarga > 0 && nfes[0].Valor == nil {
+		// Uma única nota: o valor da carga é o valor da NF-e
+		valor := cteParsed.ValorCarga
+		nfes[0].Valor = &valor
+		if err := tx.Model(&nfes[0]).Update("valor", valor).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("erro ao atualizar valor da NF-e: %w", err)
+		}
+	}
+	cteRef := models.CTE{}
+	cteRef.ID = cteID
+	if err := tx.Model(&cteRef).Association("NFes").Replace(nfes); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao vincular NF-es ao CT-e: %w", err)
+	}
+
+	// Reaplicar os eventos já recebidos (cancelamento, encerramento, inclusões)
+	if err := aplicarEventosDocumento(tx, cteParsed.Chave); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao aplicar eventos do CT-e: %w", err)
+	}
+
 	// Commit da transação
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
@@ -208,12 +349,412 @@ func processarCTe(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoPr
 	}, nil
 }
 
+// partesCTe são as partes do CT-e original herdadas pelo complementar, anulação ou substituto
+type partesCTe struct {
+	remetente, destinatario, tomador *uuid.UUID
+}
+
+// empresaOuOriginal busca ou cria a empresa informada ou, se o XML não a
+// identificar, usa a parte do CT-e original
+func empresaOuOriginal(tx *gorm.DB, empresaParsed parsers.EmpresaParsed, original *uuid.UUID) (*uuid.UUID, error) {
+	if empresaParsed.CNPJ == "" && empresaParsed.CPF == "" && original != nil {
+		return original, nil
+	}
+	empresa, err := buscarOuCriarEmpresa(tx, empresaParsed)
+	if err != nil {
+		return nil, err
+	}
+	return &empresa.ID, nil
+}
+
+// relacoesCTe mapeia o tpCTe para a relação com o CT-e referenciado
+var relacoesCTe = map[string]string{
+	parsers.CTeComplementar: models.RelacaoComplemento,
+	parsers.CTeAnulacao:     models.RelacaoAnulacao,
+	parsers.CTeSubstituto:   models.RelacaoSubstituicao,
+}
+
+// salvarReferenciasCTe grava os CT-es complementados, anulado ou substituído,
+// descartando os de um processamento anterior
+func salvarReferenciasCTe(tx *gorm.DB, cteID uuid.UUID, tipoCTe string, chaves []string) error {
+	if err := tx.Unscoped().Where("cte_id = ?", cteID).Delete(&models.CTEReferencia{}).Error; err != nil {
+		return err
+	}
+
+	relacao, ok := relacoesCTe[tipoCTe]
+	if !ok {
+		return nil
+	}
+	for _, chave := range chaves {
+		referencia := models.CTEReferencia{
+			CTEID:         cteID,
+			Relacao:       relacao,
+			ChaveOriginal: chave,
+		}
+		if err := tx.Create(&referencia).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// salvarDetalheModal grava as informações dos modais não rodoviários do
+// documento, descartando as de um processamento anterior
+func salvarDetalheModal(tx *gorm.DB, documentoID uuid.UUID, tipo string, detalhe parsers.DetalheModalParsed) error {
+	for _, tabela := range []interface{}{&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{},
+		&models.ModalDutoviario{}, &models.ModalMultimodal{}} {
+		if err := tx.Unscoped().Where("documento_id = ?", documentoID).Delete(tabela).Error; err != nil {
+			return err
+		}
+	}
+
+	var registros []interface{}
+	if aereo := detalhe.Aereo; aereo != nil {
+		registros = append(registros, &models.ModalAereo{
+			DocumentoID:       documentoID,
+			DocumentoTipo:     tipo,
+			NumeroMinuta:      aereo.NumeroMinuta,
+			NumeroOCA:         aereo.NumeroOCA,
+			DataPrevista:      aereo.DataPrevista,
+			ClasseTarifa:      aereo.ClasseTarifa,
+			CodigoTarifa:      aereo.CodigoTarifa,
+			ValorTarifa:       aereo.ValorTarifa,
+			Nacionalidade:     aereo.Nacionalidade,
+			Matricula:         aereo.Matricula,
+			NumeroVoo:         aereo.NumeroVoo,
+			AeroportoEmbarque: aereo.AeroportoEmbarque,
+			AeroportoDestino:  aereo.AeroportoDestino,
+			DataVoo:           aereo.DataVoo,
+		})
+	}
+	if aquav := detalhe.Aquaviario; aquav != nil {
+		registros = append(registros, &models.ModalAquaviario{
+			DocumentoID:      documentoID,
+			DocumentoTipo:    tipo,
+			Embarcacao:       aquav.Embarcacao,
+			CodigoEmbarcacao: aquav.CodigoEmbarcacao,
+			IRIN:             aquav.IRIN,
+			NumeroViagem:     aquav.NumeroViagem,
+			Direcao:          aquav.Direcao,
+			TipoNavegacao:    aquav.TipoNavegacao,
+			ValorPrestacao:   aquav.ValorPrestacao,
+			ValorAFRMM:       aquav.ValorAFRMM,
+			PortoEmbarque:    aquav.PortoEmbarque,
+			PortoDestino:     aquav.PortoDestino,
+			Balsas:           strings.Join(aquav.Balsas, ","),
+			Conteineres:      strings.Join(aquav.Conteineres, ","),
+		})
+	}
+	if ferrov := detalhe.Ferroviario; ferrov != nil {
+		registros = append(registros, &models.ModalFerroviario{
+			DocumentoID:            documentoID,
+			DocumentoTipo:          tipo,
+			TipoTrafego:            ferrov.TipoTrafego,
+			Fluxo:                  ferrov.Fluxo,
+			ResponsavelFaturamento: ferrov.ResponsavelFaturamento,
+			FerroviaEmitente:       ferrov.FerroviaEmitente,
+			ValorFrete:             ferrov.ValorFrete,
+			PrefixoTrem:            ferrov.PrefixoTrem,
+			DataHoraTrem:           ferrov.DataHoraTrem,
+			Origem:                 ferrov.Origem,
+			Destino:                ferrov.Destino,
+			QuantidadeVagoes:       ferrov.QuantidadeVagoes,
+			PesoVagoes:             ferrov.PesoVagoes,
+		})
+	}
+	if duto := detalhe.Dutoviario; duto != nil {
+		registros = append(registros, &models.ModalDutoviario{
+			DocumentoID:   documentoID,
+			DocumentoTipo: tipo,
+			ValorTarifa:   duto.ValorTarifa,
+			DataInicio:    duto.DataInicio,
+			DataFim:       duto.DataFim,
+		})
+	}
+	if multi := detalhe.Multimodal; multi != nil {
+		registros = append(registros, &models.ModalMultimodal{
+			DocumentoID:   documentoID,
+			DocumentoTipo: tipo,
+			COTM:          multi.COTM,
+			IndNegociavel: multi.IndNegociavel,
+		})
+	}
+
+	for _, registro := range registros {
+		if err := tx.Create(registro).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// salvarImpostosCTe grava os tributos do CT-e, descartando os de um processamento anterior
+func salvarImpostosCTe(tx *gorm.DB, cteID uuid.UUID, impostos []parsers.ImpostoParsed) error {
+	if err := tx.Unscoped().Where("cte_id = ?", cteID).Delete(&models.CTEImposto{}).Error; err != nil {
+		return err
+	}
+	if len(impostos) == 0 {
+		return nil
+	}
+
+	registros := make([]models.CTEImposto, 0, len(impostos))
+	for _, imposto := range impostos {
+		registros = append(registros, models.CTEImposto{
+			CTEID:               cteID,
+			Tributo:             imposto.Tributo,
+			Grupo:               imposto.Grupo,
+			CST:                 imposto.CST,
+			ClassTrib:           imposto.ClassTrib,
+			BaseCalculo:         imposto.BaseCalculo,
+			PercentualReducaoBC: imposto.PercentualReducaoBC,
+			Aliquota:            imposto.Aliquota,
+			Valor:               imposto.Valor,
+			ValorCredito:        imposto.ValorCredito,
+		})
+	}
+	return tx.Create(&registros).Error
+}
+
+// salvarComposicaoCTe grava os componentes do valor da prestação e as quantidades
+// da carga, descartando os de um processamento anterior
+func salvarComposicaoCTe(tx *gorm.DB, cteID uuid.UUID, componentes []parsers.ComponenteParsed, quantidades []parsers.QuantidadeParsed) error {
+	if err := tx.Unscoped().Where("cte_id = ?", cteID).Delete(&models.CTEComponente{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("cte_id = ?", cteID).Delete(&models.CTEQuantidade{}).Error; err != nil {
+		return err
+	}
+
+	if len(componentes) > 0 {
+		registros := make([]models.CTEComponente, 0, len(componentes))
+		for _, componente := range componentes {
+			registros = append(registros, models.CTEComponente{
+				CTEID: cteID,
+				Nome:  componente.Nome,
+				Tipo:  componente.Tipo,
+				Valor: componente.Valor,
+			})
+		}
+		if err := tx.Create(&registros).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(quantidades) > 0 {
+		registros := make([]models.CTEQuantidade, 0, len(quantidades))
+		for _, quantidade := range quantidades {
+			registros = append(registros, models.CTEQuantidade{
+				CTEID:         cteID,
+				CodigoUnidade: quantidade.CodigoUnidade,
+				Unidade:       quantidade.Unidade,
+				TipoMedida:    quantidade.TipoMedida,
+				Quantidade:    quantidade.Quantidade,
+			})
+		}
+		if err := tx.Create(&registros).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buscarOuCriarNFes retorna as NF-es das chaves, criando as que ainda não
+// existem com os dados decompostos da chave. Chaves repetidas são ignoradas.
+func buscarOuCriarNFes(tx *gorm.DB, chaves []string) ([]models.NFe, error) {
+	nfes := make([]models.NFe, 0, len(chaves))
+	vistas := make(map[string]bool, len(chaves))
+	for _, chave := range chaves {
+		if vistas[chave] {
+			continue
+		}
+		vistas[chave] = true
+
+		var nfe models.NFe
+		err := tx.Where("chave = ?", chave).First(&nfe).Error
+		if err == nil {
+			nfes = append(nfes, nfe)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		nfe = models.NFe{Chave: chave}
+		if decomposta, err := parsers.DecomporChaveAcesso(chave); err == nil {
+			nfe.CUF = decomposta.CUF
+			nfe.AnoMes = decomposta.AnoMes
+			nfe.CNPJEmitente = decomposta.CNPJCPF
+			nfe.Modelo = decomposta.Modelo
+			nfe.Serie = strings.TrimLeft(decomposta.Serie, "0")
+			if nfe.Serie == "" {
+				nfe.Serie = "0"
+			}
+			nfe.Numero, _ = strconv.Atoi(decomposta.Numero)
+		}
+		if err := tx.Create(&nfe).Error; err != nil {
+			return nil, err
+		}
+		nfes = append(nfes, nfe)
+	}
+	return nfes, nil
+}
+
+// processarCTeOS processa um CT-e OS
+func processarCTeOS(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoProcessado, error) {
+	// Parser do CT-e OS
+	cteOSParsed, err := parsers.ParseCTeOS(xmlContent)
+	if err != nil {
+		return nil, rejeitar(fmt.Errorf("erro ao fazer parse do CT-e OS: %w", err))
+	}
+
+	// A chave de acesso deve conferir com o DV e com os dados do documento
+	if err := cteOSParsed.ChaveAcesso.Erro(); err != nil {
+		return nil, err
+	}
+
+	// Verificar a assinatura digital: documentos adulterados não são importados
+	verificacao := assinatura.Verificar(xmlContent)
+	verificacao.ConferirEmitente(cteOSParsed.Emitente.CNPJ)
+	if err := verificacao.Erro(); err != nil {
+		return nil, err
+	}
+
+	// Iniciar transação
+	tx := db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Buscar ou criar emitente
+	emitente, err := buscarOuCriarEmpresa(tx, cteOSParsed.Emitente)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao processar emitente: %w", err)
+	}
+
+	// Buscar ou criar tomador
+	var tomadorID *uuid.UUID
+	if cteOSParsed.Tomador != nil {
+		tomador, err := buscarOuCriarEmpresa(tx, *cteOSParsed.Tomador)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("erro ao processar tomador: %w", err)
+		}
+		tomadorID = &tomador.ID
+	}
+
+	// Converter upload ID para UUID
+	var uploadUUID *uuid.UUID
+	if uploadID != "" {
+		parsed, err := uuid.Parse(uploadID)
+		if err == nil {
+			uploadUUID = &parsed
+		}
+	}
+
+	// Criar ou atualizar o CT-e OS
+	novoCteOS := models.CTEOS{
+		DocumentoFiscal: models.DocumentoFiscal{
+			Chave:             cteOSParsed.Chave,
+			Tipo:              "CTEOS",
+			Numero:            cteOSParsed.Numero,
+			Serie:             cteOSParsed.Serie,
+			DataEmissao:       cteOSParsed.DataEmissao,
+			Status:            statusDocumento(cteOSParsed.Status),
+			Protocolo:         cteOSParsed.Protocolo,
+			ValorTotal:        cteOSParsed.ValorTotal,
+			EmitenteID:        emitente.ID,
+			Modal:             cteOSParsed.Modal,
+			UFInicio:          cteOSParsed.UFInicio,
+			UFDestino:         cteOSParsed.UFDestino,
+			MunicipioInicio:   cteOSParsed.MunicipioInicio,
+			MunicipioFim:      cteOSParsed.MunicipioFim,
+			XMLOriginal:       string(xmlContent),
+			AssinaturaStatus:  verificacao.Status,
+			AssinaturaCNPJ:    verificacao.CNPJ,
+			AssinaturaTitular: verificacao.Titular,
+			UploadID:          uploadUUID,
+		},
+		TomadorID:          tomadorID,
+		CFOP:               cteOSParsed.CFOP,
+		TipoServico:        cteOSParsed.TipoServico,
+		DescricaoServico:   cteOSParsed.DescricaoServico,
+		Quantidade:         cteOSParsed.Quantidade,
+		ValorReceber:       cteOSParsed.ValorReceber,
+		ValorICMS:          cteOSParsed.ValorICMS,
+		ValorTotalTributos: cteOSParsed.ValorTotalTributos,
+		TAF:                cteOSParsed.TAF,
+		NroRegEstadual:     cteOSParsed.NroRegEstadual,
+		PlacaVeiculo:       cteOSParsed.PlacaVeiculo,
+		TipoFretamento:     cteOSParsed.TipoFretamento,
+		ObsGerais:          cteOSParsed.ObservacoesGerais,
+	}
+
+	// Verificar se já existe
+	var existingCteOS models.CTEOS
+	result := tx.Where("chave = ?", cteOSParsed.Chave).First(&existingCteOS)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Criar novo
+			if err := tx.Create(&novoCteOS).Error; err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("erro ao criar CT-e OS: %w", err)
+			}
+		} else {
+			tx.Rollback()
+			return nil, fmt.Errorf("erro ao buscar CT-e OS existente: %w", result.Error)
+		}
+	} else {
+		// Atualizar existente regravando todas as colunas, inclusive as que ficaram vazias
+		if err := tx.Model(&existingCteOS).Select("*").Omit(colunasPreservadas(uploadUUID)...).Updates(&novoCteOS).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("erro ao atualizar CT-e OS: %w", err)
+		}
+	}
+
+	// Reaplicar os eventos já recebidos (cancelamento, encerramento, inclusões)
+	if err := aplicarEventosDocumento(tx, cteOSParsed.Chave); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao aplicar eventos do CT-e OS: %w", err)
+	}
+
+	// Commit da transação
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	return &DocumentoProcessado{
+		Chave:    cteOSParsed.Chave,
+		Tipo:     "CTEOS",
+		Status:   "PROCESSADO",
+		Mensagem: fmt.Sprintf("CT-e OS %d processado com sucesso", cteOSParsed.Numero),
+	}, nil
+}
+
 // processarMDFe processa um MDF-e
 func processarMDFe(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoProcessado, error) {
 	// Parser do MDF-e
 	mdfeParsed, err := parsers.ParseMDFe(xmlContent)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao fazer parse do MDF-e: %w", err)
+		return nil, rejeitar(fmt.Errorf("erro ao fazer parse do MDF-e: %w", err))
+	}
+
+	// A chave de acesso deve conferir com o DV e com os dados do documento
+	if err := mdfeParsed.ChaveAcesso.Erro(); err != nil {
+		return nil, err
+	}
+
+	// Verificar a assinatura digital: documentos adulterados não são importados
+	verificacao := assinatura.Verificar(xmlContent)
+	verificacao.ConferirEmitente(mdfeParsed.Emitente.CNPJ)
+	if err := verificacao.Erro(); err != nil {
+		return nil, err
 	}
 
 	// Iniciar transação
@@ -231,8 +772,12 @@ func processarMDFe(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoP
 		return nil, fmt.Errorf("erro ao processar emitente: %w", err)
 	}
 
-	// Buscar ou criar veículo
-	veiculo, err := buscarOuCriarVeiculo(tx, mdfeParsed.PlacaVeiculo, mdfeParsed.UfVeiculo)
+	// Buscar ou criar o veículo de tração
+	if mdfeParsed.Tracao == nil {
+		tx.Rollback()
+		return nil, errors.New("erro ao processar veículo: placa do veículo não informada")
+	}
+	veiculo, err := buscarOuCriarVeiculo(tx, *mdfeParsed.Tracao)
 	if err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("erro ao processar veículo: %w", err)
@@ -250,20 +795,25 @@ func processarMDFe(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoP
 	// Criar ou atualizar o MDF-e
 	novoMdfe := models.MDFE{
 		DocumentoFiscal: models.DocumentoFiscal{
-			Chave:           mdfeParsed.Chave,
-			Tipo:            "MDFE",
-			Numero:          mdfeParsed.Numero,
-			Serie:           mdfeParsed.Serie,
-			DataEmissao:     mdfeParsed.DataEmissao,
-			Status:          mdfeParsed.Status,
-			Protocolo:       mdfeParsed.Protocolo,
-			ValorTotal:      mdfeParsed.ValorTotalCarga,
-			EmitenteID:      emitente.ID,
-			UFInicio:        mdfeParsed.UFInicio,
-			UFDestino:       mdfeParsed.UFDestino,
-			MunicipioInicio: mdfeParsed.MunicipioCarrega,
-			XMLOriginal:     string(xmlContent),
-			UploadID:        uploadUUID,
+			Chave:             mdfeParsed.Chave,
+			Tipo:              "MDFE",
+			Numero:            mdfeParsed.Numero,
+			Serie:             mdfeParsed.Serie,
+			DataEmissao:       mdfeParsed.DataEmissao,
+			Status:            statusDocumento(mdfeParsed.Status),
+			Protocolo:         mdfeParsed.Protocolo,
+			ValorTotal:        mdfeParsed.ValorTotalCarga,
+			EmitenteID:        emitente.ID,
+			Modal:             mdfeParsed.Modal,
+			UFInicio:          mdfeParsed.UFInicio,
+			UFDestino:         mdfeParsed.UFDestino,
+			MunicipioInicio:   mdfeParsed.MunicipioCarrega,
+			MunicipioFim:      mdfeParsed.MunicipioFim,
+			XMLOriginal:       string(xmlContent),
+			AssinaturaStatus:  verificacao.Status,
+			AssinaturaCNPJ:    verificacao.CNPJ,
+			AssinaturaTitular: verificacao.Titular,
+			UploadID:          uploadUUID,
 		},
 		VeiculoTracaoID:     veiculo.ID,
 		NomeMotorista:       mdfeParsed.NomeMotorista,
@@ -277,13 +827,15 @@ func processarMDFe(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoP
 		DataEncerramento:    mdfeParsed.DataEncerramento,
 	}
 
-	// Adicionar informações de seguro se existirem
+	// Resumo do primeiro seguro; todos os seguros ficam em mdfe_seguros
 	if len(mdfeParsed.Seguradoras) > 0 {
-		seg := mdfeParsed.Seguradoras[0] // Pegar primeira seguradora
+		seg := mdfeParsed.Seguradoras[0]
 		novoMdfe.SeguradoraNome = seg.Nome
 		novoMdfe.SeguradoraCNPJ = seg.CNPJ
 		novoMdfe.NumeroApolice = seg.Apolice
-		novoMdfe.NumeroAverbacao = seg.Averbacao
+		if len(seg.Averbacoes) > 0 {
+			novoMdfe.NumeroAverbacao = seg.Averbacoes[0]
+		}
 	}
 
 	// Verificar se já existe
@@ -303,109 +855,77 @@ func processarMDFe(db *gorm.DB, xmlContent []byte, uploadID string) (*DocumentoP
 			return nil, fmt.Errorf("erro ao buscar MDF-e existente: %w", result.Error)
 		}
 	} else {
-		// Atualizar existente
-		if err := tx.Model(&existingMdfe).Updates(novoMdfe).Error; err != nil {
+		// Atualizar existente regravando todas as colunas, inclusive as que ficaram vazias
+		if err := tx.Model(&existingMdfe).Select("*").Omit(colunasPreservadas(uploadUUID)...).Updates(&novoMdfe).Error; err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("erro ao atualizar MDF-e: %w", err)
 		}
 	}
 
-	// Vincular CT-es ao MDF-e
-	if len(mdfeParsed.ChavesCTe) > 0 {
-		for _, chaveCte := range mdfeParsed.ChavesCTe {
-			var cte models.CTE
-			if err := tx.Where("chave = ?", chaveCte).First(&cte).Error; err == nil {
-				// Adicionar relação many-to-many
-				tx.Model(&existingMdfe).Association("CTes").Append(&cte)
-			}
-		}
+	// Substituir as informações do modal
+	if err := salvarDetalheModal(tx, existingMdfe.ID, "MDFE", mdfeParsed.DetalheModal); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao salvar informações do modal do MDF-e: %w", err)
 	}
 
-	// Commit da transação
-	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	// Substituir reboques, condutores e contratantes
+	if err := salvarRodoviarioMDFe(tx, existingMdfe.ID, mdfeParsed); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao salvar modal rodoviário do MDF-e: %w", err)
 	}
 
-	return &DocumentoProcessado{
-		Chave:    mdfeParsed.Chave,
-		Tipo:     "MDFE",
-		Status:   "PROCESSADO",
-		Mensagem: fmt.Sprintf("MDF-e %d processado com sucesso", mdfeParsed.Numero),
-	}, nil
-}
-
-// processarEventoCTe processa um evento de CT-e
-func processarEventoCTe(db *gorm.DB, xmlContent []byte) (*DocumentoProcessado, error) {
-	// Parser do evento
-	evento, err := parsers.ParseEventoCTe(xmlContent)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao fazer parse do evento: %w", err)
+	// Substituir os municípios de descarga e seus documentos
+	if err := salvarDescargasMDFe(tx, existingMdfe.ID, mdfeParsed.Descargas); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao salvar municípios de descarga do MDF-e: %w", err)
 	}
 
-	// Buscar CT-e relacionado
-	var cte models.CTE
-	if err := db.Where("chave = ?", evento.Chave).First(&cte).Error; err != nil {
-		return nil, fmt.Errorf("CT-e não encontrado para o evento: %w", err)
+	// Substituir os seguros da carga e as averbações
+	if err := salvarSegurosMDFe(tx, existingMdfe.ID, mdfeParsed.Seguradoras); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao salvar seguros do MDF-e: %w", err)
 	}
 
-	// Processar tipo de evento
-	switch evento.TipoEvento {
-	case "110111": // Cancelamento
-		cte.Cancelado = true
-		cte.Status = "101"
-		if err := db.Save(&cte).Error; err != nil {
-			return nil, fmt.Errorf("erro ao cancelar CT-e: %w", err)
+	// Vincular CT-es ao MDF-e, substituindo os vínculos de um processamento anterior
+	ctes := []models.CTE{}
+	if len(mdfeParsed.ChavesCTe) > 0 {
+		if err := tx.Where("chave IN ?", mdfeParsed.ChavesCTe).Find(&ctes).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("erro ao buscar CT-es do MDF-e: %w", err)
 		}
-	default:
-		return nil, fmt.Errorf("tipo de evento não suportado: %s", evento.TipoEvento)
+	}
+	if err := tx.Model(&existingMdfe).Association("CTes").Replace(ctes); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao vincular CT-es ao MDF-e: %w", err)
 	}
 
-	return &DocumentoProcessado{
-		Chave:    evento.Chave,
-		Tipo:     "EVENTO_CTE",
-		Status:   "PROCESSADO",
-		Mensagem: fmt.Sprintf("Evento %s processado para CT-e", evento.TipoEventoDesc),
-	}, nil
-}
-
-// processarEventoMDFe processa um evento de MDF-e
-func processarEventoMDFe(db *gorm.DB, xmlContent []byte) (*DocumentoProcessado, error) {
-	// Parser do evento
-	evento, err := parsers.ParseEventoMDFe(xmlContent)
+	// Indexar as NF-es transportadas
+	nfes, err := buscarOuCriarNFes(tx, mdfeParsed.ChavesNFe)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao fazer parse do evento: %w", err)
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao processar NF-es do MDF-e: %w", err)
+	}
+	if err := tx.Model(&existingMdfe).Association("NFes").Replace(nfes); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao vincular NF-es ao MDF-e: %w", err)
 	}
 
-	// Buscar MDF-e relacionado
-	var mdfe models.MDFE
-	if err := db.Where("chave = ?", evento.Chave).First(&mdfe).Error; err != nil {
-		return nil, fmt.Errorf("MDF-e não encontrado para o evento: %w", err)
+	// Reaplicar os eventos já recebidos (cancelamento, encerramento, inclusões)
+	if err := aplicarEventosDocumento(tx, mdfeParsed.Chave); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("erro ao aplicar eventos do MDF-e: %w", err)
 	}
 
-	// Processar tipo de evento
-	switch evento.TipoEvento {
-	case "110111": // Cancelamento
-		mdfe.Cancelado = true
-		mdfe.Status = "101"
-		if err := db.Save(&mdfe).Error; err != nil {
-			return nil, fmt.Errorf("erro ao cancelar MDF-e: %w", err)
-		}
-	case "110112": // Encerramento
-		if err := mdfe.Encerrar(""); err != nil {
-			return nil, fmt.Errorf("erro ao encerrar MDF-e: %w", err)
-		}
-		if err := db.Save(&mdfe).Error; err != nil {
-			return nil, fmt.Errorf("erro ao salvar encerramento do MDF-e: %w", err)
-		}
-	default:
-		return nil, fmt.Errorf("tipo de evento não suportado: %s", evento.TipoEvento)
+	// Commit da transação
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
 	}
 
 	return &DocumentoProcessado{
-		Chave:    evento.Chave,
-		Tipo:     "EVENTO_MDFE",
+		Chave:    mdfeParsed.Chave,
+		Tipo:     "MDFE",
 		Status:   "PROCESSADO",
-		Mensagem: fmt.Sprintf("Evento %s processado para MDF-e", evento.TipoEventoDesc),
+		Mensagem: fmt.Sprintf("MDF-e %d processado com sucesso", mdfeParsed.Numero),
 	}, nil
 }
 
@@ -449,34 +969,176 @@ func buscarOuCriarEmpresa(tx *gorm.DB, empresaParsed parsers.EmpresaParsed) (*mo
 	return &empresa, nil
 }
 
-// buscarOuCriarVeiculo busca ou cria um veículo
-func buscarOuCriarVeiculo(tx *gorm.DB, placa, uf string) (*models.Veiculo, error) {
-	if placa == "" {
+// buscarOuCriarVeiculo busca ou cria um veículo pela placa e atualiza os dados
+// informados no MDF-e, inclusive o proprietário quando é de terceiro
+func buscarOuCriarVeiculo(tx *gorm.DB, veiculoParsed parsers.VeiculoParsed) (*models.Veiculo, error) {
+	if veiculoParsed.Placa == "" {
 		return nil, errors.New("placa do veículo não informada")
 	}
 
 	var veiculo models.Veiculo
-	result := tx.Where("placa = ?", placa).First(&veiculo)
+	result := tx.Where("placa = ?", veiculoParsed.Placa).First(&veiculo)
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("erro ao buscar veículo: %w", result.Error)
+	}
 
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			// Criar novo veículo
-			veiculo = models.Veiculo{
-				Placa: placa,
-				Tipo:  "PROPRIO", // Padrão
-			}
+	veiculo.Placa = veiculoParsed.Placa
+	veiculo.RENAVAM = nilIfEmpty(veiculoParsed.RENAVAM)
+	veiculo.UF = veiculoParsed.UF
+	veiculo.Tara = veiculoParsed.Tara
+	veiculo.CapacidadeKg = veiculoParsed.CapacidadeKg
+	veiculo.CapacidadeM3 = veiculoParsed.CapacidadeM3
+	veiculo.TipoCarroceria = veiculoParsed.TipoCarroceria
+	if veiculoParsed.TipoRodado != "" {
+		veiculo.TipoRodado = veiculoParsed.TipoRodado
+	}
 
-			if err := tx.Create(&veiculo).Error; err != nil {
-				return nil, fmt.Errorf("erro ao criar veículo: %w", err)
-			}
-		} else {
-			return nil, fmt.Errorf("erro ao buscar veículo: %w", result.Error)
+	// Sem o grupo prop o veículo é do próprio emitente
+	veiculo.Tipo = models.VeiculoProprio
+	veiculo.ProprietarioNome = ""
+	veiculo.ProprietarioCNPJ = ""
+	veiculo.ProprietarioCPF = ""
+	veiculo.ProprietarioRNTRC = ""
+	veiculo.TipoProprietario = ""
+	if prop := veiculoParsed.Proprietario; prop != nil {
+		veiculo.Tipo = models.VeiculoTerceiro
+		if prop.TipoProprietario == "0" {
+			veiculo.Tipo = models.VeiculoAgregado
 		}
+		veiculo.ProprietarioNome = prop.Nome
+		veiculo.ProprietarioCNPJ = prop.CNPJ
+		veiculo.ProprietarioCPF = prop.CPF
+		veiculo.ProprietarioRNTRC = prop.RNTRC
+		veiculo.TipoProprietario = prop.TipoProprietario
+	}
+
+	if err := tx.Save(&veiculo).Error; err != nil {
+		return nil, fmt.Errorf("erro ao salvar veículo: %w", err)
 	}
 
 	return &veiculo, nil
 }
 
+// salvarRodoviarioMDFe substitui os reboques, condutores e contratantes do MDF-e
+func salvarRodoviarioMDFe(tx *gorm.DB, mdfeID uuid.UUID, mdfeParsed *parsers.MDFeParsed) error {
+	if err := tx.Unscoped().Where("mdfe_id = ?", mdfeID).Delete(&models.MDFEReboque{}).Error; err != nil {
+		return fmt.Errorf("erro ao remover reboques antigos: %w", err)
+	}
+	if err := tx.Unscoped().Where("mdfe_id = ?", mdfeID).Delete(&models.MDFECondutor{}).Error; err != nil {
+		return fmt.Errorf("erro ao remover condutores antigos: %w", err)
+	}
+	if err := tx.Unscoped().Where("mdfe_id = ?", mdfeID).Delete(&models.MDFEContratante{}).Error; err != nil {
+		return fmt.Errorf("erro ao remover contratantes antigos: %w", err)
+	}
+
+	for i, reboqueParsed := range mdfeParsed.Reboques {
+		veiculo, err := buscarOuCriarVeiculo(tx, reboqueParsed)
+		if err != nil {
+			return fmt.Errorf("reboque %d: %w", i+1, err)
+		}
+		reboque := models.MDFEReboque{
+			MDFEID:        mdfeID,
+			Posicao:       i + 1,
+			VeiculoID:     veiculo.ID,
+			CodigoInterno: reboqueParsed.CodigoInterno,
+		}
+		if err := tx.Create(&reboque).Error; err != nil {
+			return fmt.Errorf("erro ao criar reboque %d: %w", i+1, err)
+		}
+	}
+
+	for i, condutorParsed := range mdfeParsed.Condutores {
+		condutor := models.MDFECondutor{
+			MDFEID: mdfeID,
+			Ordem:  i + 1,
+			Nome:   condutorParsed.Nome,
+			CPF:    condutorParsed.CPF,
+		}
+		if err := tx.Create(&condutor).Error; err != nil {
+			return fmt.Errorf("erro ao criar condutor: %w", err)
+		}
+	}
+
+	for _, contratanteParsed := range mdfeParsed.Contratantes {
+		contratante := models.MDFEContratante{
+			MDFEID:        mdfeID,
+			Nome:          contratanteParsed.Nome,
+			CNPJ:          contratanteParsed.CNPJ,
+			CPF:           contratanteParsed.CPF,
+			IdEstrangeiro: contratanteParsed.IdEstrangeiro,
+		}
+		if err := tx.Create(&contratante).Error; err != nil {
+			return fmt.Errorf("erro ao criar contratante: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// salvarDescargasMDFe substitui os municípios de descarga do MDF-e e os documentos entregues em cada um
+func salvarDescargasMDFe(tx *gorm.DB, mdfeID uuid.UUID, descargas []parsers.DescargaParsed) error {
+	antigas := tx.Model(&models.MDFEDescarga{}).Unscoped().Select("id").Where("mdfe_id = ?", mdfeID)
+	if err := tx.Unscoped().Where("descarga_id IN (?)", antigas).Delete(&models.MDFEDescargaDocumento{}).Error; err != nil {
+		return fmt.Errorf("erro ao remover documentos das descargas antigas: %w", err)
+	}
+	if err := tx.Unscoped().Where("mdfe_id = ?", mdfeID).Delete(&models.MDFEDescarga{}).Error; err != nil {
+		return fmt.Errorf("erro ao remover descargas antigas: %w", err)
+	}
+
+	for i, descargaParsed := range descargas {
+		descarga := models.MDFEDescarga{
+			MDFEID:          mdfeID,
+			Ordem:           i + 1,
+			CodigoMunicipio: descargaParsed.CodigoMunicipio,
+			Municipio:       descargaParsed.Municipio,
+			UF:              descargaParsed.UF,
+		}
+		for _, chave := range descargaParsed.ChavesCTe {
+			descarga.Documentos = append(descarga.Documentos, models.MDFEDescargaDocumento{Tipo: "CTE", Chave: chave})
+		}
+		for _, chave := range descargaParsed.ChavesNFe {
+			descarga.Documentos = append(descarga.Documentos, models.MDFEDescargaDocumento{Tipo: "NFE", Chave: chave})
+		}
+		if err := tx.Create(&descarga).Error; err != nil {
+			return fmt.Errorf("erro ao criar descarga em %s: %w", descargaParsed.Municipio, err)
+		}
+	}
+
+	return nil
+}
+
+// salvarSegurosMDFe substitui os seguros da carga do MDF-e e suas averbações
+func salvarSegurosMDFe(tx *gorm.DB, mdfeID uuid.UUID, seguradoras []parsers.SeguradoraParsed) error {
+	antigos := tx.Model(&models.MDFESeguro{}).Unscoped().Select("id").Where("mdfe_id = ?", mdfeID)
+	if err := tx.Unscoped().Where("seguro_id IN (?)", antigos).Delete(&models.MDFEAverbacao{}).Error; err != nil {
+		return fmt.Errorf("erro ao remover averbações antigas: %w", err)
+	}
+	if err := tx.Unscoped().Where("mdfe_id = ?", mdfeID).Delete(&models.MDFESeguro{}).Error; err != nil {
+		return fmt.Errorf("erro ao remover seguros antigos: %w", err)
+	}
+
+	for i, seg := range seguradoras {
+		seguro := models.MDFESeguro{
+			MDFEID:          mdfeID,
+			Ordem:           i + 1,
+			Responsavel:     seg.Responsavel,
+			ResponsavelCNPJ: seg.ResponsavelCNPJ,
+			ResponsavelCPF:  seg.ResponsavelCPF,
+			SeguradoraNome:  seg.Nome,
+			SeguradoraCNPJ:  seg.CNPJ,
+			NumeroApolice:   seg.Apolice,
+		}
+		for _, numero := range seg.Averbacoes {
+			seguro.Averbacoes = append(seguro.Averbacoes, models.MDFEAverbacao{Numero: numero})
+		}
+		if err := tx.Create(&seguro).Error; err != nil {
+			return fmt.Errorf("erro ao criar seguro da apólice %s: %w", seg.Apolice, err)
+		}
+	}
+
+	return nil
+}
+
 // nilIfEmpty retorna um ponteiro para string ou nil se vazio
 func nilIfEmpty(s string) *string {
 	if s == "" {
@@ -484,3 +1146,22 @@ func nilIfEmpty(s string) *string {
 	}
 	return &s
 }
+
+// colunasPreservadas lista as colunas mantidas ao regravar um documento já
+// importado. Sem upload informado (reprocessamento), o upload de origem é mantido.
+func colunasPreservadas(uploadID *uuid.UUID) []string {
+	colunas := []string{"id", "created_at", "deleted_at", "data_processamento"}
+	if uploadID == nil {
+		colunas = append(colunas, "upload_id")
+	}
+	return colunas
+}
+
+// statusDocumento retorna o cStat do protocolo ou "000" (não processado) quando
+// o XML não traz protocolo, o mesmo valor padrão da coluna na criação
+func statusDocumento(cStat string) string {
+	if cStat == "" {
+		return "000"
+	}
+	return cStat
+}
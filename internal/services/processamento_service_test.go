@@ -0,0 +1,386 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	chaveNFe1 = "35240111111111000191550010000004561000004560"
+	chaveNFe2 = "35240111111111000191550020000007891000007890"
+)
+
+const chaveCTe = "35240112345678000195570010000001231000001236"
+
+const cteComNFes = `<cteProc xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><CTe><infCte Id="CTe35240112345678000195570010000001231000001236" versao="4.00">
+<ide><cUF>35</cUF><cCT>00000123</cCT><CFOP>6353</CFOP><mod>57</mod><serie>1</serie><nCT>123</nCT><dhEmi>2024-01-10T08:30:00-03:00</dhEmi>
+<tpEmis>1</tpEmis><cDV>6</cDV><UFIni>SP</UFIni><UFFim>RJ</UFFim><toma3><toma>0</toma></toma3></ide>
+<emit><CNPJ>12345678000195</CNPJ><xNome>TRANSPORTADORA</xNome></emit>
+<rem><CNPJ>11111111000191</CNPJ><xNome>REMETENTE</xNome></rem>
+<dest><CNPJ>22222222000191</CNPJ><xNome>DESTINATARIO</xNome></dest>
+<vPrest><vTPrest>1500.00</vTPrest></vPrest>
+<infCTeNorm><infCarga><vCarga>10000.00</vCarga></infCarga><infDoc>{{NFES}}</infDoc></infCTeNorm>
+</infCte></CTe></cteProc>`
+
+func TestProcessarCTeIndexaNFes(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "nfe.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.CTE{}, &models.MDFE{},
+		&models.CTEImposto{}, &models.CTEComponente{}, &models.CTEQuantidade{}, &models.CTEReferencia{}, &models.NFe{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	duas := "<infNFe><chave>" + chaveNFe1 + "</chave></infNFe><infNFe><chave>" + chaveNFe2 + "</chave></infNFe>"
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(cteComNFes, "{{NFES}}", duas, 1)))
+	require.NoError(t, err)
+
+	var nfe models.NFe
+	require.NoError(t, db.Preload("CTes").First(&nfe, "chave = ?", chaveNFe2).Error)
+	assert.Equal(t, "11111111000191", nfe.CNPJEmitente)
+	assert.Equal(t, "2", nfe.Serie)
+	assert.Equal(t, 789, nfe.Numero)
+	assert.Nil(t, nfe.Valor)
+	require.Len(t, nfe.CTes, 1)
+
+	// Reprocessado com uma única nota: o vínculo é substituído e o valor da carga é o da nota
+	uma := "<infNFe><chave>" + chaveNFe1 + "</chave></infNFe>"
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(cteComNFes, "{{NFES}}", uma, 1)))
+	require.NoError(t, err)
+
+	var removida, mantida models.NFe
+	require.NoError(t, db.Preload("CTes").First(&removida, "chave = ?", chaveNFe2).Error)
+	assert.Empty(t, removida.CTes)
+	require.NoError(t, db.Preload("CTes").First(&mantida, "chave = ?", chaveNFe1).Error)
+	require.Len(t, mantida.CTes, 1)
+	require.NotNil(t, mantida.Valor)
+	assert.Equal(t, 10000.0, *mantida.Valor)
+}
+
+const cteOSExcessoBagagem = `<CTeOS xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><infCte Id="CTe35240112345678000195670010000004561000004561" versao="4.00">
+<ide><cUF>35</cUF><cCT>00000456</cCT><CFOP>5357</CFOP><mod>67</mod><serie>1</serie><nCT>456</nCT><dhEmi>2024-01-20T08:00:00-03:00</dhEmi>
+<tpEmis>1</tpEmis><cDV>1</cDV><xMunEnv>SAO PAULO</xMunEnv><UFEnv>SP</UFEnv><tpServ>8</tpServ></ide>
+<emit><CNPJ>12345678000195</CNPJ><xNome>VIACAO</xNome></emit>
+<vPrest><vTPrest>250.00</vTPrest><vRec>250.00</vRec></vPrest>
+<infCTeNorm><infServico><xDescServ>EXCESSO DE BAGAGEM</xDescServ></infServico></infCTeNorm>
+</infCte></CTeOS>`
+
+func TestProcessarCTeOS(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cteos.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.CTE{}, &models.CTEOS{},
+		&models.CTEImposto{}, &models.CTEComponente{}, &models.CTEQuantidade{}, &models.CTEReferencia{}, &models.NFe{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	resultado, err := ProcessarXML(db, "", []byte(cteOSExcessoBagagem))
+	require.NoError(t, err)
+	assert.Equal(t, "CTEOS", resultado.Tipo)
+
+	var cteOS models.CTEOS
+	require.NoError(t, db.First(&cteOS, "chave = ?", resultado.Chave).Error)
+	assert.Equal(t, "8", cteOS.TipoServico)
+	// Sem início e fim da prestação, vale o local de envio
+	assert.Equal(t, "SP", cteOS.UFInicio)
+	assert.Equal(t, "SP", cteOS.UFDestino)
+
+	// O CT-e OS entra nos totais de receita junto com os CT-es
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(cteComNFes, "{{NFES}}", "", 1)))
+	require.NoError(t, err)
+	for tipo, esperado := range map[string]float64{"": 1750, models.DocumentoCTe: 1500, models.DocumentoCTeOS: 250} {
+		var total float64
+		require.NoError(t, db.Table(models.DocumentosReceita(tipo)+" AS d").Select("COALESCE(SUM(valor_total), 0)").Scan(&total).Error)
+		assert.Equal(t, esperado, total, tipo)
+	}
+}
+
+// CT-e 125 substitui o 123 com outro valor
+const cteSubstituto = `<cteProc xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><CTe><infCte Id="CTe35240112345678000195570010000001251000001257" versao="4.00">
+<ide><cUF>35</cUF><cCT>00000125</cCT><CFOP>6353</CFOP><mod>57</mod><serie>1</serie><nCT>125</nCT><dhEmi>2024-01-12T08:30:00-03:00</dhEmi>
+<tpEmis>1</tpEmis><cDV>7</cDV><tpCTe>3</tpCTe><UFIni>SP</UFIni><UFFim>RJ</UFFim><toma3><toma>0</toma></toma3></ide>
+<emit><CNPJ>12345678000195</CNPJ><xNome>TRANSPORTADORA</xNome></emit>
+<rem><CNPJ>11111111000191</CNPJ><xNome>REMETENTE</xNome></rem>
+<dest><CNPJ>22222222000191</CNPJ><xNome>DESTINATARIO</xNome></dest>
+<vPrest><vTPrest>1400.00</vTPrest></vPrest>
+<infCTeNorm><infCarga><vCarga>10000.00</vCarga></infCarga><infCteSub><chCte>` + chaveCTe + `</chCte></infCteSub></infCTeNorm>
+</infCte></CTe></cteProc>`
+
+// CT-e 124 complementa o substituto, sem repetir as partes
+const cteComplementar = `<cteProc xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><CTe><infCte Id="CTe35240112345678000195570010000001241000001241" versao="4.00">
+<ide><cUF>35</cUF><cCT>00000124</cCT><CFOP>6353</CFOP><mod>57</mod><serie>1</serie><nCT>124</nCT><dhEmi>2024-01-15T08:30:00-03:00</dhEmi>
+<tpEmis>1</tpEmis><cDV>1</cDV><tpCTe>1</tpCTe><UFIni>SP</UFIni><UFFim>RJ</UFFim><toma3><toma>0</toma></toma3></ide>
+<emit><CNPJ>12345678000195</CNPJ><xNome>TRANSPORTADORA</xNome></emit>
+<vPrest><vTPrest>200.00</vTPrest></vPrest>
+<infCteComp><chCTe>35240112345678000195570010000001251000001257</chCTe></infCteComp>
+</infCte></CTe></cteProc>`
+
+func TestProcessarCTeComplementarESubstituto(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "referencias.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.CTE{}, &models.CTEOS{},
+		&models.CTEImposto{}, &models.CTEComponente{}, &models.CTEQuantidade{}, &models.CTEReferencia{}, &models.NFe{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	receita := func() (valor float64, prestacoes int64) {
+		var totais struct {
+			Valor      float64
+			Prestacoes int64
+		}
+		require.NoError(t, db.Table(models.DocumentosReceita("")+" AS d").
+			Where("cancelado = ?", false).
+			Select("COALESCE(SUM(valor_total), 0) AS valor, COALESCE("+models.SQLQuantidadePrestacoes+", 0) AS prestacoes").
+			Scan(&totais).Error)
+		return totais.Valor, totais.Prestacoes
+	}
+
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(cteComNFes, "{{NFES}}", "", 1)))
+	require.NoError(t, err)
+
+	// O substituto toma o lugar do original
+	_, err = ProcessarXML(db, "", []byte(cteSubstituto))
+	require.NoError(t, err)
+	valor, prestacoes := receita()
+	assert.Equal(t, 1400.0, valor)
+	assert.Equal(t, int64(1), prestacoes)
+
+	// O complementar soma ao valor, herda as partes e não conta como nova prestação
+	resultado, err := ProcessarXML(db, "", []byte(cteComplementar))
+	require.NoError(t, err)
+	valor, prestacoes = receita()
+	assert.Equal(t, 1600.0, valor)
+	assert.Equal(t, int64(1), prestacoes)
+
+	var complementar models.CTE
+	require.NoError(t, db.Preload("Remetente").Preload("Referencias").First(&complementar, "chave = ?", resultado.Chave).Error)
+	assert.Equal(t, "1", complementar.TipoCTe)
+	require.NotNil(t, complementar.Remetente.CNPJ)
+	assert.Equal(t, "11111111000191", *complementar.Remetente.CNPJ)
+	require.Len(t, complementar.Referencias, 1)
+	assert.Equal(t, models.RelacaoComplemento, complementar.Referencias[0].Relacao)
+
+	// Cancelado o substituto, o original volta a valer
+	require.NoError(t, db.Model(&models.CTE{}).Where("tipo_cte = ?", "3").Update("cancelado", true).Error)
+	valor, _ = receita()
+	assert.Equal(t, 1700.0, valor)
+}
+
+func TestProcessarCTeModalAquaviario(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "modal.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.CTE{}, &models.CTEImposto{},
+		&models.CTEComponente{}, &models.CTEQuantidade{}, &models.CTEReferencia{}, &models.NFe{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	aquav := `</infDoc><infModal versaoModal="4.00"><aquav><vPrest>1500.00</vPrest><vAFRMM>375.00</vAFRMM>
+<xNavio>NAVIO TESTE</xNavio><balsa><xBalsa>BALSA 1</xBalsa></balsa><nViag>42</nViag><direc>N</direc><irin>PP1234</irin>
+<detCont><nCont>MSCU1234567</nCont></detCont><detCont><nCont>MSCU7654321</nCont></detCont><tpNav>1</tpNav></aquav></infModal>`
+	xmlCTe := strings.NewReplacer("</infDoc>", aquav, "<tpEmis>", "<modal>03</modal><tpEmis>", "{{NFES}}", "").Replace(cteComNFes)
+	resultado, err := ProcessarXML(db, "", []byte(xmlCTe))
+	require.NoError(t, err)
+
+	var cte models.CTE
+	require.NoError(t, db.Scopes(models.PreloadModal).First(&cte, "chave = ?", resultado.Chave).Error)
+	assert.Equal(t, "03", cte.Modal)
+	assert.Nil(t, cte.ModalAereo)
+	require.NotNil(t, cte.ModalAquaviario)
+	assert.Equal(t, "CTE", cte.ModalAquaviario.DocumentoTipo)
+	assert.Equal(t, "PP1234", cte.ModalAquaviario.IRIN)
+	assert.Equal(t, 375.0, cte.ModalAquaviario.ValorAFRMM)
+	assert.Equal(t, "MSCU1234567,MSCU7654321", cte.ModalAquaviario.Conteineres)
+
+	// Reprocessado como rodoviário, o detalhe do modal é descartado
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(cteComNFes, "{{NFES}}", "", 1)))
+	require.NoError(t, err)
+	var restantes int64
+	require.NoError(t, db.Model(&models.ModalAquaviario{}).Count(&restantes).Error)
+	assert.Zero(t, restantes)
+	require.NoError(t, db.First(&cte, "chave = ?", resultado.Chave).Error)
+	assert.Equal(t, "01", cte.Modal)
+}
+
+const mdfeBitrem = `<mdfeProc xmlns="http://www.portalfiscal.inf.br/mdfe" versao="3.00"><MDFe><infMDFe Id="MDFe35240112345678000195580010000001231000001230" versao="3.00">
+<ide><cUF>35</cUF><mod>58</mod><serie>1</serie><nMDF>123</nMDF><cMDF>00000123</cMDF><cDV>0</cDV><modal>1</modal>
+<dhEmi>2024-01-12T06:00:00-03:00</dhEmi><tpEmis>1</tpEmis><UFIni>SP</UFIni><UFFim>PR</UFFim></ide>
+<emit><CNPJ>12345678000195</CNPJ><xNome>TRANSPORTADORA</xNome></emit>
+<infModal versaoModal="3.00"><rodo>
+<infANTT><RNTRC>12345678</RNTRC><infContratante><xNome>EMBARCADOR</xNome><CNPJ>11111111000191</CNPJ></infContratante></infANTT>
+<veicTracao><cInt>CV01</cInt><placa>ABC1D23</placa><tara>9000</tara><capKG>0</capKG>
+{{CONDUTORES}}<tpRod>03</tpRod><tpCar>00</tpCar><UF>SP</UF></veicTracao>
+<veicReboque><placa>REB1A11</placa><tara>7000</tara><capKG>25000</capKG><tpCar>02</tpCar><UF>SP</UF></veicReboque>
+<veicReboque><placa>REB2B22</placa><tara>6500</tara><capKG>24000</capKG>
+<prop><CPF>12345678909</CPF><RNTRC>87654321</RNTRC><xNome>JOAO AGREGADO</xNome><IE>ISENTO</IE><UF>PR</UF><tpProp>0</tpProp></prop>
+<tpCar>02</tpCar><UF>PR</UF></veicReboque>
+</rodo></infModal>
+<infDoc><infMunDescarga><cMunDescarga>4106902</cMunDescarga><xMunDescarga>CURITIBA</xMunDescarga></infMunDescarga></infDoc>
+<tot><qCTe>0</qCTe><vCarga>50000.00</vCarga><cUnid>01</cUnid><qCarga>49000.0000</qCarga></tot>
+</infMDFe></MDFe></mdfeProc>`
+
+func TestProcessarMDFeBitremDuplaConducao(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "mdfe.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.Veiculo{}, &models.CTE{}, &models.NFe{}, &models.MDFE{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.MDFEReboque{}, &models.MDFECondutor{}, &models.MDFEContratante{}, &models.MDFEDescarga{}, &models.MDFEDescargaDocumento{},
+		&models.MDFESeguro{}, &models.MDFEAverbacao{}, &models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	dois := "<condutor><xNome>MOTORISTA UM</xNome><CPF>11144477735</CPF></condutor><condutor><xNome>MOTORISTA DOIS</xNome><CPF>52998224725</CPF></condutor>"
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(mdfeBitrem, "{{CONDUTORES}}", dois, 1)))
+	require.NoError(t, err)
+
+	var mdfe models.MDFE
+	require.NoError(t, db.Preload("VeiculoTracao").Preload("Reboques.Veiculo").Preload("Condutores").Preload("Contratantes").
+		First(&mdfe, "chave = ?", "35240112345678000195580010000001231000001230").Error)
+	assert.Equal(t, "MOTORISTA UM", mdfe.NomeMotorista)
+	assert.Equal(t, "03", mdfe.VeiculoTracao.TipoRodado)
+	assert.Equal(t, models.VeiculoProprio, mdfe.VeiculoTracao.Tipo)
+
+	require.Len(t, mdfe.Reboques, 2)
+	placas := map[int]*models.Veiculo{}
+	for _, reboque := range mdfe.Reboques {
+		placas[reboque.Posicao] = reboque.Veiculo
+	}
+	assert.Equal(t, "REB1A11", placas[1].Placa)
+	assert.Equal(t, models.VeiculoProprio, placas[1].Tipo)
+	assert.Equal(t, "REB2B22", placas[2].Placa)
+	assert.Equal(t, models.VeiculoAgregado, placas[2].Tipo)
+	assert.Equal(t, "87654321", placas[2].ProprietarioRNTRC)
+	assert.Equal(t, "12345678909", placas[2].ProprietarioCPF)
+
+	require.Len(t, mdfe.Condutores, 2)
+	require.Len(t, mdfe.Contratantes, 1)
+	assert.Equal(t, "11111111000191", mdfe.Contratantes[0].CNPJ)
+
+	// Reprocessado com um único condutor: as partes são substituídas, sem duplicar reboques
+	um := "<condutor><xNome>MOTORISTA DOIS</xNome><CPF>52998224725</CPF></condutor>"
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(mdfeBitrem, "{{CONDUTORES}}", um, 1)))
+	require.NoError(t, err)
+
+	var condutores []models.MDFECondutor
+	require.NoError(t, db.Where("mdfe_id = ?", mdfe.ID).Find(&condutores).Error)
+	require.Len(t, condutores, 1)
+	assert.Equal(t, "52998224725", condutores[0].CPF)
+
+	var reboques int64
+	require.NoError(t, db.Model(&models.MDFEReboque{}).Where("mdfe_id = ?", mdfe.ID).Count(&reboques).Error)
+	assert.Equal(t, int64(2), reboques)
+}
+
+func TestProcessarMDFeDescargas(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "descargas.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.Veiculo{}, &models.CTE{}, &models.NFe{}, &models.MDFE{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.MDFEReboque{}, &models.MDFECondutor{}, &models.MDFEContratante{}, &models.MDFEDescarga{}, &models.MDFEDescargaDocumento{},
+		&models.MDFESeguro{}, &models.MDFEAverbacao{}, &models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	descargaUnica := "<infMunDescarga><cMunDescarga>4106902</cMunDescarga><xMunDescarga>CURITIBA</xMunDescarga></infMunDescarga>"
+	duasParadas := "<infMunDescarga><cMunDescarga>3509502</cMunDescarga><xMunDescarga>CAMPINAS</xMunDescarga>" +
+		"<infNFe><chNFe>" + chaveNFe1 + "</chNFe></infNFe></infMunDescarga>" +
+		"<infMunDescarga><cMunDescarga>4106902</cMunDescarga><xMunDescarga>CURITIBA</xMunDescarga>" +
+		"<infNFe><chNFe>" + chaveNFe2 + "</chNFe></infNFe></infMunDescarga>"
+	xmlMDFe := strings.Replace(mdfeBitrem, "{{CONDUTORES}}", "<condutor><xNome>MOTORISTA</xNome><CPF>11144477735</CPF></condutor>", 1)
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(xmlMDFe, descargaUnica, duasParadas, 1)))
+	require.NoError(t, err)
+
+	var mdfe models.MDFE
+	require.NoError(t, db.Preload("Descargas", func(db *gorm.DB) *gorm.DB { return db.Order("ordem") }).Preload("Descargas.Documentos").
+		First(&mdfe, "chave = ?", "35240112345678000195580010000001231000001230").Error)
+	assert.Equal(t, "CURITIBA", mdfe.MunicipioFim)
+	require.Len(t, mdfe.Descargas, 2)
+	assert.Equal(t, "CAMPINAS", mdfe.Descargas[0].Municipio)
+	assert.Equal(t, "SP", mdfe.Descargas[0].UF)
+	require.Len(t, mdfe.Descargas[0].Documentos, 1)
+	assert.Equal(t, chaveNFe1, mdfe.Descargas[0].Documentos[0].Chave)
+	assert.Equal(t, "PR", mdfe.Descargas[1].UF)
+	require.Len(t, mdfe.Descargas[1].Documentos, 1)
+	assert.Equal(t, chaveNFe2, mdfe.Descargas[1].Documentos[0].Chave)
+
+	// Reprocessado com uma única parada: descargas e documentos são substituídos
+	_, err = ProcessarXML(db, "", []byte(xmlMDFe))
+	require.NoError(t, err)
+
+	var descargas, documentos int64
+	require.NoError(t, db.Model(&models.MDFEDescarga{}).Where("mdfe_id = ?", mdfe.ID).Count(&descargas).Error)
+	assert.Equal(t, int64(1), descargas)
+	require.NoError(t, db.Model(&models.MDFEDescargaDocumento{}).Count(&documentos).Error)
+	assert.Equal(t, int64(0), documentos)
+}
+
+func TestProcessarMDFeSeguros(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "seguros.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.Veiculo{}, &models.CTE{}, &models.NFe{}, &models.MDFE{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.MDFEReboque{}, &models.MDFECondutor{}, &models.MDFEContratante{}, &models.MDFEDescarga{}, &models.MDFEDescargaDocumento{},
+		&models.MDFESeguro{}, &models.MDFEAverbacao{}, &models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	seguros := "<seg><infResp><respSeg>1</respSeg><CNPJ>12345678000195</CNPJ></infResp><infSeg><xSeg>SEGURADORA A</xSeg><CNPJ>33333333000191</CNPJ></infSeg>" +
+		"<nApol>APL-1</nApol><nAver>AV-001</nAver><nAver>AV-002</nAver></seg>" +
+		"<seg><infResp><respSeg>2</respSeg><CNPJ>11111111000191</CNPJ></infResp><infSeg><xSeg>SEGURADORA B</xSeg><CNPJ>44444444000191</CNPJ></infSeg>" +
+		"<nApol>APL-2</nApol><nAver>AV-003</nAver></seg><tot>"
+	xmlMDFe := strings.Replace(mdfeBitrem, "{{CONDUTORES}}", "<condutor><xNome>MOTORISTA</xNome><CPF>11144477735</CPF></condutor>", 1)
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(xmlMDFe, "<tot>", seguros, 1)))
+	require.NoError(t, err)
+
+	var mdfe models.MDFE
+	require.NoError(t, db.Preload("Seguros", func(db *gorm.DB) *gorm.DB { return db.Order("ordem") }).Preload("Seguros.Averbacoes").
+		First(&mdfe, "chave = ?", "35240112345678000195580010000001231000001230").Error)
+
+	// Resumo do primeiro seguro, com o CNPJ da seguradora e não o do responsável
+	assert.Equal(t, "33333333000191", mdfe.SeguradoraCNPJ)
+	assert.Equal(t, "APL-1", mdfe.NumeroApolice)
+	assert.Equal(t, "AV-001", mdfe.NumeroAverbacao)
+
+	require.Len(t, mdfe.Seguros, 2)
+	assert.Equal(t, models.ResponsavelSeguroEmitente, mdfe.Seguros[0].Responsavel)
+	assert.Len(t, mdfe.Seguros[0].Averbacoes, 2)
+	assert.Equal(t, models.ResponsavelSeguroContratante, mdfe.Seguros[1].Responsavel)
+	assert.Equal(t, "11111111000191", mdfe.Seguros[1].ResponsavelCNPJ)
+	require.Len(t, mdfe.Seguros[1].Averbacoes, 1)
+	assert.Equal(t, "AV-003", mdfe.Seguros[1].Averbacoes[0].Numero)
+
+	// Reprocessado sem seguro: seguros e averbações são removidos
+	_, err = ProcessarXML(db, "", []byte(xmlMDFe))
+	require.NoError(t, err)
+
+	var restantes int64
+	require.NoError(t, db.Model(&models.MDFEAverbacao{}).Count(&restantes).Error)
+	assert.Equal(t, int64(0), restantes)
+}
+
+func TestReprocessarCTeLimpaCamposVazios(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "vazios.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.CTE{}, &models.MDFE{},
+		&models.CTEImposto{}, &models.CTEComponente{}, &models.CTEQuantidade{}, &models.CTEReferencia{}, &models.NFe{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	xmlCTe := strings.Replace(cteComNFes, "{{NFES}}", "", 1)
+	comObs := strings.Replace(xmlCTe, "<emit>", "<compl><xObs>ENTREGA AGENDADA</xObs></compl><emit>", 1)
+	_, err = ProcessarXML(db, "", []byte(comObs))
+	require.NoError(t, err)
+
+	var cte models.CTE
+	require.NoError(t, db.First(&cte, "chave = ?", chaveCTe).Error)
+	require.Equal(t, "ENTREGA AGENDADA", cte.ObsGerais)
+	criadoEm := cte.CreatedAt
+
+	// Sem observação e sem carga, os valores gravados devem ser zerados
+	semCarga := strings.Replace(xmlCTe, "<vCarga>10000.00</vCarga>", "<vCarga>0.00</vCarga>", 1)
+	_, err = ProcessarXML(db, "", []byte(semCarga))
+	require.NoError(t, err)
+
+	require.NoError(t, db.First(&cte, "chave = ?", chaveCTe).Error)
+	assert.Empty(t, cte.ObsGerais)
+	assert.Zero(t, cte.ValorCarga)
+	assert.True(t, criadoEm.Equal(cte.CreatedAt))
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrDocumentoNaoEncontrado indica que a chave informada não existe na base
+	ErrDocumentoNaoEncontrado = errors.New("documento não encontrado")
+	// ErrXMLIndisponivel indica que o documento não possui XML original armazenado
+	ErrXMLIndisponivel = errors.New("XML original não disponível")
+)
+
+// MaxReprocessamentoLote é o máximo de documentos reprocessados por chamada em
+// lote; filtros mais amplos são percorridos em páginas com FiltroReprocessamento.AposChave
+const MaxReprocessamentoLote = 200
+
+// camposIgnoradosDiff são campos que mudam a cada reprocessamento e não interessam no diff
+var camposIgnoradosDiff = map[string]bool{
+	"updated_at":         true,
+	"data_processamento": true,
+}
+
+// CampoAlterado representa a diferença de um campo entre antes e depois do reprocessamento
+type CampoAlterado struct {
+	Campo  string      `json:"campo"`
+	Antes  interface{} `json:"antes"`
+	Depois interface{} `json:"depois"`
+}
+
+// ResultadoReprocessamento representa o resultado do reprocessamento de um documento
+type ResultadoReprocessamento struct {
+	Chave      string          `json:"chave"`
+	Tipo       string          `json:"tipo"`
+	Alteracoes []CampoAlterado `json:"alteracoes"`
+}
+
+// FiltroReprocessamento define quais documentos entram em um reprocessamento em lote
+type FiltroReprocessamento struct {
+	Chaves     []string
+	DataInicio *time.Time
+	DataFim    *time.Time
+	Status     string
+	EmitenteID string
+	Limite     int    // limitado a MaxReprocessamentoLote
+	AposChave  string // continua o lote a partir da chave seguinte
+}
+
+// ErroReprocessamento representa a falha de um documento no reprocessamento em lote
+type ErroReprocessamento struct {
+	Chave string `json:"chave"`
+	Erro  string `json:"erro"`
+}
+
+// ResumoReprocessamento representa o resultado de um reprocessamento em lote
+type ResumoReprocessamento struct {
+	Total         int                        `json:"total"`
+	Reprocessados int                        `json:"reprocessados"`
+	ComAlteracoes int                        `json:"com_alteracoes"`
+	Alterados     []ResultadoReprocessamento `json:"alterados"`
+	Erros         []ErroReprocessamento      `json:"erros"`
+	// ProximaChave é informada quando restam documentos no filtro: repetir a
+	// chamada com apos_chave igual a ela para continuar
+	ProximaChave string `json:"proxima_chave,omitempty"`
+}
+
+// ReprocessarCTe executa novamente o parser sobre o XML original do CT-e
+// e retorna os campos que mudaram
+func ReprocessarCTe(db *gorm.DB, chave string) (*ResultadoReprocessamento, error) {
+	var cte models.CTE
+	if err := db.Where("chave = ?", chave).First(&cte).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDocumentoNaoEncontrado
+		}
+		return nil, fmt.Errorf("erro ao buscar CT-e: %w", err)
+	}
+	if cte.XMLOriginal == "" {
+		return nil, ErrXMLIndisponivel
+	}
+
+	antes, err := estadoDocumento(cte)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := processarCTe(db, []byte(cte.XMLOriginal), uploadIDString(cte.DocumentoFiscal)); err != nil {
+		return nil, err
+	}
+	if err := marcarReprocessado(db, &models.CTE{}, cte.ID); err != nil {
+		return nil, err
+	}
+
+	var atualizado models.CTE
+	if err := db.Where("id = ?", cte.ID).First(&atualizado).Error; err != nil {
+		return nil, fmt.Errorf("erro ao recarregar CT-e: %w", err)
+	}
+	depois, err := estadoDocumento(atualizado)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResultadoReprocessamento{
+		Chave:      chave,
+		Tipo:       "CTE",
+		Alteracoes: compararEstados(antes, depois),
+	}, nil
+}
+
+// ReprocessarMDFe executa novamente o parser sobre o XML original do MDF-e
+// e retorna os campos que mudaram, incluindo os CT-es vinculados
+func ReprocessarMDFe(db *gorm.DB, chave string) (*ResultadoReprocessamento, error) {
+	var mdfe models.MDFE
+	if err := db.Where("chave = ?", chave).First(&mdfe).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDocumentoNaoEncontrado
+		}
+		return nil, fmt.Errorf("erro ao buscar MDF-e: %w", err)
+	}
+	if mdfe.XMLOriginal == "" {
+		return nil, ErrXMLIndisponivel
+	}
+
+	antes, err := estadoMDFe(db, mdfe)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := processarMDFe(db, []byte(mdfe.XMLOriginal), uploadIDString(mdfe.DocumentoFiscal)); err != nil {
+		return nil, err
+	}
+	if err := marcarReprocessado(db, &models.MDFE{}, mdfe.ID); err != nil {
+		return nil, err
+	}
+
+	var atualizado models.MDFE
+	if err := db.Where("id = ?", mdfe.ID).First(&atualizado).Error; err != nil {
+		return nil, fmt.Errorf("erro ao recarregar MDF-e: %w", err)
+	}
+	depois, err := estadoMDFe(db, atualizado)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResultadoReprocessamento{
+		Chave:      chave,
+		Tipo:       "MDFE",
+		Alteracoes: compararEstados(antes, depois),
+	}, nil
+}
+
+// ReprocessarCTesEmLote reprocessa todos os CT-es que atendem ao filtro.
+// Falhas individuais são registradas no resumo sem interromper o lote.
+func ReprocessarCTesEmLote(db *gorm.DB, filtro FiltroReprocessamento) (*ResumoReprocessamento, error) {
+	log := logger.GetLogger()
+
+	query := db.Model(&models.CTE{}).Where("xml_original IS NOT NULL AND xml_original <> ''")
+	if len(filtro.Chaves) > 0 {
+		query = query.Where("chave IN ?", filtro.Chaves)
+	}
+	if filtro.DataInicio != nil {
+		query = query.Where("data_emissao >= ?", *filtro.DataInicio)
+	}
+	if filtro.DataFim != nil {
+		query = query.Where("data_emissao <= ?", *filtro.DataFim)
+	}
+	if filtro.Status != "" {
+		query = query.Where("status = ?", filtro.Status)
+	}
+	if filtro.EmitenteID != "" {
+		query = query.Where("emitente_id = ?", filtro.EmitenteID)
+	}
+	if filtro.AposChave != "" {
+		query = query.Where("chave > ?", filtro.AposChave)
+	}
+
+	limite := filtro.Limite
+	if limite <= 0 || limite > MaxReprocessamentoLote {
+		limite = MaxReprocessamentoLote
+	}
+
+	// Um documento a mais indica que o filtro continua na próxima página
+	var chaves []string
+	if err := query.Order("chave").Limit(limite+1).Pluck("chave", &chaves).Error; err != nil {
+		return nil, fmt.Errorf("erro ao selecionar CT-es para reprocessamento: %w", err)
+	}
+	proximaChave := ""
+	if len(chaves) > limite {
+		chaves = chaves[:limite]
+		proximaChave = chaves[limite-1]
+	}
+
+	resumo := &ResumoReprocessamento{
+		Total:        len(chaves),
+		Alterados:    []ResultadoReprocessamento{},
+		Erros:        []ErroReprocessamento{},
+		ProximaChave: proximaChave,
+	}
+
+	for _, chave := range chaves {
+		resultado, err := ReprocessarCTe(db, chave)
+		if err != nil {
+			log.Error().Err(err).Str("chave", chave).Msg("Erro ao reprocessar CT-e em lote")
+			resumo.Erros = append(resumo.Erros, ErroReprocessamento{Chave: chave, Erro: err.Error()})
+			continue
+		}
+
+		resumo.Reprocessados++
+		if len(resultado.Alteracoes) > 0 {
+			resumo.ComAlteracoes++
+			resumo.Alterados = append(resumo.Alterados, *resultado)
+		}
+	}
+
+	log.Info().Int("total", resumo.Total).Int("reprocessados", resumo.Reprocessados).
+		Int("com_alteracoes", resumo.ComAlteracoes).Msg("Reprocessamento em lote de CT-es concluído")
+
+	return resumo, nil
+}
+
+// estadoDocumento converte o documento em um mapa de campos usando a mesma
+// representação JSON exposta pela API
+func estadoDocumento(documento interface{}) (map[string]interface{}, error) {
+	dados, err := json.Marshal(documento)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar documento: %w", err)
+	}
+
+	estado := map[string]interface{}{}
+	if err := json.Unmarshal(dados, &estado); err != nil {
+		return nil, fmt.Errorf("erro ao serializar documento: %w", err)
+	}
+	for campo := range camposIgnoradosDiff {
+		delete(estado, campo)
+	}
+	return estado, nil
+}
+
+// estadoMDFe inclui no estado do MDF-e as chaves dos CT-es vinculados
+func estadoMDFe(db *gorm.DB, mdfe models.MDFE) (map[string]interface{}, error) {
+	var ctes []models.CTE
+	if err := db.Model(&mdfe).Association("CTes").Find(&ctes); err != nil {
+		return nil, fmt.Errorf("erro ao carregar CT-es vinculados: %w", err)
+	}
+	chaves := make([]string, 0, len(ctes))
+	for _, cte := range ctes {
+		chaves = append(chaves, cte.Chave)
+	}
+	sort.Strings(chaves)
+
+	estado, err := estadoDocumento(mdfe)
+	if err != nil {
+		return nil, err
+	}
+	estado["ctes_vinculados"] = chaves
+	return estado, nil
+}
+
+// compararEstados retorna os campos cujo valor difere entre os dois estados
+func compararEstados(antes, depois map[string]interface{}) []CampoAlterado {
+	campos := map[string]bool{}
+	for campo := range antes {
+		campos[campo] = true
+	}
+	for campo := range depois {
+		campos[campo] = true
+	}
+
+	nomes := make([]string, 0, len(campos))
+	for campo := range campos {
+		nomes = append(nomes, campo)
+	}
+	sort.Strings(nomes)
+
+	alteracoes := []CampoAlterado{}
+	for _, campo := range nomes {
+		if !reflect.DeepEqual(antes[campo], depois[campo]) {
+			alteracoes = append(alteracoes, CampoAlterado{Campo: campo, Antes: antes[campo], Depois: depois[campo]})
+		}
+	}
+	return alteracoes
+}
+
+// marcarReprocessado atualiza a data de processamento do documento
+func marcarReprocessado(db *gorm.DB, modelo interface{}, id interface{}) error {
+	if err := db.Model(modelo).Where("id = ?", id).Update("data_processamento", time.Now()).Error; err != nil {
+		return fmt.Errorf("erro ao atualizar data de processamento: %w", err)
+	}
+	return nil
+}
+
+// uploadIDString retorna o ID do upload de origem do documento, se houver
+func uploadIDString(documento models.DocumentoFiscal) string {
+	if documento.UploadID == nil {
+		return ""
+	}
+	return documento.UploadID.String()
+}
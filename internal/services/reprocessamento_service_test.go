@@ -0,0 +1,79 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCompararEstados(t *testing.T) {
+	antes := map[string]interface{}{"cfop": "5353", "valor_total": 100.0, "rntrc": "", "ctes_vinculados": []string{"A"}}
+	depois := map[string]interface{}{"cfop": "6353", "valor_total": 100.0, "rntrc": "123", "ctes_vinculados": []string{"A"}}
+
+	alteracoes := compararEstados(antes, depois)
+
+	assert.Equal(t, []CampoAlterado{
+		{Campo: "cfop", Antes: "5353", Depois: "6353"},
+		{Campo: "rntrc", Antes: "", Depois: "123"},
+	}, alteracoes)
+	assert.Empty(t, compararEstados(antes, antes))
+}
+
+func TestReprocessarDocumentosArmazenados(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "reprocessamento.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Empresa{}, &models.Upload{}, &models.Veiculo{}, &models.CTE{}, &models.NFe{}, &models.MDFE{},
+		&models.CTEImposto{}, &models.CTEComponente{}, &models.CTEQuantidade{}, &models.CTEReferencia{},
+		&models.ModalAereo{}, &models.ModalAquaviario{}, &models.ModalFerroviario{}, &models.ModalDutoviario{}, &models.ModalMultimodal{},
+		&models.MDFEReboque{}, &models.MDFECondutor{}, &models.MDFEContratante{}, &models.MDFEDescarga{}, &models.MDFEDescargaDocumento{},
+		&models.MDFESeguro{}, &models.MDFEAverbacao{}, &models.DocumentoEvento{}, &models.CTECorrecao{}))
+
+	_, err = ProcessarXML(db, "", []byte(strings.Replace(cteComNFes, "{{NFES}}", "", 1)))
+	require.NoError(t, err)
+	_, err = ProcessarXML(db, "", []byte(cteSubstituto))
+	require.NoError(t, err)
+	chaveSubstituto := "35240112345678000195570010000001251000001257"
+
+	// CT-e: coluna divergente do XML armazenado volta ao valor do XML
+	require.NoError(t, db.Model(&models.CTE{}).Where("chave = ?", chaveCTe).Update("cfop", "5353").Error)
+	resultado, err := ReprocessarCTe(db, chaveCTe)
+	require.NoError(t, err)
+	assert.Equal(t, []CampoAlterado{{Campo: "cfop", Antes: "5353", Depois: "6353"}}, resultado.Alteracoes)
+
+	// MDF-e com os dois CT-es; o XML armazenado passa a trazer só um deles
+	documentos := func(chaves ...string) string {
+		infCTe := ""
+		for _, chave := range chaves {
+			infCTe += "<infCTe><chCTe>" + chave + "</chCTe></infCTe>"
+		}
+		return strings.Replace(strings.Replace(mdfeBitrem, "{{CONDUTORES}}", "<condutor><xNome>MOTORISTA</xNome><CPF>11144477735</CPF></condutor>", 1),
+			"<xMunDescarga>CURITIBA</xMunDescarga>", "<xMunDescarga>CURITIBA</xMunDescarga>"+infCTe, 1)
+	}
+	_, err = ProcessarXML(db, "", []byte(documentos(chaveCTe, chaveSubstituto)))
+	require.NoError(t, err)
+	chaveMDFe := "35240112345678000195580010000001231000001230"
+	require.NoError(t, db.Model(&models.MDFE{}).Where("chave = ?", chaveMDFe).Update("xml_original", documentos(chaveSubstituto)).Error)
+
+	resultado, err = ReprocessarMDFe(db, chaveMDFe)
+	require.NoError(t, err)
+	var vinculos *CampoAlterado
+	for i := range resultado.Alteracoes {
+		if resultado.Alteracoes[i].Campo == "ctes_vinculados" {
+			vinculos = &resultado.Alteracoes[i]
+		}
+	}
+	require.NotNil(t, vinculos)
+	assert.ElementsMatch(t, []string{chaveCTe, chaveSubstituto}, vinculos.Antes)
+	assert.Equal(t, []string{chaveSubstituto}, vinculos.Depois)
+
+	// Reprocessar de novo não relata diferença nos vínculos
+	resultado, err = ReprocessarMDFe(db, chaveMDFe)
+	require.NoError(t, err)
+	assert.Empty(t, resultado.Alteracoes)
+}
@@ -0,0 +1,290 @@
+package validacao
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+)
+
+// Modos de validação
+const (
+	// ModoEstrito rejeita o documento com violações de schema
+	ModoEstrito = "estrito"
+	// ModoTolerante processa o documento e registra as violações como avisos
+	ModoTolerante = "tolerante"
+	// ModoDesativado não valida os documentos
+	ModoDesativado = "desativado"
+)
+
+// ErrDocumentoInvalido indica violações de schema no modo estrito
+var ErrDocumentoInvalido = errors.New("documento inválido para o schema XSD")
+
+// ErrSchemaIndisponivel indica que não há schema para a família e versão do documento
+var ErrSchemaIndisponivel = errors.New("schema XSD não disponível")
+
+// ErrXmllintIndisponivel indica que o xmllint (libxml2), usado na validação, não está instalado
+var ErrXmllintIndisponivel = errors.New("xmllint não encontrado: instale o libxml2 (pacote libxml2-utils) ou desative a validação")
+
+//go:embed schemas
+var schemasEmbutidos embed.FS
+
+// familias mapeia o tipo de documento para o diretório de schemas
+var familias = map[parsers.TipoDocumento]string{
+	parsers.TipoCTe:        "cte",
+	parsers.TipoCTeOS:      "cte",
+	parsers.TipoEventoCTe:  "cte",
+	parsers.TipoMDFe:       "mdfe",
+	parsers.TipoEventoMDFe: "mdfe",
+}
+
+// arquivosRaiz mapeia o elemento raiz para o schema de entrada do pacote
+// oficial, sem o sufixo de versão (ex.: cteProc → procCTe_v4.00.xsd)
+var arquivosRaiz = map[string]string{
+	"CTe":            "cte",
+	"cteProc":        "procCTe",
+	"CTeOS":          "cteOS",
+	"cteOSProc":      "procCTeOS",
+	"eventoCTe":      "eventoCTe",
+	"procEventoCTe":  "procEventoCTe",
+	"MDFe":           "mdfe",
+	"mdfeProc":       "procMDFe",
+	"eventoMDFe":     "eventoMDFe",
+	"procEventoMDFe": "procEventoMDFe",
+}
+
+// Config define o modo e a origem dos schemas
+type Config struct {
+	Modo string
+	// Diretorio com os schemas no formato <familia>/v<versao>; vazio usa os schemas embutidos
+	Diretorio string
+}
+
+// Resultado é o resultado da validação de um documento
+type Resultado struct {
+	// Schema é o diretório usado (ex.: cte/v4.00); vazio se o documento não foi validado
+	Schema    string
+	Violacoes []Violacao
+	// Aviso explica por que o documento não foi validado no modo tolerante
+	Aviso string
+}
+
+// Valido indica se o documento não tem violações
+func (r *Resultado) Valido() bool {
+	return len(r.Violacoes) == 0
+}
+
+// Detalhes formata as violações, uma por linha, para o registro do upload
+func (r *Resultado) Detalhes() string {
+	if r.Aviso != "" {
+		return r.Aviso
+	}
+	linhas := make([]string, len(r.Violacoes))
+	for i, v := range r.Violacoes {
+		linhas[i] = v.String()
+	}
+	return strings.Join(linhas, "\n")
+}
+
+// Validador seleciona o schema pela família e versão do documento e valida
+// com o xmllint (libxml2)
+type Validador struct {
+	modo    string
+	dir     string
+	xmllint string
+	// erro impede a validação quando o validador padrão não pôde ser criado; os
+	// documentos seguem sem validação, com o erro como aviso
+	erro error
+}
+
+// NewValidador cria um validador com a configuração informada. Fora do modo
+// desativado, exige o xmllint e compila todos os schemas de entrada do
+// diretório: um schema que o libxml2 não aceita impede a inicialização.
+func NewValidador(config Config) (*Validador, error) {
+	modo := config.Modo
+	if modo == "" {
+		modo = ModoTolerante
+	}
+	if modo != ModoEstrito && modo != ModoTolerante && modo != ModoDesativado {
+		return nil, fmt.Errorf("modo de validação inválido: %s", config.Modo)
+	}
+
+	v := &Validador{modo: modo}
+	if modo == ModoDesativado {
+		return v, nil
+	}
+
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		return nil, ErrXmllintIndisponivel
+	}
+	v.xmllint = xmllint
+
+	if config.Diretorio != "" {
+		if _, err := os.Stat(config.Diretorio); err != nil {
+			return nil, fmt.Errorf("diretório de schemas: %w", err)
+		}
+		v.dir = config.Diretorio
+	} else {
+		if v.dir, err = extrairEmbutidos(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := v.compilarSchemas(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+var (
+	embutidosOnce sync.Once
+	embutidosDir  string
+	embutidosErr  error
+)
+
+// extrairEmbutidos copia os schemas embutidos para um diretório temporário,
+// uma vez por processo, para que o xmllint resolva os xs:include
+func extrairEmbutidos() (string, error) {
+	embutidosOnce.Do(func() {
+		embutidosDir, embutidosErr = os.MkdirTemp("", "destack-schemas-")
+		if embutidosErr != nil {
+			return
+		}
+		embutidosErr = fs.WalkDir(schemasEmbutidos, "schemas", func(nome string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			destino := filepath.Join(embutidosDir, filepath.FromSlash(strings.TrimPrefix(nome, "schemas")))
+			if d.IsDir() {
+				return os.MkdirAll(destino, 0o755)
+			}
+			conteudo, err := schemasEmbutidos.ReadFile(nome)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(destino, conteudo, 0o644)
+		})
+	})
+	if embutidosErr != nil {
+		return "", fmt.Errorf("erro ao extrair schemas embutidos: %w", embutidosErr)
+	}
+	return embutidosDir, nil
+}
+
+// compilarSchemas compila cada schema de entrada encontrado em <familia>/v<versao>
+func (v *Validador) compilarSchemas() error {
+	dirsVersao, err := filepath.Glob(filepath.Join(v.dir, "*", "v*"))
+	if err != nil {
+		return err
+	}
+	for _, dirVersao := range dirsVersao {
+		versao := strings.TrimPrefix(filepath.Base(dirVersao), "v")
+		for _, arquivo := range arquivosRaiz {
+			schema := filepath.Join(dirVersao, arquivo+"_v"+versao+".xsd")
+			if _, err := os.Stat(schema); err != nil {
+				continue
+			}
+			// Um documento vazio basta para o xmllint compilar o schema
+			if _, err := executarXmllint(v.xmllint, schema, []byte("<_/>")); err != nil {
+				return fmt.Errorf("erro ao compilar %s: %w", schema, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Modo retorna o modo de validação
+func (v *Validador) Modo() string {
+	return v.modo
+}
+
+// Validar valida o documento contra o schema da família e versão. No modo estrito,
+// violações e a falta de schema retornam erro; no tolerante, voltam no resultado.
+func (v *Validador) Validar(descritor *parsers.DescritorDocumento, conteudo []byte) (*Resultado, error) {
+	resultado := &Resultado{}
+	if v.modo == ModoDesativado {
+		return resultado, nil
+	}
+	if v.erro != nil {
+		resultado.Aviso = "documento não validado: " + v.erro.Error()
+		return resultado, nil
+	}
+
+	familia, ok := familias[descritor.Tipo]
+	arquivo, raizConhecida := arquivosRaiz[descritor.Raiz]
+	if !ok || !raizConhecida {
+		// Tipos sem schema são rejeitados pelo processamento
+		return resultado, nil
+	}
+
+	dir := path.Join(familia, "v"+descritor.Versao)
+	schema := filepath.Join(v.dir, filepath.FromSlash(dir), arquivo+"_v"+descritor.Versao+".xsd")
+	if _, err := os.Stat(schema); err != nil {
+		err = fmt.Errorf("%w para %s (%s)", ErrSchemaIndisponivel, dir, filepath.Base(schema))
+		if v.modo == ModoEstrito {
+			return nil, err
+		}
+		resultado.Aviso = err.Error()
+		return resultado, nil
+	}
+
+	var err error
+	resultado.Schema = dir
+	resultado.Violacoes, err = executarXmllint(v.xmllint, schema, conteudo)
+	if err != nil {
+		return nil, fmt.Errorf("erro na validação XSD (%s): %w", dir, err)
+	}
+
+	if v.modo == ModoEstrito && !resultado.Valido() {
+		return resultado, fmt.Errorf("%w (%s):\n%s", ErrDocumentoInvalido, dir, resultado.Detalhes())
+	}
+	return resultado, nil
+}
+
+var (
+	padraoMu sync.RWMutex
+	padrao   *Validador
+)
+
+// Configurar define o validador usado pelo processamento dos XMLs
+func Configurar(config Config) error {
+	v, err := NewValidador(config)
+	if err != nil {
+		return err
+	}
+	padraoMu.Lock()
+	padrao = v
+	padraoMu.Unlock()
+	return nil
+}
+
+// Padrao retorna o validador configurado (modo tolerante com os schemas embutidos,
+// se não configurado). Sem o xmllint, o validador padrão não valida os documentos
+// e registra o motivo como aviso, como no modo tolerante.
+func Padrao() *Validador {
+	padraoMu.RLock()
+	v := padrao
+	padraoMu.RUnlock()
+	if v != nil {
+		return v
+	}
+
+	padraoMu.Lock()
+	defer padraoMu.Unlock()
+	if padrao == nil {
+		var err error
+		if padrao, err = NewValidador(Config{Modo: ModoTolerante}); err != nil {
+			padrao = &Validador{modo: ModoTolerante, erro: err}
+		}
+	}
+	return padrao
+}
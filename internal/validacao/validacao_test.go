@@ -0,0 +1,217 @@
+package validacao
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/italosilva18/destack-transport-api/internal/parsers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assinaturaValida tem a estrutura do XMLDSig exigida pelos schemas; o conteúdo
+// criptográfico é conferido no processamento, não na validação
+const assinaturaValida = `<Signature xmlns="http://www.w3.org/2000/09/xmldsig#"><SignedInfo>` +
+	`<CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/>` +
+	`<SignatureMethod Algorithm="http://www.w3.org/2000/09/xmldsig#rsa-sha1"/>` +
+	`<Reference URI="#ID"><Transforms><Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"/>` +
+	`<Transform Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/></Transforms>` +
+	`<DigestMethod Algorithm="http://www.w3.org/2000/09/xmldsig#sha1"/><DigestValue>AAAA</DigestValue></Reference>` +
+	`</SignedInfo><SignatureValue>AAAA</SignatureValue><KeyInfo><X509Data><X509Certificate>AAAA</X509Certificate></X509Data></KeyInfo></Signature>`
+
+const cteProcValido = `<?xml version="1.0" encoding="UTF-8"?>
+<cteProc xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00">
+<CTe>
+<infCte Id="CTe35240112345678000195570010000001231000001230" versao="4.00">
+<ide>
+<cUF>35</cUF><cCT>00000123</cCT><CFOP>6353</CFOP><natOp>PRESTACAO DE SERVICO DE TRANSPORTE</natOp>
+<mod>57</mod><serie>1</serie><nCT>123</nCT><dhEmi>2024-01-10T08:30:00-03:00</dhEmi>
+<tpImp>1</tpImp><tpEmis>1</tpEmis><cDV>0</cDV><tpAmb>2</tpAmb><tpCTe>0</tpCTe><procEmi>0</procEmi>
+<verProc>1.0</verProc><cMunEnv>3550308</cMunEnv><xMunEnv>SAO PAULO</xMunEnv><UFEnv>SP</UFEnv>
+<modal>01</modal><tpServ>0</tpServ>
+<cMunIni>3550308</cMunIni><xMunIni>SAO PAULO</xMunIni><UFIni>SP</UFIni>
+<cMunFim>3304557</cMunFim><xMunFim>RIO DE JANEIRO</xMunFim><UFFim>RJ</UFFim>
+<retira>1</retira><indIEToma>1</indIEToma><toma3><toma>0</toma></toma3>
+</ide>
+<emit>
+<CNPJ>12345678000195</CNPJ><IE>123456789012</IE><xNome>TRANSPORTADORA TESTE LTDA</xNome>
+<enderEmit><xLgr>RUA A</xLgr><nro>1</nro><xBairro>CENTRO</xBairro><cMun>3550308</cMun><xMun>SAO PAULO</xMun><UF>SP</UF></enderEmit>
+<CRT>3</CRT>
+</emit>
+<rem><CNPJ>11111111000191</CNPJ><xNome>REMETENTE</xNome></rem>
+<dest><CNPJ>22222222000191</CNPJ><xNome>DESTINATARIO</xNome></dest>
+<vPrest><vTPrest>1500.00</vTPrest><vRec>1500.00</vRec><Comp><xNome>FRETE PESO</xNome><vComp>1500.00</vComp></Comp></vPrest>
+<imp><ICMS><ICMS00><CST>00</CST><vBC>1500.00</vBC><pICMS>12.00</pICMS><vICMS>180.00</vICMS></ICMS00></ICMS></imp>
+<infCTeNorm><infCarga><vCarga>10000.00</vCarga></infCarga></infCTeNorm>
+</infCte>
+<infCTeSupl><qrCodCTe>https://exemplo</qrCodCTe></infCTeSupl>
+` + assinaturaValida + `
+</CTe>
+<protCTe versao="4.00">
+<infProt><tpAmb>2</tpAmb><verAplic>SP-CTe-1</verAplic><chCTe>35240112345678000195570010000001231000001230</chCTe>
+<dhRecbto>2024-01-10T08:31:00-03:00</dhRecbto><nProt>135240000000001</nProt><cStat>100</cStat><xMotivo>Autorizado o uso do CT-e</xMotivo></infProt>
+</protCTe>
+</cteProc>`
+
+func validar(t *testing.T, v *Validador, xml string) (*Resultado, error) {
+	t.Helper()
+	descritor, err := parsers.DetectarDocumento([]byte(xml))
+	require.NoError(t, err)
+	return v.Validar(descritor, []byte(xml))
+}
+
+func TestValidarCTe(t *testing.T) {
+	tolerante, err := NewValidador(Config{Modo: ModoTolerante})
+	require.NoError(t, err)
+
+	resultado, err := validar(t, tolerante, cteProcValido)
+	require.NoError(t, err)
+	assert.Equal(t, "cte/v4.00", resultado.Schema)
+	assert.Empty(t, resultado.Violacoes)
+
+	// UF inválida, elemento obrigatório ausente e elemento fora de ordem
+	invalido := strings.Replace(cteProcValido, "<UFIni>SP</UFIni>", "<UFIni>XX</UFIni>", 1)
+	invalido = strings.Replace(invalido, "<vRec>1500.00</vRec>", "", 1)
+	invalido = strings.Replace(invalido, "<CRT>3</CRT>", "", 1)
+	invalido = strings.Replace(invalido, "<IE>123456789012</IE>", "<IE>123456789012</IE><CRT>3</CRT>", 1)
+
+	resultado, err = validar(t, tolerante, invalido)
+	require.NoError(t, err)
+	require.Len(t, resultado.Violacoes, 3)
+	assert.Equal(t, Violacao{Linha: 11, Caminho: "/cteProc/CTe/infCte/ide/UFIni",
+		Mensagem: resultado.Violacoes[0].Mensagem}, resultado.Violacoes[0])
+	assert.Contains(t, resultado.Violacoes[0].Mensagem, "The value 'XX' is not an element of the set")
+	assert.Equal(t, "/cteProc/CTe/infCte/emit/CRT", resultado.Violacoes[1].Caminho)
+	assert.Contains(t, resultado.Violacoes[1].Mensagem, "Expected is one of ( IEST, xNome )")
+	assert.Equal(t, "/cteProc/CTe/infCte/vPrest/Comp", resultado.Violacoes[2].Caminho)
+	assert.Contains(t, resultado.Violacoes[2].Mensagem, "Expected is ( vRec )")
+
+	// Modo estrito rejeita o documento
+	estrito, err := NewValidador(Config{Modo: ModoEstrito})
+	require.NoError(t, err)
+	_, err = validar(t, estrito, invalido)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrDocumentoInvalido))
+	assert.Contains(t, err.Error(), "linha 11: /cteProc/CTe/infCte/ide/UFIni")
+}
+
+func TestValidarVersaoSemSchema(t *testing.T) {
+	xml := strings.ReplaceAll(cteProcValido, `versao="4.00"`, `versao="9.00"`)
+
+	tolerante, err := NewValidador(Config{Modo: ModoTolerante})
+	require.NoError(t, err)
+	resultado, err := validar(t, tolerante, xml)
+	require.NoError(t, err)
+	assert.Empty(t, resultado.Violacoes)
+	assert.Contains(t, resultado.Aviso, "cte/v9.00")
+
+	estrito, err := NewValidador(Config{Modo: ModoEstrito})
+	require.NoError(t, err)
+	_, err = validar(t, estrito, xml)
+	assert.True(t, errors.Is(err, ErrSchemaIndisponivel))
+}
+
+func TestValidarEventoEMDFe(t *testing.T) {
+	v, err := NewValidador(Config{Modo: ModoTolerante})
+	require.NoError(t, err)
+
+	evento := `<procEventoMDFe xmlns="http://www.portalfiscal.inf.br/mdfe" versao="3.00">` +
+		`<eventoMDFe versao="3.00"><infEvento Id="ID1101123524011234567800019558001000000123100000123001">` +
+		`<cOrgao>35</cOrgao><tpAmb>2</tpAmb><CNPJ>12345678000195</CNPJ>` +
+		`<chMDFe>35240112345678000195580010000001231000001230</chMDFe><dhEvento>2024-01-11T10:00:00-03:00</dhEvento>` +
+		`<tpEvento>110112</tpEvento><nSeqEvento>1</nSeqEvento>` +
+		`<detEvento versaoEvento="3.00"><evEncMDFe><descEvento>Encerramento</descEvento></evEncMDFe></detEvento>` +
+		`</infEvento>` + assinaturaValida + `</eventoMDFe>` +
+		`<retEventoMDFe versao="3.00"><infEvento><tpAmb>2</tpAmb><verAplic>SP-MDFe</verAplic><cOrgao>35</cOrgao>` +
+		`<cStat>135</cStat><xMotivo>Evento registrado</xMotivo></infEvento></retEventoMDFe></procEventoMDFe>`
+
+	resultado, err := validar(t, v, evento)
+	require.NoError(t, err)
+	assert.Equal(t, "mdfe/v3.00", resultado.Schema)
+	assert.Empty(t, resultado.Violacoes)
+
+	semNamespace := strings.Replace(evento, ` xmlns="http://www.portalfiscal.inf.br/mdfe"`, "", 1)
+	resultado, err = validar(t, v, semNamespace)
+	require.NoError(t, err)
+	require.Len(t, resultado.Violacoes, 1)
+	assert.Equal(t, "/procEventoMDFe", resultado.Violacoes[0].Caminho)
+	assert.Contains(t, resultado.Violacoes[0].Mensagem, "No matching global declaration")
+}
+
+func TestValidarCTeOS(t *testing.T) {
+	v, err := NewValidador(Config{Modo: ModoEstrito})
+	require.NoError(t, err)
+
+	cteOS := `<CTeOS xmlns="http://www.portalfiscal.inf.br/cte" versao="4.00"><infCte Id="CTe35240112345678000195670010000004561000004561" versao="4.00">` +
+		`<ide><cUF>35</cUF></ide><emit><CNPJ>12345678000195</CNPJ><IE>123456789012</IE><xNome>VIACAO</xNome><enderEmit/><CRT>3</CRT></emit>` +
+		`<vPrest><vTPrest>250.00</vTPrest><vRec>250.00</vRec></vPrest><imp/><infCTeNorm/></infCte>` +
+		assinaturaValida + `</CTeOS>`
+	resultado, err := validar(t, v, cteOS)
+	require.NoError(t, err)
+	assert.Equal(t, "cte/v4.00", resultado.Schema)
+
+	_, err = validar(t, v, strings.Replace(cteOS, "<vTPrest>250.00</vTPrest>", "<vTPrest>250,00</vTPrest>", 1))
+	assert.True(t, errors.Is(err, ErrDocumentoInvalido))
+
+	// A assinatura segue o XMLDSig restrito dos documentos fiscais
+	_, err = validar(t, v, strings.Replace(cteOS, "xmldsig#rsa-sha1", "xmldsig#dsa-sha1", 1))
+	require.True(t, errors.Is(err, ErrDocumentoInvalido))
+	assert.Contains(t, err.Error(), "/CTeOS/Signature/SignedInfo/SignatureMethod")
+	_, err = validar(t, v, strings.Replace(cteOS, assinaturaValida, `<Signature xmlns="http://www.w3.org/2000/09/xmldsig#"/>`, 1))
+	assert.True(t, errors.Is(err, ErrDocumentoInvalido))
+}
+
+func TestValidarFacetasDoLibxml2(t *testing.T) {
+	// Facetas numéricas e uniões são verificadas; um schema que não compila impede a inicialização
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cte", "v4.00"), 0o755))
+	schema := filepath.Join(dir, "cte", "v4.00", "cte_v4.00.xsd")
+	require.NoError(t, os.WriteFile(schema, []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+<xs:simpleType name="TQtd"><xs:restriction base="xs:decimal"><xs:totalDigits value="3"/><xs:maxInclusive value="500"/></xs:restriction></xs:simpleType>
+<xs:simpleType name="TSerie"><xs:union memberTypes="TQtd"><xs:simpleType><xs:restriction base="xs:string"><xs:enumeration value="U"/></xs:restriction></xs:simpleType></xs:union></xs:simpleType>
+<xs:element name="CTe"><xs:complexType><xs:sequence><xs:element name="qtd" type="TQtd"/><xs:element name="serie" type="TSerie"/></xs:sequence>
+<xs:attribute name="versao" type="xs:string"/></xs:complexType></xs:element></xs:schema>`), 0o644))
+
+	v, err := NewValidador(Config{Modo: ModoTolerante, Diretorio: dir})
+	require.NoError(t, err)
+
+	resultado, err := validar(t, v, `<CTe versao="4.00"><qtd>120</qtd><serie>U</serie></CTe>`)
+	require.NoError(t, err)
+	assert.Empty(t, resultado.Violacoes)
+
+	resultado, err = validar(t, v, `<CTe versao="4.00"><qtd>1200</qtd><serie>X</serie></CTe>`)
+	require.NoError(t, err)
+	require.Len(t, resultado.Violacoes, 3)
+	assert.Contains(t, resultado.Violacoes[0].Mensagem, "totalDigits")
+	assert.Contains(t, resultado.Violacoes[1].Mensagem, "maxInclusive")
+	assert.Equal(t, "/CTe/qtd", resultado.Violacoes[1].Caminho)
+	assert.Equal(t, "/CTe/serie", resultado.Violacoes[2].Caminho)
+	assert.Contains(t, resultado.Violacoes[2].Mensagem, "union type")
+
+	require.NoError(t, os.WriteFile(schema, []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+<xs:element name="CTe" type="TInexistente"/></xs:schema>`), 0o644))
+	_, err = NewValidador(Config{Modo: ModoTolerante, Diretorio: dir})
+	assert.True(t, errors.Is(err, ErrSchemaInvalido))
+}
+
+func TestPadraoSemXmllint(t *testing.T) {
+	// Sem configuração e sem o xmllint, os documentos seguem com aviso
+	t.Setenv("PATH", t.TempDir())
+	padraoMu.Lock()
+	anterior := padrao
+	padrao = nil
+	padraoMu.Unlock()
+	t.Cleanup(func() {
+		padraoMu.Lock()
+		padrao = anterior
+		padraoMu.Unlock()
+	})
+
+	resultado, err := validar(t, Padrao(), cteProcValido)
+	require.NoError(t, err)
+	assert.Empty(t, resultado.Schema)
+	assert.Contains(t, resultado.Aviso, "xmllint não encontrado")
+}
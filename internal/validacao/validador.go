@@ -0,0 +1,197 @@
+package validacao
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxViolacoes limita o número de violações reportadas por documento
+const maxViolacoes = 50
+
+// ErrSchemaInvalido indica que o xmllint não conseguiu compilar o schema
+var ErrSchemaInvalido = errors.New("schema XSD inválido")
+
+// Códigos de saída do xmllint
+const (
+	saidaValido              = 0
+	saidaErroLeitura         = 1
+	saidaViolacaoSchema      = 3
+	saidaDocumentoMalformado = 4
+	saidaSchemaInvalido      = 5
+)
+
+// Violacao descreve um erro de validação com a posição no documento
+type Violacao struct {
+	Linha    int    `json:"linha"`
+	Caminho  string `json:"caminho"`
+	Mensagem string `json:"mensagem"`
+}
+
+func (v Violacao) String() string {
+	return fmt.Sprintf("linha %d: %s: %s", v.Linha, v.Caminho, v.Mensagem)
+}
+
+var (
+	// erroXmllint é uma linha de erro do xmllint lendo da entrada padrão ("-:11: ... error : mensagem")
+	erroXmllint = regexp.MustCompile(`^-:(\d+): .*?error : (.*)$`)
+	// elementoXmllint é o elemento citado na mensagem ("Element '{namespace}UFIni': ...")
+	elementoXmllint = regexp.MustCompile(`^Element '(?:\{[^}]*\})?([^']+)'`)
+	// namespaceXmllint remove o namespace dos nomes citados nas mensagens
+	namespaceXmllint = regexp.MustCompile(`\{https?://[^}]*\}`)
+)
+
+// executarXmllint valida o conteúdo com o schema informado. As violações de
+// schema e de sintaxe voltam como Violacao; falhas do próprio xmllint, como um
+// schema que não compila, voltam como erro.
+func executarXmllint(xmllint, schema string, conteudo []byte) ([]Violacao, error) {
+	cmd := exec.Command(xmllint, "--noout", "--nonet", "--schema", schema, "-")
+	cmd.Stdin = bytes.NewReader(conteudo)
+	var saida bytes.Buffer
+	cmd.Stderr = &saida
+
+	codigo := saidaValido
+	if err := cmd.Run(); err != nil {
+		var erroSaida *exec.ExitError
+		if !errors.As(err, &erroSaida) {
+			return nil, fmt.Errorf("erro ao executar xmllint: %w", err)
+		}
+		codigo = erroSaida.ExitCode()
+	}
+
+	switch codigo {
+	case saidaValido:
+		return nil, nil
+	case saidaSchemaInvalido:
+		return nil, fmt.Errorf("%w: %s", ErrSchemaInvalido, strings.TrimSpace(saida.String()))
+	case saidaErroLeitura, saidaViolacaoSchema, saidaDocumentoMalformado:
+		violacoes := lerViolacoes(saida.String(), conteudo)
+		if len(violacoes) == 0 {
+			return nil, fmt.Errorf("xmllint terminou com código %d sem violações reconhecidas: %s", codigo, strings.TrimSpace(saida.String()))
+		}
+		return violacoes, nil
+	default:
+		return nil, fmt.Errorf("xmllint terminou com código %d: %s", codigo, strings.TrimSpace(saida.String()))
+	}
+}
+
+// lerViolacoes converte a saída de erros do xmllint em violações, localizando
+// o XPath do elemento citado pela linha
+func lerViolacoes(saida string, conteudo []byte) []Violacao {
+	raiz, _ := lerDocumento(conteudo)
+
+	var violacoes []Violacao
+	for _, linha := range strings.Split(saida, "\n") {
+		partes := erroXmllint.FindStringSubmatch(strings.TrimSpace(linha))
+		if partes == nil {
+			continue
+		}
+		if len(violacoes) == maxViolacoes {
+			violacoes = append(violacoes, Violacao{Caminho: "/", Mensagem: fmt.Sprintf("validação interrompida após %d violações", maxViolacoes)})
+			break
+		}
+
+		numero, _ := strconv.Atoi(partes[1])
+		mensagem := namespaceXmllint.ReplaceAllString(partes[2], "")
+		caminho := "/"
+		if elemento := elementoXmllint.FindStringSubmatch(mensagem); elemento != nil && raiz != nil {
+			if no := raiz.localizar(numero, elemento[1]); no != nil {
+				caminho = no.caminho
+			}
+		}
+		violacoes = append(violacoes, Violacao{Linha: numero, Caminho: caminho, Mensagem: mensagem})
+	}
+	return violacoes
+}
+
+// noDocumento é um elemento do documento validado
+type noDocumento struct {
+	nome    xml.Name
+	filhos  []*noDocumento
+	linha   int
+	caminho string
+}
+
+// lerDocumento monta a árvore do documento guardando a linha de cada elemento
+func lerDocumento(conteudo []byte) (*noDocumento, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(conteudo))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var pilha []*noDocumento
+	var raiz *noDocumento
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			linha, _ := decoder.InputPos()
+			no := &noDocumento{nome: t.Name, linha: linha}
+			if len(pilha) == 0 {
+				raiz = no
+			} else {
+				pai := pilha[len(pilha)-1]
+				pai.filhos = append(pai.filhos, no)
+			}
+			pilha = append(pilha, no)
+		case xml.EndElement:
+			pilha = pilha[:len(pilha)-1]
+		}
+	}
+
+	if raiz == nil {
+		return nil, errors.New("elemento raiz não encontrado")
+	}
+	raiz.definirCaminho("/" + raiz.nome.Local)
+	return raiz, nil
+}
+
+// definirCaminho define o XPath do elemento e calcula o dos filhos; o índice
+// só aparece quando há irmãos com o mesmo nome
+func (n *noDocumento) definirCaminho(caminho string) {
+	n.caminho = caminho
+
+	total := make(map[string]int)
+	for _, filho := range n.filhos {
+		total[filho.nome.Local]++
+	}
+	indice := make(map[string]int)
+	for _, filho := range n.filhos {
+		local := filho.nome.Local
+		indice[local]++
+		if total[local] > 1 {
+			filho.definirCaminho(fmt.Sprintf("%s/%s[%d]", caminho, local, indice[local]))
+		} else {
+			filho.definirCaminho(caminho + "/" + local)
+		}
+	}
+}
+
+// localizar retorna o primeiro elemento com o nome informado aberto na linha
+func (n *noDocumento) localizar(linha int, local string) *noDocumento {
+	if n.linha == linha && n.nome.Local == local {
+		return n
+	}
+	for _, filho := range n.filhos {
+		if filho.linha > linha {
+			break
+		}
+		if no := filho.localizar(linha, local); no != nil {
+			return no
+		}
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/google/uuid"
+	"github.com/italosilva18/destack-transport-api/internal/ingestao"
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/internal/services"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+// Subpastas criadas em cada diretório monitorado
+const (
+	PastaProcessados = "processed"
+	PastaErros       = "error"
+)
+
+// extensaoRelatorio é anexada ao nome do arquivo para o relatório de erro
+const extensaoRelatorio = ".erro.txt"
+
+// Config define os diretórios monitorados e os parâmetros da ingestão
+type Config struct {
+	Diretorios []string
+	Limites    ingestao.Limites
+	// Estabilizacao é o tempo sem alterações no arquivo antes de lê-lo,
+	// para não importar arquivos ainda em cópia
+	Estabilizacao time.Duration
+	// IntervaloVerificacao é o intervalo de verificação dos arquivos pendentes
+	IntervaloVerificacao time.Duration
+}
+
+// registro identifica o que foi criado na ingestão de um arquivo
+type registro struct {
+	uploadID *uuid.UUID
+	batchID  *uuid.UUID
+}
+
+// Watcher importa os arquivos colocados nos diretórios monitorados pelo mesmo
+// fluxo do upload via API. Após o processamento, cada arquivo é movido para
+// processed/ ou para error/, acompanhado de um relatório com os erros.
+type Watcher struct {
+	db     *gorm.DB
+	config Config
+	logger zerolog.Logger
+	fsw    *fsnotify.Watcher
+
+	// aguardando guarda o último evento de cada arquivo ainda não lido
+	aguardando map[string]time.Time
+	// registrados guarda os arquivos já importados, aguardando o processamento
+	registrados map[string]registro
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWatcher cria uma nova instância de Watcher
+func NewWatcher(db *gorm.DB, config Config) *Watcher {
+	if config.Estabilizacao <= 0 {
+		config.Estabilizacao = 2 * time.Second
+	}
+	if config.IntervaloVerificacao <= 0 {
+		config.IntervaloVerificacao = time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Watcher{
+		db:          db,
+		config:      config,
+		logger:      logger.GetLogger(),
+		aguardando:  make(map[string]time.Time),
+		registrados: make(map[string]registro),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start prepara os diretórios, agenda os arquivos já existentes e inicia o monitoramento
+func (w *Watcher) Start() error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("erro ao criar monitor de arquivos: %w", err)
+	}
+
+	for _, dir := range w.config.Diretorios {
+		if err := w.prepararDiretorio(fsw, dir); err != nil {
+			fsw.Close()
+			return err
+		}
+	}
+	w.fsw = fsw
+
+	w.wg.Add(1)
+	go w.loop()
+
+	w.logger.Info().Strs("diretorios", w.config.Diretorios).Msg("Monitoramento de diretórios iniciado")
+	return nil
+}
+
+// Shutdown encerra o monitoramento. Arquivos ainda não finalizados permanecem no
+// diretório e são importados de novo na próxima inicialização (como DUPLICADO).
+func (w *Watcher) Shutdown(ctx context.Context) error {
+	w.cancel()
+
+	concluido := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(concluido)
+	}()
+
+	select {
+	case <-concluido:
+		w.logger.Info().Msg("Monitoramento de diretórios finalizado")
+		return w.fsw.Close()
+	case <-ctx.Done():
+		return fmt.Errorf("monitoramento de diretórios não terminou a tempo: %w", ctx.Err())
+	}
+}
+
+// prepararDiretorio cria as subpastas, registra o diretório no monitor e agenda
+// os arquivos colocados enquanto o servidor estava parado
+func (w *Watcher) prepararDiretorio(fsw *fsnotify.Watcher, dir string) error {
+	for _, pasta := range []string{PastaProcessados, PastaErros} {
+		if err := os.MkdirAll(filepath.Join(dir, pasta), 0755); err != nil {
+			return fmt.Errorf("erro ao criar pasta %s em %s: %w", pasta, dir, err)
+		}
+	}
+
+	if err := fsw.Add(dir); err != nil {
+		return fmt.Errorf("erro ao monitorar %s: %w", dir, err)
+	}
+
+	entradas, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("erro ao listar %s: %w", dir, err)
+	}
+	for _, entrada := range entradas {
+		if !entrada.IsDir() {
+			w.aguardando[filepath.Join(dir, entrada.Name())] = time.Time{}
+		}
+	}
+
+	return nil
+}
+
+// loop concentra os eventos e as verificações em uma única goroutine
+func (w *Watcher) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.config.IntervaloVerificacao)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case evento, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if _, importado := w.registrados[evento.Name]; importado {
+				continue
+			}
+			if evento.Has(fsnotify.Create) || evento.Has(fsnotify.Write) {
+				w.aguardando[evento.Name] = time.Now()
+			}
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Error().Err(err).Msg("Erro no monitoramento de diretórios")
+		case <-ticker.C:
+			w.verificar()
+		}
+	}
+}
+
+// verificar importa os arquivos estáveis e move os que terminaram de ser processados
+func (w *Watcher) verificar() {
+	for caminho, ultimoEvento := range w.aguardando {
+		if time.Since(ultimoEvento) < w.config.Estabilizacao {
+			continue
+		}
+		delete(w.aguardando, caminho)
+		w.importar(caminho)
+	}
+
+	for caminho, reg := range w.registrados {
+		finalizado, relatorio, err := w.resultado(reg)
+		if err != nil {
+			w.logger.Error().Err(err).Str("arquivo", caminho).Msg("Erro ao consultar processamento do arquivo")
+			continue
+		}
+		if !finalizado {
+			continue
+		}
+		delete(w.registrados, caminho)
+
+		pasta := PastaProcessados
+		if relatorio != "" {
+			pasta = PastaErros
+		}
+		w.mover(caminho, pasta, relatorio)
+	}
+}
+
+// importar registra o arquivo pelo mesmo fluxo do upload via API
+func (w *Watcher) importar(caminho string) {
+	nome := filepath.Base(caminho)
+	info, err := os.Stat(caminho)
+	if err != nil || info.IsDir() || strings.HasPrefix(nome, ".") {
+		return
+	}
+	tipo := ingestao.TipoArquivo(nome)
+	if tipo == "" {
+		// Arquivos temporários e de outros tipos são ignorados
+		return
+	}
+
+	reg, err := w.registrar(caminho, tipo, info.Size())
+	if err != nil {
+		w.logger.Error().Err(err).Str("arquivo", caminho).Msg("Erro ao importar arquivo")
+		w.mover(caminho, PastaErros, err.Error())
+		return
+	}
+
+	w.logger.Info().Str("arquivo", caminho).Msg("Arquivo importado")
+	w.registrados[caminho] = reg
+}
+
+// registrar lê o arquivo e cria o upload ou o lote correspondente
+func (w *Watcher) registrar(caminho, tipo string, tamanho int64) (registro, error) {
+	if tipo == ingestao.TipoXML {
+		if limite := w.config.Limites.MaxTamanhoEntrada; limite > 0 && tamanho > limite {
+			return registro{}, fmt.Errorf("%w: arquivo excede %d bytes", ingestao.ErrLimiteExcedido, limite)
+		}
+		conteudo, err := os.ReadFile(caminho)
+		if err != nil {
+			return registro{}, fmt.Errorf("erro ao ler arquivo: %w", err)
+		}
+		upload, err := ingestao.RegistrarXML(w.db, filepath.Base(caminho), conteudo)
+		if err != nil {
+			return registro{}, err
+		}
+		return registro{uploadID: &upload.ID}, nil
+	}
+
+	arquivo, err := os.Open(caminho)
+	if err != nil {
+		return registro{}, fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	defer arquivo.Close()
+
+	batch, err := ingestao.RegistrarCompactado(w.db, filepath.Base(caminho), tipo, arquivo, tamanho, w.config.Limites)
+	if err != nil {
+		if batch != nil {
+			return registro{}, fmt.Errorf("%w (lote %s registrado parcialmente, com %d XMLs)", err, batch.ID, batch.TotalArquivos)
+		}
+		return registro{}, err
+	}
+	return registro{batchID: &batch.ID}, nil
+}
+
+// resultado indica se o processamento terminou e, em caso de erro, devolve o relatório
+func (w *Watcher) resultado(reg registro) (bool, string, error) {
+	if reg.batchID != nil {
+		return w.resultadoLote(*reg.batchID)
+	}
+
+	var upload models.Upload
+	if err := w.db.First(&upload, "id = ?", *reg.uploadID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, "Upload excluído antes da conclusão do processamento", nil
+		}
+		return false, "", err
+	}
+
+	// Reenvio de um arquivo já importado: o resultado é o do original
+	if upload.Status == "DUPLICADO" && upload.DuplicadoDeID != nil {
+		return w.resultado(registro{uploadID: upload.DuplicadoDeID})
+	}
+
+	switch upload.Status {
+	case "CONCLUIDO", "DUPLICADO":
+		return true, "", nil
+	case "ERRO":
+		if upload.DetalhesProcessamento == "" {
+			return true, "Erro no processamento do XML", nil
+		}
+		return true, upload.DetalhesProcessamento, nil
+	}
+	return false, "", nil
+}
+
+// resultadoLote verifica o lote de um arquivo compactado; o relatório lista os XMLs com erro
+func (w *Watcher) resultadoLote(batchID uuid.UUID) (bool, string, error) {
+	if err := services.AtualizarLote(w.db, batchID); err != nil {
+		return false, "", err
+	}
+
+	var batch models.UploadBatch
+	if err := w.db.First(&batch, "id = ?", batchID).Error; err != nil {
+		return false, "", err
+	}
+	if batch.Status != services.StatusLoteConcluido && batch.Status != services.StatusLoteConcluidoComErros {
+		return false, "", nil
+	}
+	if batch.Erros == 0 {
+		return true, "", nil
+	}
+
+	var falhas []models.Upload
+	if err := w.db.Where("batch_id = ? AND status = ?", batchID, "ERRO").Order("nome_arquivo").Find(&falhas).Error; err != nil {
+		return false, "", err
+	}
+
+	var relatorio strings.Builder
+	fmt.Fprintf(&relatorio, "%d de %d XMLs com erro (os demais foram processados):\n", batch.Erros, batch.TotalArquivos)
+	for _, falha := range falhas {
+		fmt.Fprintf(&relatorio, "\n%s: %s", falha.NomeArquivo, falha.DetalhesProcessamento)
+	}
+	return true, relatorio.String(), nil
+}
+
+// mover move o arquivo para a subpasta informada e grava o relatório de erro ao lado
+func (w *Watcher) mover(caminho, pasta, relatorio string) {
+	nome := filepath.Base(caminho)
+	destino := filepath.Join(filepath.Dir(caminho), pasta, nome)
+	// Não sobrescrever um arquivo com o mesmo nome movido antes
+	if _, err := os.Stat(destino); err == nil {
+		destino = filepath.Join(filepath.Dir(caminho), pasta, time.Now().Format("20060102-150405")+"-"+nome)
+	}
+
+	if err := os.Rename(caminho, destino); err != nil {
+		w.logger.Error().Err(err).Str("arquivo", caminho).Str("destino", destino).Msg("Erro ao mover arquivo")
+		return
+	}
+
+	if relatorio == "" {
+		return
+	}
+	conteudo := fmt.Sprintf("Arquivo: %s\nData: %s\n\n%s\n", nome, time.Now().Format(time.RFC3339), relatorio)
+	if err := os.WriteFile(destino+extensaoRelatorio, []byte(conteudo), 0644); err != nil {
+		w.logger.Error().Err(err).Str("arquivo", destino).Msg("Erro ao gravar relatório de erro")
+	}
+}
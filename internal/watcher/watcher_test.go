@@ -0,0 +1,67 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/italosilva18/destack-transport-api/internal/models"
+	"github.com/italosilva18/destack-transport-api/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestWatcher(t *testing.T) {
+	logger.InitLogger()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "watcher.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.UploadBatch{}, &models.Upload{}, &models.Job{}))
+
+	dir := t.TempDir()
+	// Arquivo colocado antes da inicialização
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cte.xml"), []byte("<CTe/>"), 0644))
+
+	w := NewWatcher(db, Config{
+		Diretorios:           []string{dir},
+		Estabilizacao:        10 * time.Millisecond,
+		IntervaloVerificacao: 10 * time.Millisecond,
+	})
+	require.NoError(t, w.Start())
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, w.Shutdown(ctx))
+	}()
+
+	// Arquivo compactado inválido vai direto para error/
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lote.zip"), []byte("não é zip"), 0644))
+	// Outros tipos são ignorados
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "leia-me.txt"), []byte("ignorado"), 0644))
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, PastaErros, "lote.zip"+extensaoRelatorio))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// O XML aguarda o processamento antes de ser movido
+	var upload models.Upload
+	assert.Eventually(t, func() bool {
+		return db.First(&upload, "nome_arquivo = ?", "cte.xml").Error == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.FileExists(t, filepath.Join(dir, "cte.xml"))
+
+	db.Model(&upload).Updates(map[string]interface{}{"status": "ERRO", "detalhes_processamento": "tipo de documento não identificado"})
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, PastaErros, "cte.xml"))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	relatorio, err := os.ReadFile(filepath.Join(dir, PastaErros, "cte.xml"+extensaoRelatorio))
+	require.NoError(t, err)
+	assert.Contains(t, string(relatorio), "tipo de documento não identificado")
+	assert.FileExists(t, filepath.Join(dir, "leia-me.txt"))
+}
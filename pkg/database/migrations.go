@@ -17,11 +17,34 @@ func MigrateModels(db *gorm.DB) error {
 		&models.User{},
 		&models.Empresa{},
 		&models.Veiculo{},
+		&models.UploadBatch{},
 		&models.Upload{},
+		&models.Job{},
+		&models.ChaveIdempotencia{},
 
 		// Documentos fiscais
 		&models.CTE{},
 		&models.MDFE{},
+		&models.CTEOS{},
+		&models.CTEImposto{},
+		&models.CTEComponente{},
+		&models.CTEQuantidade{},
+		&models.CTEReferencia{},
+		&models.ModalAereo{},
+		&models.ModalAquaviario{},
+		&models.ModalFerroviario{},
+		&models.ModalDutoviario{},
+		&models.ModalMultimodal{},
+		&models.MDFEReboque{},
+		&models.MDFECondutor{},
+		&models.MDFEContratante{},
+		&models.MDFEDescarga{},
+		&models.MDFEDescargaDocumento{},
+		&models.MDFESeguro{},
+		&models.MDFEAverbacao{},
+		&models.DocumentoEvento{},
+		&models.CTECorrecao{},
+		&models.NFe{},
 
 		// Outras entidades
 		&models.Manutencao{},
@@ -32,6 +55,14 @@ func MigrateModels(db *gorm.DB) error {
 		return err
 	}
 
+	// A chave de idempotência era única em toda a base; agora é única por usuário e endpoint
+	if db.Migrator().HasIndex(&models.ChaveIdempotencia{}, "idx_chaves_idempotencia_chave") {
+		if err := db.Migrator().DropIndex(&models.ChaveIdempotencia{}, "idx_chaves_idempotencia_chave"); err != nil {
+			log.Error().Err(err).Msg("Erro ao remover índice antigo das chaves de idempotência")
+			return err
+		}
+	}
+
 	// Criar índices adicionais se necessário
 	createAdditionalIndexes(db)
 
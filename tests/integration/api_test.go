@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/italosilva18/destack-transport-api/configs"
 	"github.com/italosilva18/destack-transport-api/internal/api/routes"
 	"github.com/italosilva18/destack-transport-api/internal/models"
 	"github.com/italosilva18/destack-transport-api/pkg/logger"
@@ -44,7 +45,30 @@ func (suite *APITestSuite) SetupSuite() {
 		&models.Veiculo{},
 		&models.CTE{},
 		&models.MDFE{},
+		&models.CTEOS{},
+		&models.CTEImposto{},
+		&models.CTEComponente{},
+		&models.CTEQuantidade{},
+		&models.CTEReferencia{},
+		&models.ModalAereo{},
+		&models.ModalAquaviario{},
+		&models.ModalFerroviario{},
+		&models.ModalDutoviario{},
+		&models.ModalMultimodal{},
+		&models.MDFEReboque{},
+		&models.MDFECondutor{},
+		&models.MDFEContratante{},
+		&models.MDFEDescarga{},
+		&models.MDFEDescargaDocumento{},
+		&models.MDFESeguro{},
+		&models.MDFEAverbacao{},
+		&models.DocumentoEvento{},
+		&models.CTECorrecao{},
+		&models.NFe{},
+		&models.UploadBatch{},
 		&models.Upload{},
+		&models.Job{},
+		&models.ChaveIdempotencia{},
 		&models.Manutencao{},
 	)
 	suite.NoError(err)
@@ -63,7 +87,9 @@ func (suite *APITestSuite) SetupSuite() {
 	// Configurar router
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
-	routes.SetupRoutes(router, db)
+	config, err := configs.LoadConfig(".")
+	suite.NoError(err)
+	routes.SetupRoutes(router, db, config)
 
 	suite.db = db
 	suite.router = router